@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+)
+
+// calendarsCommand implements "daysctl calendars ..."
+func (c *cli) calendarsCommand(args []string) error {
+	command, args, err := subcommand("calendars", args)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "list":
+		return c.listCalendars(args)
+	case "move":
+		return c.moveCalendar(args)
+	default:
+		return fmt.Errorf("%w: unknown command calendars %q", errUsage, command)
+	}
+}
+
+// listCalendars lists the calendars a user owns or is a member of
+func (c *cli) listCalendars(args []string) error {
+	args, err := parseArgs(newFlagSet("calendars list"), args, 1)
+	if err != nil {
+		return err
+	}
+
+	user, err := c.admin.FindUser(c.ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	calendars, err := c.calendars.GetAllCalendarsByUserID(c.ctx, user.ID, services.CalendarListRequest{})
+	if err != nil {
+		return err
+	}
+
+	t := table{header: []string{"ID", "NAME", "ROLE", "OWNER", "CREATED"}}
+	for _, calendar := range calendars {
+		t.rows = append(t.rows, []string{
+			calendar.ID.String(),
+			calendar.Name,
+			string(calendar.Role),
+			calendar.UserID.String(),
+			calendar.CreatedAt,
+		})
+	}
+	return c.print(calendars, t)
+}
+
+// moveCalendar hands a calendar over to another user
+func (c *cli) moveCalendar(args []string) error {
+	args, err := parseArgs(newFlagSet("calendars move"), args, 2)
+	if err != nil {
+		return err
+	}
+
+	calendarID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("%w: invalid calendar ID %q", errUsage, args[0])
+	}
+	user, err := c.admin.FindUser(c.ctx, args[1])
+	if err != nil {
+		return err
+	}
+
+	calendar, err := c.admin.MoveCalendar(c.ctx, calendarID, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.print(calendar, table{
+		header: []string{"ID", "NAME", "OWNER", "OWNER EMAIL"},
+		rows:   [][]string{{calendar.ID.String(), calendar.Name, calendar.UserID.String(), user.Email}},
+	})
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"days/internal/services"
+)
+
+// export implements "daysctl export": it writes a user's data in the format
+// of GET /api/export, to a file or standard output
+func (c *cli) export(args []string) error {
+	fs := newFlagSet("export")
+	format := fs.String("format", services.ExportFormatJSON, "json, or csv for a zip archive of CSV files")
+	output := fs.String("o", "", "file to write to instead of standard output")
+	args, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	if *format != services.ExportFormatJSON && *format != services.ExportFormatCSV {
+		return fmt.Errorf("%w: %v", errUsage, services.ErrInvalidExportFormat)
+	}
+
+	user, err := c.admin.FindUser(c.ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return c.exports.Export(c.ctx, user.ID, *format, c.out)
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	if err := c.exports.Export(c.ctx, user.ID, *format, file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
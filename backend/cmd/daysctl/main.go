@@ -0,0 +1,168 @@
+// Command daysctl runs operator tasks against the Days database: managing
+// users and calendars, migrating the schema, checking data integrity and
+// exporting a user's data. It reads the same config file, environment and
+// flags as the server and goes through the same services.
+//
+//	daysctl [flags] <command> [arguments]
+//
+// Output is a human-readable table, or JSON with --json.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"days/internal/audit"
+	"days/internal/config"
+	"days/internal/database"
+	"days/internal/services"
+
+	"github.com/joho/godotenv"
+)
+
+const usage = `usage: daysctl [flags] <command> [arguments]
+
+Commands:
+  users list
+  users create [--password-stdin] <email>
+  users disable <user>
+  users enable <user>
+  users delete --yes <user>
+  users reset-password [--password-stdin] <user>
+  calendars list <user>
+  calendars move <calendar-id> <user>
+  migrate [up | down | to <version> | status]
+  verify
+  export [--format json|csv] [-o file] <user>
+
+A <user> is a user ID or email address. Passwords are generated and printed
+unless --password-stdin reads one from standard input.
+
+Flags:`
+
+// errUsage is returned for commands that cannot be run as given
+var errUsage = errors.New("invalid usage, see daysctl -h")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout))
+}
+
+// run executes a command and returns the exit status: 0 on success, 1 when
+// the command failed and 2 when it was not understood
+func run(args []string, out io.Writer) int {
+	_ = godotenv.Load()
+
+	fs := flag.NewFlagSet("daysctl", flag.ContinueOnError)
+	jsonOutput := fs.Bool("json", false, "print JSON instead of tables")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	cfg, err := config.Load(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, "daysctl:", err)
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Audit events of changes made here name daysctl as the client
+	ctx = audit.WithClient(ctx, "daysctl", "")
+
+	db, err := database.Connect(&cfg.Database)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "daysctl: failed to connect to database:", err)
+		return 1
+	}
+	defer db.Close()
+
+	c := &cli{
+		ctx:       ctx,
+		db:        db,
+		admin:     services.NewAdminService(db.Queries),
+		calendars: services.NewCalendarService(db.Queries),
+		exports:   services.NewExportService(db.Queries),
+		out:       out,
+		json:      *jsonOutput,
+	}
+	if err := c.run(fs.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "daysctl:", err)
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+// cli holds what commands run with
+type cli struct {
+	ctx       context.Context
+	db        *database.Database
+	admin     *services.AdminService
+	calendars *services.CalendarService
+	exports   *services.ExportService
+	out       io.Writer
+	json      bool
+}
+
+// run dispatches a command line to its command
+func (c *cli) run(args []string) error {
+	command, args := args[0], args[1:]
+	switch command {
+	case "users":
+		return c.users(args)
+	case "calendars":
+		return c.calendarsCommand(args)
+	case "migrate":
+		return c.migrate(args)
+	case "verify":
+		return c.verify(args)
+	case "export":
+		return c.export(args)
+	default:
+		return fmt.Errorf("%w: unknown command %q", errUsage, command)
+	}
+}
+
+// subcommand splits the name of a subcommand from its arguments
+func subcommand(command string, args []string) (string, []string, error) {
+	if len(args) == 0 {
+		return "", nil, fmt.Errorf("%w: %s needs a subcommand", errUsage, command)
+	}
+	return args[0], args[1:], nil
+}
+
+// newFlagSet returns the flag set of a subcommand, whose errors are reported
+// by run rather than printed as they are found
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// parseArgs parses the flags of a subcommand and checks that exactly n
+// arguments follow them
+func parseArgs(fs *flag.FlagSet, args []string, n int) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", errUsage, fs.Name(), err)
+	}
+	if fs.NArg() != n {
+		return nil, fmt.Errorf("%w: %s takes %d argument(s)", errUsage, fs.Name(), n)
+	}
+	return fs.Args(), nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"days/db/migrations"
+	"days/internal/migrate"
+)
+
+// migrationStatus is a row of "daysctl migrate status"
+type migrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// schemaVersion is the output of the commands that change the schema
+type schemaVersion struct {
+	Version int64 `json:"version"`
+	Latest  int64 `json:"latest"`
+}
+
+// migrate implements "daysctl migrate ...", like "days migrate" does for the server
+func (c *cli) migrate(args []string) error {
+	migrator, err := migrate.New(c.db.DB, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	command := "up"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "up", "down":
+		if len(args) != 0 {
+			return fmt.Errorf("%w: migrate %s takes no arguments", errUsage, command)
+		}
+		if command == "up" {
+			err = migrator.Up(c.ctx)
+		} else {
+			err = migrator.Down(c.ctx)
+		}
+		if err != nil {
+			return err
+		}
+	case "to":
+		if len(args) != 1 {
+			return fmt.Errorf("%w: migrate to takes a version", errUsage)
+		}
+		target, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: invalid version %q", errUsage, args[0])
+		}
+		if err := migrator.To(c.ctx, target); err != nil {
+			return err
+		}
+	case "status":
+		return c.migrationStatus(migrator)
+	default:
+		return fmt.Errorf("%w: unknown command migrate %q", errUsage, command)
+	}
+
+	version, err := migrator.Version(c.ctx)
+	if err != nil {
+		return err
+	}
+	result := schemaVersion{Version: version, Latest: migrator.Latest()}
+	return c.print(result, table{
+		header: []string{"VERSION", "LATEST"},
+		rows:   [][]string{{strconv.FormatInt(result.Version, 10), strconv.FormatInt(result.Latest, 10)}},
+	})
+}
+
+func (c *cli) migrationStatus(migrator *migrate.Migrator) error {
+	statuses, err := migrator.Status(c.ctx)
+	if err != nil {
+		return err
+	}
+
+	result := make([]migrationStatus, 0, len(statuses))
+	t := table{header: []string{"VERSION", "NAME", "STATE", "APPLIED AT"}}
+	for _, status := range statuses {
+		row := migrationStatus{Version: status.Version, Name: status.Name, Applied: status.Applied}
+		state, appliedAt := "pending", ""
+		if status.Applied {
+			row.AppliedAt = &status.AppliedAt
+			state, appliedAt = "applied", status.AppliedAt.Format(time.RFC3339)
+		}
+		result = append(result, row)
+		t.rows = append(t.rows, []string{fmt.Sprintf("%03d", status.Version), status.Name, state, appliedAt})
+	}
+	return c.print(result, t)
+}
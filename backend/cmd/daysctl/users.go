@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// userResult is the output of commands that change one user
+type userResult struct {
+	ID       uuid.UUID `json:"id"`
+	Email    string    `json:"email"`
+	Status   string    `json:"status"`             // e.g., "disabled"
+	Password string    `json:"password,omitempty"` // set when generated
+}
+
+func (c *cli) printUserResult(result userResult) error {
+	t := table{header: []string{"ID", "EMAIL", "STATUS"}}
+	row := []string{result.ID.String(), result.Email, result.Status}
+	if result.Password != "" {
+		t.header = append(t.header, "PASSWORD")
+		row = append(row, result.Password)
+	}
+	t.rows = [][]string{row}
+	return c.print(result, t)
+}
+
+// users implements "daysctl users ..."
+func (c *cli) users(args []string) error {
+	command, args, err := subcommand("users", args)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "list":
+		return c.listUsers(args)
+	case "create":
+		return c.createUser(args)
+	case "disable", "enable", "delete":
+		return c.setUserStatus(command, args)
+	case "reset-password":
+		return c.resetPassword(args)
+	default:
+		return fmt.Errorf("%w: unknown command users %q", errUsage, command)
+	}
+}
+
+func (c *cli) listUsers(args []string) error {
+	if _, err := parseArgs(newFlagSet("users list"), args, 0); err != nil {
+		return err
+	}
+
+	users, err := c.admin.ListUsers(c.ctx)
+	if err != nil {
+		return err
+	}
+
+	t := table{header: []string{"ID", "EMAIL", "VERIFIED", "2FA", "DISABLED", "CALENDARS", "CREATED"}}
+	for _, user := range users {
+		t.rows = append(t.rows, []string{
+			user.ID.String(),
+			user.Email,
+			yesNo(user.EmailVerified),
+			yesNo(user.TwoFactor),
+			yesNo(user.Disabled),
+			strconv.FormatInt(user.Calendars, 10),
+			user.CreatedAt,
+		})
+	}
+	return c.print(users, t)
+}
+
+func (c *cli) createUser(args []string) error {
+	fs := newFlagSet("users create")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from standard input")
+	args, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	var password string
+	if *passwordStdin {
+		if password, err = readPassword(); err != nil {
+			return err
+		}
+	}
+
+	user, generated, err := c.admin.CreateUser(c.ctx, args[0], password)
+	if err != nil {
+		return err
+	}
+
+	result := userResult{ID: user.ID, Email: user.Email, Status: "created"}
+	if !*passwordStdin {
+		result.Password = generated
+	}
+	return c.printUserResult(result)
+}
+
+func (c *cli) setUserStatus(command string, args []string) error {
+	fs := newFlagSet("users " + command)
+	var yes bool
+	if command == "delete" {
+		fs.BoolVar(&yes, "yes", false, "confirm deleting the user and everything they own")
+	}
+	args, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	user, err := c.admin.FindUser(c.ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	status := command + "d"
+	switch command {
+	case "disable":
+		err = c.admin.DisableUser(c.ctx, user.ID)
+	case "enable":
+		err = c.admin.EnableUser(c.ctx, user.ID)
+	case "delete":
+		if !yes {
+			return fmt.Errorf("%w: deleting %s also deletes their calendars, pass --yes to confirm", errUsage, user.Email)
+		}
+		err = c.admin.DeleteUser(c.ctx, user.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.printUserResult(userResult{ID: user.ID, Email: user.Email, Status: status})
+}
+
+func (c *cli) resetPassword(args []string) error {
+	fs := newFlagSet("users reset-password")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from standard input")
+	args, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	user, err := c.admin.FindUser(c.ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	var password string
+	if *passwordStdin {
+		if password, err = readPassword(); err != nil {
+			return err
+		}
+	}
+
+	generated, err := c.admin.ResetPassword(c.ctx, user.ID, password)
+	if err != nil {
+		return err
+	}
+
+	result := userResult{ID: user.ID, Email: user.Email, Status: "password reset"}
+	if !*passwordStdin {
+		result.Password = generated
+	}
+	return c.printUserResult(result)
+}
+
+// readPassword reads a password from the first line of standard input, so
+// that it does not show up in the shell history or process list
+func readPassword() (string, error) {
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		if err != nil {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		return "", errors.New("no password on standard input")
+	}
+	return password, nil
+}
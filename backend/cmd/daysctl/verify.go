@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"days/db/migrations"
+	"days/internal/migrate"
+	"days/internal/services"
+
+	"github.com/google/uuid"
+)
+
+// errProblemsFound makes verify exit non-zero once it has listed the problems
+var errProblemsFound = errors.New("data integrity problems found")
+
+// verify implements "daysctl verify": it checks that the schema is up to
+// date and that no rows break the invariants the services keep
+func (c *cli) verify(args []string) error {
+	if _, err := parseArgs(newFlagSet("verify"), args, 0); err != nil {
+		return err
+	}
+
+	migrator, err := migrate.New(c.db.DB, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	var problems []services.IntegrityProblem
+	if err := migrator.CheckApplied(c.ctx); err != nil {
+		if !errors.Is(err, migrate.ErrPending) {
+			return err
+		}
+		problems = append(problems, services.IntegrityProblem{Check: "migrations", Detail: err.Error()})
+	}
+
+	found, err := c.admin.VerifyIntegrity(c.ctx)
+	if err != nil {
+		return err
+	}
+	problems = append(problems, found...)
+
+	if !c.json && len(problems) == 0 {
+		fmt.Fprintln(c.out, "No problems found")
+		return nil
+	}
+
+	t := table{header: []string{"CHECK", "RESOURCE", "DETAIL"}}
+	for _, problem := range problems {
+		resource := ""
+		if problem.ResourceID != uuid.Nil {
+			resource = problem.ResourceID.String()
+		}
+		t.rows = append(t.rows, []string{problem.Check, resource, problem.Detail})
+	}
+	if problems == nil {
+		problems = []services.IntegrityProblem{}
+	}
+	if err := c.print(problems, t); err != nil {
+		return err
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %d", errProblemsFound, len(problems))
+	}
+	return nil
+}
@@ -9,55 +9,171 @@
 //	@securityDefinitions.apikey	BearerAuth
 //	@in							header
 //	@name						Authorization
-//	@description				Type "Bearer" followed by a space and JWT token.
+//	@description				Type "Bearer" followed by a space and a JWT access token or a personal API key.
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"days/db/migrations"
 	_ "days/docs"
+	"days/internal/audit"
+	"days/internal/auth"
+	"days/internal/config"
 	"days/internal/database"
 	"days/internal/handlers"
+	"days/internal/logging"
+	"days/internal/mail"
+	"days/internal/metrics"
+	"days/internal/migrate"
+	"days/internal/ratelimit"
 	"days/internal/services"
+	"days/internal/tracing"
 
 	"github.com/joho/godotenv"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 func main() {
-	log.Println("Starting Days Backend Server...")
-
 	// Load environment variables from .env file
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using system environment variables")
+	envErr := godotenv.Load()
+
+	// Settings come from a config file, the environment and flags; the
+	// server does not start on invalid ones, such as a weak JWT secret.
+	// "days migrate ..." runs where only the database is configured, such
+	// as an init container, and checks no more than that.
+	cfg, err := config.Read(flag.CommandLine, os.Args[1:])
+	if err == nil {
+		if flag.Arg(0) == "migrate" {
+			err = cfg.ValidateDatabase()
+		} else {
+			err = cfg.Validate()
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to load config:", err)
+		os.Exit(1)
+	}
+
+	// Logs go to stderr at the level and in the format configured
+	logger, err := logging.Setup(&cfg.Logging)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to configure logging:", err)
+		os.Exit(1)
+	}
+	logger.Info("starting Days backend server")
+	if envErr != nil {
+		logger.Info("no .env file found, using system environment variables")
 	}
 
-	// Load database configuration
-	config := database.NewConfig()
+	// SIGTERM, as sent by Kubernetes, or Ctrl-C shuts the server down gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Spans are exported when OTEL_TRACES_EXPORTER names an exporter
+	shutdownTracing, err := tracing.Setup(context.Background(), &cfg.Tracing)
+	if err != nil {
+		fatal("failed to configure tracing", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("failed to flush spans", "error", err)
+		}
+	}()
 
 	// Log configuration (without password)
-	log.Printf("Attempting to connect to database: %s:%s/%s", config.Host, config.Port, config.DBName)
+	logger.Info("connecting to database", "host", cfg.Database.Host, "port", cfg.Database.Port, "name", cfg.Database.DBName)
 
 	// Connect to database
-	db, err := database.Connect(config)
+	db, err := database.Connect(&cfg.Database)
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		fatal("failed to connect to database", err)
 	}
 	defer db.Close()
 
-	log.Println("Database connection successful!")
+	// "days migrate ..." only manages the schema and exits
+	if args := flag.Args(); len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrate(db, args[1:]); err != nil {
+			fatal("migration failed", err)
+		}
+		return
+	}
+
+	if cfg.Server.MigrateOnStart {
+		if err := migrateOnStart(db); err != nil {
+			fatal("migration failed", err)
+		}
+	}
+
+	mailer, err := mail.New(&cfg.Mail)
+	if err != nil {
+		fatal("failed to configure mail", err)
+	}
+	appURL := cfg.Server.AppURL
+
+	// Rate limits and login lockouts share one in-process store
+	rateLimitStore := ratelimit.NewMemoryStore()
+	loginLockout := ratelimit.NewLockout(rateLimitStore, ratelimit.DefaultLockoutPolicy)
+	limiter := handlers.NewRateLimiter(rateLimitStore, handlers.DefaultRateLimitPolicies())
+
+	// Access tokens are signed with the active key of the keyring
+	keys, err := auth.LoadKeyring(cfg.Auth.Keys)
+	if err != nil {
+		fatal("failed to load JWT keys", err)
+	}
 
 	// Initialize services
-	userService := services.NewUserService(db.Queries)
+	sessionService := services.NewSessionService(db.Queries, keys, cfg.Auth.Sessions)
+	accountService := services.NewAccountService(db.Queries, mailer, appURL)
+	twoFactorService := services.NewTwoFactorService(db.Queries, sessionService, loginLockout)
+	userService := services.NewUserService(db.Queries, sessionService, accountService, twoFactorService, loginLockout)
 	calendarService := services.NewCalendarService(db.Queries)
-	// colorMeaningService := services.NewColorMeaningService(db.Queries, calendarService)
-	// dayEntryService := services.NewDayEntryService(db.Queries, calendarService, colorMeaningService)
+	colorMeaningService := services.NewColorMeaningService(db.Queries, calendarService)
+	dayEntryService := services.NewDayEntryService(db.Queries, calendarService, colorMeaningService)
+	statsService := services.NewStatsService(db.Queries)
+	renderService := services.NewRenderService(db.Queries)
+	exportService := services.NewExportService(db.Queries)
+	importService := services.NewImportService(services.NewSQLImportTransactor(db.DB), db.Queries)
+	icalService := services.NewICalService(db.Queries)
+	memberService := services.NewCalendarMemberService(db.Queries)
+	apiKeyService := services.NewAPIKeyService(db.Queries)
+	auditService := services.NewAuditService(db.Queries)
+
+	// Single sign-on is on when an identity provider is configured
+	var oidcService services.OIDCServiceInterface
+	if oidcConfig := cfg.OIDC; oidcConfig.Enabled() {
+		if oidcConfig.RedirectURL == "" {
+			oidcConfig.RedirectURL = strings.TrimSuffix(appURL, "/") + "/auth/oidc/callback"
+		}
+		client := &http.Client{Timeout: 10 * time.Second, Transport: otelhttp.NewTransport(http.DefaultTransport)}
+		provider, err := auth.DiscoverOIDCProvider(context.Background(), oidcConfig, client)
+		if err != nil {
+			fatal("failed to configure single sign-on", err)
+		}
+		oidcService = services.NewOIDCService(db.Queries, provider, sessionService, twoFactorService)
+		logger.Info("single sign-on enabled", "issuer", provider.Issuer())
+	}
+
+	// Audit events older than the retention period are pruned daily
+	go audit.RunRetention(ctx, db.Queries, cfg.Audit.Retention())
+
+	// Prometheus metrics, including the connection pool and usage read from the database
+	serverMetrics := metrics.New(db.DB, db.Queries)
 
 	// Initialize server with handlers
-	server := handlers.NewServer(userService, calendarService)
+	server := handlers.NewServer(userService, calendarService, colorMeaningService, dayEntryService, sessionService, statsService, renderService, exportService, importService, icalService, memberService, accountService, twoFactorService, apiKeyService, oidcService, auditService, keys, limiter, serverMetrics, cfg.Server.HTTP())
 
 	// Setup routes
 	mux := server.SetupRoutes()
@@ -65,28 +181,112 @@ func main() {
 	// Add Swagger UI
 	mux.Handle("/swagger/", httpSwagger.WrapHandler)
 
-	// Get port from environment
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// Probes: the server is live while it answers, and ready while the
+	// database answers with an up-to-date schema and it is not shutting down
+	migrator, err := migrate.New(db.DB, migrations.FS)
+	if err != nil {
+		fatal("failed to load migrations", err)
+	}
+	probes := handlers.NewProbes()
+	probes.AddCheck("database", db.DB.PingContext)
+	probes.AddCheck("migrations", migrator.CheckApplied)
+	mux.HandleFunc("/livez", probes.Livez)
+	mux.HandleFunc("/readyz", handlers.WithTimeout(readinessTimeout, probes.Readyz))
+
+	// Every request gets a client address, a deadline, an ID, a span, an
+	// access log line and its metrics
+	handler := handlers.ClientIPMiddleware(cfg.Server.HTTP().TrustedProxies, handlers.TimeoutMiddleware(cfg.Server.RequestTimeout, cfg.Server.BulkTimeout, handlers.RequestIDMiddleware(handlers.TracingMiddleware(handlers.AccessLogMiddleware(logger, handlers.MetricsMiddleware(serverMetrics, mux))))))
+	var servers []*http.Server
+
+	// Metrics are served on their own port when one is set, so that they
+	// need not be reachable from where the API is
+	if metricsPort := cfg.Server.MetricsPort; metricsPort != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", serverMetrics.Handler())
+		metricsAddr := fmt.Sprintf(":%s", metricsPort)
+		servers = append(servers, newHTTPServer(metricsAddr, metricsMux, &cfg.Server))
+		logger.Info("metrics server starting", "url", "http://localhost"+metricsAddr+"/metrics")
+	} else {
+		mux.Handle("/metrics", serverMetrics.Handler())
 	}
 
 	// Start HTTP server
-	addr := fmt.Sprintf(":%s", port)
-	log.Printf("Server starting on http://localhost%s", addr)
-	log.Printf("Swagger documentation available at: http://localhost%s/swagger/", addr)
-	log.Printf("API endpoints:")
-	log.Printf("  POST   /api/users          - Create user")
-	log.Printf("  POST   /api/auth/login     - Login")
-	log.Printf("  GET    /api/users/{id}     - Get user")
-	log.Printf("  GET    /api/calendars      - Get user calendars")
-	log.Printf("  POST   /api/calendars      - Create calendar")
-	log.Printf("  GET    /api/calendars/{id} - Get calendar")
-	log.Printf("  PUT    /api/calendars/{id} - Update calendar")
-	log.Printf("  DELETE /api/calendars/{id} - Delete calendar")
-	log.Printf("  GET    /health             - Health check")
-
-	if err := http.ListenAndServe(addr, mux); err != nil {
-		log.Fatal("Server failed to start:", err)
+	addr := fmt.Sprintf(":%s", cfg.Server.Port)
+	logger.Info("server starting", "url", "http://localhost"+addr, "docs", "http://localhost"+addr+"/swagger/")
+	logger.Debug("endpoint", "route", "GET /.well-known/jwks.json", "description", "Token signing keys")
+	logger.Debug("endpoint", "route", "POST /api/users", "description", "Create user")
+	logger.Debug("endpoint", "route", "POST /api/auth/login", "description", "Login")
+	logger.Debug("endpoint", "route", "POST /api/auth/refresh", "description", "Refresh access token")
+	logger.Debug("endpoint", "route", "POST /api/auth/forgot-password", "description", "Email a password reset link")
+	logger.Debug("endpoint", "route", "POST /api/auth/reset-password", "description", "Reset password with emailed token")
+	logger.Debug("endpoint", "route", "POST /api/auth/verify-email", "description", "Verify email with emailed token")
+	logger.Debug("endpoint", "route", "POST /api/auth/verify-email/resend", "description", "Resend verification email")
+	logger.Debug("endpoint", "route", "POST /api/auth/2fa/verify", "description", "Complete login with a two-factor code")
+	logger.Debug("endpoint", "route", "POST /api/auth/oidc/login", "description", "Start a single sign-on login")
+	logger.Debug("endpoint", "route", "POST /api/auth/oidc/callback", "description", "Complete a single sign-on login")
+	logger.Debug("endpoint", "route", "GET /api/auth/2fa", "description", "Get two-factor status")
+	logger.Debug("endpoint", "route", "POST /api/auth/2fa/enroll", "description", "Start two-factor enrollment")
+	logger.Debug("endpoint", "route", "POST /api/auth/2fa/confirm", "description", "Enable two-factor with a code")
+	logger.Debug("endpoint", "route", "POST /api/auth/2fa/recovery-codes", "description", "Regenerate recovery codes")
+	logger.Debug("endpoint", "route", "POST /api/auth/2fa/disable", "description", "Disable two-factor")
+	logger.Debug("endpoint", "route", "POST /api/auth/logout", "description", "Logout")
+	logger.Debug("endpoint", "route", "GET /api/auth/sessions", "description", "List active sessions")
+	logger.Debug("endpoint", "route", "DELETE /api/auth/sessions/{id}", "description", "Revoke session")
+	logger.Debug("endpoint", "route", "GET /api/auth/api-keys", "description", "List API keys")
+	logger.Debug("endpoint", "route", "POST /api/auth/api-keys", "description", "Create API key")
+	logger.Debug("endpoint", "route", "DELETE /api/auth/api-keys/{id}", "description", "Revoke API key")
+	logger.Debug("endpoint", "route", "GET /api/users/{id}", "description", "Get user")
+	logger.Debug("endpoint", "route", "PUT /api/users/me/password", "description", "Change password")
+	logger.Debug("endpoint", "route", "PUT /api/users/me/email", "description", "Change email address")
+	logger.Debug("endpoint", "route", "DELETE /api/users/me", "description", "Delete account")
+	logger.Debug("endpoint", "route", "GET /api/users/me/audit?cursor=", "description", "List my audit events")
+	logger.Debug("endpoint", "route", "GET /api/calendars", "description", "Get user calendars")
+	logger.Debug("endpoint", "route", "POST /api/calendars", "description", "Create calendar")
+	logger.Debug("endpoint", "route", "GET /api/calendars/{id}", "description", "Get calendar")
+	logger.Debug("endpoint", "route", "PUT /api/calendars/{id}", "description", "Update calendar")
+	logger.Debug("endpoint", "route", "DELETE /api/calendars/{id}", "description", "Delete calendar")
+	logger.Debug("endpoint", "route", "GET /api/calendars/{id}/colors", "description", "Get calendar legend")
+	logger.Debug("endpoint", "route", "POST /api/calendars/{id}/colors", "description", "Create color meaning")
+	logger.Debug("endpoint", "route", "GET /api/calendars/{id}/colors/{colorId}", "description", "Get color meaning")
+	logger.Debug("endpoint", "route", "PUT /api/calendars/{id}/colors/{colorId}", "description", "Update color meaning")
+	logger.Debug("endpoint", "route", "DELETE /api/calendars/{id}/colors/{colorId}", "description", "Delete color meaning")
+	logger.Debug("endpoint", "route", "GET /api/calendars/{id}/entries", "description", "Get calendar entries")
+	logger.Debug("endpoint", "route", "POST /api/calendars/{id}/entries", "description", "Create day entry")
+	logger.Debug("endpoint", "route", "GET /api/calendars/{id}/entries/{date}", "description", "Get day entry")
+	logger.Debug("endpoint", "route", "PUT /api/calendars/{id}/entries/{date}", "description", "Update day entry")
+	logger.Debug("endpoint", "route", "DELETE /api/calendars/{id}/entries/{date}", "description", "Delete day entry")
+	logger.Debug("endpoint", "route", "GET /api/calendars/{id}/stats", "description", "Get calendar statistics")
+	logger.Debug("endpoint", "route", "GET /api/calendars/{id}/render", "description", "Render calendar year as SVG or PNG")
+	logger.Debug("endpoint", "route", "GET /api/calendars/{id}/ical?token=", "description", "iCalendar feed (feed token auth)")
+	logger.Debug("endpoint", "route", "POST /api/calendars/{id}/ical/token", "description", "Create calendar feed token")
+	logger.Debug("endpoint", "route", "DELETE /api/calendars/{id}/ical/token", "description", "Revoke calendar feed token")
+	logger.Debug("endpoint", "route", "GET /api/calendars/{id}/members", "description", "Get calendar members")
+	logger.Debug("endpoint", "route", "PUT /api/calendars/{id}/members/{userId}", "description", "Change member role")
+	logger.Debug("endpoint", "route", "DELETE /api/calendars/{id}/members/{userId}", "description", "Remove member or leave calendar")
+	logger.Debug("endpoint", "route", "GET /api/calendars/{id}/invitations", "description", "Get pending calendar invitations")
+	logger.Debug("endpoint", "route", "POST /api/calendars/{id}/invitations", "description", "Invite calendar member")
+	logger.Debug("endpoint", "route", "GET /api/invitations", "description", "Get my invitations")
+	logger.Debug("endpoint", "route", "POST /api/invitations/{id}/accept", "description", "Accept invitation")
+	logger.Debug("endpoint", "route", "DELETE /api/invitations/{id}", "description", "Decline invitation")
+	logger.Debug("endpoint", "route", "GET /api/entries?start=&end=", "description", "Get entries by date range")
+	logger.Debug("endpoint", "route", "GET /api/export?format=json|csv", "description", "Export all user data")
+	logger.Debug("endpoint", "route", "POST /api/import", "description", "Import exported data")
+	logger.Debug("endpoint", "route", "GET /health", "description", "Health check")
+	logger.Debug("endpoint", "route", "GET /livez", "description", "Liveness probe")
+	logger.Debug("endpoint", "route", "GET /readyz", "description", "Readiness probe: database and migrations")
+	logger.Debug("endpoint", "route", "GET /metrics", "description", "Prometheus metrics (on METRICS_PORT when set)")
+
+	// Serve until SIGTERM, then report not ready and drain in-flight requests
+	servers = append(servers, newHTTPServer(addr, handler, &cfg.Server))
+	if err := serve(ctx, &cfg.Server, probes.SetShuttingDown, servers...); err != nil {
+		logger.Error("server stopped", "error", err)
+		os.Exit(1)
 	}
+	logger.Info("server stopped")
+}
+
+// fatal logs an error that keeps the server from running and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"days/db/migrations"
+	"days/internal/database"
+	"days/internal/migrate"
+)
+
+const migrateUsage = "usage: days migrate [up | down | to <version> | status]"
+
+// runMigrate implements the "migrate" subcommand
+func runMigrate(db *database.Database, args []string) error {
+	migrator, err := migrate.New(db.DB, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		if err := migrator.Up(ctx); err != nil {
+			return err
+		}
+	case "down":
+		if err := migrator.Down(ctx); err != nil {
+			return err
+		}
+	case "to":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		target, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if err := migrator.To(ctx, target); err != nil {
+			return err
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02T15:04:05Z07:00")
+			}
+			fmt.Printf("%03d  %-24s %s\n", status.Version, status.Name, state)
+		}
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	version, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+	slog.Info("database schema migrated", "version", version, "latest", migrator.Latest())
+	return nil
+}
+
+// migrateOnStart applies pending migrations before the server starts serving
+func migrateOnStart(db *database.Database) error {
+	migrator, err := migrate.New(db.DB, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	if err := migrator.Up(context.Background()); err != nil {
+		return err
+	}
+
+	slog.Info("database schema migrated", "version", migrator.Latest())
+	return nil
+}
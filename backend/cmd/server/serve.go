@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"days/internal/config"
+)
+
+// readinessTimeout bounds the checks of a readiness probe, which must answer
+// before the probe itself times out
+const readinessTimeout = 2 * time.Second
+
+// newHTTPServer creates a server for handler with the configured timeouts
+func newHTTPServer(addr string, handler http.Handler, cfg *config.ServerConfig) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+}
+
+// serve runs servers until ctx is done or one of them fails. On the way out,
+// beforeShutdown is called, the servers keep serving for the shutdown delay
+// and then stop taking connections while in-flight requests drain.
+func serve(ctx context.Context, cfg *config.ServerConfig, beforeShutdown func(), servers ...*http.Server) error {
+	errs := make(chan error, len(servers))
+	for _, server := range servers {
+		go func() {
+			if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				errs <- fmt.Errorf("server on %s failed: %w", server.Addr, err)
+			}
+		}()
+	}
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		slog.Info("shutting down", "delay", cfg.ShutdownDelay, "timeout", cfg.ShutdownTimeout)
+	case serveErr = <-errs:
+	}
+
+	beforeShutdown()
+	if serveErr == nil {
+		time.Sleep(cfg.ShutdownDelay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	for _, server := range servers {
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			serveErr = errors.Join(serveErr, fmt.Errorf("failed to drain server on %s: %w", server.Addr, err))
+		}
+	}
+	return serveErr
+}
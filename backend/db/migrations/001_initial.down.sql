@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS day_entries;
+DROP TABLE IF EXISTS color_meanings;
+DROP TABLE IF EXISTS calendars;
+DROP TABLE IF EXISTS users;
@@ -1,8 +1,10 @@
+-- Tables and indexes use IF NOT EXISTS so that databases created before the
+-- schema_migrations table existed are adopted instead of failing on version 1.
 -- Required for gen_random_uuid()
 CREATE EXTENSION IF NOT EXISTS pgcrypto;
 
 -- Users table for authentication
-CREATE TABLE users (
+CREATE TABLE IF NOT EXISTS users (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     email VARCHAR(255) UNIQUE NOT NULL,
     password_hash VARCHAR(255) NOT NULL,
@@ -11,7 +13,7 @@ CREATE TABLE users (
 );
 
 -- Calendars table - each user can have multiple calendars
-CREATE TABLE calendars (
+CREATE TABLE IF NOT EXISTS calendars (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     name VARCHAR(100) NOT NULL,
@@ -22,7 +24,7 @@ CREATE TABLE calendars (
 );
 
 -- Color meanings for each calendar (e.g., red = stressed, green = relaxed)
-CREATE TABLE color_meanings (
+CREATE TABLE IF NOT EXISTS color_meanings (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     calendar_id UUID NOT NULL REFERENCES calendars(id) ON DELETE CASCADE,
     color_hex VARCHAR(7) NOT NULL, -- e.g., "#FF0000"
@@ -31,7 +33,7 @@ CREATE TABLE color_meanings (
 );
 
 -- Day entries - tracks a specific day for a specific calendar
-CREATE TABLE day_entries (
+CREATE TABLE IF NOT EXISTS day_entries (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     calendar_id UUID NOT NULL REFERENCES calendars(id) ON DELETE CASCADE,
     date DATE NOT NULL,
@@ -43,8 +45,8 @@ CREATE TABLE day_entries (
 );
 
 -- Indexes for better performance
-CREATE INDEX idx_calendars_user_id ON calendars(user_id);
-CREATE INDEX idx_color_meanings_calendar_id ON color_meanings(calendar_id);
-CREATE INDEX idx_day_entries_calendar_id ON day_entries(calendar_id);
-CREATE INDEX idx_day_entries_date ON day_entries(date);
-CREATE INDEX idx_day_entries_calendar_date ON day_entries(calendar_id, date);
+CREATE INDEX IF NOT EXISTS idx_calendars_user_id ON calendars(user_id);
+CREATE INDEX IF NOT EXISTS idx_color_meanings_calendar_id ON color_meanings(calendar_id);
+CREATE INDEX IF NOT EXISTS idx_day_entries_calendar_id ON day_entries(calendar_id);
+CREATE INDEX IF NOT EXISTS idx_day_entries_date ON day_entries(date);
+CREATE INDEX IF NOT EXISTS idx_day_entries_calendar_date ON day_entries(calendar_id, date);
@@ -0,0 +1,8 @@
+-- Secret tokens that let calendar apps subscribe to a calendar's iCalendar
+-- feed, since they cannot send Bearer headers. Each calendar has at most one
+-- token; creating a new one replaces it and deleting the row revokes the feed.
+CREATE TABLE calendar_feed_tokens (
+    calendar_id UUID PRIMARY KEY REFERENCES calendars(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) UNIQUE NOT NULL, -- SHA-256 hex of the token
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
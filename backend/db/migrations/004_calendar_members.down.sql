@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS calendar_invitations;
+DROP TRIGGER IF EXISTS calendars_add_owner ON calendars;
+DROP FUNCTION IF EXISTS add_calendar_owner();
+DROP TABLE IF EXISTS calendar_members;
@@ -0,0 +1,44 @@
+-- Calendar sharing. Every calendar has exactly one owner, the user it was
+-- created by (calendars.user_id); editors and viewers are added by accepting
+-- an invitation. Access checks read the member row, so the owner gets one too.
+CREATE TABLE calendar_members (
+    calendar_id UUID NOT NULL REFERENCES calendars(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(10) NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (calendar_id, user_id)
+);
+
+CREATE INDEX idx_calendar_members_user_id ON calendar_members(user_id);
+
+-- Owners of existing calendars
+INSERT INTO calendar_members (calendar_id, user_id, role)
+SELECT id, user_id, 'owner' FROM calendars;
+
+-- New calendars get their owner row no matter which code path creates them
+CREATE FUNCTION add_calendar_owner() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO calendar_members (calendar_id, user_id, role)
+    VALUES (NEW.id, NEW.user_id, 'owner');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER calendars_add_owner
+AFTER INSERT ON calendars
+FOR EACH ROW EXECUTE FUNCTION add_calendar_owner();
+
+-- Pending invitations, addressed by email so that people can be invited
+-- before they sign up. Accepting one turns it into a member row.
+CREATE TABLE calendar_invitations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    calendar_id UUID NOT NULL REFERENCES calendars(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL, -- lower case, like users.email
+    role VARCHAR(10) NOT NULL CHECK (role IN ('editor', 'viewer')),
+    invited_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (calendar_id, email)
+);
+
+CREATE INDEX idx_calendar_invitations_email ON calendar_invitations(email);
@@ -0,0 +1,17 @@
+-- Accounts start unverified; verifying the email address sets the timestamp.
+-- Existing accounts predate verification and are left unverified.
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP WITH TIME ZONE;
+
+-- Single-use tokens mailed to users to reset their password or verify their
+-- email address. Only the hash is stored; using a token sets used_at.
+CREATE TABLE account_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose VARCHAR(20) NOT NULL CHECK (purpose IN ('password_reset', 'email_verification')),
+    token_hash VARCHAR(64) UNIQUE NOT NULL, -- SHA-256 hex of the token
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_account_tokens_user_id ON account_tokens(user_id);
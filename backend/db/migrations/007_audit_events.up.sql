@@ -0,0 +1,14 @@
+-- Security-relevant changes to accounts, such as a new password or email
+-- address. Rows keep the user's ID without a foreign key so that they outlive
+-- the account they describe.
+CREATE TABLE audit_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    action VARCHAR(64) NOT NULL, -- e.g., "user.password_changed"
+    metadata JSONB NOT NULL DEFAULT '{}',
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_events_user_id_created_at ON audit_events(user_id, created_at DESC);
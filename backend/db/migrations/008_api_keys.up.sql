@@ -0,0 +1,15 @@
+-- Personal API keys for scripts and integrations. Only the hash of a key is
+-- stored, next to its first characters so that users can tell keys apart.
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) UNIQUE NOT NULL, -- SHA-256 hex of the key
+    scopes TEXT[] NOT NULL DEFAULT '{}', -- e.g., {"entries:write", "calendars:read"}
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE, -- NULL for keys that never expire
+    last_used_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_api_keys_user_id ON api_keys(user_id);
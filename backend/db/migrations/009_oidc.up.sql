@@ -0,0 +1,30 @@
+-- Accounts created through single sign-on have no password until one is set
+-- with a password reset
+ALTER TABLE users ALTER COLUMN password_hash DROP NOT NULL;
+
+-- Links accounts to identities at OpenID Connect providers. The subject is
+-- the provider's stable ID for the user; the email is as last reported.
+CREATE TABLE user_identities (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    issuer VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE(issuer, subject)
+);
+
+CREATE INDEX idx_user_identities_user_id ON user_identities(user_id);
+
+-- Logins that have left for the provider and not come back yet. The state
+-- travels through the browser, so only its hash is stored; the PKCE verifier
+-- and nonce never leave the server.
+CREATE TABLE oidc_login_states (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    state_hash VARCHAR(64) UNIQUE NOT NULL, -- SHA-256 hex of the state
+    code_verifier VARCHAR(128) NOT NULL,
+    nonce VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_audit_events_created_at;
+DROP INDEX IF EXISTS idx_audit_events_resource;
+
+ALTER TABLE audit_events
+    DROP COLUMN IF EXISTS changes,
+    DROP COLUMN IF EXISTS resource_id,
+    DROP COLUMN IF EXISTS resource_type;
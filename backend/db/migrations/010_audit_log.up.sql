@@ -0,0 +1,13 @@
+-- Audit events also record changes to calendars and their contents. An event
+-- names the resource it is about, and changes holds the fields that changed,
+-- each with its value before and after.
+ALTER TABLE audit_events
+    ADD COLUMN resource_type VARCHAR(32) NOT NULL DEFAULT '', -- e.g., "calendar"
+    ADD COLUMN resource_id UUID,
+    ADD COLUMN changes JSONB NOT NULL DEFAULT '{}';
+
+-- Answers "who deleted this calendar?"
+CREATE INDEX idx_audit_events_resource ON audit_events(resource_type, resource_id, created_at DESC);
+
+-- Events past the retention period are pruned by age
+CREATE INDEX idx_audit_events_created_at ON audit_events(created_at);
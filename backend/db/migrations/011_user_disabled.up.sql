@@ -0,0 +1,3 @@
+-- Operators can disable an account without deleting it. A disabled user
+-- cannot log in or use API keys; disabling also signs out every session.
+ALTER TABLE users ADD COLUMN disabled_at TIMESTAMP WITH TIME ZONE;
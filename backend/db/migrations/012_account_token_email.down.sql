@@ -0,0 +1 @@
+ALTER TABLE account_tokens DROP COLUMN IF EXISTS email;
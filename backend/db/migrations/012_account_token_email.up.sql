@@ -0,0 +1,4 @@
+-- The address a token was mailed to. Using a password reset token proves
+-- control of that address only, so the account's email is marked verified
+-- only while it still matches. Tokens issued before this column have none.
+ALTER TABLE account_tokens ADD COLUMN email VARCHAR(255);
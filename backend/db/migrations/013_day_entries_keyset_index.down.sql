@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_day_entries_calendar_date_id;
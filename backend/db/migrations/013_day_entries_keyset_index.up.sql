@@ -0,0 +1,3 @@
+-- Pages of a calendar's entries are read in (date, id) order in either
+-- direction; this index serves both without a sort.
+CREATE INDEX IF NOT EXISTS idx_day_entries_calendar_date_id ON day_entries(calendar_id, date, id);
@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL schema migrations so they ship inside the
+// server binary. Files are named {version}_{name}.up.sql and
+// {version}_{name}.down.sql and are applied by internal/migrate.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
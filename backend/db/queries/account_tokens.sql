@@ -0,0 +1,22 @@
+-- name: CreateAccountToken :one
+INSERT INTO account_tokens (user_id, purpose, token_hash, expires_at, email)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ConsumeAccountToken :one
+-- Marks a token used in the same statement that finds it, so that two
+-- requests racing with one token cannot both succeed.
+UPDATE account_tokens
+SET used_at = NOW()
+WHERE token_hash = $1
+  AND purpose = $2
+  AND used_at IS NULL
+  AND expires_at > NOW()
+RETURNING *;
+
+-- name: InvalidateAccountTokens :exec
+UPDATE account_tokens
+SET used_at = NOW()
+WHERE user_id = $1
+  AND purpose = $2
+  AND used_at IS NULL;
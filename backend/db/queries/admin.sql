@@ -0,0 +1,77 @@
+-- name: ListUsers :many
+SELECT u.id, u.email, u.email_verified_at, u.totp_enabled_at, u.disabled_at, u.created_at,
+    (SELECT COUNT(*) FROM calendars c WHERE c.user_id = u.id) AS calendar_count
+FROM users u
+ORDER BY u.created_at;
+
+-- name: DisableUser :execrows
+UPDATE users
+SET disabled_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND disabled_at IS NULL;
+
+-- name: EnableUser :execrows
+UPDATE users
+SET disabled_at = NULL, updated_at = NOW()
+WHERE id = $1 AND disabled_at IS NOT NULL;
+
+-- name: TransferCalendar :one
+-- Hands a calendar to a new owner. The previous owner loses access, and the
+-- feed token is revoked since whoever holds it no longer owns the calendar.
+-- A new owner who was already a member is promoted.
+WITH moved AS (
+    UPDATE calendars
+    SET user_id = sqlc.arg(new_owner_id), updated_at = NOW()
+    WHERE calendars.id = sqlc.arg(id)
+    RETURNING *
+), previous_owner AS (
+    DELETE FROM calendar_members
+    WHERE calendar_id = sqlc.arg(id) AND role = 'owner'
+), new_owner AS (
+    INSERT INTO calendar_members (calendar_id, user_id, role)
+    SELECT moved.id, moved.user_id, 'owner' FROM moved
+    ON CONFLICT (calendar_id, user_id) DO UPDATE
+    SET role = 'owner', updated_at = NOW()
+), feed_token AS (
+    DELETE FROM calendar_feed_tokens
+    WHERE calendar_id = sqlc.arg(id)
+)
+SELECT * FROM moved;
+
+-- name: ListIntegrityProblems :many
+-- Rows the schema cannot rule out but the services never write. Each names
+-- the check it failed, the row at fault and what is wrong with it.
+SELECT 'calendar_owner'::TEXT AS check_name, c.id AS resource_id,
+    ('calendar has no owner member row for its user ' || c.user_id::TEXT)::TEXT AS detail
+FROM calendars c
+WHERE NOT EXISTS (
+    SELECT 1 FROM calendar_members m
+    WHERE m.calendar_id = c.id AND m.user_id = c.user_id AND m.role = 'owner'
+)
+UNION ALL
+SELECT 'calendar_owner'::TEXT, m.calendar_id,
+    'user ' || m.user_id::TEXT || ' is an owner member but does not own the calendar'
+FROM calendar_members m
+JOIN calendars c ON c.id = m.calendar_id
+WHERE m.role = 'owner' AND m.user_id <> c.user_id
+UNION ALL
+SELECT 'day_entry_color'::TEXT, e.id,
+    'entry uses color meaning ' || cm.id::TEXT || ' of calendar ' || cm.calendar_id::TEXT
+FROM day_entries e
+JOIN color_meanings cm ON cm.id = e.color_meaning_id
+WHERE cm.calendar_id <> e.calendar_id
+UNION ALL
+SELECT 'color_meaning_hex'::TEXT, cm.id,
+    'color ' || cm.color_hex || ' is not a #RRGGBB hex color'
+FROM color_meanings cm
+WHERE cm.color_hex !~ '^#[0-9A-Fa-f]{6}$'
+UNION ALL
+SELECT 'color_meaning_duplicate'::TEXT, cm.id,
+    'color ' || cm.color_hex || ' is already used in calendar ' || cm.calendar_id::TEXT
+FROM color_meanings cm
+WHERE EXISTS (
+    SELECT 1 FROM color_meanings earlier
+    WHERE earlier.calendar_id = cm.calendar_id
+      AND UPPER(earlier.color_hex) = UPPER(cm.color_hex)
+      AND (earlier.created_at, earlier.id) < (cm.created_at, cm.id)
+)
+ORDER BY 1, 2;
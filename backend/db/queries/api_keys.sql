@@ -0,0 +1,25 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (user_id, name, key_prefix, key_hash, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetAPIKeysByUserID :many
+SELECT * FROM api_keys
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetActiveAPIKeyByHash :one
+-- Keys of disabled users are not active.
+SELECT * FROM api_keys
+WHERE key_hash = $1
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND user_id NOT IN (SELECT id FROM users WHERE disabled_at IS NOT NULL);
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteAPIKey :execrows
+DELETE FROM api_keys
+WHERE id = $1 AND user_id = $2;
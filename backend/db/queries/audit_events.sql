@@ -0,0 +1,16 @@
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (user_id, action, resource_type, resource_id, metadata, changes, user_agent, ip_address)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: ListAuditEventsByUser :many
+-- Newest first, from the event after the cursor when one is given
+SELECT * FROM audit_events
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL
+       OR (created_at, id) < (sqlc.narg(cursor_created_at)::timestamptz, sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: DeleteAuditEventsBefore :execrows
+DELETE FROM audit_events
+WHERE created_at < $1;
@@ -0,0 +1,14 @@
+-- name: UpsertCalendarFeedToken :one
+INSERT INTO calendar_feed_tokens (calendar_id, token_hash)
+VALUES ($1, $2)
+ON CONFLICT (calendar_id) DO UPDATE
+SET token_hash = EXCLUDED.token_hash, created_at = NOW()
+RETURNING *;
+
+-- name: GetCalendarFeedToken :one
+SELECT * FROM calendar_feed_tokens
+WHERE calendar_id = $1;
+
+-- name: DeleteCalendarFeedToken :execrows
+DELETE FROM calendar_feed_tokens
+WHERE calendar_id = $1;
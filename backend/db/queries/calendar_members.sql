@@ -0,0 +1,92 @@
+-- name: GetCalendarWithRole :one
+SELECT sqlc.embed(c), m.role
+FROM calendars c
+LEFT JOIN calendar_members m ON m.calendar_id = c.id AND m.user_id = sqlc.arg(user_id)
+WHERE c.id = sqlc.arg(id);
+
+-- name: ListCalendarsByMemberID :many
+-- A page of the calendars a user is a member of in ascending (created_at, id)
+-- order, from the calendar after the cursor when one is given.
+-- ListCalendarsByMemberIDDesc is the same page the other way round.
+SELECT sqlc.embed(c), m.role
+FROM calendar_members m
+JOIN calendars c ON c.id = m.calendar_id
+WHERE m.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (c.created_at, c.id) > (sqlc.narg(cursor_created_at)::timestamptz, sqlc.narg(cursor_id)::uuid))
+ORDER BY c.created_at, c.id
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListCalendarsByMemberIDDesc :many
+SELECT sqlc.embed(c), m.role
+FROM calendar_members m
+JOIN calendars c ON c.id = m.calendar_id
+WHERE m.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (c.created_at, c.id) < (sqlc.narg(cursor_created_at)::timestamptz, sqlc.narg(cursor_id)::uuid))
+ORDER BY c.created_at DESC, c.id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetCalendarMembers :many
+SELECT m.calendar_id, m.user_id, u.email, m.role, m.created_at, m.updated_at
+FROM calendar_members m
+JOIN users u ON u.id = m.user_id
+WHERE m.calendar_id = $1
+ORDER BY m.role = 'owner' DESC, m.created_at;
+
+-- name: GetCalendarMember :one
+SELECT m.calendar_id, m.user_id, u.email, m.role, m.created_at, m.updated_at
+FROM calendar_members m
+JOIN users u ON u.id = m.user_id
+WHERE m.calendar_id = $1 AND m.user_id = $2;
+
+-- name: UpdateCalendarMemberRole :one
+UPDATE calendar_members
+SET role = $3, updated_at = NOW()
+WHERE calendar_id = $1 AND user_id = $2 AND role <> 'owner'
+RETURNING *;
+
+-- name: DeleteCalendarMember :execrows
+DELETE FROM calendar_members
+WHERE calendar_id = $1 AND user_id = $2 AND role <> 'owner';
+
+-- name: UpsertCalendarInvitation :one
+INSERT INTO calendar_invitations (calendar_id, email, role, invited_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (calendar_id, email) DO UPDATE
+SET role = EXCLUDED.role, invited_by = EXCLUDED.invited_by, created_at = NOW()
+RETURNING *;
+
+-- name: GetCalendarInvitationsByCalendarID :many
+SELECT * FROM calendar_invitations
+WHERE calendar_id = $1
+ORDER BY created_at;
+
+-- name: GetCalendarInvitationsByEmail :many
+SELECT i.id, i.calendar_id, c.name AS calendar_name, i.email, i.role, i.invited_by, u.email AS invited_by_email, i.created_at
+FROM calendar_invitations i
+JOIN calendars c ON c.id = i.calendar_id
+JOIN users u ON u.id = i.invited_by
+WHERE i.email = $1
+ORDER BY i.created_at;
+
+-- name: AcceptCalendarInvitation :one
+-- Only the user who verified the address invited may accept. Accepting
+-- twice, or an invitation to a calendar one is already a member of, keeps
+-- the existing role
+WITH invitation AS (
+    DELETE FROM calendar_invitations
+    WHERE calendar_invitations.id = sqlc.arg(id) AND calendar_invitations.email = sqlc.arg(email)
+      AND EXISTS (
+          SELECT 1 FROM users
+          WHERE users.id = sqlc.arg(user_id) AND users.email = sqlc.arg(email)
+            AND users.email_verified_at IS NOT NULL
+      )
+    RETURNING calendar_id, role
+)
+INSERT INTO calendar_members (calendar_id, user_id, role)
+SELECT invitation.calendar_id, sqlc.arg(user_id), invitation.role FROM invitation
+ON CONFLICT (calendar_id, user_id) DO UPDATE SET role = calendar_members.role
+RETURNING *;
+
+-- name: DeleteCalendarInvitation :execrows
+DELETE FROM calendar_invitations
+WHERE id = $1 AND email = $2;
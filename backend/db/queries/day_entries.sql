@@ -10,12 +10,45 @@ JOIN color_meanings cm ON de.color_meaning_id = cm.id
 WHERE de.calendar_id = $1
 ORDER BY de.date DESC;
 
+-- name: ListDayEntriesByCalendar :many
+-- A page of a calendar's entries in ascending (date, id) order, from the
+-- entry after the cursor when one is given. Filters left NULL or empty
+-- match every entry. ListDayEntriesByCalendarDesc is the same page the
+-- other way round; the two stay separate so each walks the index one way.
+SELECT de.*, cm.color_hex, cm.meaning
+FROM day_entries de
+JOIN color_meanings cm ON de.color_meaning_id = cm.id
+WHERE de.calendar_id = sqlc.arg(calendar_id)
+  AND (sqlc.narg(start_date)::date IS NULL OR de.date >= sqlc.narg(start_date)::date)
+  AND (sqlc.narg(end_date)::date IS NULL OR de.date <= sqlc.narg(end_date)::date)
+  AND (COALESCE(cardinality(sqlc.arg(color_meaning_ids)::uuid[]), 0) = 0 OR de.color_meaning_id = ANY(sqlc.arg(color_meaning_ids)::uuid[]))
+  AND (sqlc.narg(has_notes)::boolean IS NULL OR (COALESCE(de.notes, '') <> '') = sqlc.narg(has_notes)::boolean)
+  AND (sqlc.narg(notes_pattern)::text IS NULL OR de.notes ILIKE sqlc.narg(notes_pattern)::text)
+  AND (sqlc.narg(cursor_date)::date IS NULL OR (de.date, de.id) > (sqlc.narg(cursor_date)::date, sqlc.narg(cursor_id)::uuid))
+ORDER BY de.date, de.id
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListDayEntriesByCalendarDesc :many
+SELECT de.*, cm.color_hex, cm.meaning
+FROM day_entries de
+JOIN color_meanings cm ON de.color_meaning_id = cm.id
+WHERE de.calendar_id = sqlc.arg(calendar_id)
+  AND (sqlc.narg(start_date)::date IS NULL OR de.date >= sqlc.narg(start_date)::date)
+  AND (sqlc.narg(end_date)::date IS NULL OR de.date <= sqlc.narg(end_date)::date)
+  AND (COALESCE(cardinality(sqlc.arg(color_meaning_ids)::uuid[]), 0) = 0 OR de.color_meaning_id = ANY(sqlc.arg(color_meaning_ids)::uuid[]))
+  AND (sqlc.narg(has_notes)::boolean IS NULL OR (COALESCE(de.notes, '') <> '') = sqlc.narg(has_notes)::boolean)
+  AND (sqlc.narg(notes_pattern)::text IS NULL OR de.notes ILIKE sqlc.narg(notes_pattern)::text)
+  AND (sqlc.narg(cursor_date)::date IS NULL OR (de.date, de.id) < (sqlc.narg(cursor_date)::date, sqlc.narg(cursor_id)::uuid))
+ORDER BY de.date DESC, de.id DESC
+LIMIT sqlc.arg(row_limit);
+
 -- name: GetDayEntriesByDateRange :many
 SELECT de.*, cm.color_hex, cm.meaning, c.name as calendar_name
 FROM day_entries de
 JOIN color_meanings cm ON de.color_meaning_id = cm.id
 JOIN calendars c ON de.calendar_id = c.id
-WHERE c.user_id = $1 
+JOIN calendar_members m ON m.calendar_id = c.id
+WHERE m.user_id = $1
   AND de.date >= $2 
   AND de.date <= $3
 ORDER BY de.date DESC, c.name;
@@ -35,3 +68,11 @@ RETURNING *;
 -- name: DeleteDayEntry :exec
 DELETE FROM day_entries
 WHERE calendar_id = $1 AND date = $2;
+
+-- name: GetDayEntryDatesByCalendarAndDateRange :many
+SELECT date, color_meaning_id
+FROM day_entries
+WHERE calendar_id = sqlc.arg(calendar_id)
+  AND date >= sqlc.arg(start_date)
+  AND date <= sqlc.arg(end_date)
+ORDER BY date;
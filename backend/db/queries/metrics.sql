@@ -0,0 +1,7 @@
+-- name: GetUsageMetrics :one
+SELECT
+    (SELECT COUNT(*) FROM users) AS users,
+    (SELECT COUNT(*) FROM calendars) AS calendars,
+    (SELECT COUNT(*) FROM day_entries) AS day_entries,
+    (SELECT COUNT(*) FROM day_entries WHERE day_entries.created_at >= NOW() - INTERVAL '1 day') AS day_entries_created_last_day,
+    (SELECT COUNT(*) FROM sessions WHERE sessions.revoked_at IS NULL AND sessions.expires_at > NOW()) AS active_sessions;
@@ -0,0 +1,29 @@
+-- name: CreateOIDCLoginState :exec
+INSERT INTO oidc_login_states (state_hash, code_verifier, nonce, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: ConsumeOIDCLoginState :one
+-- Deletes the state in the same statement that finds it, so that a state
+-- can complete only one login.
+DELETE FROM oidc_login_states
+WHERE state_hash = $1
+  AND expires_at > NOW()
+RETURNING *;
+
+-- name: DeleteExpiredOIDCLoginStates :exec
+DELETE FROM oidc_login_states
+WHERE expires_at <= NOW();
+
+-- name: GetUserIdentity :one
+SELECT * FROM user_identities
+WHERE issuer = $1 AND subject = $2;
+
+-- name: CreateUserIdentity :one
+INSERT INTO user_identities (user_id, issuer, subject, email)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: RecordUserIdentityLogin :exec
+UPDATE user_identities
+SET email = $2, last_login_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,61 @@
+-- name: SetUserTOTPSecret :exec
+-- Starts or restarts enrollment; accounts with 2FA on keep their secret.
+UPDATE users
+SET totp_secret = $2, totp_last_used_step = NULL, updated_at = NOW()
+WHERE id = $1 AND totp_enabled_at IS NULL;
+
+-- name: EnableUserTOTP :execrows
+UPDATE users
+SET totp_enabled_at = NOW(), totp_last_used_step = $2, updated_at = NOW()
+WHERE id = $1 AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL;
+
+-- name: DisableUserTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_used_step = NULL, updated_at = NOW()
+WHERE id = $1;
+
+-- name: UseTOTPStep :execrows
+-- Records an accepted time step only if it is later than the last one, so
+-- that a code, even one seen over a shoulder, works once.
+UPDATE users
+SET totp_last_used_step = $2
+WHERE id = $1 AND (totp_last_used_step IS NULL OR totp_last_used_step < $2);
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (user_id, code_hash)
+VALUES ($1, $2);
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1;
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*) FROM recovery_codes
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: CreateTwoFactorChallenge :one
+INSERT INTO two_factor_challenges (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetTwoFactorChallenge :one
+SELECT * FROM two_factor_challenges
+WHERE token_hash = $1
+  AND expires_at > NOW()
+  AND failed_attempts < sqlc.arg(max_attempts)::int;
+
+-- name: RecordTwoFactorChallengeFailure :exec
+UPDATE two_factor_challenges
+SET failed_attempts = failed_attempts + 1
+WHERE id = $1;
+
+-- name: DeleteTwoFactorChallenge :execrows
+-- Deleting is what redeems a challenge: of two racing requests, only the one
+-- that deletes the row may start a session.
+DELETE FROM two_factor_challenges
+WHERE id = $1;
@@ -1,6 +1,12 @@
 -- name: CreateUser :one
 INSERT INTO users (email, password_hash)
-VALUES ($1, $2)
+VALUES ($1, sqlc.arg(password_hash)::VARCHAR)
+RETURNING *;
+
+-- name: CreatePasswordlessUser :one
+-- Users who sign in through a provider whose word on the address is trusted.
+INSERT INTO users (email, email_verified_at)
+VALUES ($1, NOW())
 RETURNING *;
 
 -- name: GetUserByEmail :one
@@ -10,3 +16,24 @@ WHERE email = $1;
 -- name: GetUserByID :one
 SELECT * FROM users
 WHERE id = $1;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password_hash = sqlc.arg(password_hash)::VARCHAR, updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkUserEmailVerified :exec
+UPDATE users
+SET email_verified_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND email_verified_at IS NULL;
+
+-- name: UpdateUserEmail :exec
+-- A new address starts unverified.
+UPDATE users
+SET email = $2, email_verified_at = NULL, updated_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteUser :exec
+-- Calendars, entries, sessions and tokens go with the user through ON DELETE CASCADE.
+DELETE FROM users
+WHERE id = $1;
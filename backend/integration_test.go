@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -10,9 +11,12 @@ import (
 	"testing"
 	"time"
 
+	"days/db/migrations"
 	"days/internal/auth"
 	"days/internal/database"
 	"days/internal/handlers"
+	"days/internal/mail"
+	"days/internal/migrate"
 	"days/internal/services"
 
 	"github.com/google/uuid"
@@ -33,29 +37,42 @@ type IntegrationTestSuite struct {
 
 // SetupSuite runs once before all tests
 func (suite *IntegrationTestSuite) SetupSuite() {
-	// Set up test environment
-	os.Setenv("JWT_SECRET", "test-secret-for-integration")
-	os.Setenv("DB_HOST", "localhost")
-	os.Setenv("DB_PORT", "5432")
-	os.Setenv("DB_USER", "postgres")
-	os.Setenv("DB_NAME", "days_test")
-	os.Setenv("DB_SSLMODE", "disable")
-
 	// Connect to test database
-	config := database.NewConfig()
-	db, err := database.Connect(config)
+	config := database.DefaultConfig()
+	config.DBName = "days_test"
+	db, err := database.Connect(&config)
 	if err != nil {
 		suite.T().Skip("Test database not available, skipping integration tests")
 		return
 	}
 	suite.db = db
 
+	// Bring the test database schema up to date
+	migrator, err := migrate.New(db.DB, migrations.FS)
+	suite.Require().NoError(err)
+	suite.Require().NoError(migrator.Up(context.Background()))
+
+	keys, err := auth.LoadKeyring(auth.KeyringConfig{Secret: "test-secret-for-integration"})
+	suite.Require().NoError(err)
+
 	// Initialize services
-	userService := services.NewUserService(db.Queries)
+	sessionService := services.NewSessionService(db.Queries, keys, services.DefaultSessionConfig())
+	accountService := services.NewAccountService(db.Queries, mail.NewLogMailer(nil, "Days <no-reply@localhost>"), "http://localhost")
+	twoFactorService := services.NewTwoFactorService(db.Queries, sessionService, nil)
+	userService := services.NewUserService(db.Queries, sessionService, accountService, twoFactorService, nil)
 	calendarService := services.NewCalendarService(db.Queries)
+	colorMeaningService := services.NewColorMeaningService(db.Queries, calendarService)
+	dayEntryService := services.NewDayEntryService(db.Queries, calendarService, colorMeaningService)
+	statsService := services.NewStatsService(db.Queries)
+	renderService := services.NewRenderService(db.Queries)
+	exportService := services.NewExportService(db.Queries)
+	importService := services.NewImportService(services.NewSQLImportTransactor(db.DB), db.Queries)
+	icalService := services.NewICalService(db.Queries)
+	memberService := services.NewCalendarMemberService(db.Queries)
+	apiKeyService := services.NewAPIKeyService(db.Queries)
 
 	// Initialize server
-	suite.server = handlers.NewServer(userService, calendarService)
+	suite.server = handlers.NewServer(userService, calendarService, colorMeaningService, dayEntryService, sessionService, statsService, renderService, exportService, importService, icalService, memberService, accountService, twoFactorService, apiKeyService, nil, services.NewAuditService(db.Queries), keys, nil, nil, handlers.DefaultConfig())
 	mux := suite.server.SetupRoutes()
 	suite.httpServer = httptest.NewServer(mux)
 }
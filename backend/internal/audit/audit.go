@@ -0,0 +1,154 @@
+// Package audit records security and data-changing events.
+//
+// Events are written to the audit_events table by a Recorder. An event names
+// who acted, what they did and, for changes to calendars and their contents,
+// the resource changed along with the fields that changed. Recording happens
+// after the change it describes, so failures are logged rather than undoing
+// it.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+
+	"days/internal/db"
+
+	"github.com/google/uuid"
+)
+
+// maxUserAgentLength and maxIPAddressLength fit the audit_events columns
+const (
+	maxUserAgentLength = 512
+	maxIPAddressLength = 45
+)
+
+// Store writes audit events; *db.Queries implements it
+type Store interface {
+	CreateAuditEvent(ctx context.Context, arg db.CreateAuditEventParams) error
+}
+
+// Event is something a user did
+type Event struct {
+	UserID       uuid.UUID
+	Action       string // e.g., "calendar.deleted"
+	ResourceType string // e.g., "calendar", empty for account events
+	ResourceID   uuid.UUID
+	Metadata     map[string]string
+	Changes      map[string]Change
+
+	// The client the user acted from. Left empty, they are taken from the
+	// context when it carries them.
+	UserAgent string
+	IPAddress string
+}
+
+// Change is the value of a field before and after an event. Before is nil
+// for fields that were created, and After for fields that were deleted.
+type Change struct {
+	Before any `json:"before,omitempty"`
+	After  any `json:"after,omitempty"`
+}
+
+// Recorder writes audit events to a Store
+type Recorder struct {
+	store Store
+}
+
+// NewRecorder creates a Recorder. A nil store records nothing.
+func NewRecorder(store Store) *Recorder {
+	return &Recorder{store: store}
+}
+
+// Record writes an event, logging rather than returning failures
+func (r *Recorder) Record(ctx context.Context, event Event) {
+	if r == nil || r.store == nil {
+		return
+	}
+
+	metadata, err := encode(event.Metadata)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode audit metadata", "action", event.Action, "error", err)
+		return
+	}
+	changes, err := encode(event.Changes)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode audit changes", "action", event.Action, "error", err)
+		return
+	}
+
+	if event.UserAgent == "" && event.IPAddress == "" {
+		event.UserAgent, event.IPAddress = ClientFromContext(ctx)
+	}
+
+	if err := r.store.CreateAuditEvent(ctx, db.CreateAuditEventParams{
+		UserID:       event.UserID,
+		Action:       event.Action,
+		ResourceType: event.ResourceType,
+		ResourceID:   uuid.NullUUID{UUID: event.ResourceID, Valid: event.ResourceID != uuid.Nil},
+		Metadata:     metadata,
+		Changes:      changes,
+		UserAgent:    truncate(event.UserAgent, maxUserAgentLength),
+		IpAddress:    truncate(event.IPAddress, maxIPAddressLength),
+	}); err != nil {
+		slog.ErrorContext(ctx, "failed to record audit event", "action", event.Action, "user_id", event.UserID, "error", err)
+	}
+}
+
+// Diff returns the fields whose values differ between two snapshots of a
+// resource. Pass a nil before for a created resource and a nil after for a
+// deleted one.
+func Diff(before, after map[string]any) map[string]Change {
+	fields := make(map[string]struct{}, len(before)+len(after))
+	for field := range before {
+		fields[field] = struct{}{}
+	}
+	for field := range after {
+		fields[field] = struct{}{}
+	}
+
+	changes := make(map[string]Change)
+	for field := range fields {
+		b, a := before[field], after[field]
+		if !reflect.DeepEqual(b, a) {
+			changes[field] = Change{Before: b, After: a}
+		}
+	}
+	return changes
+}
+
+type clientKey struct{}
+
+type client struct {
+	userAgent string
+	ipAddress string
+}
+
+// WithClient returns a context whose events are recorded as coming from the
+// given client, for services that are not told about it otherwise
+func WithClient(ctx context.Context, userAgent, ipAddress string) context.Context {
+	return context.WithValue(ctx, clientKey{}, client{userAgent: userAgent, ipAddress: ipAddress})
+}
+
+// ClientFromContext returns the client set with WithClient, if any
+func ClientFromContext(ctx context.Context) (userAgent, ipAddress string) {
+	c, _ := ctx.Value(clientKey{}).(client)
+	return c.userAgent, c.ipAddress
+}
+
+// encode returns the JSON of v, or an empty object for an empty map
+func encode[V any](v map[string]V) (json.RawMessage, error) {
+	if len(v) == 0 {
+		return json.RawMessage("{}"), nil
+	}
+	return json.Marshal(v)
+}
+
+// truncate shortens s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
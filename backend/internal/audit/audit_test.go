@@ -0,0 +1,168 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"days/internal/db"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubStore keeps the events written to it
+type stubStore struct {
+	events  []db.CreateAuditEventParams
+	err     error
+	before  time.Time
+	deleted int64
+}
+
+func (s *stubStore) CreateAuditEvent(ctx context.Context, arg db.CreateAuditEventParams) error {
+	s.events = append(s.events, arg)
+	return s.err
+}
+
+func (s *stubStore) DeleteAuditEventsBefore(ctx context.Context, createdAt time.Time) (int64, error) {
+	s.before = createdAt
+	return s.deleted, s.err
+}
+
+func TestRecorder_Record(t *testing.T) {
+	userID := uuid.New()
+	calendarID := uuid.New()
+
+	t.Run("writes changes and metadata", func(t *testing.T) {
+		store := &stubStore{}
+		NewRecorder(store).Record(context.Background(), Event{
+			UserID:       userID,
+			Action:       "calendar.updated",
+			ResourceType: "calendar",
+			ResourceID:   calendarID,
+			Metadata:     map[string]string{"source": "test"},
+			Changes:      map[string]Change{"name": {Before: "Mood", After: "Moods"}},
+			UserAgent:    "days-test",
+			IPAddress:    "203.0.113.7",
+		})
+
+		require.Len(t, store.events, 1)
+		event := store.events[0]
+		assert.Equal(t, userID, event.UserID)
+		assert.Equal(t, "calendar.updated", event.Action)
+		assert.Equal(t, "calendar", event.ResourceType)
+		assert.Equal(t, uuid.NullUUID{UUID: calendarID, Valid: true}, event.ResourceID)
+		assert.JSONEq(t, `{"source":"test"}`, string(event.Metadata))
+		assert.JSONEq(t, `{"name":{"before":"Mood","after":"Moods"}}`, string(event.Changes))
+		assert.Equal(t, "days-test", event.UserAgent)
+		assert.Equal(t, "203.0.113.7", event.IpAddress)
+	})
+
+	t.Run("account events have no resource", func(t *testing.T) {
+		store := &stubStore{}
+		NewRecorder(store).Record(context.Background(), Event{UserID: userID, Action: "user.login"})
+
+		require.Len(t, store.events, 1)
+		assert.False(t, store.events[0].ResourceID.Valid)
+		assert.Equal(t, "{}", string(store.events[0].Metadata))
+		assert.Equal(t, "{}", string(store.events[0].Changes))
+	})
+
+	t.Run("takes the client from the context", func(t *testing.T) {
+		store := &stubStore{}
+		ctx := WithClient(context.Background(), strings.Repeat("a", 600), "2001:db8::1")
+		NewRecorder(store).Record(ctx, Event{UserID: userID, Action: "calendar.deleted"})
+
+		require.Len(t, store.events, 1)
+		assert.Len(t, store.events[0].UserAgent, maxUserAgentLength)
+		assert.Equal(t, "2001:db8::1", store.events[0].IpAddress)
+	})
+
+	t.Run("store errors are not returned", func(t *testing.T) {
+		store := &stubStore{err: errors.New("database down")}
+		NewRecorder(store).Record(context.Background(), Event{UserID: userID, Action: "user.login"})
+		assert.Len(t, store.events, 1)
+	})
+
+	t.Run("nil recorder records nothing", func(t *testing.T) {
+		var recorder *Recorder
+		recorder.Record(context.Background(), Event{UserID: userID, Action: "user.login"})
+		NewRecorder(nil).Record(context.Background(), Event{UserID: userID, Action: "user.login"})
+	})
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before map[string]any
+		after  map[string]any
+		want   map[string]Change
+	}{
+		{
+			name:  "created",
+			after: map[string]any{"name": "Mood", "description": "Daily"},
+			want: map[string]Change{
+				"name":        {After: "Mood"},
+				"description": {After: "Daily"},
+			},
+		},
+		{
+			name:   "updated",
+			before: map[string]any{"name": "Mood", "description": "Daily"},
+			after:  map[string]any{"name": "Moods", "description": "Daily"},
+			want:   map[string]Change{"name": {Before: "Mood", After: "Moods"}},
+		},
+		{
+			name:   "field cleared",
+			before: map[string]any{"name": "Mood", "notes": "Ran"},
+			after:  map[string]any{"name": "Mood"},
+			want:   map[string]Change{"notes": {Before: "Ran"}},
+		},
+		{
+			name:   "deleted",
+			before: map[string]any{"name": "Mood"},
+			want:   map[string]Change{"name": {Before: "Mood"}},
+		},
+		{
+			name:   "unchanged",
+			before: map[string]any{"name": "Mood"},
+			after:  map[string]any{"name": "Mood"},
+			want:   map[string]Change{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Diff(tt.before, tt.after))
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	store := &stubStore{deleted: 3}
+
+	deleted, err := Prune(context.Background(), store, 30*24*time.Hour, now)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+	assert.Equal(t, time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC), store.before)
+}
+
+func TestRunRetention(t *testing.T) {
+	store := &stubStore{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Prunes once straight away, then stops with the context
+	RunRetention(ctx, store, time.Hour)
+	assert.False(t, store.before.IsZero())
+
+	// Zero retention keeps events forever
+	store = &stubStore{}
+	RunRetention(context.Background(), store, 0)
+	assert.True(t, store.before.IsZero())
+}
@@ -0,0 +1,53 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// DefaultRetentionDays is how long events are kept unless configured otherwise
+const DefaultRetentionDays = 365
+
+// pruneInterval is how often expired events are deleted
+const pruneInterval = 24 * time.Hour
+
+// PruneStore deletes old audit events; *db.Queries implements it
+type PruneStore interface {
+	DeleteAuditEventsBefore(ctx context.Context, createdAt time.Time) (int64, error)
+}
+
+// Prune deletes the events recorded longer than retention before now
+func Prune(ctx context.Context, store PruneStore, retention time.Duration, now time.Time) (int64, error) {
+	deleted, err := store.DeleteAuditEventsBefore(ctx, now.Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune audit events: %w", err)
+	}
+	return deleted, nil
+}
+
+// RunRetention prunes expired events now and then daily until ctx is done.
+// Every server instance runs it; deletes by age do not conflict.
+func RunRetention(ctx context.Context, store PruneStore, retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	for {
+		deleted, err := Prune(ctx, store, retention, time.Now())
+		if err != nil {
+			slog.ErrorContext(ctx, "audit retention failed", "error", err)
+		} else if deleted > 0 {
+			slog.InfoContext(ctx, "pruned audit events", "deleted", deleted, "retention", retention)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package auth
 
 import (
-	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -10,35 +9,35 @@ import (
 
 type Claims struct {
 	jwt.RegisteredClaims
+	// SessionID links an access token to the session that issued it (empty for session-less tokens)
+	SessionID string `json:"sid,omitempty"`
 }
 
+// The functions below sign and verify with a single HS256 secret. Servers
+// use a Keyring, which can also hold asymmetric keys and rotate them.
+
 // GenerateToken creates a signed JWT with subject set to the user ID and standard claims.
 func GenerateToken(userID uuid.UUID, secret string, ttl time.Duration) (string, error) {
-	claims := Claims{
-		RegisteredClaims: jwt.RegisteredClaims{
-			Subject:   userID.String(),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
-		},
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return secretKeyring(secret).GenerateToken(userID, ttl)
+}
+
+// GenerateSessionToken creates a signed JWT like GenerateToken, bound to a session via the sid claim.
+func GenerateSessionToken(userID, sessionID uuid.UUID, secret string, ttl time.Duration) (string, error) {
+	return secretKeyring(secret).GenerateSessionToken(userID, sessionID, ttl)
 }
 
 // ParseToken validates a JWT and returns the user ID (from subject claim).
 func ParseToken(tokenStr, secret string) (uuid.UUID, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(secret), nil
-	})
-	if err != nil || !token.Valid {
-		return uuid.Nil, errors.New("invalid token")
-	}
-	claims, ok := token.Claims.(*Claims)
-	if !ok || claims.Subject == "" {
-		return uuid.Nil, errors.New("invalid claims")
-	}
-	return uuid.Parse(claims.Subject)
+	return secretKeyring(secret).ParseToken(tokenStr)
+}
+
+// ParseSessionToken validates a JWT and returns the user ID and session ID.
+// The session ID is uuid.Nil for tokens that were not issued for a session.
+func ParseSessionToken(tokenStr, secret string) (uuid.UUID, uuid.UUID, error) {
+	return secretKeyring(secret).ParseSessionToken(tokenStr)
+}
+
+func secretKeyring(secret string) *Keyring {
+	key := NewHMACKey(secret)
+	return &Keyring{active: key, keys: map[string]*Key{key.ID: key}}
 }
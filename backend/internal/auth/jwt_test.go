@@ -168,3 +168,47 @@ func TestTokenTimeClaims(t *testing.T) {
 	// and not expired, and that time progressed during generation.
 	assert.True(t, after.After(before) || after.Equal(before))
 }
+
+func TestSessionTokenRoundTrip(t *testing.T) {
+	secret := "test-secret"
+	userID := uuid.New()
+	sessionID := uuid.New()
+
+	t.Run("session token carries session ID", func(t *testing.T) {
+		token, err := GenerateSessionToken(userID, sessionID, secret, time.Hour)
+		require.NoError(t, err)
+
+		parsedUserID, parsedSessionID, err := ParseSessionToken(token, secret)
+		require.NoError(t, err)
+		assert.Equal(t, userID, parsedUserID)
+		assert.Equal(t, sessionID, parsedSessionID)
+
+		// ParseToken still accepts session tokens
+		parsedUserID, err = ParseToken(token, secret)
+		require.NoError(t, err)
+		assert.Equal(t, userID, parsedUserID)
+	})
+
+	t.Run("plain token has no session ID", func(t *testing.T) {
+		token, err := GenerateToken(userID, secret, time.Hour)
+		require.NoError(t, err)
+
+		parsedUserID, parsedSessionID, err := ParseSessionToken(token, secret)
+		require.NoError(t, err)
+		assert.Equal(t, userID, parsedUserID)
+		assert.Equal(t, uuid.Nil, parsedSessionID)
+	})
+}
+
+func TestGenerateRefreshToken(t *testing.T) {
+	token, hash, err := GenerateRefreshToken()
+	require.NoError(t, err)
+	assert.NotEmpty(t, token)
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, HashRefreshToken(token))
+
+	other, otherHash, err := GenerateRefreshToken()
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+	assert.NotEqual(t, hash, otherHash)
+}
@@ -0,0 +1,386 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Algorithms access tokens can be signed with
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// minRSAKeyBits is the smallest RSA key accepted
+const minRSAKeyBits = 2048
+
+var (
+	ErrNoSigningKey   = errors.New("no JWT signing key configured")
+	ErrUnsupportedKey = errors.New("unsupported JWT key")
+	ErrDuplicateKeyID = errors.New("duplicate JWT key ID")
+	errInvalidToken   = errors.New("invalid token")
+	errInvalidClaims  = errors.New("invalid claims")
+)
+
+// Key is a key access tokens are signed or verified with. Keys parsed from
+// public key files, and keys retired from signing, only verify.
+type Key struct {
+	// ID is the kid header of the tokens the key signs. The HS256 secret has
+	// none, as tokens signed with it predate key IDs.
+	ID        string
+	Algorithm string
+
+	signingKey   any // []byte, *rsa.PrivateKey or ed25519.PrivateKey; nil when only verifying
+	verifyingKey any // []byte, *rsa.PublicKey or ed25519.PublicKey
+}
+
+// NewHMACKey returns an HS256 key for a shared secret
+func NewHMACKey(secret string) *Key {
+	return &Key{Algorithm: AlgorithmHS256, signingKey: []byte(secret), verifyingKey: []byte(secret)}
+}
+
+// GenerateKey creates a new RS256 or EdDSA key
+func GenerateKey(algorithm string) (*Key, error) {
+	switch algorithm {
+	case AlgorithmRS256:
+		private, err := rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+		if err != nil {
+			return nil, err
+		}
+		return newAsymmetricKey(private)
+	case AlgorithmEdDSA:
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return newAsymmetricKey(private)
+	default:
+		return nil, fmt.Errorf("%w: algorithm %q", ErrUnsupportedKey, algorithm)
+	}
+}
+
+// ParseKeyPEM reads an RSA or Ed25519 key from PEM. A private key signs
+// with RS256 or EdDSA; a public key only verifies. The key ID is the key's
+// RFC 7638 thumbprint, so every instance derives the same one.
+func ParseKeyPEM(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM block found", ErrUnsupportedKey)
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%w: PEM block %q", ErrUnsupportedKey, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedKey, err)
+	}
+	return newAsymmetricKey(parsed)
+}
+
+// MarshalPEM encodes the key as PKCS #8 when it can sign and as a PKIX
+// public key otherwise. HS256 secrets have no PEM form.
+func (k *Key) MarshalPEM() ([]byte, error) {
+	if k.Algorithm == AlgorithmHS256 {
+		return nil, fmt.Errorf("%w: HS256 secrets have no PEM form", ErrUnsupportedKey)
+	}
+	if k.signingKey != nil {
+		der, err := x509.MarshalPKCS8PrivateKey(k.signingKey)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+	}
+	der, err := x509.MarshalPKIXPublicKey(k.verifyingKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// CanSign reports whether the key holds the private or secret part
+func (k *Key) CanSign() bool {
+	return k.signingKey != nil
+}
+
+// Public returns the key without its private part
+func (k *Key) Public() *Key {
+	return &Key{ID: k.ID, Algorithm: k.Algorithm, verifyingKey: k.verifyingKey}
+}
+
+// JWK returns the public key as a JSON web key. HS256 secrets are not
+// public and report false.
+func (k *Key) JWK() (JWK, bool) {
+	switch public := k.verifyingKey.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Kid: k.ID,
+			Use: "sig",
+			Alg: k.Algorithm,
+			N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Kid: k.ID,
+			Use: "sig",
+			Alg: k.Algorithm,
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(public),
+		}, true
+	default:
+		return JWK{}, false
+	}
+}
+
+func newAsymmetricKey(parsed any) (*Key, error) {
+	key := &Key{}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Algorithm, key.signingKey, key.verifyingKey = AlgorithmRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Algorithm, key.verifyingKey = AlgorithmRS256, k
+	case ed25519.PrivateKey:
+		key.Algorithm, key.signingKey, key.verifyingKey = AlgorithmEdDSA, k, k.Public().(ed25519.PublicKey)
+	case ed25519.PublicKey:
+		key.Algorithm, key.verifyingKey = AlgorithmEdDSA, k
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedKey, parsed)
+	}
+	if public, ok := key.verifyingKey.(*rsa.PublicKey); ok && public.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("%w: RSA keys must have at least %d bits", ErrUnsupportedKey, minRSAKeyBits)
+	}
+
+	jwk, _ := key.JWK()
+	key.ID = jwkThumbprint(jwk)
+	return key, nil
+}
+
+// jwkThumbprint computes the RFC 7638 thumbprint of a public key: the
+// SHA-256 of its required members in lexicographic order
+func jwkThumbprint(jwk JWK) string {
+	var members any
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Keyring signs access tokens with its active key and verifies them with
+// any of its keys, found by the token's kid header. Rotating keys means
+// making a new one active while the old one stays for verification until
+// the tokens it signed have expired.
+type Keyring struct {
+	active *Key
+	keys   map[string]*Key
+}
+
+// NewKeyring returns a keyring that signs with active and also accepts
+// tokens signed with the previous keys
+func NewKeyring(active *Key, previous ...*Key) (*Keyring, error) {
+	if active == nil || !active.CanSign() {
+		return nil, ErrNoSigningKey
+	}
+
+	k := &Keyring{active: active, keys: make(map[string]*Key, len(previous)+1)}
+	for _, key := range append([]*Key{active}, previous...) {
+		if _, ok := k.keys[key.ID]; ok {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateKeyID, key.ID)
+		}
+		k.keys[key.ID] = key.Public()
+	}
+	return k, nil
+}
+
+// KeyringConfig names the keys access tokens are signed and verified with
+type KeyringConfig struct {
+	// Secret is an HS256 secret that signs when no key file is set and is
+	// otherwise kept for verifying the tokens signed before the switch
+	Secret string `yaml:"secret" toml:"secret"`
+	// SigningKeyFile is a PEM file with the RSA or Ed25519 private key to sign with
+	SigningKeyFile string `yaml:"signing_key_file" toml:"signing_key_file"`
+	// VerificationKeyFiles are PEM files of earlier keys still accepted
+	VerificationKeyFiles []string `yaml:"verification_key_files" toml:"verification_key_files"`
+}
+
+// LoadKeyring reads the keys config names into a keyring
+func LoadKeyring(config KeyringConfig) (*Keyring, error) {
+	var active *Key
+	var previous []*Key
+	if path := config.SigningKeyFile; path != "" {
+		key, err := readKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if !key.CanSign() {
+			return nil, fmt.Errorf("%w: %s holds a public key", ErrNoSigningKey, path)
+		}
+		active = key
+		if config.Secret != "" {
+			previous = append(previous, NewHMACKey(config.Secret))
+		}
+	} else if config.Secret != "" {
+		active = NewHMACKey(config.Secret)
+	} else {
+		return nil, fmt.Errorf("%w: set JWT_SIGNING_KEY_FILE or JWT_SECRET", ErrNoSigningKey)
+	}
+
+	for _, path := range config.VerificationKeyFiles {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		key, err := readKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		previous = append(previous, key)
+	}
+	return NewKeyring(active, previous...)
+}
+
+func readKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT key: %w", err)
+	}
+	key, err := ParseKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// ActiveKeyID returns the ID of the key new tokens are signed with
+func (k *Keyring) ActiveKeyID() string {
+	return k.active.ID
+}
+
+// JWKS returns the public keys of the keyring, for services that verify
+// Days tokens. HS256 secrets are left out.
+func (k *Keyring) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	// The active key first, then the rest in a stable order
+	if jwk, ok := k.active.JWK(); ok {
+		set.Keys = append(set.Keys, jwk)
+	}
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		if id != k.active.ID {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		if jwk, ok := k.keys[id].JWK(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
+
+// GenerateToken creates a signed JWT with subject set to the user ID and standard claims.
+func (k *Keyring) GenerateToken(userID uuid.UUID, ttl time.Duration) (string, error) {
+	return k.sign(userID, "", ttl)
+}
+
+// GenerateSessionToken creates a signed JWT like GenerateToken, bound to a session via the sid claim.
+func (k *Keyring) GenerateSessionToken(userID, sessionID uuid.UUID, ttl time.Duration) (string, error) {
+	return k.sign(userID, sessionID.String(), ttl)
+}
+
+// ParseToken validates a JWT and returns the user ID (from subject claim).
+func (k *Keyring) ParseToken(tokenStr string) (uuid.UUID, error) {
+	userID, _, err := k.ParseSessionToken(tokenStr)
+	return userID, err
+}
+
+// ParseSessionToken validates a JWT and returns the user ID and session ID.
+// The session ID is uuid.Nil for tokens that were not issued for a session.
+func (k *Keyring) ParseSessionToken(tokenStr string) (uuid.UUID, uuid.UUID, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := k.keys[kid]
+		// The algorithm is the key's, never the one the token claims
+		if !ok || t.Method.Alg() != key.Algorithm {
+			return nil, errors.New("unknown signing key")
+		}
+		return key.verifyingKey, nil
+	}, jwt.WithValidMethods([]string{AlgorithmHS256, AlgorithmRS256, AlgorithmEdDSA}))
+	if err != nil || !token.Valid {
+		return uuid.Nil, uuid.Nil, errInvalidToken
+	}
+	claims, ok := token.Claims.(*Claims)
+	if !ok || claims.Subject == "" {
+		return uuid.Nil, uuid.Nil, errInvalidClaims
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	sessionID := uuid.Nil
+	if claims.SessionID != "" {
+		if sessionID, err = uuid.Parse(claims.SessionID); err != nil {
+			return uuid.Nil, uuid.Nil, errInvalidClaims
+		}
+	}
+	return userID, sessionID, nil
+}
+
+func (k *Keyring) sign(userID uuid.UUID, sessionID string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		SessionID: sessionID,
+	}
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(k.active.Algorithm), claims)
+	if k.active.ID != "" {
+		token.Header["kid"] = k.active.ID
+	}
+	return token.SignedString(k.active.signingKey)
+}
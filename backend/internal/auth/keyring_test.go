@@ -0,0 +1,228 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyring_SignAndVerify(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmRS256, AlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			key, err := GenerateKey(algorithm)
+			require.NoError(t, err)
+			keys, err := NewKeyring(key)
+			require.NoError(t, err)
+
+			userID, sessionID := uuid.New(), uuid.New()
+			token, err := keys.GenerateSessionToken(userID, sessionID, time.Hour)
+			require.NoError(t, err)
+
+			// The header names the algorithm and the key
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, algorithm, parsed.Header["alg"])
+			assert.Equal(t, key.ID, parsed.Header["kid"])
+
+			parsedUserID, parsedSessionID, err := keys.ParseSessionToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, userID, parsedUserID)
+			assert.Equal(t, sessionID, parsedSessionID)
+
+			expired, err := keys.GenerateToken(userID, -time.Hour)
+			require.NoError(t, err)
+			_, err = keys.ParseToken(expired)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	userID := uuid.New()
+	secret := NewHMACKey("the-old-shared-secret")
+	oldKey, err := GenerateKey(AlgorithmRS256)
+	require.NoError(t, err)
+	newKey, err := GenerateKey(AlgorithmEdDSA)
+	require.NoError(t, err)
+
+	before, err := NewKeyring(oldKey, secret)
+	require.NoError(t, err)
+	oldToken, err := before.GenerateToken(userID, time.Hour)
+	require.NoError(t, err)
+	secretToken, err := GenerateToken(userID, "the-old-shared-secret", time.Hour)
+	require.NoError(t, err)
+
+	// The new key signs; the old ones still verify
+	after, err := NewKeyring(newKey, oldKey.Public(), secret)
+	require.NoError(t, err)
+	for _, token := range []string{oldToken, secretToken} {
+		parsed, err := after.ParseToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, userID, parsed)
+	}
+	newToken, err := after.GenerateToken(userID, time.Hour)
+	require.NoError(t, err)
+
+	// Once dropped, a key's tokens stop working
+	retired, err := NewKeyring(newKey)
+	require.NoError(t, err)
+	_, err = retired.ParseToken(oldToken)
+	assert.Error(t, err)
+	_, err = retired.ParseToken(newToken)
+	assert.NoError(t, err)
+
+	// Keyrings that do not hold the key reject its tokens
+	_, err = before.ParseToken(newToken)
+	assert.Error(t, err)
+}
+
+func TestKeyring_RejectsAlgorithmSwitch(t *testing.T) {
+	key, err := GenerateKey(AlgorithmRS256)
+	require.NoError(t, err)
+	keys, err := NewKeyring(key)
+	require.NoError(t, err)
+
+	// An HS256 token keyed with the public key, claiming the RSA key's kid
+	pemBytes, err := key.Public().MarshalPEM()
+	require.NoError(t, err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   uuid.New().String(),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}})
+	forged.Header["kid"] = key.ID
+	token, err := forged.SignedString(pemBytes)
+	require.NoError(t, err)
+
+	_, err = keys.ParseToken(token)
+	assert.Error(t, err)
+}
+
+func TestNewKeyring(t *testing.T) {
+	key, err := GenerateKey(AlgorithmEdDSA)
+	require.NoError(t, err)
+
+	_, err = NewKeyring(key.Public())
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+
+	_, err = NewKeyring(key, key.Public())
+	assert.ErrorIs(t, err, ErrDuplicateKeyID)
+}
+
+func TestKeyring_JWKS(t *testing.T) {
+	active, err := GenerateKey(AlgorithmEdDSA)
+	require.NoError(t, err)
+	previous, err := GenerateKey(AlgorithmRS256)
+	require.NoError(t, err)
+	keys, err := NewKeyring(active, previous, NewHMACKey("never-published"))
+	require.NoError(t, err)
+
+	set := keys.JWKS()
+
+	require.Len(t, set.Keys, 2)
+	assert.Equal(t, active.ID, set.Keys[0].Kid)
+	assert.Equal(t, "OKP", set.Keys[0].Kty)
+	assert.Equal(t, previous.ID, set.Keys[1].Kid)
+	assert.Equal(t, "RSA", set.Keys[1].Kty)
+
+	// Services verifying with the published keys accept our tokens
+	token, err := keys.GenerateToken(uuid.New(), time.Hour)
+	require.NoError(t, err)
+	_, err = jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
+		return set.Keys[0].PublicKey()
+	})
+	assert.NoError(t, err)
+}
+
+func TestParseKeyPEM(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmRS256, AlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			key, err := GenerateKey(algorithm)
+			require.NoError(t, err)
+
+			private, err := key.MarshalPEM()
+			require.NoError(t, err)
+			parsed, err := ParseKeyPEM(private)
+			require.NoError(t, err)
+			assert.True(t, parsed.CanSign())
+			assert.Equal(t, key.ID, parsed.ID)
+			assert.Equal(t, algorithm, parsed.Algorithm)
+
+			public, err := key.Public().MarshalPEM()
+			require.NoError(t, err)
+			assert.Contains(t, string(public), "PUBLIC KEY")
+			parsed, err = ParseKeyPEM(public)
+			require.NoError(t, err)
+			assert.False(t, parsed.CanSign())
+			assert.Equal(t, key.ID, parsed.ID)
+		})
+	}
+
+	_, err := ParseKeyPEM([]byte("not a key"))
+	assert.ErrorIs(t, err, ErrUnsupportedKey)
+}
+
+func TestLoadKeyring(t *testing.T) {
+	dir := t.TempDir()
+	writeKey := func(name string, key *Key) string {
+		data, err := key.MarshalPEM()
+		require.NoError(t, err)
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+		return path
+	}
+	active, err := GenerateKey(AlgorithmEdDSA)
+	require.NoError(t, err)
+	previous, err := GenerateKey(AlgorithmRS256)
+	require.NoError(t, err)
+	activePath := writeKey("active.pem", active)
+	previousPath := writeKey("previous.pem", previous.Public())
+
+	t.Run("secret only", func(t *testing.T) {
+		keys, err := LoadKeyring(KeyringConfig{Secret: "env-secret"})
+		require.NoError(t, err)
+		token, err := keys.GenerateToken(uuid.New(), time.Hour)
+		require.NoError(t, err)
+		_, err = ParseToken(token, "env-secret")
+		assert.NoError(t, err)
+		assert.Empty(t, keys.JWKS().Keys)
+	})
+
+	t.Run("signing key with verification keys", func(t *testing.T) {
+		keys, err := LoadKeyring(KeyringConfig{
+			Secret:               "env-secret",
+			SigningKeyFile:       activePath,
+			VerificationKeyFiles: []string{previousPath, " "},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, active.ID, keys.ActiveKeyID())
+		assert.Len(t, keys.JWKS().Keys, 2)
+
+		// Tokens signed with the secret before the switch still verify
+		token, err := GenerateToken(uuid.New(), "env-secret", time.Hour)
+		require.NoError(t, err)
+		_, err = keys.ParseToken(token)
+		assert.NoError(t, err)
+	})
+
+	t.Run("public signing key", func(t *testing.T) {
+		_, err := LoadKeyring(KeyringConfig{SigningKeyFile: previousPath})
+		assert.ErrorIs(t, err, ErrNoSigningKey)
+	})
+
+	t.Run("nothing configured", func(t *testing.T) {
+		_, err := LoadKeyring(KeyringConfig{})
+		assert.ErrorIs(t, err, ErrNoSigningKey)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadKeyring(KeyringConfig{SigningKeyFile: filepath.Join(dir, "missing.pem")})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read JWT key")
+	})
+}
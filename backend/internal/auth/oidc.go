@@ -0,0 +1,401 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrOIDCDiscovery  = errors.New("failed to discover OpenID Connect provider")
+	ErrOIDCExchange   = errors.New("authorization code exchange failed")
+	ErrInvalidIDToken = errors.New("invalid ID token")
+	ErrUnknownSigner  = errors.New("ID token signed with an unknown key")
+	errUnsupportedJWK = errors.New("unsupported JSON web key")
+)
+
+// oidcKeyRefreshInterval limits how often an unknown key ID refetches the provider's keys
+const oidcKeyRefreshInterval = time.Minute
+
+// oidcSigningMethods are the ID token algorithms accepted; symmetric ones
+// are not, as the client secret is no signing key
+var oidcSigningMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+
+// OIDCConfig configures login through an OpenID Connect provider. Login
+// through a provider is off while IssuerURL is empty.
+type OIDCConfig struct {
+	IssuerURL    string   `yaml:"issuer_url" toml:"issuer_url"`
+	ClientID     string   `yaml:"client_id" toml:"client_id"`
+	ClientSecret string   `yaml:"client_secret" toml:"client_secret"`
+	RedirectURL  string   `yaml:"redirect_url" toml:"redirect_url"` // where the provider sends the browser back with a code
+	Scopes       []string `yaml:"scopes" toml:"scopes"`             // openid is always requested
+}
+
+// DefaultOIDCConfig leaves single sign-on off and asks for the email and
+// profile scopes once a provider is set
+func DefaultOIDCConfig() OIDCConfig {
+	return OIDCConfig{Scopes: []string{"email", "profile"}}
+}
+
+// Enabled reports whether a provider is configured
+func (c *OIDCConfig) Enabled() bool {
+	return c.IssuerURL != ""
+}
+
+// OIDCIdentity is the user a provider vouched for in an ID token
+type OIDCIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// OIDCProvider runs the authorization code flow with PKCE against one
+// OpenID Connect provider and verifies the ID tokens it returns
+type OIDCProvider struct {
+	config                OIDCConfig
+	client                *http.Client
+	authorizationEndpoint string
+	tokenEndpoint         string
+	jwksURI               string
+
+	mu            sync.Mutex
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+	now           func() time.Time
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// DiscoverOIDCProvider reads the provider's endpoints from its discovery
+// document. A nil client uses http.DefaultClient.
+func DiscoverOIDCProvider(ctx context.Context, config OIDCConfig, client *http.Client) (*OIDCProvider, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if config.ClientID == "" || config.RedirectURL == "" {
+		return nil, fmt.Errorf("%w: client ID and redirect URL are required", ErrOIDCDiscovery)
+	}
+
+	issuer := strings.TrimSuffix(config.IssuerURL, "/")
+	var discovery oidcDiscovery
+	if err := getJSON(ctx, client, issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCDiscovery, err)
+	}
+	// The issuer must match exactly, as ID tokens are checked against it
+	if discovery.Issuer != issuer {
+		return nil, fmt.Errorf("%w: issuer %q does not match %q", ErrOIDCDiscovery, discovery.Issuer, issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: discovery document is missing endpoints", ErrOIDCDiscovery)
+	}
+
+	config.IssuerURL = issuer
+	return &OIDCProvider{
+		config:                config,
+		client:                client,
+		authorizationEndpoint: discovery.AuthorizationEndpoint,
+		tokenEndpoint:         discovery.TokenEndpoint,
+		jwksURI:               discovery.JWKSURI,
+		now:                   time.Now,
+	}, nil
+}
+
+// Issuer returns the provider's issuer identifier
+func (p *OIDCProvider) Issuer() string {
+	return p.config.IssuerURL
+}
+
+// AuthCodeURL returns the provider URL to send the browser to. The state is
+// echoed back with the code; the nonce comes back inside the ID token; the
+// verifier is kept by the caller and only its S256 challenge is sent.
+func (p *OIDCProvider) AuthCodeURL(state, nonce, codeVerifier string) string {
+	scopes := append([]string{"openid"}, p.config.Scopes...)
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {PKCEChallenge(codeVerifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(p.authorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return p.authorizationEndpoint + separator + params.Encode()
+}
+
+// Exchange trades an authorization code for tokens and returns the identity
+// in the verified ID token, which must carry the nonce of the login
+func (p *OIDCProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*OIDCIdentity, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCExchange, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCExchange, err)
+	}
+	defer resp.Body.Close()
+
+	var tokens struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("%w: status %d: %v", ErrOIDCExchange, resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s: %s", ErrOIDCExchange, tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("%w: no ID token in response", ErrOIDCExchange)
+	}
+
+	return p.VerifyIDToken(ctx, tokens.IDToken, nonce)
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified any    `json:"email_verified"` // some providers send "true"
+	Name          string `json:"name"`
+}
+
+// VerifyIDToken checks an ID token's signature, issuer, audience, expiry and nonce
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*OIDCIdentity, error) {
+	claims := &idTokenClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.publicKey(ctx, kid)
+	},
+		jwt.WithValidMethods(oidcSigningMethods),
+		jwt.WithIssuer(p.config.IssuerURL),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+		jwt.WithTimeFunc(p.now),
+	)
+	if err != nil {
+		if errors.Is(err, ErrUnknownSigner) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	verified := claims.EmailVerified == true || claims.EmailVerified == "true"
+	return &OIDCIdentity{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(strings.TrimSpace(claims.Email)),
+		EmailVerified: verified,
+		Name:          claims.Name,
+	}, nil
+}
+
+// publicKey returns the provider key with the given ID, refetching the key
+// set when the ID is unknown, as providers rotate keys
+func (p *OIDCProvider) publicKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if p.keys != nil && p.now().Sub(p.keysFetchedAt) < oidcKeyRefreshInterval {
+		return nil, ErrUnknownSigner
+	}
+
+	keys, err := fetchJWKS(ctx, p.client, p.jwksURI)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	p.keysFetchedAt = p.now()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, ErrUnknownSigner
+}
+
+// lookupKey finds a key by ID; tokens without one match a lone key
+func (p *OIDCProvider) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if key, ok := p.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// GeneratePKCEVerifier returns a random PKCE code verifier (RFC 7636)
+func GeneratePKCEVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// PKCEChallenge returns the S256 code challenge of a verifier
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// JWK is a public JSON web key (RFC 7517) as served in a key set
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC and OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON web key set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicKey decodes the key
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("%w: modulus: %v", errUnsupportedJWK, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("%w: exponent", errUnsupportedJWK)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("%w: curve %q", errUnsupportedJWK, k.Crv)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("%w: coordinates", errUnsupportedJWK)
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("%w: point is not on the curve", errUnsupportedJWK)
+		}
+		return key, nil
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if k.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%w: curve %q", errUnsupportedJWK, k.Crv)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("%w: key type %q", errUnsupportedJWK, k.Kty)
+	}
+}
+
+// fetchJWKS downloads a key set, skipping keys that are not for signatures
+// or of unsupported types
+func fetchJWKS(ctx context.Context, client *http.Client, uri string) (map[string]crypto.PublicKey, error) {
+	var set JWKS
+	if err := getJSON(ctx, client, uri, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch provider keys: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, uri string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", uri, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
@@ -0,0 +1,156 @@
+package auth_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"days/internal/auth"
+	"days/internal/auth/oidctest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURL = "https://days.example.com/auth/oidc/callback"
+
+func newTestProvider(t *testing.T) (*oidctest.Provider, *auth.OIDCProvider) {
+	t.Helper()
+	fake := oidctest.NewProvider("days", "client-secret")
+	t.Cleanup(fake.Close)
+	fake.SetUser(oidctest.User{Subject: "user-1", Email: "Alice@Example.com", EmailVerified: true, Name: "Alice"})
+
+	provider, err := auth.DiscoverOIDCProvider(context.Background(), fake.Config(redirectURL), fake.Client())
+	require.NoError(t, err)
+	return fake, provider
+}
+
+func TestOIDCProvider_AuthorizationCodeFlow(t *testing.T) {
+	fake, provider := newTestProvider(t)
+	ctx := context.Background()
+
+	verifier, err := auth.GeneratePKCEVerifier()
+	require.NoError(t, err)
+	authURL := provider.AuthCodeURL("state-1", "nonce-1", verifier)
+
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "openid email profile", parsed.Query().Get("scope"))
+	assert.Equal(t, auth.PKCEChallenge(verifier), parsed.Query().Get("code_challenge"))
+	assert.Empty(t, parsed.Query().Get("code_verifier"))
+
+	code, state, err := fake.Authorize(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "state-1", state)
+
+	identity, err := provider.Exchange(ctx, code, verifier, "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, &auth.OIDCIdentity{
+		Issuer:        fake.Issuer(),
+		Subject:       "user-1",
+		Email:         "alice@example.com",
+		EmailVerified: true,
+		Name:          "Alice",
+	}, identity)
+
+	// Codes are single-use
+	_, err = provider.Exchange(ctx, code, verifier, "nonce-1")
+	assert.ErrorIs(t, err, auth.ErrOIDCExchange)
+}
+
+func TestOIDCProvider_ExchangeRejects(t *testing.T) {
+	fake, provider := newTestProvider(t)
+	ctx := context.Background()
+
+	authorize := func(nonce string) (code, verifier string) {
+		verifier, err := auth.GeneratePKCEVerifier()
+		require.NoError(t, err)
+		code, _, err = fake.Authorize(provider.AuthCodeURL("state", nonce, verifier))
+		require.NoError(t, err)
+		return code, verifier
+	}
+
+	t.Run("wrong code verifier", func(t *testing.T) {
+		code, _ := authorize("nonce")
+		other, err := auth.GeneratePKCEVerifier()
+		require.NoError(t, err)
+		_, err = provider.Exchange(ctx, code, other, "nonce")
+		assert.ErrorIs(t, err, auth.ErrOIDCExchange)
+	})
+
+	t.Run("nonce of another login", func(t *testing.T) {
+		code, verifier := authorize("nonce")
+		_, err := provider.Exchange(ctx, code, verifier, "other-nonce")
+		assert.ErrorIs(t, err, auth.ErrInvalidIDToken)
+	})
+
+	t.Run("wrong client secret", func(t *testing.T) {
+		config := fake.Config(redirectURL)
+		config.ClientSecret = "wrong"
+		other, err := auth.DiscoverOIDCProvider(ctx, config, fake.Client())
+		require.NoError(t, err)
+
+		code, verifier := authorize("nonce")
+		_, err = other.Exchange(ctx, code, verifier, "nonce")
+		assert.ErrorIs(t, err, auth.ErrOIDCExchange)
+	})
+}
+
+func TestOIDCProvider_VerifyIDToken(t *testing.T) {
+	fake, provider := newTestProvider(t)
+	ctx := context.Background()
+	user := oidctest.User{Subject: "user-1", Email: "alice@example.com"}
+
+	identity, err := provider.VerifyIDToken(ctx, fake.IDToken(user, "days", "nonce", time.Hour), "nonce")
+	require.NoError(t, err)
+	assert.False(t, identity.EmailVerified)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"other audience", fake.IDToken(user, "another-client", "nonce", time.Hour)},
+		{"expired", fake.IDToken(user, "days", "nonce", -time.Hour)},
+		{"missing nonce", fake.IDToken(user, "days", "", time.Hour)},
+		{"missing subject", fake.IDToken(oidctest.User{Email: "alice@example.com"}, "days", "nonce", time.Hour)},
+		{"not a JWT", "not-a-token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.VerifyIDToken(ctx, tt.token, "nonce")
+			assert.ErrorIs(t, err, auth.ErrInvalidIDToken)
+		})
+	}
+
+	// Tokens signed by another provider's key do not verify
+	other := oidctest.NewProvider("days", "client-secret")
+	defer other.Close()
+	_, err = provider.VerifyIDToken(ctx, other.IDToken(user, "days", "nonce", time.Hour), "nonce")
+	assert.Error(t, err)
+}
+
+func TestDiscoverOIDCProvider(t *testing.T) {
+	fake := oidctest.NewProvider("days", "client-secret")
+	defer fake.Close()
+	ctx := context.Background()
+
+	config := fake.Config(redirectURL)
+	config.IssuerURL = fake.URL + "/"
+	provider, err := auth.DiscoverOIDCProvider(ctx, config, fake.Client())
+	require.NoError(t, err)
+	assert.Equal(t, fake.Issuer(), provider.Issuer())
+
+	config.IssuerURL = fake.URL + "/other"
+	_, err = auth.DiscoverOIDCProvider(ctx, config, fake.Client())
+	assert.ErrorIs(t, err, auth.ErrOIDCDiscovery)
+
+	config = fake.Config("")
+	_, err = auth.DiscoverOIDCProvider(ctx, config, fake.Client())
+	assert.ErrorIs(t, err, auth.ErrOIDCDiscovery)
+}
+
+func TestPKCEChallenge(t *testing.T) {
+	// RFC 7636 appendix B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", auth.PKCEChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// GenerateOpaqueToken returns a random URL-safe secret token and the hash to store for it.
+func GenerateOpaqueToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashOpaqueToken(token), nil
+}
+
+// HashOpaqueToken returns the SHA-256 hex digest under which a secret token is stored.
+// The tokens are high-entropy, so a fast unsalted hash is enough to keep them useless if leaked.
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateRefreshToken returns a random opaque refresh token and the hash to store for it.
+func GenerateRefreshToken() (token, hash string, err error) {
+	return GenerateOpaqueToken()
+}
+
+// HashRefreshToken returns the SHA-256 hex digest under which a refresh token is stored.
+func HashRefreshToken(token string) string {
+	return HashOpaqueToken(token)
+}
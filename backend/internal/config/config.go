@@ -0,0 +1,343 @@
+// Package config loads the configuration of the server into one struct.
+//
+// Settings start from their defaults and are overridden, in order, by a YAML
+// or TOML file, by environment variables and by command-line flags. Every
+// setting has an environment variable, such as DB_HOST, and a flag named
+// after it, such as --db-host; secrets have no flag, as the command lines of
+// processes are visible to other users of the host. The loaded config is
+// validated as a whole, so that the server refuses to start on a setting it
+// cannot run with rather than failing on the first request that needs it.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	netmail "net/mail"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"days/internal/audit"
+	"days/internal/auth"
+	"days/internal/database"
+	"days/internal/handlers"
+	"days/internal/logging"
+	"days/internal/mail"
+	"days/internal/services"
+	"days/internal/tracing"
+)
+
+// MinSecretLength is the shortest JWT secret accepted: 256 bits, the size
+// of an HS256 key
+const MinSecretLength = 32
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+// weakSecrets are example secrets published with Days, long enough to pass
+// the length check, that must never sign tokens
+var weakSecrets = []string{
+	"your_jwt_secret_here_change_in_production",
+}
+
+// Config is the configuration of the server
+type Config struct {
+	Server   ServerConfig    `yaml:"server" toml:"server"`
+	Database database.Config `yaml:"database" toml:"database"`
+	Auth     AuthConfig      `yaml:"auth" toml:"auth"`
+	OIDC     auth.OIDCConfig `yaml:"oidc" toml:"oidc"`
+	Mail     mail.Config     `yaml:"mail" toml:"mail"`
+	Logging  logging.Config  `yaml:"logging" toml:"logging"`
+	Tracing  tracing.Config  `yaml:"tracing" toml:"tracing"`
+	Audit    AuditConfig     `yaml:"audit" toml:"audit"`
+}
+
+// ServerConfig configures the HTTP server
+type ServerConfig struct {
+	Port           string `yaml:"port" toml:"port"`
+	MetricsPort    string `yaml:"metrics_port" toml:"metrics_port"` // serves /metrics apart from the API when set
+	AppURL         string `yaml:"app_url" toml:"app_url"`           // where links in account emails point
+	MigrateOnStart bool   `yaml:"migrate_on_start" toml:"migrate_on_start"`
+
+	CORSOrigins    []string `yaml:"cors_origins" toml:"cors_origins"` // origins browsers may call the API from, or "*" for any
+	MaxBodyBytes   int64    `yaml:"max_body_bytes" toml:"max_body_bytes"`
+	MaxImportBytes int64    `yaml:"max_import_bytes" toml:"max_import_bytes"`
+
+	// TrustedProxies are the addresses or CIDR ranges of reverse proxies in
+	// front of the server, whose X-Forwarded-For header names the client
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies"`
+
+	// ReadHeaderTimeout and ReadTimeout bound how long a client may take to
+	// send a request, WriteTimeout how long writing the response may take,
+	// and IdleTimeout how long a keep-alive connection waits for the next one
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+
+	// RequestTimeout is the deadline of the context handlers run with.
+	// Exports and imports get BulkTimeout instead, which also replaces
+	// ReadTimeout and WriteTimeout for them.
+	RequestTimeout time.Duration `yaml:"request_timeout" toml:"request_timeout"`
+	BulkTimeout    time.Duration `yaml:"bulk_timeout" toml:"bulk_timeout"`
+
+	// ShutdownDelay is how long the server keeps serving after a SIGTERM
+	// while reporting not ready, for load balancers to stop sending it
+	// requests; ShutdownTimeout then bounds how long in-flight requests
+	// have to finish
+	ShutdownDelay   time.Duration `yaml:"shutdown_delay" toml:"shutdown_delay"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+}
+
+// HTTP returns the settings of the API handlers
+func (c ServerConfig) HTTP() handlers.Config {
+	return handlers.Config{
+		CORSOrigins:    c.CORSOrigins,
+		MaxBodyBytes:   c.MaxBodyBytes,
+		MaxImportBytes: c.MaxImportBytes,
+		TrustedProxies: c.trustedProxies(),
+	}
+}
+
+// trustedProxies parses TrustedProxies, skipping entries Validate rejects
+func (c ServerConfig) trustedProxies() []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, proxy := range c.TrustedProxies {
+		if prefix, err := parseProxy(proxy); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// parseProxy parses a CIDR range, or a single address as the range holding
+// only it
+func parseProxy(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// AuthConfig configures the keys tokens are signed with and how long they last
+type AuthConfig struct {
+	Keys     auth.KeyringConfig     `yaml:"keys" toml:"keys"`
+	Sessions services.SessionConfig `yaml:"sessions" toml:"sessions"`
+}
+
+// AuditConfig configures the audit log
+type AuditConfig struct {
+	RetentionDays int `yaml:"retention_days" toml:"retention_days"` // 0 keeps events forever
+}
+
+// Retention returns how long audit events are kept, or 0 to keep them forever
+func (c AuditConfig) Retention() time.Duration {
+	return time.Duration(c.RetentionDays) * 24 * time.Hour
+}
+
+// Default returns the config the server runs with when nothing is set. It
+// has no JWT secret and so does not validate.
+func Default() *Config {
+	http := handlers.DefaultConfig()
+	return &Config{
+		Server: ServerConfig{
+			Port:              "8080",
+			AppURL:            "http://localhost:8080",
+			CORSOrigins:       http.CORSOrigins,
+			MaxBodyBytes:      http.MaxBodyBytes,
+			MaxImportBytes:    http.MaxImportBytes,
+			TrustedProxies:    []string{},
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			RequestTimeout:    50 * time.Second,
+			BulkTimeout:       15 * time.Minute,
+			ShutdownDelay:     5 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+		},
+		Database: database.DefaultConfig(),
+		Auth:     AuthConfig{Sessions: services.DefaultSessionConfig()},
+		OIDC:     auth.DefaultOIDCConfig(),
+		Mail:     mail.DefaultConfig(),
+		Logging:  logging.DefaultConfig(),
+		Tracing:  tracing.DefaultConfig(),
+		Audit:    AuditConfig{RetentionDays: audit.DefaultRetentionDays},
+	}
+}
+
+// problems collects the settings a config cannot run with
+type problems []error
+
+// add records a problem, wrapping ErrInvalidConfig
+func (p *problems) add(format string, args ...any) {
+	*p = append(*p, fmt.Errorf("%w: "+format, append([]any{ErrInvalidConfig}, args...)...))
+}
+
+// Validate reports every setting the server cannot run with, each wrapping
+// ErrInvalidConfig. Settings are named by their environment variable.
+func (c *Config) Validate() error {
+	var errs problems
+	invalid := errs.add
+
+	// Server
+	if !isPort(c.Server.Port) {
+		invalid("PORT %q is not a port", c.Server.Port)
+	}
+	if c.Server.MetricsPort != "" {
+		if !isPort(c.Server.MetricsPort) {
+			invalid("METRICS_PORT %q is not a port", c.Server.MetricsPort)
+		} else if c.Server.MetricsPort == c.Server.Port {
+			invalid("METRICS_PORT must differ from PORT")
+		}
+	}
+	if !isAbsoluteURL(c.Server.AppURL) {
+		invalid("APP_URL %q is not an absolute URL", c.Server.AppURL)
+	}
+	for _, origin := range c.Server.CORSOrigins {
+		if origin != "*" && !isOrigin(origin) {
+			invalid("CORS_ALLOW_ORIGIN %q is neither * nor an origin such as https://days.example.com", origin)
+		}
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, err := parseProxy(proxy); err != nil {
+			invalid("TRUSTED_PROXIES %q is neither an IP address nor a CIDR range", proxy)
+		}
+	}
+	if c.Server.MaxBodyBytes <= 0 {
+		invalid("MAX_BODY_BYTES must be positive")
+	}
+	if c.Server.MaxImportBytes <= 0 {
+		invalid("MAX_IMPORT_BYTES must be positive")
+	}
+	for _, d := range []struct {
+		key   string
+		value time.Duration
+	}{
+		{"HTTP_READ_HEADER_TIMEOUT", c.Server.ReadHeaderTimeout},
+		{"HTTP_READ_TIMEOUT", c.Server.ReadTimeout},
+		{"HTTP_WRITE_TIMEOUT", c.Server.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", c.Server.IdleTimeout},
+		{"SHUTDOWN_DELAY", c.Server.ShutdownDelay},
+		{"SHUTDOWN_TIMEOUT", c.Server.ShutdownTimeout},
+	} {
+		if d.value < 0 {
+			invalid("%s must not be negative", d.key)
+		}
+	}
+	if c.Server.RequestTimeout <= 0 {
+		invalid("HTTP_REQUEST_TIMEOUT must be positive")
+	}
+	if c.Server.BulkTimeout <= 0 {
+		invalid("BULK_TIMEOUT must be positive")
+	}
+
+	// Database
+	c.validateDatabase(invalid)
+
+	// Auth
+	keys := c.Auth.Keys
+	if keys.Secret == "" && keys.SigningKeyFile == "" {
+		invalid("JWT_SECRET or JWT_SIGNING_KEY_FILE is required")
+	}
+	// A secret kept only for verifying old tokens can still forge new ones
+	if keys.Secret != "" {
+		if len(keys.Secret) < MinSecretLength {
+			invalid("JWT_SECRET is shorter than %d bytes; generate one with: openssl rand -base64 32", MinSecretLength)
+		} else if slices.Contains(weakSecrets, keys.Secret) {
+			invalid("JWT_SECRET is a published example secret; generate one with: openssl rand -base64 32")
+		}
+	}
+	sessions := c.Auth.Sessions
+	if sessions.AccessTokenTTL <= 0 || sessions.RefreshTokenTTL <= 0 {
+		invalid("ACCESS_TOKEN_TTL and REFRESH_TOKEN_TTL must be positive")
+	} else if sessions.AccessTokenTTL > sessions.RefreshTokenTTL {
+		invalid("ACCESS_TOKEN_TTL (%s) exceeds REFRESH_TOKEN_TTL (%s)", sessions.AccessTokenTTL, sessions.RefreshTokenTTL)
+	}
+	if c.OIDC.IssuerURL != "" {
+		if !isAbsoluteURL(c.OIDC.IssuerURL) {
+			invalid("OIDC_ISSUER_URL %q is not an absolute URL", c.OIDC.IssuerURL)
+		}
+		if c.OIDC.ClientID == "" {
+			invalid("OIDC_CLIENT_ID is required with OIDC_ISSUER_URL")
+		}
+	}
+
+	// Mail, logging, tracing and audit
+	if !slices.Contains([]string{"smtp", "file", "log"}, c.Mail.Driver) {
+		invalid("MAIL_DRIVER %q is not smtp, file or log", c.Mail.Driver)
+	}
+	if _, err := netmail.ParseAddress(c.Mail.From); err != nil {
+		invalid("MAIL_FROM %q is not an email address", c.Mail.From)
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Logging.Level)); err != nil {
+		invalid("LOG_LEVEL %q is not debug, info, warn or error", c.Logging.Level)
+	}
+	if format := strings.ToLower(c.Logging.Format); format != "json" && format != "text" {
+		invalid("LOG_FORMAT %q is not json or text", c.Logging.Format)
+	}
+	if exporter := strings.ToLower(c.Tracing.Exporter); !slices.Contains([]string{tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterStdout}, exporter) {
+		invalid("OTEL_TRACES_EXPORTER %q is not none, otlp or stdout", c.Tracing.Exporter)
+	}
+	if c.Audit.RetentionDays < 0 {
+		invalid("AUDIT_RETENTION_DAYS must not be negative")
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateDatabase reports the database settings that migrations cannot run
+// with. A migration job is given database credentials and nothing else, so
+// the rest of the config is not checked.
+func (c *Config) ValidateDatabase() error {
+	var errs problems
+	c.validateDatabase(errs.add)
+	return errors.Join(errs...)
+}
+
+func (c *Config) validateDatabase(invalid func(format string, args ...any)) {
+	if c.Database.Host == "" {
+		invalid("DB_HOST is required")
+	}
+	if c.Database.DBName == "" {
+		invalid("DB_NAME is required")
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 {
+		invalid("DB_MAX_OPEN_CONNS and DB_MAX_IDLE_CONNS must not be negative")
+	} else if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		invalid("DB_MAX_IDLE_CONNS (%d) exceeds DB_MAX_OPEN_CONNS (%d)", c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+	}
+	if c.Database.ConnMaxLifetime < 0 {
+		invalid("DB_CONN_MAX_LIFETIME must not be negative")
+	}
+	if c.Database.ConnMaxIdleTime < 0 {
+		invalid("DB_CONN_MAX_IDLE_TIME must not be negative")
+	}
+}
+
+func isPort(value string) bool {
+	n, err := strconv.Atoi(value)
+	return err == nil && n > 0 && n <= 65535
+}
+
+func isAbsoluteURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// isOrigin reports whether value is a scheme and host, as browsers send in
+// the Origin header
+func isOrigin(value string) bool {
+	u, err := url.Parse(value)
+	return isAbsoluteURL(value) && err == nil && u.Path == "" && u.RawQuery == "" && u.Fragment == ""
+}
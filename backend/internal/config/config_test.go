@@ -0,0 +1,331 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"days/internal/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// clearEnv unsets every variable Load reads for the duration of the test
+func clearEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("CONFIG_FILE", "")
+	for _, b := range Default().bindings() {
+		t.Setenv(b.env, "")
+	}
+}
+
+func newFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("days", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func validConfig() *Config {
+	config := Default()
+	config.Auth.Keys.Secret = testSecret
+	return config
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv("JWT_SECRET", testSecret)
+
+		config, err := Load(newFlagSet(), nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, validConfig(), config)
+	})
+
+	t.Run("flags override the environment, which overrides the file", func(t *testing.T) {
+		clearEnv(t)
+		path := writeFile(t, "days.yaml", `
+server:
+  port: "9000"
+  cors_origins: [https://days.example.com]
+database:
+  host: db.internal
+  max_open_conns: 50
+auth:
+  keys:
+    secret: `+testSecret+`
+  sessions:
+    access_token_ttl: 5m
+`)
+		t.Setenv("DB_HOST", "db.example.com")
+		t.Setenv("DB_MAX_IDLE_CONNS", "10")
+		t.Setenv("CORS_ALLOW_ORIGIN", "https://a.example.com, https://b.example.com")
+
+		fs := newFlagSet()
+		config, err := Load(fs, []string{"--config", path, "--db-host", "db.local", "--migrate-on-start", "migrate", "up"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "9000", config.Server.Port)
+		assert.Equal(t, "db.local", config.Database.Host)
+		assert.Equal(t, 50, config.Database.MaxOpenConns)
+		assert.Equal(t, 10, config.Database.MaxIdleConns)
+		assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, config.Server.CORSOrigins)
+		assert.Equal(t, 5*time.Minute, config.Auth.Sessions.AccessTokenTTL)
+		assert.True(t, config.Server.MigrateOnStart)
+		assert.Equal(t, []string{"migrate", "up"}, fs.Args())
+	})
+
+	t.Run("TOML file", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv("CONFIG_FILE", writeFile(t, "days.toml", `
+[server]
+max_body_bytes = 2048
+shutdown_delay = "10s"
+
+[auth.keys]
+secret = "`+testSecret+`"
+
+[audit]
+retention_days = 0
+`))
+
+		config, err := Load(newFlagSet(), nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(2048), config.Server.MaxBodyBytes)
+		assert.Equal(t, 10*time.Second, config.Server.ShutdownDelay)
+		assert.Zero(t, config.Audit.Retention())
+	})
+
+	t.Run("example file", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv("JWT_SECRET", testSecret)
+
+		// The example documents every setting with its default
+		config, err := Load(newFlagSet(), []string{"--config", "../../config.example.yaml"})
+
+		require.NoError(t, err)
+		assert.Equal(t, validConfig().Server, config.Server)
+		assert.Equal(t, validConfig().Database, config.Database)
+	})
+
+	t.Run("secrets have no flag", func(t *testing.T) {
+		clearEnv(t)
+
+		_, err := Load(newFlagSet(), []string{"--jwt-secret", testSecret})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name string
+			env  map[string]string
+			args []string
+		}{
+			{name: "missing JWT secret"},
+			{name: "weak JWT secret", env: map[string]string{"JWT_SECRET": "your_jwt_secret_here_change_in_production"}},
+			{name: "invalid number", env: map[string]string{"JWT_SECRET": testSecret, "DB_MAX_OPEN_CONNS": "many"}},
+			{name: "invalid duration flag", env: map[string]string{"JWT_SECRET": testSecret}, args: []string{"--access-token-ttl", "15"}},
+			{name: "unknown file setting", env: map[string]string{"JWT_SECRET": testSecret, "CONFIG_FILE": writeFile(t, "days.yaml", "server:\n  prot: 9000\n")}},
+			{name: "unsupported file format", env: map[string]string{"JWT_SECRET": testSecret, "CONFIG_FILE": writeFile(t, "days.json", "{}")}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				clearEnv(t)
+				for key, value := range tt.env {
+					t.Setenv(key, value)
+				}
+
+				_, err := Load(newFlagSet(), tt.args)
+
+				assert.ErrorIs(t, err, ErrInvalidConfig)
+			})
+		}
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			modify: func(c *Config) {},
+		},
+		{
+			name:   "signing key file without secret",
+			modify: func(c *Config) { c.Auth.Keys = auth.KeyringConfig{SigningKeyFile: "/etc/days/jwt.pem"} },
+		},
+		{
+			name:    "no signing key",
+			modify:  func(c *Config) { c.Auth.Keys.Secret = "" },
+			wantErr: "JWT_SECRET or JWT_SIGNING_KEY_FILE is required",
+		},
+		{
+			name:    "short secret",
+			modify:  func(c *Config) { c.Auth.Keys.Secret = "too-short" },
+			wantErr: "JWT_SECRET is shorter than 32 bytes",
+		},
+		{
+			name: "short secret kept for verification",
+			modify: func(c *Config) {
+				c.Auth.Keys = auth.KeyringConfig{Secret: "too-short", SigningKeyFile: "/etc/days/jwt.pem"}
+			},
+			wantErr: "JWT_SECRET is shorter than 32 bytes",
+		},
+		{
+			name:    "example secret",
+			modify:  func(c *Config) { c.Auth.Keys.Secret = "your_jwt_secret_here_change_in_production" },
+			wantErr: "JWT_SECRET is a published example secret",
+		},
+		{
+			name:    "no bulk timeout",
+			modify:  func(c *Config) { c.Server.BulkTimeout = 0 },
+			wantErr: "BULK_TIMEOUT must be positive",
+		},
+		{
+			name:    "idle connections exceed open ones",
+			modify:  func(c *Config) { c.Database.MaxOpenConns, c.Database.MaxIdleConns = 10, 20 },
+			wantErr: "DB_MAX_IDLE_CONNS (20) exceeds DB_MAX_OPEN_CONNS (10)",
+		},
+		{
+			name:   "unlimited open connections",
+			modify: func(c *Config) { c.Database.MaxOpenConns, c.Database.MaxIdleConns = 0, 20 },
+		},
+		{
+			name:    "access tokens outlive sessions",
+			modify:  func(c *Config) { c.Auth.Sessions.AccessTokenTTL = 60 * 24 * time.Hour },
+			wantErr: "ACCESS_TOKEN_TTL (1440h0m0s) exceeds REFRESH_TOKEN_TTL (720h0m0s)",
+		},
+		{
+			name:    "zero token TTL",
+			modify:  func(c *Config) { c.Auth.Sessions.RefreshTokenTTL = 0 },
+			wantErr: "ACCESS_TOKEN_TTL and REFRESH_TOKEN_TTL must be positive",
+		},
+		{
+			name:    "CORS origin with a path",
+			modify:  func(c *Config) { c.Server.CORSOrigins = []string{"https://days.example.com/app"} },
+			wantErr: `CORS_ALLOW_ORIGIN "https://days.example.com/app"`,
+		},
+		{
+			name:   "CORS origins",
+			modify: func(c *Config) { c.Server.CORSOrigins = []string{"https://days.example.com", "http://localhost:3000"} },
+		},
+		{
+			name:    "trusted proxy that is not an address",
+			modify:  func(c *Config) { c.Server.TrustedProxies = []string{"10.0.0.0/8", "ingress"} },
+			wantErr: `TRUSTED_PROXIES "ingress"`,
+		},
+		{
+			name:   "trusted proxies",
+			modify: func(c *Config) { c.Server.TrustedProxies = []string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32"} },
+		},
+		{
+			name:    "body limit",
+			modify:  func(c *Config) { c.Server.MaxBodyBytes = 0 },
+			wantErr: "MAX_BODY_BYTES must be positive",
+		},
+		{
+			name:    "metrics on the API port",
+			modify:  func(c *Config) { c.Server.MetricsPort = c.Server.Port },
+			wantErr: "METRICS_PORT must differ from PORT",
+		},
+		{
+			name:    "negative timeout",
+			modify:  func(c *Config) { c.Server.ShutdownDelay = -time.Second },
+			wantErr: "SHUTDOWN_DELAY must not be negative",
+		},
+		{
+			name:    "log level",
+			modify:  func(c *Config) { c.Logging.Level = "verbose" },
+			wantErr: `LOG_LEVEL "verbose"`,
+		},
+		{
+			name:    "tracing exporter",
+			modify:  func(c *Config) { c.Tracing.Exporter = "zipkin" },
+			wantErr: `OTEL_TRACES_EXPORTER "zipkin"`,
+		},
+		{
+			name:    "OIDC without client ID",
+			modify:  func(c *Config) { c.OIDC.IssuerURL = "https://login.example.com" },
+			wantErr: "OIDC_CLIENT_ID is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := validConfig()
+			tt.modify(config)
+
+			err := config.Validate()
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalidConfig)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("reports every problem", func(t *testing.T) {
+		config := Default()
+		config.Server.MaxBodyBytes = 0
+
+		err := config.Validate()
+
+		assert.ErrorContains(t, err, "JWT_SECRET or JWT_SIGNING_KEY_FILE is required")
+		assert.ErrorContains(t, err, "MAX_BODY_BYTES must be positive")
+	})
+}
+
+func TestConfig_ValidateDatabase(t *testing.T) {
+	t.Run("needs no more than the database", func(t *testing.T) {
+		config := Default()
+		config.Server.AppURL = ""
+
+		assert.Error(t, config.Validate())
+		assert.NoError(t, config.ValidateDatabase())
+	})
+
+	t.Run("reports database problems", func(t *testing.T) {
+		config := Default()
+		config.Database.Host = ""
+		config.Database.ConnMaxLifetime = -time.Second
+
+		err := config.ValidateDatabase()
+
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "DB_HOST is required")
+		assert.ErrorContains(t, err, "DB_CONN_MAX_LIFETIME must not be negative")
+	})
+}
+
+func TestRead(t *testing.T) {
+	clearEnv(t)
+	fs := newFlagSet()
+
+	config, err := Read(fs, []string{"--db-host", "db.internal", "migrate", "up"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "db.internal", config.Database.Host)
+	assert.Equal(t, []string{"migrate", "up"}, fs.Args())
+	assert.ErrorIs(t, config.Validate(), ErrInvalidConfig)
+}
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// binding ties a setting to its environment variable and flag
+type binding struct {
+	env    string
+	target any // *string, *int, *int64, *bool, *time.Duration or *[]string
+	usage  string
+	secret bool // set from the file or environment only
+}
+
+// bindings lists the settings of c that the environment and flags override
+func (c *Config) bindings() []binding {
+	return []binding{
+		{env: "PORT", target: &c.Server.Port, usage: "port the API listens on"},
+		{env: "METRICS_PORT", target: &c.Server.MetricsPort, usage: "port /metrics is served on instead of PORT"},
+		{env: "APP_URL", target: &c.Server.AppURL, usage: "URL links in account emails point to"},
+		{env: "MIGRATE_ON_START", target: &c.Server.MigrateOnStart, usage: "apply pending migrations before serving"},
+		{env: "CORS_ALLOW_ORIGIN", target: &c.Server.CORSOrigins, usage: "origins browsers may call the API from, or * for any"},
+		{env: "TRUSTED_PROXIES", target: &c.Server.TrustedProxies, usage: "addresses or CIDR ranges of reverse proxies whose X-Forwarded-For is trusted"},
+		{env: "MAX_BODY_BYTES", target: &c.Server.MaxBodyBytes, usage: "largest request body accepted"},
+		{env: "MAX_IMPORT_BYTES", target: &c.Server.MaxImportBytes, usage: "largest import upload accepted"},
+		{env: "HTTP_READ_HEADER_TIMEOUT", target: &c.Server.ReadHeaderTimeout, usage: "time allowed to read request headers"},
+		{env: "HTTP_READ_TIMEOUT", target: &c.Server.ReadTimeout, usage: "time allowed to read a request"},
+		{env: "HTTP_WRITE_TIMEOUT", target: &c.Server.WriteTimeout, usage: "time allowed to write a response"},
+		{env: "HTTP_IDLE_TIMEOUT", target: &c.Server.IdleTimeout, usage: "time a keep-alive connection waits for the next request"},
+		{env: "HTTP_REQUEST_TIMEOUT", target: &c.Server.RequestTimeout, usage: "deadline of the context handlers run with"},
+		{env: "BULK_TIMEOUT", target: &c.Server.BulkTimeout, usage: "time an export or import may take, in place of the request, read and write timeouts"},
+		{env: "SHUTDOWN_DELAY", target: &c.Server.ShutdownDelay, usage: "time to keep serving while reporting not ready on shutdown"},
+		{env: "SHUTDOWN_TIMEOUT", target: &c.Server.ShutdownTimeout, usage: "time in-flight requests have to finish on shutdown"},
+
+		{env: "DB_HOST", target: &c.Database.Host, usage: "database host"},
+		{env: "DB_PORT", target: &c.Database.Port, usage: "database port"},
+		{env: "DB_USER", target: &c.Database.User, usage: "database user"},
+		{env: "DB_PASSWORD", target: &c.Database.Password, secret: true},
+		{env: "DB_NAME", target: &c.Database.DBName, usage: "database name"},
+		{env: "DB_SSLMODE", target: &c.Database.SSLMode, usage: "database sslmode"},
+		{env: "DB_MAX_OPEN_CONNS", target: &c.Database.MaxOpenConns, usage: "most open database connections, 0 for no limit"},
+		{env: "DB_MAX_IDLE_CONNS", target: &c.Database.MaxIdleConns, usage: "most idle database connections kept open"},
+		{env: "DB_CONN_MAX_LIFETIME", target: &c.Database.ConnMaxLifetime, usage: "age at which database connections are closed, 0 for never"},
+		{env: "DB_CONN_MAX_IDLE_TIME", target: &c.Database.ConnMaxIdleTime, usage: "idle time after which database connections are closed, 0 for never"},
+
+		{env: "JWT_SECRET", target: &c.Auth.Keys.Secret, secret: true},
+		{env: "JWT_SIGNING_KEY_FILE", target: &c.Auth.Keys.SigningKeyFile, usage: "PEM file of the RSA or Ed25519 key access tokens are signed with"},
+		{env: "JWT_VERIFICATION_KEY_FILES", target: &c.Auth.Keys.VerificationKeyFiles, usage: "PEM files of earlier keys whose tokens are still accepted"},
+		{env: "ACCESS_TOKEN_TTL", target: &c.Auth.Sessions.AccessTokenTTL, usage: "lifetime of access tokens"},
+		{env: "REFRESH_TOKEN_TTL", target: &c.Auth.Sessions.RefreshTokenTTL, usage: "how long a session survives without being refreshed"},
+
+		{env: "OIDC_ISSUER_URL", target: &c.OIDC.IssuerURL, usage: "OpenID Connect provider to log in through"},
+		{env: "OIDC_CLIENT_ID", target: &c.OIDC.ClientID, usage: "OpenID Connect client ID"},
+		{env: "OIDC_CLIENT_SECRET", target: &c.OIDC.ClientSecret, secret: true},
+		{env: "OIDC_REDIRECT_URL", target: &c.OIDC.RedirectURL, usage: "OpenID Connect redirect URL, by default APP_URL/auth/oidc/callback"},
+		{env: "OIDC_SCOPES", target: &c.OIDC.Scopes, usage: "OpenID Connect scopes requested besides openid"},
+
+		{env: "MAIL_DRIVER", target: &c.Mail.Driver, usage: "smtp, file or log"},
+		{env: "MAIL_FROM", target: &c.Mail.From, usage: "sender of account emails"},
+		{env: "MAIL_DIR", target: &c.Mail.Dir, usage: "directory the file mail driver writes to"},
+		{env: "SMTP_HOST", target: &c.Mail.SMTPHost, usage: "SMTP server host"},
+		{env: "SMTP_PORT", target: &c.Mail.SMTPPort, usage: "SMTP server port"},
+		{env: "SMTP_USERNAME", target: &c.Mail.SMTPUsername, usage: "SMTP username"},
+		{env: "SMTP_PASSWORD", target: &c.Mail.SMTPPassword, secret: true},
+
+		{env: "LOG_LEVEL", target: &c.Logging.Level, usage: "debug, info, warn or error"},
+		{env: "LOG_FORMAT", target: &c.Logging.Format, usage: "json or text"},
+		{env: "OTEL_TRACES_EXPORTER", target: &c.Tracing.Exporter, usage: "none, otlp or stdout"},
+		{env: "OTEL_SERVICE_NAME", target: &c.Tracing.ServiceName, usage: "service name spans are exported under"},
+		{env: "AUDIT_RETENTION_DAYS", target: &c.Audit.RetentionDays, usage: "days audit events are kept, 0 for forever"},
+	}
+}
+
+// flagName returns the flag of an environment variable: DB_HOST is --db-host
+func flagName(env string) string {
+	return strings.ReplaceAll(strings.ToLower(env), "_", "-")
+}
+
+// set parses value into the setting. Lists are separated by commas or spaces.
+func (b binding) set(value string) error {
+	switch target := b.target.(type) {
+	case *string:
+		*target = value
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*target = n
+	case *int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		*target = n
+	case *bool:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*target = v
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*target = d
+	case *[]string:
+		*target = strings.FieldsFunc(value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+	default:
+		return fmt.Errorf("unsupported setting type %T", target)
+	}
+	return nil
+}
+
+// Load returns the validated config: the defaults, overridden by the config
+// file, then by environment variables, then by the flags in args. The file
+// is named by the --config flag or CONFIG_FILE. Load defines the flags on fs
+// and parses args with it, leaving the arguments after the flags in
+// fs.Args().
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	config, err := Read(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Read is Load without validation, for commands that need only part of the
+// config and check that part themselves. Settings that do not parse, such as
+// a duration without a unit, are still errors.
+func Read(fs *flag.FlagSet, args []string) (*Config, error) {
+	config := Default()
+	bindings := config.bindings()
+
+	path := os.Getenv("CONFIG_FILE")
+	fs.StringVar(&path, "config", path, "YAML or TOML config file")
+
+	// Flags are applied once the file and environment have been, so that
+	// they win whatever order the sources are read in
+	type flagValue struct {
+		binding binding
+		value   string
+	}
+	var flags []flagValue
+	for _, b := range bindings {
+		if b.secret {
+			continue
+		}
+		record := func(value string) error {
+			flags = append(flags, flagValue{b, value})
+			return nil
+		}
+		usage := fmt.Sprintf("%s (%s)", b.usage, b.env)
+		if _, ok := b.target.(*bool); ok {
+			fs.BoolFunc(flagName(b.env), usage, record)
+		} else {
+			fs.Func(flagName(b.env), usage, record)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if path != "" {
+		if err := readFile(path, config); err != nil {
+			return nil, err
+		}
+	}
+	for _, b := range bindings {
+		if value := os.Getenv(b.env); value != "" {
+			if err := b.set(value); err != nil {
+				return nil, fmt.Errorf("%w: %s %q: %v", ErrInvalidConfig, b.env, value, err)
+			}
+		}
+	}
+	for _, f := range flags {
+		if err := f.binding.set(f.value); err != nil {
+			return nil, fmt.Errorf("%w: --%s %q: %v", ErrInvalidConfig, flagName(f.binding.env), f.value, err)
+		}
+	}
+	return config, nil
+}
+
+// readFile decodes a YAML or TOML file, told apart by its extension, over
+// config. Settings the file does not mention keep their value, and unknown
+// ones are rejected so that a typo does not go unnoticed.
+func readFile(path string, config *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), config)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("%w: %s: unknown setting %s", ErrInvalidConfig, path, undecoded[0])
+		}
+	default:
+		return fmt.Errorf("%w: %s: config files are .yaml, .yml or .toml", ErrInvalidConfig, path)
+	}
+	return nil
+}
@@ -3,21 +3,28 @@ package database
 import (
 	"database/sql"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"time"
 
 	"days/internal/db"
+	"days/internal/tracing"
 
 	_ "github.com/lib/pq" // PostgreSQL driver
 )
 
 type Config struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Host     string `yaml:"host" toml:"host"`
+	Port     string `yaml:"port" toml:"port"`
+	User     string `yaml:"user" toml:"user"`
+	Password string `yaml:"password" toml:"password"`
+	DBName   string `yaml:"name" toml:"name"`
+	SSLMode  string `yaml:"sslmode" toml:"sslmode"`
+
+	// Connection pool; zero lifetimes keep connections open indefinitely
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time"`
 }
 
 type Database struct {
@@ -25,15 +32,16 @@ type Database struct {
 	Queries *db.Queries
 }
 
-// NewConfig creates database config from environment variables
-func NewConfig() *Config {
-	return &Config{
-		Host:     getEnv("DB_HOST", "localhost"),
-		Port:     getEnv("DB_PORT", "5432"),
-		User:     getEnv("DB_USER", "postgres"),
-		Password: getEnv("DB_PASSWORD", ""),
-		DBName:   getEnv("DB_NAME", "days"),
-		SSLMode:  getEnv("DB_SSLMODE", "disable"),
+// DefaultConfig returns the config of a local development database
+func DefaultConfig() Config {
+	return Config{
+		Host:         "localhost",
+		Port:         "5432",
+		User:         "postgres",
+		DBName:       "days",
+		SSLMode:      "disable",
+		MaxOpenConns: 25,
+		MaxIdleConns: 25,
 	}
 }
 
@@ -55,13 +63,15 @@ func Connect(config *Config) (*Database, error) {
 	}
 
 	// Configure connection pool
-	sqlDB.SetMaxOpenConns(25)
-	sqlDB.SetMaxIdleConns(25)
+	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(config.ConnMaxIdleTime)
 
-	// Create queries instance
-	queries := db.New(sqlDB)
+	// Create queries instance, tracing each statement
+	queries := db.New(tracing.WrapDBTX(sqlDB))
 
-	log.Printf("Connected to database: %s:%s/%s", config.Host, config.Port, config.DBName)
+	slog.Info("connected to database", "host", config.Host, "port", config.Port, "name", config.DBName)
 
 	return &Database{
 		DB:      sqlDB,
@@ -73,11 +83,3 @@ func Connect(config *Config) (*Database, error) {
 func (d *Database) Close() error {
 	return d.DB.Close()
 }
-
-// getEnv gets environment variable with a default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
@@ -0,0 +1,101 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: account_tokens.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const consumeAccountToken = `-- name: ConsumeAccountToken :one
+UPDATE account_tokens
+SET used_at = NOW()
+WHERE token_hash = $1
+  AND purpose = $2
+  AND used_at IS NULL
+  AND expires_at > NOW()
+RETURNING id, user_id, purpose, token_hash, created_at, expires_at, used_at, email
+`
+
+type ConsumeAccountTokenParams struct {
+	TokenHash string `json:"token_hash"`
+	Purpose   string `json:"purpose"`
+}
+
+// Marks a token used in the same statement that finds it, so that two
+// requests racing with one token cannot both succeed.
+func (q *Queries) ConsumeAccountToken(ctx context.Context, arg ConsumeAccountTokenParams) (AccountToken, error) {
+	row := q.db.QueryRowContext(ctx, consumeAccountToken, arg.TokenHash, arg.Purpose)
+	var i AccountToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Purpose,
+		&i.TokenHash,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.Email,
+	)
+	return i, err
+}
+
+const createAccountToken = `-- name: CreateAccountToken :one
+INSERT INTO account_tokens (user_id, purpose, token_hash, expires_at, email)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_id, purpose, token_hash, created_at, expires_at, used_at, email
+`
+
+type CreateAccountTokenParams struct {
+	UserID    uuid.UUID      `json:"user_id"`
+	Purpose   string         `json:"purpose"`
+	TokenHash string         `json:"token_hash"`
+	ExpiresAt time.Time      `json:"expires_at"`
+	Email     sql.NullString `json:"email"`
+}
+
+func (q *Queries) CreateAccountToken(ctx context.Context, arg CreateAccountTokenParams) (AccountToken, error) {
+	row := q.db.QueryRowContext(ctx, createAccountToken,
+		arg.UserID,
+		arg.Purpose,
+		arg.TokenHash,
+		arg.ExpiresAt,
+		arg.Email,
+	)
+	var i AccountToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Purpose,
+		&i.TokenHash,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.Email,
+	)
+	return i, err
+}
+
+const invalidateAccountTokens = `-- name: InvalidateAccountTokens :exec
+UPDATE account_tokens
+SET used_at = NOW()
+WHERE user_id = $1
+  AND purpose = $2
+  AND used_at IS NULL
+`
+
+type InvalidateAccountTokensParams struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Purpose string    `json:"purpose"`
+}
+
+func (q *Queries) InvalidateAccountTokens(ctx context.Context, arg InvalidateAccountTokensParams) error {
+	_, err := q.db.ExecContext(ctx, invalidateAccountTokens, arg.UserID, arg.Purpose)
+	return err
+}
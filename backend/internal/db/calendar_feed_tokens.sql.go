@@ -0,0 +1,57 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: calendar_feed_tokens.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const deleteCalendarFeedToken = `-- name: DeleteCalendarFeedToken :execrows
+DELETE FROM calendar_feed_tokens
+WHERE calendar_id = $1
+`
+
+func (q *Queries) DeleteCalendarFeedToken(ctx context.Context, calendarID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCalendarFeedToken, calendarID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getCalendarFeedToken = `-- name: GetCalendarFeedToken :one
+SELECT calendar_id, token_hash, created_at FROM calendar_feed_tokens
+WHERE calendar_id = $1
+`
+
+func (q *Queries) GetCalendarFeedToken(ctx context.Context, calendarID uuid.UUID) (CalendarFeedToken, error) {
+	row := q.db.QueryRowContext(ctx, getCalendarFeedToken, calendarID)
+	var i CalendarFeedToken
+	err := row.Scan(&i.CalendarID, &i.TokenHash, &i.CreatedAt)
+	return i, err
+}
+
+const upsertCalendarFeedToken = `-- name: UpsertCalendarFeedToken :one
+INSERT INTO calendar_feed_tokens (calendar_id, token_hash)
+VALUES ($1, $2)
+ON CONFLICT (calendar_id) DO UPDATE
+SET token_hash = EXCLUDED.token_hash, created_at = NOW()
+RETURNING calendar_id, token_hash, created_at
+`
+
+type UpsertCalendarFeedTokenParams struct {
+	CalendarID uuid.UUID `json:"calendar_id"`
+	TokenHash  string    `json:"token_hash"`
+}
+
+func (q *Queries) UpsertCalendarFeedToken(ctx context.Context, arg UpsertCalendarFeedTokenParams) (CalendarFeedToken, error) {
+	row := q.db.QueryRowContext(ctx, upsertCalendarFeedToken, arg.CalendarID, arg.TokenHash)
+	var i CalendarFeedToken
+	err := row.Scan(&i.CalendarID, &i.TokenHash, &i.CreatedAt)
+	return i, err
+}
@@ -0,0 +1,470 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: calendar_members.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const acceptCalendarInvitation = `-- name: AcceptCalendarInvitation :one
+WITH invitation AS (
+    DELETE FROM calendar_invitations
+    WHERE calendar_invitations.id = $2 AND calendar_invitations.email = $3
+      AND EXISTS (
+          SELECT 1 FROM users
+          WHERE users.id = $1 AND users.email = $3
+            AND users.email_verified_at IS NOT NULL
+      )
+    RETURNING calendar_id, role
+)
+INSERT INTO calendar_members (calendar_id, user_id, role)
+SELECT invitation.calendar_id, $1, invitation.role FROM invitation
+ON CONFLICT (calendar_id, user_id) DO UPDATE SET role = calendar_members.role
+RETURNING calendar_id, user_id, role, created_at, updated_at
+`
+
+type AcceptCalendarInvitationParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	ID     uuid.UUID `json:"id"`
+	Email  string    `json:"email"`
+}
+
+// Only the user who verified the address invited may accept. Accepting
+// twice, or an invitation to a calendar one is already a member of, keeps
+// the existing role
+func (q *Queries) AcceptCalendarInvitation(ctx context.Context, arg AcceptCalendarInvitationParams) (CalendarMember, error) {
+	row := q.db.QueryRowContext(ctx, acceptCalendarInvitation, arg.UserID, arg.ID, arg.Email)
+	var i CalendarMember
+	err := row.Scan(
+		&i.CalendarID,
+		&i.UserID,
+		&i.Role,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteCalendarInvitation = `-- name: DeleteCalendarInvitation :execrows
+DELETE FROM calendar_invitations
+WHERE id = $1 AND email = $2
+`
+
+type DeleteCalendarInvitationParams struct {
+	ID    uuid.UUID `json:"id"`
+	Email string    `json:"email"`
+}
+
+func (q *Queries) DeleteCalendarInvitation(ctx context.Context, arg DeleteCalendarInvitationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCalendarInvitation, arg.ID, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteCalendarMember = `-- name: DeleteCalendarMember :execrows
+DELETE FROM calendar_members
+WHERE calendar_id = $1 AND user_id = $2 AND role <> 'owner'
+`
+
+type DeleteCalendarMemberParams struct {
+	CalendarID uuid.UUID `json:"calendar_id"`
+	UserID     uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) DeleteCalendarMember(ctx context.Context, arg DeleteCalendarMemberParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCalendarMember, arg.CalendarID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getCalendarInvitationsByCalendarID = `-- name: GetCalendarInvitationsByCalendarID :many
+SELECT id, calendar_id, email, role, invited_by, created_at FROM calendar_invitations
+WHERE calendar_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) GetCalendarInvitationsByCalendarID(ctx context.Context, calendarID uuid.UUID) ([]CalendarInvitation, error) {
+	rows, err := q.db.QueryContext(ctx, getCalendarInvitationsByCalendarID, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CalendarInvitation
+	for rows.Next() {
+		var i CalendarInvitation
+		if err := rows.Scan(
+			&i.ID,
+			&i.CalendarID,
+			&i.Email,
+			&i.Role,
+			&i.InvitedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCalendarInvitationsByEmail = `-- name: GetCalendarInvitationsByEmail :many
+SELECT i.id, i.calendar_id, c.name AS calendar_name, i.email, i.role, i.invited_by, u.email AS invited_by_email, i.created_at
+FROM calendar_invitations i
+JOIN calendars c ON c.id = i.calendar_id
+JOIN users u ON u.id = i.invited_by
+WHERE i.email = $1
+ORDER BY i.created_at
+`
+
+type GetCalendarInvitationsByEmailRow struct {
+	ID             uuid.UUID `json:"id"`
+	CalendarID     uuid.UUID `json:"calendar_id"`
+	CalendarName   string    `json:"calendar_name"`
+	Email          string    `json:"email"`
+	Role           string    `json:"role"`
+	InvitedBy      uuid.UUID `json:"invited_by"`
+	InvitedByEmail string    `json:"invited_by_email"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (q *Queries) GetCalendarInvitationsByEmail(ctx context.Context, email string) ([]GetCalendarInvitationsByEmailRow, error) {
+	rows, err := q.db.QueryContext(ctx, getCalendarInvitationsByEmail, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCalendarInvitationsByEmailRow
+	for rows.Next() {
+		var i GetCalendarInvitationsByEmailRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CalendarID,
+			&i.CalendarName,
+			&i.Email,
+			&i.Role,
+			&i.InvitedBy,
+			&i.InvitedByEmail,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCalendarMember = `-- name: GetCalendarMember :one
+SELECT m.calendar_id, m.user_id, u.email, m.role, m.created_at, m.updated_at
+FROM calendar_members m
+JOIN users u ON u.id = m.user_id
+WHERE m.calendar_id = $1 AND m.user_id = $2
+`
+
+type GetCalendarMemberParams struct {
+	CalendarID uuid.UUID `json:"calendar_id"`
+	UserID     uuid.UUID `json:"user_id"`
+}
+
+type GetCalendarMemberRow struct {
+	CalendarID uuid.UUID `json:"calendar_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Email      string    `json:"email"`
+	Role       string    `json:"role"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (q *Queries) GetCalendarMember(ctx context.Context, arg GetCalendarMemberParams) (GetCalendarMemberRow, error) {
+	row := q.db.QueryRowContext(ctx, getCalendarMember, arg.CalendarID, arg.UserID)
+	var i GetCalendarMemberRow
+	err := row.Scan(
+		&i.CalendarID,
+		&i.UserID,
+		&i.Email,
+		&i.Role,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getCalendarMembers = `-- name: GetCalendarMembers :many
+SELECT m.calendar_id, m.user_id, u.email, m.role, m.created_at, m.updated_at
+FROM calendar_members m
+JOIN users u ON u.id = m.user_id
+WHERE m.calendar_id = $1
+ORDER BY m.role = 'owner' DESC, m.created_at
+`
+
+type GetCalendarMembersRow struct {
+	CalendarID uuid.UUID `json:"calendar_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Email      string    `json:"email"`
+	Role       string    `json:"role"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (q *Queries) GetCalendarMembers(ctx context.Context, calendarID uuid.UUID) ([]GetCalendarMembersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getCalendarMembers, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCalendarMembersRow
+	for rows.Next() {
+		var i GetCalendarMembersRow
+		if err := rows.Scan(
+			&i.CalendarID,
+			&i.UserID,
+			&i.Email,
+			&i.Role,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCalendarWithRole = `-- name: GetCalendarWithRole :one
+SELECT c.id, c.user_id, c.name, c.description, c.created_at, c.updated_at, m.role
+FROM calendars c
+LEFT JOIN calendar_members m ON m.calendar_id = c.id AND m.user_id = $1
+WHERE c.id = $2
+`
+
+type GetCalendarWithRoleParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	ID     uuid.UUID `json:"id"`
+}
+
+type GetCalendarWithRoleRow struct {
+	Calendar Calendar       `json:"calendar"`
+	Role     sql.NullString `json:"role"`
+}
+
+func (q *Queries) GetCalendarWithRole(ctx context.Context, arg GetCalendarWithRoleParams) (GetCalendarWithRoleRow, error) {
+	row := q.db.QueryRowContext(ctx, getCalendarWithRole, arg.UserID, arg.ID)
+	var i GetCalendarWithRoleRow
+	err := row.Scan(
+		&i.Calendar.ID,
+		&i.Calendar.UserID,
+		&i.Calendar.Name,
+		&i.Calendar.Description,
+		&i.Calendar.CreatedAt,
+		&i.Calendar.UpdatedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const listCalendarsByMemberID = `-- name: ListCalendarsByMemberID :many
+SELECT c.id, c.user_id, c.name, c.description, c.created_at, c.updated_at, m.role
+FROM calendar_members m
+JOIN calendars c ON c.id = m.calendar_id
+WHERE m.user_id = $1
+  AND ($2::timestamptz IS NULL OR (c.created_at, c.id) > ($2::timestamptz, $3::uuid))
+ORDER BY c.created_at, c.id
+LIMIT $4
+`
+
+type ListCalendarsByMemberIDParams struct {
+	UserID          uuid.UUID     `json:"user_id"`
+	CursorCreatedAt sql.NullTime  `json:"cursor_created_at"`
+	CursorID        uuid.NullUUID `json:"cursor_id"`
+	RowLimit        int32         `json:"row_limit"`
+}
+
+type ListCalendarsByMemberIDRow struct {
+	Calendar Calendar `json:"calendar"`
+	Role     string   `json:"role"`
+}
+
+// A page of the calendars a user is a member of in ascending (created_at, id)
+// order, from the calendar after the cursor when one is given.
+// ListCalendarsByMemberIDDesc is the same page the other way round.
+func (q *Queries) ListCalendarsByMemberID(ctx context.Context, arg ListCalendarsByMemberIDParams) ([]ListCalendarsByMemberIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCalendarsByMemberID,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCalendarsByMemberIDRow
+	for rows.Next() {
+		var i ListCalendarsByMemberIDRow
+		if err := rows.Scan(
+			&i.Calendar.ID,
+			&i.Calendar.UserID,
+			&i.Calendar.Name,
+			&i.Calendar.Description,
+			&i.Calendar.CreatedAt,
+			&i.Calendar.UpdatedAt,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCalendarsByMemberIDDesc = `-- name: ListCalendarsByMemberIDDesc :many
+SELECT c.id, c.user_id, c.name, c.description, c.created_at, c.updated_at, m.role
+FROM calendar_members m
+JOIN calendars c ON c.id = m.calendar_id
+WHERE m.user_id = $1
+  AND ($2::timestamptz IS NULL OR (c.created_at, c.id) < ($2::timestamptz, $3::uuid))
+ORDER BY c.created_at DESC, c.id DESC
+LIMIT $4
+`
+
+type ListCalendarsByMemberIDDescParams struct {
+	UserID          uuid.UUID     `json:"user_id"`
+	CursorCreatedAt sql.NullTime  `json:"cursor_created_at"`
+	CursorID        uuid.NullUUID `json:"cursor_id"`
+	RowLimit        int32         `json:"row_limit"`
+}
+
+type ListCalendarsByMemberIDDescRow struct {
+	Calendar Calendar `json:"calendar"`
+	Role     string   `json:"role"`
+}
+
+func (q *Queries) ListCalendarsByMemberIDDesc(ctx context.Context, arg ListCalendarsByMemberIDDescParams) ([]ListCalendarsByMemberIDDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCalendarsByMemberIDDesc,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCalendarsByMemberIDDescRow
+	for rows.Next() {
+		var i ListCalendarsByMemberIDDescRow
+		if err := rows.Scan(
+			&i.Calendar.ID,
+			&i.Calendar.UserID,
+			&i.Calendar.Name,
+			&i.Calendar.Description,
+			&i.Calendar.CreatedAt,
+			&i.Calendar.UpdatedAt,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateCalendarMemberRole = `-- name: UpdateCalendarMemberRole :one
+UPDATE calendar_members
+SET role = $3, updated_at = NOW()
+WHERE calendar_id = $1 AND user_id = $2 AND role <> 'owner'
+RETURNING calendar_id, user_id, role, created_at, updated_at
+`
+
+type UpdateCalendarMemberRoleParams struct {
+	CalendarID uuid.UUID `json:"calendar_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Role       string    `json:"role"`
+}
+
+func (q *Queries) UpdateCalendarMemberRole(ctx context.Context, arg UpdateCalendarMemberRoleParams) (CalendarMember, error) {
+	row := q.db.QueryRowContext(ctx, updateCalendarMemberRole, arg.CalendarID, arg.UserID, arg.Role)
+	var i CalendarMember
+	err := row.Scan(
+		&i.CalendarID,
+		&i.UserID,
+		&i.Role,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertCalendarInvitation = `-- name: UpsertCalendarInvitation :one
+INSERT INTO calendar_invitations (calendar_id, email, role, invited_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (calendar_id, email) DO UPDATE
+SET role = EXCLUDED.role, invited_by = EXCLUDED.invited_by, created_at = NOW()
+RETURNING id, calendar_id, email, role, invited_by, created_at
+`
+
+type UpsertCalendarInvitationParams struct {
+	CalendarID uuid.UUID `json:"calendar_id"`
+	Email      string    `json:"email"`
+	Role       string    `json:"role"`
+	InvitedBy  uuid.UUID `json:"invited_by"`
+}
+
+func (q *Queries) UpsertCalendarInvitation(ctx context.Context, arg UpsertCalendarInvitationParams) (CalendarInvitation, error) {
+	row := q.db.QueryRowContext(ctx, upsertCalendarInvitation,
+		arg.CalendarID,
+		arg.Email,
+		arg.Role,
+		arg.InvitedBy,
+	)
+	var i CalendarInvitation
+	err := row.Scan(
+		&i.ID,
+		&i.CalendarID,
+		&i.Email,
+		&i.Role,
+		&i.InvitedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
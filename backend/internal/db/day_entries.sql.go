@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createDayEntry = `-- name: CreateDayEntry :one
@@ -119,7 +120,8 @@ SELECT de.id, de.calendar_id, de.date, de.color_meaning_id, de.notes, de.created
 FROM day_entries de
 JOIN color_meanings cm ON de.color_meaning_id = cm.id
 JOIN calendars c ON de.calendar_id = c.id
-WHERE c.user_id = $1 
+JOIN calendar_members m ON m.calendar_id = c.id
+WHERE m.user_id = $1
   AND de.date >= $2 
   AND de.date <= $3
 ORDER BY de.date DESC, c.name
@@ -219,6 +221,217 @@ func (q *Queries) GetDayEntryByCalendarAndDate(ctx context.Context, arg GetDayEn
 	return i, err
 }
 
+const getDayEntryDatesByCalendarAndDateRange = `-- name: GetDayEntryDatesByCalendarAndDateRange :many
+SELECT date, color_meaning_id
+FROM day_entries
+WHERE calendar_id = $1
+  AND date >= $2
+  AND date <= $3
+ORDER BY date
+`
+
+type GetDayEntryDatesByCalendarAndDateRangeParams struct {
+	CalendarID uuid.UUID `json:"calendar_id"`
+	StartDate  time.Time `json:"start_date"`
+	EndDate    time.Time `json:"end_date"`
+}
+
+type GetDayEntryDatesByCalendarAndDateRangeRow struct {
+	Date           time.Time `json:"date"`
+	ColorMeaningID uuid.UUID `json:"color_meaning_id"`
+}
+
+func (q *Queries) GetDayEntryDatesByCalendarAndDateRange(ctx context.Context, arg GetDayEntryDatesByCalendarAndDateRangeParams) ([]GetDayEntryDatesByCalendarAndDateRangeRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDayEntryDatesByCalendarAndDateRange, arg.CalendarID, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDayEntryDatesByCalendarAndDateRangeRow
+	for rows.Next() {
+		var i GetDayEntryDatesByCalendarAndDateRangeRow
+		if err := rows.Scan(&i.Date, &i.ColorMeaningID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDayEntriesByCalendar = `-- name: ListDayEntriesByCalendar :many
+SELECT de.id, de.calendar_id, de.date, de.color_meaning_id, de.notes, de.created_at, de.updated_at, cm.color_hex, cm.meaning
+FROM day_entries de
+JOIN color_meanings cm ON de.color_meaning_id = cm.id
+WHERE de.calendar_id = $1
+  AND ($2::date IS NULL OR de.date >= $2::date)
+  AND ($3::date IS NULL OR de.date <= $3::date)
+  AND (COALESCE(cardinality($4::uuid[]), 0) = 0 OR de.color_meaning_id = ANY($4::uuid[]))
+  AND ($5::boolean IS NULL OR (COALESCE(de.notes, '') <> '') = $5::boolean)
+  AND ($6::text IS NULL OR de.notes ILIKE $6::text)
+  AND ($7::date IS NULL OR (de.date, de.id) > ($7::date, $8::uuid))
+ORDER BY de.date, de.id
+LIMIT $9
+`
+
+type ListDayEntriesByCalendarParams struct {
+	CalendarID      uuid.UUID      `json:"calendar_id"`
+	StartDate       sql.NullTime   `json:"start_date"`
+	EndDate         sql.NullTime   `json:"end_date"`
+	ColorMeaningIds []uuid.UUID    `json:"color_meaning_ids"`
+	HasNotes        sql.NullBool   `json:"has_notes"`
+	NotesPattern    sql.NullString `json:"notes_pattern"`
+	CursorDate      sql.NullTime   `json:"cursor_date"`
+	CursorID        uuid.NullUUID  `json:"cursor_id"`
+	RowLimit        int32          `json:"row_limit"`
+}
+
+type ListDayEntriesByCalendarRow struct {
+	ID             uuid.UUID      `json:"id"`
+	CalendarID     uuid.UUID      `json:"calendar_id"`
+	Date           time.Time      `json:"date"`
+	ColorMeaningID uuid.UUID      `json:"color_meaning_id"`
+	Notes          sql.NullString `json:"notes"`
+	CreatedAt      sql.NullTime   `json:"created_at"`
+	UpdatedAt      sql.NullTime   `json:"updated_at"`
+	ColorHex       string         `json:"color_hex"`
+	Meaning        string         `json:"meaning"`
+}
+
+// A page of a calendar's entries in ascending (date, id) order, from the
+// entry after the cursor when one is given. Filters left NULL or empty
+// match every entry. ListDayEntriesByCalendarDesc is the same page the
+// other way round; the two stay separate so each walks the index one way.
+func (q *Queries) ListDayEntriesByCalendar(ctx context.Context, arg ListDayEntriesByCalendarParams) ([]ListDayEntriesByCalendarRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDayEntriesByCalendar,
+		arg.CalendarID,
+		arg.StartDate,
+		arg.EndDate,
+		pq.Array(arg.ColorMeaningIds),
+		arg.HasNotes,
+		arg.NotesPattern,
+		arg.CursorDate,
+		arg.CursorID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDayEntriesByCalendarRow
+	for rows.Next() {
+		var i ListDayEntriesByCalendarRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CalendarID,
+			&i.Date,
+			&i.ColorMeaningID,
+			&i.Notes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ColorHex,
+			&i.Meaning,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDayEntriesByCalendarDesc = `-- name: ListDayEntriesByCalendarDesc :many
+SELECT de.id, de.calendar_id, de.date, de.color_meaning_id, de.notes, de.created_at, de.updated_at, cm.color_hex, cm.meaning
+FROM day_entries de
+JOIN color_meanings cm ON de.color_meaning_id = cm.id
+WHERE de.calendar_id = $1
+  AND ($2::date IS NULL OR de.date >= $2::date)
+  AND ($3::date IS NULL OR de.date <= $3::date)
+  AND (COALESCE(cardinality($4::uuid[]), 0) = 0 OR de.color_meaning_id = ANY($4::uuid[]))
+  AND ($5::boolean IS NULL OR (COALESCE(de.notes, '') <> '') = $5::boolean)
+  AND ($6::text IS NULL OR de.notes ILIKE $6::text)
+  AND ($7::date IS NULL OR (de.date, de.id) < ($7::date, $8::uuid))
+ORDER BY de.date DESC, de.id DESC
+LIMIT $9
+`
+
+type ListDayEntriesByCalendarDescParams struct {
+	CalendarID      uuid.UUID      `json:"calendar_id"`
+	StartDate       sql.NullTime   `json:"start_date"`
+	EndDate         sql.NullTime   `json:"end_date"`
+	ColorMeaningIds []uuid.UUID    `json:"color_meaning_ids"`
+	HasNotes        sql.NullBool   `json:"has_notes"`
+	NotesPattern    sql.NullString `json:"notes_pattern"`
+	CursorDate      sql.NullTime   `json:"cursor_date"`
+	CursorID        uuid.NullUUID  `json:"cursor_id"`
+	RowLimit        int32          `json:"row_limit"`
+}
+
+type ListDayEntriesByCalendarDescRow struct {
+	ID             uuid.UUID      `json:"id"`
+	CalendarID     uuid.UUID      `json:"calendar_id"`
+	Date           time.Time      `json:"date"`
+	ColorMeaningID uuid.UUID      `json:"color_meaning_id"`
+	Notes          sql.NullString `json:"notes"`
+	CreatedAt      sql.NullTime   `json:"created_at"`
+	UpdatedAt      sql.NullTime   `json:"updated_at"`
+	ColorHex       string         `json:"color_hex"`
+	Meaning        string         `json:"meaning"`
+}
+
+func (q *Queries) ListDayEntriesByCalendarDesc(ctx context.Context, arg ListDayEntriesByCalendarDescParams) ([]ListDayEntriesByCalendarDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDayEntriesByCalendarDesc,
+		arg.CalendarID,
+		arg.StartDate,
+		arg.EndDate,
+		pq.Array(arg.ColorMeaningIds),
+		arg.HasNotes,
+		arg.NotesPattern,
+		arg.CursorDate,
+		arg.CursorID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDayEntriesByCalendarDescRow
+	for rows.Next() {
+		var i ListDayEntriesByCalendarDescRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CalendarID,
+			&i.Date,
+			&i.ColorMeaningID,
+			&i.Notes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ColorHex,
+			&i.Meaning,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateDayEntry = `-- name: UpdateDayEntry :one
 UPDATE day_entries
 SET color_meaning_id = $2, notes = $3, updated_at = NOW()
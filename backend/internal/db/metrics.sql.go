@@ -0,0 +1,40 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: metrics.sql
+
+package db
+
+import (
+	"context"
+)
+
+const getUsageMetrics = `-- name: GetUsageMetrics :one
+SELECT
+    (SELECT COUNT(*) FROM users) AS users,
+    (SELECT COUNT(*) FROM calendars) AS calendars,
+    (SELECT COUNT(*) FROM day_entries) AS day_entries,
+    (SELECT COUNT(*) FROM day_entries WHERE day_entries.created_at >= NOW() - INTERVAL '1 day') AS day_entries_created_last_day,
+    (SELECT COUNT(*) FROM sessions WHERE sessions.revoked_at IS NULL AND sessions.expires_at > NOW()) AS active_sessions
+`
+
+type GetUsageMetricsRow struct {
+	Users                    int64 `json:"users"`
+	Calendars                int64 `json:"calendars"`
+	DayEntries               int64 `json:"day_entries"`
+	DayEntriesCreatedLastDay int64 `json:"day_entries_created_last_day"`
+	ActiveSessions           int64 `json:"active_sessions"`
+}
+
+func (q *Queries) GetUsageMetrics(ctx context.Context) (GetUsageMetricsRow, error) {
+	row := q.db.QueryRowContext(ctx, getUsageMetrics)
+	var i GetUsageMetricsRow
+	err := row.Scan(
+		&i.Users,
+		&i.Calendars,
+		&i.DayEntries,
+		&i.DayEntriesCreatedLastDay,
+		&i.ActiveSessions,
+	)
+	return i, err
+}
@@ -6,11 +6,48 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type AccountToken struct {
+	ID        uuid.UUID      `json:"id"`
+	UserID    uuid.UUID      `json:"user_id"`
+	Purpose   string         `json:"purpose"`
+	TokenHash string         `json:"token_hash"`
+	CreatedAt time.Time      `json:"created_at"`
+	ExpiresAt time.Time      `json:"expires_at"`
+	UsedAt    sql.NullTime   `json:"used_at"`
+	Email     sql.NullString `json:"email"`
+}
+
+type ApiKey struct {
+	ID         uuid.UUID    `json:"id"`
+	UserID     uuid.UUID    `json:"user_id"`
+	Name       string       `json:"name"`
+	KeyPrefix  string       `json:"key_prefix"`
+	KeyHash    string       `json:"key_hash"`
+	Scopes     []string     `json:"scopes"`
+	CreatedAt  time.Time    `json:"created_at"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+}
+
+type AuditEvent struct {
+	ID           uuid.UUID       `json:"id"`
+	UserID       uuid.UUID       `json:"user_id"`
+	Action       string          `json:"action"`
+	Metadata     json.RawMessage `json:"metadata"`
+	UserAgent    string          `json:"user_agent"`
+	IpAddress    string          `json:"ip_address"`
+	CreatedAt    time.Time       `json:"created_at"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   uuid.NullUUID   `json:"resource_id"`
+	Changes      json.RawMessage `json:"changes"`
+}
+
 type Calendar struct {
 	ID          uuid.UUID      `json:"id"`
 	UserID      uuid.UUID      `json:"user_id"`
@@ -20,6 +57,29 @@ type Calendar struct {
 	UpdatedAt   sql.NullTime   `json:"updated_at"`
 }
 
+type CalendarFeedToken struct {
+	CalendarID uuid.UUID `json:"calendar_id"`
+	TokenHash  string    `json:"token_hash"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CalendarInvitation struct {
+	ID         uuid.UUID `json:"id"`
+	CalendarID uuid.UUID `json:"calendar_id"`
+	Email      string    `json:"email"`
+	Role       string    `json:"role"`
+	InvitedBy  uuid.UUID `json:"invited_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CalendarMember struct {
+	CalendarID uuid.UUID `json:"calendar_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Role       string    `json:"role"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
 type ColorMeaning struct {
 	ID         uuid.UUID    `json:"id"`
 	CalendarID uuid.UUID    `json:"calendar_id"`
@@ -38,10 +98,63 @@ type DayEntry struct {
 	UpdatedAt      sql.NullTime   `json:"updated_at"`
 }
 
+type OidcLoginState struct {
+	ID           uuid.UUID `json:"id"`
+	StateHash    string    `json:"state_hash"`
+	CodeVerifier string    `json:"code_verifier"`
+	Nonce        string    `json:"nonce"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type RecoveryCode struct {
+	ID        uuid.UUID    `json:"id"`
+	UserID    uuid.UUID    `json:"user_id"`
+	CodeHash  string       `json:"code_hash"`
+	CreatedAt time.Time    `json:"created_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+}
+
+type Session struct {
+	ID               uuid.UUID    `json:"id"`
+	UserID           uuid.UUID    `json:"user_id"`
+	RefreshTokenHash string       `json:"refresh_token_hash"`
+	UserAgent        string       `json:"user_agent"`
+	IpAddress        string       `json:"ip_address"`
+	CreatedAt        sql.NullTime `json:"created_at"`
+	LastUsedAt       time.Time    `json:"last_used_at"`
+	ExpiresAt        time.Time    `json:"expires_at"`
+	RevokedAt        sql.NullTime `json:"revoked_at"`
+}
+
+type TwoFactorChallenge struct {
+	ID             uuid.UUID `json:"id"`
+	UserID         uuid.UUID `json:"user_id"`
+	TokenHash      string    `json:"token_hash"`
+	FailedAttempts int32     `json:"failed_attempts"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
 type User struct {
-	ID           uuid.UUID    `json:"id"`
-	Email        string       `json:"email"`
-	PasswordHash string       `json:"password_hash"`
-	CreatedAt    sql.NullTime `json:"created_at"`
-	UpdatedAt    sql.NullTime `json:"updated_at"`
+	ID               uuid.UUID      `json:"id"`
+	Email            string         `json:"email"`
+	PasswordHash     sql.NullString `json:"password_hash"`
+	CreatedAt        sql.NullTime   `json:"created_at"`
+	UpdatedAt        sql.NullTime   `json:"updated_at"`
+	EmailVerifiedAt  sql.NullTime   `json:"email_verified_at"`
+	TotpSecret       sql.NullString `json:"totp_secret"`
+	TotpEnabledAt    sql.NullTime   `json:"totp_enabled_at"`
+	TotpLastUsedStep sql.NullInt64  `json:"totp_last_used_step"`
+	DisabledAt       sql.NullTime   `json:"disabled_at"`
+}
+
+type UserIdentity struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Issuer      string    `json:"issuer"`
+	Subject     string    `json:"subject"`
+	Email       string    `json:"email"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
 }
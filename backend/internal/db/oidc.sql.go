@@ -0,0 +1,142 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: oidc.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const consumeOIDCLoginState = `-- name: ConsumeOIDCLoginState :one
+DELETE FROM oidc_login_states
+WHERE state_hash = $1
+  AND expires_at > NOW()
+RETURNING id, state_hash, code_verifier, nonce, created_at, expires_at
+`
+
+// Deletes the state in the same statement that finds it, so that a state
+// can complete only one login.
+func (q *Queries) ConsumeOIDCLoginState(ctx context.Context, stateHash string) (OidcLoginState, error) {
+	row := q.db.QueryRowContext(ctx, consumeOIDCLoginState, stateHash)
+	var i OidcLoginState
+	err := row.Scan(
+		&i.ID,
+		&i.StateHash,
+		&i.CodeVerifier,
+		&i.Nonce,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const createOIDCLoginState = `-- name: CreateOIDCLoginState :exec
+INSERT INTO oidc_login_states (state_hash, code_verifier, nonce, expires_at)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateOIDCLoginStateParams struct {
+	StateHash    string    `json:"state_hash"`
+	CodeVerifier string    `json:"code_verifier"`
+	Nonce        string    `json:"nonce"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateOIDCLoginState(ctx context.Context, arg CreateOIDCLoginStateParams) error {
+	_, err := q.db.ExecContext(ctx, createOIDCLoginState,
+		arg.StateHash,
+		arg.CodeVerifier,
+		arg.Nonce,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const createUserIdentity = `-- name: CreateUserIdentity :one
+INSERT INTO user_identities (user_id, issuer, subject, email)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, issuer, subject, email, created_at, last_login_at
+`
+
+type CreateUserIdentityParams struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Issuer  string    `json:"issuer"`
+	Subject string    `json:"subject"`
+	Email   string    `json:"email"`
+}
+
+func (q *Queries) CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error) {
+	row := q.db.QueryRowContext(ctx, createUserIdentity,
+		arg.UserID,
+		arg.Issuer,
+		arg.Subject,
+		arg.Email,
+	)
+	var i UserIdentity
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Issuer,
+		&i.Subject,
+		&i.Email,
+		&i.CreatedAt,
+		&i.LastLoginAt,
+	)
+	return i, err
+}
+
+const deleteExpiredOIDCLoginStates = `-- name: DeleteExpiredOIDCLoginStates :exec
+DELETE FROM oidc_login_states
+WHERE expires_at <= NOW()
+`
+
+func (q *Queries) DeleteExpiredOIDCLoginStates(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredOIDCLoginStates)
+	return err
+}
+
+const getUserIdentity = `-- name: GetUserIdentity :one
+SELECT id, user_id, issuer, subject, email, created_at, last_login_at FROM user_identities
+WHERE issuer = $1 AND subject = $2
+`
+
+type GetUserIdentityParams struct {
+	Issuer  string `json:"issuer"`
+	Subject string `json:"subject"`
+}
+
+func (q *Queries) GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (UserIdentity, error) {
+	row := q.db.QueryRowContext(ctx, getUserIdentity, arg.Issuer, arg.Subject)
+	var i UserIdentity
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Issuer,
+		&i.Subject,
+		&i.Email,
+		&i.CreatedAt,
+		&i.LastLoginAt,
+	)
+	return i, err
+}
+
+const recordUserIdentityLogin = `-- name: RecordUserIdentityLogin :exec
+UPDATE user_identities
+SET email = $2, last_login_at = NOW()
+WHERE id = $1
+`
+
+type RecordUserIdentityLoginParams struct {
+	ID    uuid.UUID `json:"id"`
+	Email string    `json:"email"`
+}
+
+func (q *Queries) RecordUserIdentityLogin(ctx context.Context, arg RecordUserIdentityLoginParams) error {
+	_, err := q.db.ExecContext(ctx, recordUserIdentityLogin, arg.ID, arg.Email)
+	return err
+}
@@ -0,0 +1,216 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: two_factor.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const countUnusedRecoveryCodes = `-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*) FROM recovery_codes
+WHERE user_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) CountUnusedRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUnusedRecoveryCodes, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (user_id, code_hash)
+VALUES ($1, $2)
+`
+
+type CreateRecoveryCodeParams struct {
+	UserID   uuid.UUID `json:"user_id"`
+	CodeHash string    `json:"code_hash"`
+}
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error {
+	_, err := q.db.ExecContext(ctx, createRecoveryCode, arg.UserID, arg.CodeHash)
+	return err
+}
+
+const createTwoFactorChallenge = `-- name: CreateTwoFactorChallenge :one
+INSERT INTO two_factor_challenges (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING id, user_id, token_hash, failed_attempts, created_at, expires_at
+`
+
+type CreateTwoFactorChallengeParams struct {
+	UserID    uuid.UUID `json:"user_id"`
+	TokenHash string    `json:"token_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateTwoFactorChallenge(ctx context.Context, arg CreateTwoFactorChallengeParams) (TwoFactorChallenge, error) {
+	row := q.db.QueryRowContext(ctx, createTwoFactorChallenge, arg.UserID, arg.TokenHash, arg.ExpiresAt)
+	var i TwoFactorChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.FailedAttempts,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const deleteRecoveryCodes = `-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteRecoveryCodes, userID)
+	return err
+}
+
+const deleteTwoFactorChallenge = `-- name: DeleteTwoFactorChallenge :execrows
+DELETE FROM two_factor_challenges
+WHERE id = $1
+`
+
+// Deleting is what redeems a challenge: of two racing requests, only the one
+// that deletes the row may start a session.
+func (q *Queries) DeleteTwoFactorChallenge(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteTwoFactorChallenge, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const disableUserTOTP = `-- name: DisableUserTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_used_step = NULL, updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) DisableUserTOTP(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, disableUserTOTP, id)
+	return err
+}
+
+const enableUserTOTP = `-- name: EnableUserTOTP :execrows
+UPDATE users
+SET totp_enabled_at = NOW(), totp_last_used_step = $2, updated_at = NOW()
+WHERE id = $1 AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL
+`
+
+type EnableUserTOTPParams struct {
+	ID               uuid.UUID     `json:"id"`
+	TotpLastUsedStep sql.NullInt64 `json:"totp_last_used_step"`
+}
+
+func (q *Queries) EnableUserTOTP(ctx context.Context, arg EnableUserTOTPParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, enableUserTOTP, arg.ID, arg.TotpLastUsedStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getTwoFactorChallenge = `-- name: GetTwoFactorChallenge :one
+SELECT id, user_id, token_hash, failed_attempts, created_at, expires_at FROM two_factor_challenges
+WHERE token_hash = $1
+  AND expires_at > NOW()
+  AND failed_attempts < $2::int
+`
+
+type GetTwoFactorChallengeParams struct {
+	TokenHash   string `json:"token_hash"`
+	MaxAttempts int32  `json:"max_attempts"`
+}
+
+func (q *Queries) GetTwoFactorChallenge(ctx context.Context, arg GetTwoFactorChallengeParams) (TwoFactorChallenge, error) {
+	row := q.db.QueryRowContext(ctx, getTwoFactorChallenge, arg.TokenHash, arg.MaxAttempts)
+	var i TwoFactorChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.FailedAttempts,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const recordTwoFactorChallengeFailure = `-- name: RecordTwoFactorChallengeFailure :exec
+UPDATE two_factor_challenges
+SET failed_attempts = failed_attempts + 1
+WHERE id = $1
+`
+
+func (q *Queries) RecordTwoFactorChallengeFailure(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, recordTwoFactorChallengeFailure, id)
+	return err
+}
+
+const setUserTOTPSecret = `-- name: SetUserTOTPSecret :exec
+UPDATE users
+SET totp_secret = $2, totp_last_used_step = NULL, updated_at = NOW()
+WHERE id = $1 AND totp_enabled_at IS NULL
+`
+
+type SetUserTOTPSecretParams struct {
+	ID         uuid.UUID      `json:"id"`
+	TotpSecret sql.NullString `json:"totp_secret"`
+}
+
+// Starts or restarts enrollment; accounts with 2FA on keep their secret.
+func (q *Queries) SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) error {
+	_, err := q.db.ExecContext(ctx, setUserTOTPSecret, arg.ID, arg.TotpSecret)
+	return err
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+`
+
+type UseRecoveryCodeParams struct {
+	UserID   uuid.UUID `json:"user_id"`
+	CodeHash string    `json:"code_hash"`
+}
+
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useRecoveryCode, arg.UserID, arg.CodeHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const useTOTPStep = `-- name: UseTOTPStep :execrows
+UPDATE users
+SET totp_last_used_step = $2
+WHERE id = $1 AND (totp_last_used_step IS NULL OR totp_last_used_step < $2)
+`
+
+type UseTOTPStepParams struct {
+	ID               uuid.UUID     `json:"id"`
+	TotpLastUsedStep sql.NullInt64 `json:"totp_last_used_step"`
+}
+
+// Records an accepted time step only if it is later than the last one, so
+// that a code, even one seen over a shoulder, works once.
+func (q *Queries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useTOTPStep, arg.ID, arg.TotpLastUsedStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
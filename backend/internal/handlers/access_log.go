@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"days/internal/logging"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID that ties a request to its log lines
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the request IDs accepted from clients
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware gives each request an ID: the X-Request-ID it came
+// with, as set by a proxy in front of the server, or a new one. The ID is
+// echoed in the response and carried by every log line of the request.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), requestID)))
+	})
+}
+
+// validRequestID accepts IDs that are safe to echo and to log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':', c == '/', c == '+', c == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// AccessLogMiddleware logs a line per request with its method, route,
+// status, latency, response size and, once authenticated, user ID. Place it
+// inside RequestIDMiddleware so that the line carries the request ID.
+func AccessLogMiddleware(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		// Handlers abort broken responses by panicking; the line is logged
+		// for them too before the panic goes on to the server
+		aborted := true
+		defer func() {
+			level := slog.LevelInfo
+			if aborted || recorder.status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			// The mux fills in the pattern that matched, which groups requests
+			// to the same endpoint where the path would not
+			route := r.Pattern
+			if route == "" {
+				route = "unmatched"
+			}
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("route", route),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", recorder.status),
+				slog.Duration("latency", time.Since(start)),
+				slog.Int64("bytes", recorder.bytes),
+				slog.String("ip", clientIP(r)),
+				slog.String("user_agent", r.UserAgent()),
+			}
+			if aborted {
+				attrs = append(attrs, slog.Bool("aborted", true))
+			}
+			logger.LogAttrs(r.Context(), level, "request", attrs...)
+		}()
+
+		next.ServeHTTP(recorder, r)
+		aborted = false
+	})
+}
+
+// statusRecorder remembers the status and size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
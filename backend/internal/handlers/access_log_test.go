@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"days/internal/auth"
+	"days/internal/logging"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{"kept from the client", "abc-123", "abc-123"},
+		{"generated when missing", "", ""},
+		{"replaced when unsafe", "abc\r\nX-Injected: 1", ""},
+		{"replaced when too long", strings.Repeat("a", maxRequestIDLength+1), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = logging.RequestID(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/health", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if tt.expected != "" {
+				assert.Equal(t, tt.expected, seen)
+			} else {
+				_, err := uuid.Parse(seen)
+				assert.NoError(t, err, "expected a generated ID, got %q", seen)
+			}
+			assert.Equal(t, seen, w.Header().Get(RequestIDHeader))
+		})
+	}
+}
+
+// newTestLogger returns a JSON logger and a func that reads its last line
+func newTestLogger(t *testing.T) (*slog.Logger, func() map[string]any) {
+	t.Helper()
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, &logging.Config{Level: "info", Format: "json"})
+	require.NoError(t, err)
+	return logger, func() map[string]any {
+		t.Helper()
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+		buf.Reset()
+		return line
+	}
+}
+
+func TestAccessLogMiddleware(t *testing.T) {
+	logger, readLine := newTestLogger(t)
+
+	secret := "access-log-secret"
+	userID := uuid.New()
+	token, err := auth.GenerateToken(userID, secret, time.Hour)
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/things/{id}", AuthMiddleware(secretKeyring(t, secret), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	}))
+	handler := RequestIDMiddleware(AccessLogMiddleware(logger, mux))
+
+	t.Run("authenticated request", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/things/42", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set(RequestIDHeader, "req-42")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		line := readLine()
+		assert.Equal(t, "INFO", line["level"])
+		assert.Equal(t, "request", line["msg"])
+		assert.Equal(t, "GET", line["method"])
+		assert.Equal(t, "GET /api/things/{id}", line["route"])
+		assert.Equal(t, "/api/things/42", line["path"])
+		assert.EqualValues(t, http.StatusCreated, line["status"])
+		assert.EqualValues(t, 5, line["bytes"])
+		assert.Contains(t, line, "latency")
+		assert.Equal(t, "req-42", line["request_id"])
+		assert.Equal(t, userID.String(), line["user_id"])
+	})
+
+	t.Run("unauthenticated request", func(t *testing.T) {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/things/42", nil))
+
+		line := readLine()
+		assert.EqualValues(t, http.StatusUnauthorized, line["status"])
+		assert.NotContains(t, line, "user_id")
+	})
+
+	t.Run("unmatched route", func(t *testing.T) {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+
+		line := readLine()
+		assert.Equal(t, "unmatched", line["route"])
+		assert.EqualValues(t, http.StatusNotFound, line["status"])
+	})
+}
+
+func TestAccessLogMiddleware_ServerErrors(t *testing.T) {
+	logger, readLine := newTestLogger(t)
+
+	t.Run("error response", func(t *testing.T) {
+		handler := AccessLogMiddleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeJSONError(w, http.StatusServiceUnavailable, "unavailable")
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		line := readLine()
+		assert.Equal(t, "ERROR", line["level"])
+		assert.EqualValues(t, http.StatusServiceUnavailable, line["status"])
+	})
+
+	t.Run("aborted response", func(t *testing.T) {
+		handler := AccessLogMiddleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("partial"))
+			panic(http.ErrAbortHandler)
+		}))
+
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+
+		line := readLine()
+		assert.Equal(t, "ERROR", line["level"])
+		assert.Equal(t, true, line["aborted"])
+		assert.EqualValues(t, 7, line["bytes"])
+	})
+}
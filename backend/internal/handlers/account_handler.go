@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"days/internal/audit"
+	"days/internal/services"
+
+	"github.com/google/uuid"
+)
+
+type AccountHandler struct {
+	accountService services.AccountServiceInterface
+}
+
+func NewAccountHandler(accountService services.AccountServiceInterface) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+	}
+}
+
+// ForgotPassword handles POST /api/auth/forgot-password
+//
+//	@Summary		Request a password reset
+//	@Description	Email a single-use link for choosing a new password, valid for an hour. The response is the same whether or not the address has an account.
+//	@Tags			auth
+//	@Accept			json
+//	@Param			request	body	services.ForgotPasswordRequest	true	"Account email"
+//	@Success		202		"Accepted"
+//	@Failure		400		{object}	ErrorResponse
+//	@Router			/api/auth/forgot-password [post]
+func (h *AccountHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req services.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	// Failures are only logged: answering differently would tell callers
+	// which addresses have accounts
+	if err := h.accountService.ForgotPassword(r.Context(), req); err != nil {
+		slog.ErrorContext(r.Context(), "password reset request failed", "error", err)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ResetPassword handles POST /api/auth/reset-password
+//
+//	@Summary		Reset password
+//	@Description	Choose a new password with the token from a password reset email. The token works once, and every session of the account is signed out.
+//	@Tags			auth
+//	@Accept			json
+//	@Param			request	body	services.ResetPasswordRequest	true	"Reset token and new password"
+//	@Success		204		"No Content"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/api/auth/reset-password [post]
+func (h *AccountHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req services.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	// The request is not authenticated, so nothing else notes the client
+	// for the audit log
+	ctx := audit.WithClient(r.Context(), r.UserAgent(), clientIP(r))
+	if err := h.accountService.ResetPassword(ctx, req); err != nil {
+		writeAccountError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifyEmail handles POST /api/auth/verify-email
+//
+//	@Summary		Verify email address
+//	@Description	Confirm the account's email address with the token from a verification email
+//	@Tags			auth
+//	@Accept			json
+//	@Param			request	body	services.VerifyEmailRequest	true	"Verification token"
+//	@Success		204		"No Content"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/api/auth/verify-email [post]
+func (h *AccountHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req services.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	if err := h.accountService.VerifyEmail(r.Context(), req); err != nil {
+		writeAccountError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResendVerificationEmail handles POST /api/auth/verify-email/resend
+//
+//	@Summary		Resend verification email
+//	@Description	Email the authenticated user a new verification link. Earlier links stop working.
+//	@Tags			auth
+//	@Success		202	"Accepted"
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		409	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/auth/verify-email/resend [post]
+func (h *AccountHandler) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.accountService.SendVerificationEmail(r.Context(), userID); err != nil {
+		writeAccountError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// writeAccountError maps account service errors to HTTP responses
+func writeAccountError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAccountToken), errors.Is(err, services.ErrWeakPassword):
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrUserNotFound):
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+	case errors.Is(err, services.ErrEmailAlreadyVerified):
+		writeJSONError(w, http.StatusConflict, err.Error())
+	default:
+		writeInternalError(w, r, err)
+	}
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAccountService implements a mock for the AccountService
+type MockAccountService struct {
+	mock.Mock
+}
+
+func (m *MockAccountService) SendVerificationEmail(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockAccountService) ForgotPassword(ctx context.Context, req services.ForgotPasswordRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockAccountService) ResetPassword(ctx context.Context, req services.ResetPasswordRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockAccountService) VerifyEmail(ctx context.Context, req services.VerifyEmailRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func TestAccountHandler_ForgotPassword(t *testing.T) {
+	req := services.ForgotPasswordRequest{Email: "ann@example.com"}
+
+	// Every outcome looks the same to the caller
+	for _, serviceErr := range []error{nil, errors.New("mail server down")} {
+		mockService := new(MockAccountService)
+		handler := NewAccountHandler(mockService)
+		mockService.On("ForgotPassword", mock.Anything, req).Return(serviceErr).Once()
+
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		handler.ForgotPassword(w, httptest.NewRequest(http.MethodPost, "/api/auth/forgot-password", bytes.NewReader(body)))
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Empty(t, w.Body.String())
+		mockService.AssertExpectations(t)
+	}
+}
+
+func TestAccountHandler_ResetPassword(t *testing.T) {
+	req := services.ResetPasswordRequest{Token: "token", Password: "newpassword123"}
+
+	tests := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectedStatus int
+	}{
+		{"reset", "", nil, http.StatusNoContent},
+		{"invalid token", "", services.ErrInvalidAccountToken, http.StatusBadRequest},
+		{"weak password", "", services.ErrWeakPassword, http.StatusBadRequest},
+		{"database error", "", errors.New("connection refused"), http.StatusInternalServerError},
+		{"invalid JSON", "{", nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAccountService)
+			handler := NewAccountHandler(mockService)
+			mockService.On("ResetPassword", mock.Anything, req).Return(tt.serviceErr).Maybe()
+
+			body := tt.body
+			if body == "" {
+				data, _ := json.Marshal(req)
+				body = string(data)
+			}
+			w := httptest.NewRecorder()
+			handler.ResetPassword(w, httptest.NewRequest(http.MethodPost, "/api/auth/reset-password", bytes.NewBufferString(body)))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestAccountHandler_VerifyEmail(t *testing.T) {
+	mockService := new(MockAccountService)
+	handler := NewAccountHandler(mockService)
+	mockService.On("VerifyEmail", mock.Anything, services.VerifyEmailRequest{Token: "good"}).Return(nil).Once()
+	mockService.On("VerifyEmail", mock.Anything, services.VerifyEmailRequest{Token: "used"}).Return(services.ErrInvalidAccountToken).Once()
+
+	w := httptest.NewRecorder()
+	handler.VerifyEmail(w, httptest.NewRequest(http.MethodPost, "/api/auth/verify-email", bytes.NewBufferString(`{"token":"good"}`)))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.VerifyEmail(w, httptest.NewRequest(http.MethodPost, "/api/auth/verify-email", bytes.NewBufferString(`{"token":"used"}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestAccountHandler_ResendVerificationEmail(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name           string
+		serviceErr     error
+		expectedStatus int
+	}{
+		{"sent", nil, http.StatusAccepted},
+		{"already verified", services.ErrEmailAlreadyVerified, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAccountService)
+			handler := NewAccountHandler(mockService)
+			mockService.On("SendVerificationEmail", mock.Anything, userID).Return(tt.serviceErr).Once()
+
+			w := httptest.NewRecorder()
+			handler.ResendVerificationEmail(w, withUserID(httptest.NewRequest(http.MethodPost, "/api/auth/verify-email/resend", nil), userID))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		handler := NewAccountHandler(new(MockAccountService))
+		w := httptest.NewRecorder()
+		handler.ResendVerificationEmail(w, httptest.NewRequest(http.MethodPost, "/api/auth/verify-email/resend", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+)
+
+type APIKeyHandler struct {
+	apiKeyService services.APIKeyServiceInterface
+}
+
+func NewAPIKeyHandler(apiKeyService services.APIKeyServiceInterface) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// CreateAPIKey handles POST /api/auth/api-keys
+//
+//	@Summary		Create API key
+//	@Description	Issue a personal API key for scripts and integrations, sent as a Bearer token like an access token. The key is limited to its scopes (calendars:read, calendars:write, entries:read, entries:write; write includes read) and is shown only once.
+//	@Tags			api-keys
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		services.CreateAPIKeyRequest	true	"Name, scopes and optional expiry"
+//	@Success		201		{object}	services.CreatedAPIKeyResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/auth/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req services.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	apiKey, err := h.apiKeyService.CreateAPIKey(r.Context(), userID, req)
+	if err != nil {
+		writeAPIKeyError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(apiKey)
+}
+
+// GetAPIKeys handles GET /api/auth/api-keys
+//
+//	@Summary		List API keys
+//	@Description	List the authenticated user's API keys, newest first. Keys are identified by their first characters; the keys themselves are never shown again.
+//	@Tags			api-keys
+//	@Produce		json
+//	@Success		200	{array}		services.APIKeyResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/auth/api-keys [get]
+func (h *APIKeyHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	apiKeys, err := h.apiKeyService.GetAPIKeys(r.Context(), userID)
+	if err != nil {
+		writeAPIKeyError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(apiKeys)
+}
+
+// RevokeAPIKey handles DELETE /api/auth/api-keys/{id}
+//
+//	@Summary		Revoke API key
+//	@Description	Delete one of the authenticated user's API keys; requests made with it are rejected from then on
+//	@Tags			api-keys
+//	@Param			id	path	string	true	"API key ID"
+//	@Success		204	"No Content"
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/auth/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// Extract API key ID from URL path
+	keyID, err := uuid.Parse(extractIDFromPath(r.URL.Path, "/api/auth/api-keys/"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid API key ID")
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(r.Context(), userID, keyID); err != nil {
+		writeAPIKeyError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeAPIKeyError maps API key service errors to HTTP responses
+func writeAPIKeyError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAPIKeyName),
+		errors.Is(err, services.ErrInvalidAPIKeyScope),
+		errors.Is(err, services.ErrInvalidAPIKeyExpiry):
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrAPIKeyNotFound):
+		writeJSONError(w, http.StatusNotFound, err.Error())
+	default:
+		writeInternalError(w, r, err)
+	}
+}
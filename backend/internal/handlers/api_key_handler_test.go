@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAPIKeyService implements a mock for the APIKeyService
+type MockAPIKeyService struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyService) CreateAPIKey(ctx context.Context, userID uuid.UUID, req services.CreateAPIKeyRequest) (*services.CreatedAPIKeyResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.CreatedAPIKeyResponse), args.Error(1)
+}
+
+func (m *MockAPIKeyService) GetAPIKeys(ctx context.Context, userID uuid.UUID) ([]*services.APIKeyResponse, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*services.APIKeyResponse), args.Error(1)
+}
+
+func (m *MockAPIKeyService) RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) error {
+	args := m.Called(ctx, userID, keyID)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyService) AuthenticateAPIKey(ctx context.Context, key string) (*services.APIKeyPrincipal, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.APIKeyPrincipal), args.Error(1)
+}
+
+func TestAPIKeyHandler_CreateAPIKey(t *testing.T) {
+	userID := uuid.New()
+	req := services.CreateAPIKeyRequest{Name: "Home Assistant", Scopes: []string{services.ScopeEntriesWrite}}
+
+	tests := []struct {
+		name           string
+		result         *services.CreatedAPIKeyResponse
+		serviceErr     error
+		expectedStatus int
+	}{
+		{"created", &services.CreatedAPIKeyResponse{
+			APIKeyResponse: services.APIKeyResponse{ID: uuid.New(), Name: "Home Assistant", Scopes: []string{services.ScopeEntriesWrite}},
+			Key:            "days_3q2-7wAAAAA",
+		}, nil, http.StatusCreated},
+		{"unknown scope", nil, services.ErrInvalidAPIKeyScope, http.StatusBadRequest},
+		{"past expiry", nil, services.ErrInvalidAPIKeyExpiry, http.StatusBadRequest},
+		{"database error", nil, errors.New("connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAPIKeyService)
+			handler := NewAPIKeyHandler(mockService)
+			mockService.On("CreateAPIKey", mock.Anything, userID, req).Return(tt.result, tt.serviceErr).Once()
+
+			body, _ := json.Marshal(req)
+			w := httptest.NewRecorder()
+			handler.CreateAPIKey(w, withUserID(httptest.NewRequest(http.MethodPost, "/api/auth/api-keys", bytes.NewReader(body)), userID))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.result != nil {
+				var response services.CreatedAPIKeyResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, "days_3q2-7wAAAAA", response.Key)
+				assert.Equal(t, "Home Assistant", response.Name)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAPIKeyHandler_RevokeAPIKey(t *testing.T) {
+	userID := uuid.New()
+	keyID := uuid.New()
+
+	tests := []struct {
+		name           string
+		path           string
+		serviceErr     error
+		expectedStatus int
+	}{
+		{"revoked", "/api/auth/api-keys/" + keyID.String(), nil, http.StatusNoContent},
+		{"not found", "/api/auth/api-keys/" + keyID.String(), services.ErrAPIKeyNotFound, http.StatusNotFound},
+		{"invalid ID", "/api/auth/api-keys/not-a-uuid", nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAPIKeyService)
+			handler := NewAPIKeyHandler(mockService)
+			if tt.expectedStatus != http.StatusBadRequest {
+				mockService.On("RevokeAPIKey", mock.Anything, userID, keyID).Return(tt.serviceErr).Once()
+			}
+
+			w := httptest.NewRecorder()
+			handler.RevokeAPIKey(w, withUserID(httptest.NewRequest(http.MethodDelete, tt.path, nil), userID))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_APIKeyRoutes(t *testing.T) {
+	userID := uuid.New()
+	calendarID := uuid.New()
+	key := services.APIKeyPrefix + "3q2-7wAAAAA"
+
+	apiKeyService := new(MockAPIKeyService)
+	apiKeyService.On("AuthenticateAPIKey", mock.Anything, key).
+		Return(&services.APIKeyPrincipal{KeyID: uuid.New(), UserID: userID, Scopes: []string{services.ScopeEntriesRead}}, nil)
+	dayEntryService := new(MockDayEntryService)
+	server := &Server{
+		apiKeyHandler:   NewAPIKeyHandler(apiKeyService),
+		dayEntryHandler: NewDayEntryHandler(dayEntryService),
+		userHandler:     NewUserHandler(new(MockUserService)),
+		apiKeys:         apiKeyService,
+		tokens:          secretKeyring(t, "test-secret-for-api-keys"),
+	}
+	mux := server.SetupRoutes()
+	request := func(method, path string) *http.Request {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(`{}`))
+		req.Header.Set("Authorization", "Bearer "+key)
+		return req
+	}
+
+	// The key's scope allows reading entries
+	dayEntryService.On("GetAllDayEntriesByCalendarID", mock.Anything, userID, calendarID, services.DayEntryListRequest{}).
+		Return([]*services.DayEntryResponse{}, nil).Once()
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, request(http.MethodGet, "/api/calendars/"+calendarID.String()+"/entries"))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// but not writing them, nor reading calendars
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, request(http.MethodPost, "/api/calendars/"+calendarID.String()+"/entries"))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t, `{"error":"API key lacks the entries:write scope"}`, w.Body.String())
+
+	// Account routes only accept access tokens
+	for _, path := range []string{"/api/auth/api-keys", "/api/users/me/password"} {
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, request(http.MethodPost, path))
+		assert.Equal(t, http.StatusUnauthorized, w.Code, path)
+	}
+
+	dayEntryService.AssertExpectations(t)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+)
+
+type AuditHandler struct {
+	auditService services.AuditServiceInterface
+}
+
+func NewAuditHandler(auditService services.AuditServiceInterface) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// GetAuditEvents handles GET /api/users/me/audit
+//
+//	@Summary		List audit events
+//	@Description	List the security and data-changing events recorded for the authenticated user, newest first. Pass the next_cursor of a page as cursor to get the one after it. Changes to calendars and their contents carry the fields that changed, with their values before and after.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			cursor	query		string	false	"next_cursor of the previous page"
+//	@Param			limit	query		int		false	"Events per page, at most 200"	default(50)
+//	@Success		200		{object}	services.AuditEventPage
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/users/me/audit [get]
+func (h *AuditHandler) GetAuditEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.auditService.GetAuditEvents(r.Context(), userID, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCursor):
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+		default:
+			writeInternalError(w, r, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
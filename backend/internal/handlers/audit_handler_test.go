@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAuditService implements a mock for the AuditService
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) GetAuditEvents(ctx context.Context, userID uuid.UUID, cursor string, limit int) (*services.AuditEventPage, error) {
+	args := m.Called(ctx, userID, cursor, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.AuditEventPage), args.Error(1)
+}
+
+func TestAuditHandler_GetAuditEvents(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func(*MockAuditService)
+		expectedStatus int
+	}{
+		{
+			name:  "first page",
+			query: "",
+			setupMock: func(m *MockAuditService) {
+				m.On("GetAuditEvents", mock.Anything, userID, "", 0).Return(&services.AuditEventPage{
+					Events:     []*services.AuditEventResponse{{ID: uuid.New(), Action: services.AuditActionCalendarDeleted}},
+					NextCursor: "next",
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "later page",
+			query: "?cursor=next&limit=10",
+			setupMock: func(m *MockAuditService) {
+				m.On("GetAuditEvents", mock.Anything, userID, "next", 10).
+					Return(&services.AuditEventPage{Events: []*services.AuditEventResponse{}}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid limit",
+			query:          "?limit=0",
+			setupMock:      func(m *MockAuditService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "invalid cursor",
+			query: "?cursor=garbage",
+			setupMock: func(m *MockAuditService) {
+				m.On("GetAuditEvents", mock.Anything, userID, "garbage", 0).Return(nil, services.ErrInvalidCursor).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "service error",
+			query: "",
+			setupMock: func(m *MockAuditService) {
+				m.On("GetAuditEvents", mock.Anything, userID, "", 0).Return(nil, errors.New("database down")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAuditService)
+			tt.setupMock(mockService)
+			handler := NewAuditHandler(mockService)
+
+			req := withUserID(httptest.NewRequest(http.MethodGet, "/api/users/me/audit"+tt.query, nil), userID)
+			w := httptest.NewRecorder()
+
+			handler.GetAuditEvents(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+
+	t.Run("response body", func(t *testing.T) {
+		mockService := new(MockAuditService)
+		mockService.On("GetAuditEvents", mock.Anything, userID, "", 0).Return(&services.AuditEventPage{
+			Events:     []*services.AuditEventResponse{{Action: services.AuditActionCalendarDeleted}},
+			NextCursor: "next",
+		}, nil).Once()
+
+		req := withUserID(httptest.NewRequest(http.MethodGet, "/api/users/me/audit", nil), userID)
+		w := httptest.NewRecorder()
+		NewAuditHandler(mockService).GetAuditEvents(w, req)
+
+		var page services.AuditEventPage
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&page))
+		assert.Equal(t, "next", page.NextCursor)
+		require.Len(t, page.Events, 1)
+		assert.Equal(t, services.AuditActionCalendarDeleted, page.Events[0].Action)
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/users/me/audit", nil)
+		w := httptest.NewRecorder()
+		NewAuditHandler(new(MockAuditService)).GetAuditEvents(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -47,6 +47,9 @@ func (h *CalendarHandler) CreateCalendar(w http.ResponseWriter, r *http.Request)
 		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
+	if !requireScope(w, r, services.ScopeCalendarsWrite) {
+		return
+	}
 
 	var req services.CreateCalendarRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -62,7 +65,7 @@ func (h *CalendarHandler) CreateCalendar(w http.ResponseWriter, r *http.Request)
 		case services.ErrCalendarNameExists:
 			writeJSONError(w, http.StatusConflict, err.Error())
 		default:
-			writeJSONError(w, http.StatusInternalServerError, "internal server error")
+			writeInternalError(w, r, err)
 		}
 		return
 	}
@@ -75,13 +78,19 @@ func (h *CalendarHandler) CreateCalendar(w http.ResponseWriter, r *http.Request)
 // GetCalendars handles GET /api/calendars
 //
 //	@Summary		Get user calendars
-//	@Description	Retrieve all calendars for the authenticated user
+//	@Description	Retrieve the calendars the authenticated user owns or is a member of, each with the caller's role, oldest first unless order is desc. Without limit or cursor the response is an array of every calendar. With either, it is a page object ({"calendars": [...], "next_cursor": "..."}); pass the next_cursor of a page as cursor to get the one after it, and the Link header of a page that has one points at the next page.
 //	@Tags			calendars
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{array}		services.CalendarResponse
-//	@Failure		401	{object}	ErrorResponse
-//	@Failure		500	{object}	ErrorResponse
+//	@Param			cursor	query		string						false	"next_cursor of the previous page"
+//	@Param			limit	query		int							false	"Calendars per page, at most 200"	default(50)
+//	@Param			order	query		string						false	"Sort order by creation time"		Enums(asc, desc)	default(asc)
+//	@Success		200		{object}	services.CalendarPage		"A page of calendars, with limit or cursor"
+//	@Success		200		{array}		services.CalendarResponse	"Every calendar without limit or cursor, a services.CalendarPage with either"
+//	@Header			200		{string}	Link						"URL of the next page, as rel=next"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
 //	@Security		BearerAuth
 //	@Router			/api/calendars [get]
 func (h *CalendarHandler) GetCalendars(w http.ResponseWriter, r *http.Request) {
@@ -96,21 +105,49 @@ func (h *CalendarHandler) GetCalendars(w http.ResponseWriter, r *http.Request) {
 		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
+	if !requireScope(w, r, services.ScopeCalendarsRead) {
+		return
+	}
+
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+	req := services.CalendarListRequest{
+		Limit:  limit,
+		Cursor: r.URL.Query().Get("cursor"),
+		Order:  r.URL.Query().Get("order"),
+	}
 
-	calendars, err := h.calendarService.GetCalendarsByUserID(r.Context(), userID)
+	var response any
+	var err error
+	if isPaginated(r) {
+		var page *services.CalendarPage
+		if page, err = h.calendarService.GetCalendarsByUserID(r.Context(), userID, req); err == nil {
+			setNextLink(w, r, page.NextCursor)
+			response = page
+		}
+	} else {
+		response, err = h.calendarService.GetAllCalendarsByUserID(r.Context(), userID, req)
+	}
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, "internal server error")
+		switch err {
+		case services.ErrInvalidCursor, services.ErrInvalidSortOrder:
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+		default:
+			writeInternalError(w, r, err)
+		}
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(calendars)
+	json.NewEncoder(w).Encode(response)
 }
 
 // GetCalendar handles GET /api/calendars/{id}
 //
 //	@Summary		Get calendar by ID
-//	@Description	Retrieve a specific calendar by ID (user must own the calendar)
+//	@Description	Retrieve a specific calendar by ID (user must be a member)
 //	@Tags			calendars
 //	@Accept			json
 //	@Produce		json
@@ -135,6 +172,9 @@ func (h *CalendarHandler) GetCalendar(w http.ResponseWriter, r *http.Request) {
 		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
+	if !requireScope(w, r, services.ScopeCalendarsRead) {
+		return
+	}
 
 	// Extract calendar ID from URL path
 	calendarIDStr := extractIDFromPath(r.URL.Path, "/api/calendars/")
@@ -152,7 +192,7 @@ func (h *CalendarHandler) GetCalendar(w http.ResponseWriter, r *http.Request) {
 		case services.ErrUnauthorizedCalendar:
 			writeJSONError(w, http.StatusForbidden, err.Error())
 		default:
-			writeJSONError(w, http.StatusInternalServerError, "internal server error")
+			writeInternalError(w, r, err)
 		}
 		return
 	}
@@ -191,6 +231,9 @@ func (h *CalendarHandler) UpdateCalendar(w http.ResponseWriter, r *http.Request)
 		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
+	if !requireScope(w, r, services.ScopeCalendarsWrite) {
+		return
+	}
 
 	// Extract calendar ID from URL path
 	calendarIDStr := extractIDFromPath(r.URL.Path, "/api/calendars/")
@@ -218,7 +261,7 @@ func (h *CalendarHandler) UpdateCalendar(w http.ResponseWriter, r *http.Request)
 		case services.ErrCalendarNameExists:
 			writeJSONError(w, http.StatusConflict, err.Error())
 		default:
-			writeJSONError(w, http.StatusInternalServerError, "internal server error")
+			writeInternalError(w, r, err)
 		}
 		return
 	}
@@ -255,6 +298,9 @@ func (h *CalendarHandler) DeleteCalendar(w http.ResponseWriter, r *http.Request)
 		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
+	if !requireScope(w, r, services.ScopeCalendarsWrite) {
+		return
+	}
 
 	// Extract calendar ID from URL path
 	calendarIDStr := extractIDFromPath(r.URL.Path, "/api/calendars/")
@@ -272,7 +318,7 @@ func (h *CalendarHandler) DeleteCalendar(w http.ResponseWriter, r *http.Request)
 		case services.ErrUnauthorizedCalendar:
 			writeJSONError(w, http.StatusForbidden, err.Error())
 		default:
-			writeJSONError(w, http.StatusInternalServerError, "internal server error")
+			writeInternalError(w, r, err)
 		}
 		return
 	}
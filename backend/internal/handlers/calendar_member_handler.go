@@ -0,0 +1,439 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+)
+
+type CalendarMemberHandler struct {
+	memberService services.CalendarMemberServiceInterface
+}
+
+func NewCalendarMemberHandler(memberService services.CalendarMemberServiceInterface) *CalendarMemberHandler {
+	return &CalendarMemberHandler{
+		memberService: memberService,
+	}
+}
+
+// InviteMember handles POST /api/calendars/{id}/invitations
+//
+//	@Summary		Invite a calendar member
+//	@Description	Invite someone by email to a calendar as editor or viewer (user must own the calendar). Inviting the same address again replaces the pending invitation.
+//	@Tags			members
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		string							true	"Calendar ID"
+//	@Param			invitation	body		services.InviteMemberRequest	true	"Invitation request"
+//	@Success		201			{object}	services.InvitationResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		409			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/calendars/{id}/invitations [post]
+func (h *CalendarMemberHandler) InviteMember(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeCalendarsWrite) {
+		return
+	}
+
+	// Extract calendar ID from URL path
+	calendarID, err := uuid.Parse(extractIDFromPath(r.URL.Path, "/api/calendars/"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid calendar ID")
+		return
+	}
+
+	var req services.InviteMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	invitation, err := h.memberService.InviteMember(r.Context(), userID, calendarID, req)
+	if err != nil {
+		writeCalendarMemberError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(invitation)
+}
+
+// GetCalendarInvitations handles GET /api/calendars/{id}/invitations
+//
+//	@Summary		Get pending invitations of a calendar
+//	@Description	List the invitations of a calendar nobody has accepted yet (user must own the calendar)
+//	@Tags			members
+//	@Produce		json
+//	@Param			id	path		string	true	"Calendar ID"
+//	@Success		200	{array}		services.InvitationResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/calendars/{id}/invitations [get]
+func (h *CalendarMemberHandler) GetCalendarInvitations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeCalendarsRead) {
+		return
+	}
+
+	// Extract calendar ID from URL path
+	calendarID, err := uuid.Parse(extractIDFromPath(r.URL.Path, "/api/calendars/"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid calendar ID")
+		return
+	}
+
+	invitations, err := h.memberService.GetCalendarInvitations(r.Context(), userID, calendarID)
+	if err != nil {
+		writeCalendarMemberError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(invitations)
+}
+
+// GetInvitations handles GET /api/invitations
+//
+//	@Summary		Get my invitations
+//	@Description	List the pending calendar invitations addressed to the authenticated user's email
+//	@Tags			members
+//	@Produce		json
+//	@Success		200	{array}		services.InvitationResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/invitations [get]
+func (h *CalendarMemberHandler) GetInvitations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeCalendarsRead) {
+		return
+	}
+
+	invitations, err := h.memberService.GetInvitations(r.Context(), userID)
+	if err != nil {
+		writeCalendarMemberError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(invitations)
+}
+
+// AcceptInvitation handles POST /api/invitations/{id}/accept
+//
+//	@Summary		Accept an invitation
+//	@Description	Join the calendar an invitation addressed to the authenticated user is for. The user's email address must be verified.
+//	@Tags			members
+//	@Produce		json
+//	@Param			id	path		string	true	"Invitation ID"
+//	@Success		200	{object}	services.CalendarMemberResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/invitations/{id}/accept [post]
+func (h *CalendarMemberHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeCalendarsWrite) {
+		return
+	}
+
+	// Extract invitation ID from URL path
+	invitationID, err := uuid.Parse(extractIDFromPath(r.URL.Path, "/api/invitations/"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid invitation ID")
+		return
+	}
+
+	member, err := h.memberService.AcceptInvitation(r.Context(), userID, invitationID)
+	if err != nil {
+		writeCalendarMemberError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(member)
+}
+
+// DeclineInvitation handles DELETE /api/invitations/{id}
+//
+//	@Summary		Decline an invitation
+//	@Description	Delete an invitation addressed to the authenticated user
+//	@Tags			members
+//	@Param			id	path	string	true	"Invitation ID"
+//	@Success		204	"No Content"
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/invitations/{id} [delete]
+func (h *CalendarMemberHandler) DeclineInvitation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeCalendarsWrite) {
+		return
+	}
+
+	// Extract invitation ID from URL path
+	invitationID, err := uuid.Parse(extractIDFromPath(r.URL.Path, "/api/invitations/"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid invitation ID")
+		return
+	}
+
+	if err := h.memberService.DeclineInvitation(r.Context(), userID, invitationID); err != nil {
+		writeCalendarMemberError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetMembers handles GET /api/calendars/{id}/members
+//
+//	@Summary		Get calendar members
+//	@Description	List the members of a calendar with their roles, owner first (user must be a member)
+//	@Tags			members
+//	@Produce		json
+//	@Param			id	path		string	true	"Calendar ID"
+//	@Success		200	{array}		services.CalendarMemberResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/calendars/{id}/members [get]
+func (h *CalendarMemberHandler) GetMembers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeCalendarsRead) {
+		return
+	}
+
+	// Extract calendar ID from URL path
+	calendarID, err := uuid.Parse(extractIDFromPath(r.URL.Path, "/api/calendars/"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid calendar ID")
+		return
+	}
+
+	members, err := h.memberService.GetMembers(r.Context(), userID, calendarID)
+	if err != nil {
+		writeCalendarMemberError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(members)
+}
+
+// UpdateMemberRole handles PUT /api/calendars/{id}/members/{userId}
+//
+//	@Summary		Change a member's role
+//	@Description	Switch a member between editor and viewer (user must own the calendar)
+//	@Tags			members
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string								true	"Calendar ID"
+//	@Param			userId	path		string								true	"Member user ID"
+//	@Param			role	body		services.UpdateMemberRoleRequest	true	"New role"
+//	@Success		200		{object}	services.CalendarMemberResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/calendars/{id}/members/{userId} [put]
+func (h *CalendarMemberHandler) UpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeCalendarsWrite) {
+		return
+	}
+
+	calendarID, memberID, ok := parseCalendarMemberPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req services.UpdateMemberRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	member, err := h.memberService.UpdateMemberRole(r.Context(), userID, calendarID, memberID, req)
+	if err != nil {
+		writeCalendarMemberError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(member)
+}
+
+// RemoveMember handles DELETE /api/calendars/{id}/members/{userId}
+//
+//	@Summary		Remove a calendar member
+//	@Description	Take a member off a calendar. Owners remove anyone but themselves; other members may only remove themselves to leave.
+//	@Tags			members
+//	@Param			id		path	string	true	"Calendar ID"
+//	@Param			userId	path	string	true	"Member user ID"
+//	@Success		204		"No Content"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/calendars/{id}/members/{userId} [delete]
+func (h *CalendarMemberHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeCalendarsWrite) {
+		return
+	}
+
+	calendarID, memberID, ok := parseCalendarMemberPath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.memberService.RemoveMember(r.Context(), userID, calendarID, memberID); err != nil {
+		writeCalendarMemberError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseCalendarMemberPath extracts the calendar and member user IDs from
+// /api/calendars/{id}/members/{userId}, writing a 400 when either is invalid.
+func parseCalendarMemberPath(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	calendarIDStr := extractIDFromPath(r.URL.Path, "/api/calendars/")
+	calendarID, err := uuid.Parse(calendarIDStr)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid calendar ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	memberID, err := uuid.Parse(extractIDFromPath(r.URL.Path, "/api/calendars/"+calendarIDStr+"/members/"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid user ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return calendarID, memberID, true
+}
+
+// writeCalendarMemberError maps calendar sharing service errors to HTTP responses
+func writeCalendarMemberError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidRole), errors.Is(err, services.ErrInvalidEmail), errors.Is(err, services.ErrCannotChangeOwner):
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrCalendarNotFound), errors.Is(err, services.ErrMemberNotFound), errors.Is(err, services.ErrInvitationNotFound):
+		writeJSONError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrUnauthorizedCalendar), errors.Is(err, services.ErrUnauthorizedRoleEdit), errors.Is(err, services.ErrEmailNotVerified):
+		writeJSONError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrAlreadyMember):
+		writeJSONError(w, http.StatusConflict, err.Error())
+	default:
+		writeInternalError(w, r, err)
+	}
+}
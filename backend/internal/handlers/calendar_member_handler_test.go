@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockCalendarMemberService implements a mock for the CalendarMemberService
+type MockCalendarMemberService struct {
+	mock.Mock
+}
+
+func (m *MockCalendarMemberService) InviteMember(ctx context.Context, userID, calendarID uuid.UUID, req services.InviteMemberRequest) (*services.InvitationResponse, error) {
+	args := m.Called(ctx, userID, calendarID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.InvitationResponse), args.Error(1)
+}
+
+func (m *MockCalendarMemberService) GetCalendarInvitations(ctx context.Context, userID, calendarID uuid.UUID) ([]*services.InvitationResponse, error) {
+	args := m.Called(ctx, userID, calendarID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*services.InvitationResponse), args.Error(1)
+}
+
+func (m *MockCalendarMemberService) GetInvitations(ctx context.Context, userID uuid.UUID) ([]*services.InvitationResponse, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*services.InvitationResponse), args.Error(1)
+}
+
+func (m *MockCalendarMemberService) AcceptInvitation(ctx context.Context, userID, invitationID uuid.UUID) (*services.CalendarMemberResponse, error) {
+	args := m.Called(ctx, userID, invitationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.CalendarMemberResponse), args.Error(1)
+}
+
+func (m *MockCalendarMemberService) DeclineInvitation(ctx context.Context, userID, invitationID uuid.UUID) error {
+	args := m.Called(ctx, userID, invitationID)
+	return args.Error(0)
+}
+
+func (m *MockCalendarMemberService) GetMembers(ctx context.Context, userID, calendarID uuid.UUID) ([]*services.CalendarMemberResponse, error) {
+	args := m.Called(ctx, userID, calendarID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*services.CalendarMemberResponse), args.Error(1)
+}
+
+func (m *MockCalendarMemberService) UpdateMemberRole(ctx context.Context, userID, calendarID, memberID uuid.UUID, req services.UpdateMemberRoleRequest) (*services.CalendarMemberResponse, error) {
+	args := m.Called(ctx, userID, calendarID, memberID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.CalendarMemberResponse), args.Error(1)
+}
+
+func (m *MockCalendarMemberService) RemoveMember(ctx context.Context, userID, calendarID, memberID uuid.UUID) error {
+	args := m.Called(ctx, userID, calendarID, memberID)
+	return args.Error(0)
+}
+
+func TestCalendarMemberHandler_InviteMember(t *testing.T) {
+	userID := uuid.New()
+	calendarID := uuid.New()
+	req := services.InviteMemberRequest{Email: "friend@example.com", Role: services.RoleEditor}
+
+	tests := []struct {
+		name           string
+		serviceResult  *services.InvitationResponse
+		serviceErr     error
+		expectedStatus int
+	}{
+		{"invited", &services.InvitationResponse{ID: uuid.New(), CalendarID: calendarID, Email: req.Email, Role: req.Role}, nil, http.StatusCreated},
+		{"invalid role", nil, services.ErrInvalidRole, http.StatusBadRequest},
+		{"not the owner", nil, services.ErrUnauthorizedRoleEdit, http.StatusForbidden},
+		{"not a member", nil, services.ErrUnauthorizedCalendar, http.StatusForbidden},
+		{"calendar not found", nil, services.ErrCalendarNotFound, http.StatusNotFound},
+		{"already a member", nil, services.ErrAlreadyMember, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockCalendarMemberService)
+			handler := NewCalendarMemberHandler(mockService)
+			mockService.On("InviteMember", mock.Anything, userID, calendarID, req).Return(tt.serviceResult, tt.serviceErr).Once()
+
+			body, _ := json.Marshal(req)
+			r := httptest.NewRequest(http.MethodPost, "/api/calendars/"+calendarID.String()+"/invitations", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			handler.InviteMember(w, withUserID(r, userID))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCalendarMemberHandler_AcceptInvitation(t *testing.T) {
+	userID := uuid.New()
+	invitationID := uuid.New()
+
+	t.Run("accepted", func(t *testing.T) {
+		mockService := new(MockCalendarMemberService)
+		handler := NewCalendarMemberHandler(mockService)
+		member := &services.CalendarMemberResponse{CalendarID: uuid.New(), UserID: userID, Role: services.RoleViewer}
+		mockService.On("AcceptInvitation", mock.Anything, userID, invitationID).Return(member, nil).Once()
+
+		r := httptest.NewRequest(http.MethodPost, "/api/invitations/"+invitationID.String()+"/accept", nil)
+		w := httptest.NewRecorder()
+		handler.AcceptInvitation(w, withUserID(r, userID))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response services.CalendarMemberResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		assert.Equal(t, services.RoleViewer, response.Role)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockService := new(MockCalendarMemberService)
+		handler := NewCalendarMemberHandler(mockService)
+		mockService.On("AcceptInvitation", mock.Anything, userID, invitationID).Return(nil, services.ErrInvitationNotFound).Once()
+
+		r := httptest.NewRequest(http.MethodPost, "/api/invitations/"+invitationID.String()+"/accept", nil)
+		w := httptest.NewRecorder()
+		handler.AcceptInvitation(w, withUserID(r, userID))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("unverified email", func(t *testing.T) {
+		mockService := new(MockCalendarMemberService)
+		handler := NewCalendarMemberHandler(mockService)
+		mockService.On("AcceptInvitation", mock.Anything, userID, invitationID).Return(nil, services.ErrEmailNotVerified).Once()
+
+		r := httptest.NewRequest(http.MethodPost, "/api/invitations/"+invitationID.String()+"/accept", nil)
+		w := httptest.NewRecorder()
+		handler.AcceptInvitation(w, withUserID(r, userID))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("invalid ID", func(t *testing.T) {
+		handler := NewCalendarMemberHandler(new(MockCalendarMemberService))
+
+		r := httptest.NewRequest(http.MethodPost, "/api/invitations/nope/accept", nil)
+		w := httptest.NewRecorder()
+		handler.AcceptInvitation(w, withUserID(r, userID))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestCalendarMemberHandler_UpdateMemberRole(t *testing.T) {
+	userID := uuid.New()
+	calendarID := uuid.New()
+	memberID := uuid.New()
+	req := services.UpdateMemberRoleRequest{Role: services.RoleViewer}
+
+	tests := []struct {
+		name           string
+		path           string
+		serviceErr     error
+		expectedStatus int
+	}{
+		{"updated", memberID.String(), nil, http.StatusOK},
+		{"owner", memberID.String(), services.ErrCannotChangeOwner, http.StatusBadRequest},
+		{"member not found", memberID.String(), services.ErrMemberNotFound, http.StatusNotFound},
+		{"invalid user ID", "nope", nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockCalendarMemberService)
+			handler := NewCalendarMemberHandler(mockService)
+			if tt.serviceErr != nil {
+				mockService.On("UpdateMemberRole", mock.Anything, userID, calendarID, memberID, req).Return(nil, tt.serviceErr).Once()
+			} else {
+				mockService.On("UpdateMemberRole", mock.Anything, userID, calendarID, memberID, req).
+					Return(&services.CalendarMemberResponse{CalendarID: calendarID, UserID: memberID, Role: req.Role}, nil).Maybe()
+			}
+
+			body, _ := json.Marshal(req)
+			r := httptest.NewRequest(http.MethodPut, "/api/calendars/"+calendarID.String()+"/members/"+tt.path, bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			handler.UpdateMemberRole(w, withUserID(r, userID))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestServer_CalendarMemberRouting(t *testing.T) {
+	mockService := new(MockCalendarMemberService)
+	server := &Server{memberHandler: NewCalendarMemberHandler(mockService)}
+
+	userID := uuid.New()
+	calendarID := uuid.New()
+	memberID := uuid.New()
+	invitationID := uuid.New()
+	calendarPath := "/api/calendars/" + calendarID.String()
+
+	mockService.On("GetMembers", mock.Anything, userID, calendarID).Return([]*services.CalendarMemberResponse{}, nil).Once()
+	w := httptest.NewRecorder()
+	server.handleCalendarByID(w, withUserID(httptest.NewRequest(http.MethodGet, calendarPath+"/members", nil), userID))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mockService.On("RemoveMember", mock.Anything, userID, calendarID, memberID).Return(nil).Once()
+	w = httptest.NewRecorder()
+	server.handleCalendarByID(w, withUserID(httptest.NewRequest(http.MethodDelete, calendarPath+"/members/"+memberID.String(), nil), userID))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	mockService.On("GetCalendarInvitations", mock.Anything, userID, calendarID).Return([]*services.InvitationResponse{}, nil).Once()
+	w = httptest.NewRecorder()
+	server.handleCalendarByID(w, withUserID(httptest.NewRequest(http.MethodGet, calendarPath+"/invitations", nil), userID))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	server.handleCalendarByID(w, withUserID(httptest.NewRequest(http.MethodPost, calendarPath+"/members", nil), userID))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	mockService.On("DeclineInvitation", mock.Anything, userID, invitationID).Return(nil).Once()
+	w = httptest.NewRecorder()
+	server.handleInvitationByID(w, withUserID(httptest.NewRequest(http.MethodDelete, "/api/invitations/"+invitationID.String(), nil), userID))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	mockService.On("AcceptInvitation", mock.Anything, userID, invitationID).Return(&services.CalendarMemberResponse{}, nil).Once()
+	w = httptest.NewRecorder()
+	server.handleInvitationByID(w, withUserID(httptest.NewRequest(http.MethodPost, "/api/invitations/"+invitationID.String()+"/accept", nil), userID))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	server.handleInvitationByID(w, withUserID(httptest.NewRequest(http.MethodPost, "/api/invitations/"+invitationID.String()+"/other", nil), userID))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+)
+
+type ColorMeaningHandler struct {
+	colorMeaningService services.ColorMeaningServiceInterface
+}
+
+func NewColorMeaningHandler(colorMeaningService services.ColorMeaningServiceInterface) *ColorMeaningHandler {
+	return &ColorMeaningHandler{
+		colorMeaningService: colorMeaningService,
+	}
+}
+
+// CreateColorMeaning handles POST /api/calendars/{id}/colors
+//
+//	@Summary		Create a color meaning
+//	@Description	Add a color and its meaning to a calendar legend (user must be an editor or owner)
+//	@Tags			colors
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string								true	"Calendar ID"
+//	@Param			color	body		services.CreateColorMeaningRequest	true	"Color meaning creation request"
+//	@Success		201		{object}	services.ColorMeaningResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/calendars/{id}/colors [post]
+func (h *ColorMeaningHandler) CreateColorMeaning(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeCalendarsWrite) {
+		return
+	}
+
+	// Extract calendar ID from URL path
+	calendarID, err := uuid.Parse(extractIDFromPath(r.URL.Path, "/api/calendars/"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid calendar ID")
+		return
+	}
+
+	var req services.CreateColorMeaningRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	colorMeaning, err := h.colorMeaningService.CreateColorMeaning(r.Context(), userID, calendarID, req)
+	if err != nil {
+		writeColorMeaningError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(colorMeaning)
+}
+
+// GetColorMeanings handles GET /api/calendars/{id}/colors
+//
+//	@Summary		Get calendar legend
+//	@Description	Retrieve all color meanings of a calendar (user must be a member)
+//	@Tags			colors
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Calendar ID"
+//	@Success		200	{array}		services.ColorMeaningResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/calendars/{id}/colors [get]
+func (h *ColorMeaningHandler) GetColorMeanings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeCalendarsRead) {
+		return
+	}
+
+	// Extract calendar ID from URL path
+	calendarID, err := uuid.Parse(extractIDFromPath(r.URL.Path, "/api/calendars/"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid calendar ID")
+		return
+	}
+
+	colorMeanings, err := h.colorMeaningService.GetColorMeaningsByCalendarID(r.Context(), userID, calendarID)
+	if err != nil {
+		writeColorMeaningError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(colorMeanings)
+}
+
+// GetColorMeaning handles GET /api/calendars/{id}/colors/{colorId}
+//
+//	@Summary		Get color meaning by ID
+//	@Description	Retrieve a single color meaning of a calendar (user must be a member)
+//	@Tags			colors
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string	true	"Calendar ID"
+//	@Param			colorId	path		string	true	"Color meaning ID"
+//	@Success		200		{object}	services.ColorMeaningResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/calendars/{id}/colors/{colorId} [get]
+func (h *ColorMeaningHandler) GetColorMeaning(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeCalendarsRead) {
+		return
+	}
+
+	calendarID, colorMeaningID, ok := parseColorMeaningPath(w, r)
+	if !ok {
+		return
+	}
+
+	colorMeaning, err := h.colorMeaningService.GetCalendarColorMeaning(r.Context(), userID, calendarID, colorMeaningID)
+	if err != nil {
+		writeColorMeaningError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(colorMeaning)
+}
+
+// UpdateColorMeaning handles PUT /api/calendars/{id}/colors/{colorId}
+//
+//	@Summary		Update color meaning
+//	@Description	Change the color and meaning of a legend item (user must be an editor or owner)
+//	@Tags			colors
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string								true	"Calendar ID"
+//	@Param			colorId	path		string								true	"Color meaning ID"
+//	@Param			color	body		services.UpdateColorMeaningRequest	true	"Color meaning update request"
+//	@Success		200		{object}	services.ColorMeaningResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/calendars/{id}/colors/{colorId} [put]
+func (h *ColorMeaningHandler) UpdateColorMeaning(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeCalendarsWrite) {
+		return
+	}
+
+	calendarID, colorMeaningID, ok := parseColorMeaningPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req services.UpdateColorMeaningRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	colorMeaning, err := h.colorMeaningService.UpdateColorMeaning(r.Context(), userID, calendarID, colorMeaningID, req)
+	if err != nil {
+		writeColorMeaningError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(colorMeaning)
+}
+
+// DeleteColorMeaning handles DELETE /api/calendars/{id}/colors/{colorId}
+//
+//	@Summary		Delete color meaning
+//	@Description	Remove a legend item and the day entries that use it (user must be an editor or owner)
+//	@Tags			colors
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path	string	true	"Calendar ID"
+//	@Param			colorId	path	string	true	"Color meaning ID"
+//	@Success		204		"No Content"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/calendars/{id}/colors/{colorId} [delete]
+func (h *ColorMeaningHandler) DeleteColorMeaning(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeCalendarsWrite) {
+		return
+	}
+
+	calendarID, colorMeaningID, ok := parseColorMeaningPath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.colorMeaningService.DeleteColorMeaning(r.Context(), userID, calendarID, colorMeaningID); err != nil {
+		writeColorMeaningError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseColorMeaningPath extracts the calendar and color meaning IDs from
+// /api/calendars/{id}/colors/{colorId}, writing a 400 when either is invalid.
+func parseColorMeaningPath(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	calendarIDStr := extractIDFromPath(r.URL.Path, "/api/calendars/")
+	calendarID, err := uuid.Parse(calendarIDStr)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid calendar ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	colorMeaningID, err := uuid.Parse(extractIDFromPath(r.URL.Path, "/api/calendars/"+calendarIDStr+"/colors/"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid color meaning ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return calendarID, colorMeaningID, true
+}
+
+// writeColorMeaningError maps color meaning service errors to HTTP responses
+func writeColorMeaningError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case services.ErrInvalidColorHex, services.ErrMeaningEmpty, services.ErrMeaningTooLong:
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	case services.ErrCalendarNotFound, services.ErrColorMeaningNotFound:
+		writeJSONError(w, http.StatusNotFound, err.Error())
+	case services.ErrUnauthorizedCalendar, services.ErrUnauthorizedColorMeaning:
+		writeJSONError(w, http.StatusForbidden, err.Error())
+	case services.ErrColorExists, services.ErrMeaningExists, services.ErrColorMeaningExists:
+		writeJSONError(w, http.StatusConflict, err.Error())
+	default:
+		writeInternalError(w, r, err)
+	}
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockColorMeaningService implements a mock for the ColorMeaningService
+type MockColorMeaningService struct {
+	mock.Mock
+}
+
+func (m *MockColorMeaningService) CreateColorMeaning(ctx context.Context, userID, calendarID uuid.UUID, req services.CreateColorMeaningRequest) (*services.ColorMeaningResponse, error) {
+	args := m.Called(ctx, userID, calendarID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.ColorMeaningResponse), args.Error(1)
+}
+
+func (m *MockColorMeaningService) GetColorMeaningsByCalendarID(ctx context.Context, userID, calendarID uuid.UUID) ([]*services.ColorMeaningResponse, error) {
+	args := m.Called(ctx, userID, calendarID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*services.ColorMeaningResponse), args.Error(1)
+}
+
+func (m *MockColorMeaningService) GetCalendarColorMeaning(ctx context.Context, userID, calendarID, colorMeaningID uuid.UUID) (*services.ColorMeaningResponse, error) {
+	args := m.Called(ctx, userID, calendarID, colorMeaningID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.ColorMeaningResponse), args.Error(1)
+}
+
+func (m *MockColorMeaningService) UpdateColorMeaning(ctx context.Context, userID, calendarID, colorMeaningID uuid.UUID, req services.UpdateColorMeaningRequest) (*services.ColorMeaningResponse, error) {
+	args := m.Called(ctx, userID, calendarID, colorMeaningID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.ColorMeaningResponse), args.Error(1)
+}
+
+func (m *MockColorMeaningService) DeleteColorMeaning(ctx context.Context, userID, calendarID, colorMeaningID uuid.UUID) error {
+	args := m.Called(ctx, userID, calendarID, colorMeaningID)
+	return args.Error(0)
+}
+
+func TestColorMeaningHandler_CreateColorMeaning(t *testing.T) {
+	mockService := new(MockColorMeaningService)
+	handler := NewColorMeaningHandler(mockService)
+
+	userID := uuid.New()
+	calendarID := uuid.New()
+	path := "/api/calendars/" + calendarID.String() + "/colors"
+
+	t.Run("successful creation", func(t *testing.T) {
+		req := services.CreateColorMeaningRequest{ColorHex: "#00FF00", Meaning: "relaxed"}
+		expectedResponse := &services.ColorMeaningResponse{
+			ID:         uuid.New(),
+			CalendarID: calendarID,
+			ColorHex:   "#00FF00",
+			Meaning:    "relaxed",
+		}
+
+		mockService.On("CreateColorMeaning", mock.Anything, userID, calendarID, req).Return(expectedResponse, nil).Once()
+
+		reqBody, _ := json.Marshal(req)
+		httpReq := withUserID(httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(reqBody)), userID)
+		w := httptest.NewRecorder()
+
+		handler.CreateColorMeaning(w, httpReq)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var response services.ColorMeaningResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+		assert.Equal(t, expectedResponse.ID, response.ID)
+
+		mockService.AssertExpectations(t)
+	})
+
+	errorCases := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{"invalid hex", services.ErrInvalidColorHex, http.StatusBadRequest},
+		{"empty meaning", services.ErrMeaningEmpty, http.StatusBadRequest},
+		{"meaning too long", services.ErrMeaningTooLong, http.StatusBadRequest},
+		{"color exists", services.ErrColorExists, http.StatusConflict},
+		{"meaning exists", services.ErrMeaningExists, http.StatusConflict},
+		{"calendar not found", services.ErrCalendarNotFound, http.StatusNotFound},
+		{"calendar not owned", services.ErrUnauthorizedCalendar, http.StatusForbidden},
+		{"unexpected error", fmt.Errorf("db down"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := services.CreateColorMeaningRequest{ColorHex: "#FF0000", Meaning: tc.name}
+
+			mockService.On("CreateColorMeaning", mock.Anything, userID, calendarID, req).Return(nil, tc.err).Once()
+
+			reqBody, _ := json.Marshal(req)
+			httpReq := withUserID(httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(reqBody)), userID)
+			w := httptest.NewRecorder()
+
+			handler.CreateColorMeaning(w, httpReq)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestColorMeaningHandler_ColorIDRoutes(t *testing.T) {
+	mockService := new(MockColorMeaningService)
+	handler := NewColorMeaningHandler(mockService)
+
+	userID := uuid.New()
+	calendarID := uuid.New()
+	colorMeaningID := uuid.New()
+	path := "/api/calendars/" + calendarID.String() + "/colors/" + colorMeaningID.String()
+
+	t.Run("get color meaning", func(t *testing.T) {
+		expectedResponse := &services.ColorMeaningResponse{ID: colorMeaningID, CalendarID: calendarID}
+
+		mockService.On("GetCalendarColorMeaning", mock.Anything, userID, calendarID, colorMeaningID).Return(expectedResponse, nil).Once()
+
+		httpReq := withUserID(httptest.NewRequest(http.MethodGet, path, nil), userID)
+		w := httptest.NewRecorder()
+
+		handler.GetColorMeaning(w, httpReq)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("color meaning of another calendar", func(t *testing.T) {
+		mockService.On("GetCalendarColorMeaning", mock.Anything, userID, calendarID, colorMeaningID).Return(nil, services.ErrColorMeaningNotFound).Once()
+
+		httpReq := withUserID(httptest.NewRequest(http.MethodGet, path, nil), userID)
+		w := httptest.NewRecorder()
+
+		handler.GetColorMeaning(w, httpReq)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid color meaning ID", func(t *testing.T) {
+		httpReq := withUserID(httptest.NewRequest(http.MethodGet, "/api/calendars/"+calendarID.String()+"/colors/nope", nil), userID)
+		w := httptest.NewRecorder()
+
+		handler.GetColorMeaning(w, httpReq)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var errorResp errorResponse
+		err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+		require.NoError(t, err)
+		assert.Equal(t, "invalid color meaning ID", errorResp.Error)
+	})
+
+	t.Run("update with duplicate color", func(t *testing.T) {
+		req := services.UpdateColorMeaningRequest{ColorHex: "#FF0000", Meaning: "angry"}
+
+		mockService.On("UpdateColorMeaning", mock.Anything, userID, calendarID, colorMeaningID, req).Return(nil, services.ErrColorExists).Once()
+
+		reqBody, _ := json.Marshal(req)
+		httpReq := withUserID(httptest.NewRequest(http.MethodPut, path, bytes.NewBuffer(reqBody)), userID)
+		w := httptest.NewRecorder()
+
+		handler.UpdateColorMeaning(w, httpReq)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("delete color meaning", func(t *testing.T) {
+		mockService.On("DeleteColorMeaning", mock.Anything, userID, calendarID, colorMeaningID).Return(nil).Once()
+
+		httpReq := withUserID(httptest.NewRequest(http.MethodDelete, path, nil), userID)
+		w := httptest.NewRecorder()
+
+		handler.DeleteColorMeaning(w, httpReq)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,424 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+)
+
+type DayEntryHandler struct {
+	dayEntryService services.DayEntryServiceInterface
+}
+
+func NewDayEntryHandler(dayEntryService services.DayEntryServiceInterface) *DayEntryHandler {
+	return &DayEntryHandler{
+		dayEntryService: dayEntryService,
+	}
+}
+
+// CreateDayEntry handles POST /api/calendars/{id}/entries
+//
+//	@Summary		Create a day entry
+//	@Description	Record a day in a calendar with one of the calendar's color meanings (user must be an editor or owner)
+//	@Tags			entries
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string							true	"Calendar ID"
+//	@Param			entry	body		services.CreateDayEntryRequest	true	"Day entry creation request"
+//	@Success		201		{object}	services.DayEntryResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/calendars/{id}/entries [post]
+func (h *DayEntryHandler) CreateDayEntry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeEntriesWrite) {
+		return
+	}
+
+	// Extract calendar ID from URL path
+	calendarID, err := uuid.Parse(extractIDFromPath(r.URL.Path, "/api/calendars/"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid calendar ID")
+		return
+	}
+
+	var req services.CreateDayEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	entry, err := h.dayEntryService.CreateDayEntry(r.Context(), userID, calendarID, req)
+	if err != nil {
+		writeDayEntryError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+// GetDayEntries handles GET /api/calendars/{id}/entries
+//
+//	@Summary		Get calendar day entries
+//	@Description	Retrieve the day entries of a calendar, most recent first unless order is asc (user must be a member). Filters combine, so only entries matching all of them are returned. Without limit or cursor the response is an array of every matching entry. With either, it is a page object ({"entries": [...], "next_cursor": "..."}); pass the next_cursor of a page as cursor to get the one after it, and the Link header of a page that has one points at the next page.
+//	@Tags			entries
+//	@Accept			json
+//	@Produce		json
+//	@Param			id					path		string						true	"Calendar ID"
+//	@Param			cursor				query		string						false	"next_cursor of the previous page"
+//	@Param			limit				query		int							false	"Entries per page, at most 1000"	default(100)
+//	@Param			order				query		string						false	"Sort order by date"				Enums(asc, desc)	default(desc)
+//	@Param			start				query		string						false	"Earliest date (YYYY-MM-DD), inclusive"
+//	@Param			end					query		string						false	"Latest date (YYYY-MM-DD), inclusive"
+//	@Param			color_meaning_id	query		[]string					false	"Only entries with one of these color meanings"	collectionFormat(multi)
+//	@Param			has_notes			query		bool						false	"Only entries with (true) or without (false) notes"
+//	@Param			notes				query		string						false	"Only entries whose notes contain this text, ignoring case"
+//	@Success		200					{object}	services.DayEntryPage		"A page of entries, with limit or cursor"
+//	@Success		200					{array}		services.DayEntryResponse	"Every matching entry without limit or cursor, a services.DayEntryPage with either"
+//	@Header			200					{string}	Link						"URL of the next page, as rel=next"
+//	@Failure		400					{object}	ErrorResponse
+//	@Failure		401					{object}	ErrorResponse
+//	@Failure		403					{object}	ErrorResponse
+//	@Failure		404					{object}	ErrorResponse
+//	@Failure		500					{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/calendars/{id}/entries [get]
+func (h *DayEntryHandler) GetDayEntries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeEntriesRead) {
+		return
+	}
+
+	// Extract calendar ID from URL path
+	calendarID, err := uuid.Parse(extractIDFromPath(r.URL.Path, "/api/calendars/"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid calendar ID")
+		return
+	}
+
+	req, ok := parseDayEntryListRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var response any
+	if isPaginated(r) {
+		var page *services.DayEntryPage
+		if page, err = h.dayEntryService.GetDayEntriesByCalendarID(r.Context(), userID, calendarID, req); err == nil {
+			setNextLink(w, r, page.NextCursor)
+			response = page
+		}
+	} else {
+		response, err = h.dayEntryService.GetAllDayEntriesByCalendarID(r.Context(), userID, calendarID, req)
+	}
+	if err != nil {
+		writeDayEntryError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetDayEntry handles GET /api/calendars/{id}/entries/{date}
+//
+//	@Summary		Get day entry by date
+//	@Description	Retrieve the entry recorded for a given day in a calendar (user must be a member)
+//	@Tags			entries
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string	true	"Calendar ID"
+//	@Param			date	path		string	true	"Date (YYYY-MM-DD)"
+//	@Success		200		{object}	services.DayEntryResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/calendars/{id}/entries/{date} [get]
+func (h *DayEntryHandler) GetDayEntry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeEntriesRead) {
+		return
+	}
+
+	// Extract calendar ID and date from URL path
+	calendarIDStr := extractIDFromPath(r.URL.Path, "/api/calendars/")
+	calendarID, err := uuid.Parse(calendarIDStr)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid calendar ID")
+		return
+	}
+	date := extractIDFromPath(r.URL.Path, "/api/calendars/"+calendarIDStr+"/entries/")
+
+	entry, err := h.dayEntryService.GetDayEntryByCalendarAndDate(r.Context(), userID, calendarID, date)
+	if err != nil {
+		writeDayEntryError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
+
+// UpdateDayEntry handles PUT /api/calendars/{id}/entries/{date}
+//
+//	@Summary		Update day entry
+//	@Description	Change the color meaning and notes of a day entry (user must be an editor or owner)
+//	@Tags			entries
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string							true	"Calendar ID"
+//	@Param			date	path		string							true	"Date (YYYY-MM-DD)"
+//	@Param			entry	body		services.UpdateDayEntryRequest	true	"Day entry update request"
+//	@Success		200		{object}	services.DayEntryResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/calendars/{id}/entries/{date} [put]
+func (h *DayEntryHandler) UpdateDayEntry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeEntriesWrite) {
+		return
+	}
+
+	// Extract calendar ID and date from URL path
+	calendarIDStr := extractIDFromPath(r.URL.Path, "/api/calendars/")
+	calendarID, err := uuid.Parse(calendarIDStr)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid calendar ID")
+		return
+	}
+	date := extractIDFromPath(r.URL.Path, "/api/calendars/"+calendarIDStr+"/entries/")
+
+	var req services.UpdateDayEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	entry, err := h.dayEntryService.UpdateDayEntry(r.Context(), userID, calendarID, date, req)
+	if err != nil {
+		writeDayEntryError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
+
+// DeleteDayEntry handles DELETE /api/calendars/{id}/entries/{date}
+//
+//	@Summary		Delete day entry
+//	@Description	Remove the entry recorded for a given day in a calendar (user must be an editor or owner)
+//	@Tags			entries
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path	string	true	"Calendar ID"
+//	@Param			date	path	string	true	"Date (YYYY-MM-DD)"
+//	@Success		204		"No Content"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/calendars/{id}/entries/{date} [delete]
+func (h *DayEntryHandler) DeleteDayEntry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeEntriesWrite) {
+		return
+	}
+
+	// Extract calendar ID and date from URL path
+	calendarIDStr := extractIDFromPath(r.URL.Path, "/api/calendars/")
+	calendarID, err := uuid.Parse(calendarIDStr)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid calendar ID")
+		return
+	}
+	date := extractIDFromPath(r.URL.Path, "/api/calendars/"+calendarIDStr+"/entries/")
+
+	if err := h.dayEntryService.DeleteDayEntry(r.Context(), userID, calendarID, date); err != nil {
+		writeDayEntryError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetDayEntriesByDateRange handles GET /api/entries
+//
+//	@Summary		Get day entries by date range
+//	@Description	Retrieve the entries of all calendars the user owns or is a member of between two dates (inclusive)
+//	@Tags			entries
+//	@Accept			json
+//	@Produce		json
+//	@Param			start	query		string	true	"Start date (YYYY-MM-DD)"
+//	@Param			end		query		string	true	"End date (YYYY-MM-DD)"
+//	@Success		200		{array}		services.DayEntryResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/entries [get]
+func (h *DayEntryHandler) GetDayEntriesByDateRange(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeEntriesRead) {
+		return
+	}
+
+	req := services.DateRangeRequest{
+		StartDate: r.URL.Query().Get("start"),
+		EndDate:   r.URL.Query().Get("end"),
+	}
+
+	entries, err := h.dayEntryService.GetDayEntriesByDateRange(r.Context(), userID, req)
+	if err != nil {
+		writeDayEntryError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// parseDayEntryListRequest reads the page and filters of an entry list from
+// the query string, writing a 400 response and returning false when one is
+// malformed. Color meanings may be repeated or comma-separated.
+func parseDayEntryListRequest(w http.ResponseWriter, r *http.Request) (services.DayEntryListRequest, bool) {
+	query := r.URL.Query()
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return services.DayEntryListRequest{}, false
+	}
+
+	req := services.DayEntryListRequest{
+		Limit:      limit,
+		Cursor:     query.Get("cursor"),
+		Order:      query.Get("order"),
+		StartDate:  query.Get("start"),
+		EndDate:    query.Get("end"),
+		NotesQuery: query.Get("notes"),
+	}
+	for _, value := range query["color_meaning_id"] {
+		for _, id := range strings.Split(value, ",") {
+			colorMeaningID, err := uuid.Parse(strings.TrimSpace(id))
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, "invalid color meaning ID")
+				return services.DayEntryListRequest{}, false
+			}
+			req.ColorMeaningIDs = append(req.ColorMeaningIDs, colorMeaningID)
+		}
+	}
+	if value := query.Get("has_notes"); value != "" {
+		hasNotes, err := strconv.ParseBool(value)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid has_notes")
+			return services.DayEntryListRequest{}, false
+		}
+		req.HasNotes = &hasNotes
+	}
+	return req, true
+}
+
+// writeDayEntryError maps day entry service errors to HTTP responses.
+// Some errors are wrapped by the service, hence errors.Is rather than ==.
+func writeDayEntryError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidDate),
+		errors.Is(err, services.ErrInvalidDateRange),
+		errors.Is(err, services.ErrInvalidCursor),
+		errors.Is(err, services.ErrInvalidSortOrder),
+		errors.Is(err, services.ErrColorMeaningMismatch),
+		errors.Is(err, services.ErrColorMeaningNotFound),
+		errors.Is(err, services.ErrUnauthorizedColorMeaning):
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrCalendarNotFound),
+		errors.Is(err, services.ErrDayEntryNotFound):
+		writeJSONError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrUnauthorizedCalendar):
+		writeJSONError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrDayEntryExists):
+		writeJSONError(w, http.StatusConflict, err.Error())
+	default:
+		writeInternalError(w, r, err)
+	}
+}
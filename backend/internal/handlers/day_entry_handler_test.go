@@ -0,0 +1,471 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockDayEntryService implements a mock for the DayEntryService
+type MockDayEntryService struct {
+	mock.Mock
+}
+
+func (m *MockDayEntryService) CreateDayEntry(ctx context.Context, userID, calendarID uuid.UUID, req services.CreateDayEntryRequest) (*services.DayEntryResponse, error) {
+	args := m.Called(ctx, userID, calendarID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.DayEntryResponse), args.Error(1)
+}
+
+func (m *MockDayEntryService) GetDayEntriesByCalendarID(ctx context.Context, userID, calendarID uuid.UUID, req services.DayEntryListRequest) (*services.DayEntryPage, error) {
+	args := m.Called(ctx, userID, calendarID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.DayEntryPage), args.Error(1)
+}
+
+func (m *MockDayEntryService) GetAllDayEntriesByCalendarID(ctx context.Context, userID, calendarID uuid.UUID, req services.DayEntryListRequest) ([]*services.DayEntryResponse, error) {
+	args := m.Called(ctx, userID, calendarID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*services.DayEntryResponse), args.Error(1)
+}
+
+func (m *MockDayEntryService) GetDayEntriesByDateRange(ctx context.Context, userID uuid.UUID, req services.DateRangeRequest) ([]*services.DayEntryResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*services.DayEntryResponse), args.Error(1)
+}
+
+func (m *MockDayEntryService) GetDayEntryByCalendarAndDate(ctx context.Context, userID, calendarID uuid.UUID, dateStr string) (*services.DayEntryResponse, error) {
+	args := m.Called(ctx, userID, calendarID, dateStr)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.DayEntryResponse), args.Error(1)
+}
+
+func (m *MockDayEntryService) UpdateDayEntry(ctx context.Context, userID, calendarID uuid.UUID, dateStr string, req services.UpdateDayEntryRequest) (*services.DayEntryResponse, error) {
+	args := m.Called(ctx, userID, calendarID, dateStr, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.DayEntryResponse), args.Error(1)
+}
+
+func (m *MockDayEntryService) DeleteDayEntry(ctx context.Context, userID, calendarID uuid.UUID, dateStr string) error {
+	args := m.Called(ctx, userID, calendarID, dateStr)
+	return args.Error(0)
+}
+
+func withUserID(r *http.Request, userID uuid.UUID) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), ctxUserIDKey, userID))
+}
+
+func TestDayEntryHandler_CreateDayEntry(t *testing.T) {
+	mockService := new(MockDayEntryService)
+	handler := NewDayEntryHandler(mockService)
+
+	userID := uuid.New()
+	calendarID := uuid.New()
+	colorMeaningID := uuid.New()
+	path := "/api/calendars/" + calendarID.String() + "/entries"
+
+	t.Run("successful creation", func(t *testing.T) {
+		req := services.CreateDayEntryRequest{
+			Date:           "2024-01-15",
+			ColorMeaningID: colorMeaningID,
+		}
+		expectedResponse := &services.DayEntryResponse{
+			ID:             uuid.New(),
+			CalendarID:     calendarID,
+			Date:           "2024-01-15",
+			ColorMeaningID: colorMeaningID,
+			ColorHex:       "#00FF00",
+			Meaning:        "relaxed",
+		}
+
+		mockService.On("CreateDayEntry", mock.Anything, userID, calendarID, req).Return(expectedResponse, nil).Once()
+
+		reqBody, _ := json.Marshal(req)
+		httpReq := withUserID(httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(reqBody)), userID)
+		w := httptest.NewRecorder()
+
+		handler.CreateDayEntry(w, httpReq)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var response services.DayEntryResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+		assert.Equal(t, expectedResponse.ID, response.ID)
+		assert.Equal(t, "2024-01-15", response.Date)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid calendar ID", func(t *testing.T) {
+		httpReq := withUserID(httptest.NewRequest(http.MethodPost, "/api/calendars/not-a-uuid/entries", nil), userID)
+		w := httptest.NewRecorder()
+
+		handler.CreateDayEntry(w, httpReq)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("missing user ID in context", func(t *testing.T) {
+		httpReq := httptest.NewRequest(http.MethodPost, path, nil)
+		w := httptest.NewRecorder()
+
+		handler.CreateDayEntry(w, httpReq)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	errorCases := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{"invalid date", services.ErrInvalidDate, http.StatusBadRequest},
+		{"entry already exists", services.ErrDayEntryExists, http.StatusConflict},
+		{"calendar not found", services.ErrCalendarNotFound, http.StatusNotFound},
+		{"calendar not owned", services.ErrUnauthorizedCalendar, http.StatusForbidden},
+		{"color meaning from another calendar", services.ErrColorMeaningMismatch, http.StatusBadRequest},
+		{"wrapped color meaning not found", fmt.Errorf("invalid color meaning: %w", services.ErrColorMeaningNotFound), http.StatusBadRequest},
+		{"unexpected error", fmt.Errorf("db down"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := services.CreateDayEntryRequest{
+				Date:           tc.name,
+				ColorMeaningID: colorMeaningID,
+			}
+
+			mockService.On("CreateDayEntry", mock.Anything, userID, calendarID, req).Return(nil, tc.err).Once()
+
+			reqBody, _ := json.Marshal(req)
+			httpReq := withUserID(httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(reqBody)), userID)
+			w := httptest.NewRecorder()
+
+			handler.CreateDayEntry(w, httpReq)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			var errorResp errorResponse
+			err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+			require.NoError(t, err)
+			assert.NotEmpty(t, errorResp.Error)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDayEntryHandler_DateRoutes(t *testing.T) {
+	mockService := new(MockDayEntryService)
+	handler := NewDayEntryHandler(mockService)
+
+	userID := uuid.New()
+	calendarID := uuid.New()
+	path := "/api/calendars/" + calendarID.String() + "/entries/2024-01-15"
+
+	t.Run("get entry by date", func(t *testing.T) {
+		expectedResponse := &services.DayEntryResponse{
+			ID:         uuid.New(),
+			CalendarID: calendarID,
+			Date:       "2024-01-15",
+		}
+
+		mockService.On("GetDayEntryByCalendarAndDate", mock.Anything, userID, calendarID, "2024-01-15").Return(expectedResponse, nil).Once()
+
+		httpReq := withUserID(httptest.NewRequest(http.MethodGet, path, nil), userID)
+		w := httptest.NewRecorder()
+
+		handler.GetDayEntry(w, httpReq)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response services.DayEntryResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+		assert.Equal(t, expectedResponse.ID, response.ID)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("get missing entry", func(t *testing.T) {
+		mockService.On("GetDayEntryByCalendarAndDate", mock.Anything, userID, calendarID, "2024-01-15").Return(nil, services.ErrDayEntryNotFound).Once()
+
+		httpReq := withUserID(httptest.NewRequest(http.MethodGet, path, nil), userID)
+		w := httptest.NewRecorder()
+
+		handler.GetDayEntry(w, httpReq)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		var errorResp errorResponse
+		err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+		require.NoError(t, err)
+		assert.Equal(t, services.ErrDayEntryNotFound.Error(), errorResp.Error)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("update entry", func(t *testing.T) {
+		req := services.UpdateDayEntryRequest{ColorMeaningID: uuid.New()}
+		expectedResponse := &services.DayEntryResponse{
+			ID:             uuid.New(),
+			CalendarID:     calendarID,
+			Date:           "2024-01-15",
+			ColorMeaningID: req.ColorMeaningID,
+		}
+
+		mockService.On("UpdateDayEntry", mock.Anything, userID, calendarID, "2024-01-15", req).Return(expectedResponse, nil).Once()
+
+		reqBody, _ := json.Marshal(req)
+		httpReq := withUserID(httptest.NewRequest(http.MethodPut, path, bytes.NewBuffer(reqBody)), userID)
+		w := httptest.NewRecorder()
+
+		handler.UpdateDayEntry(w, httpReq)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("delete entry", func(t *testing.T) {
+		mockService.On("DeleteDayEntry", mock.Anything, userID, calendarID, "2024-01-15").Return(nil).Once()
+
+		httpReq := withUserID(httptest.NewRequest(http.MethodDelete, path, nil), userID)
+		w := httptest.NewRecorder()
+
+		handler.DeleteDayEntry(w, httpReq)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestDayEntryHandler_GetDayEntries(t *testing.T) {
+	userID := uuid.New()
+	calendarID := uuid.New()
+	mood, sport := uuid.New(), uuid.New()
+	hasNotes := true
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func(*MockDayEntryService)
+		expectedStatus int
+		expectedLink   string
+		expectedPage   bool
+	}{
+		{
+			name:  "every entry without limit or cursor",
+			query: "?order=asc",
+			setupMock: func(m *MockDayEntryService) {
+				m.On("GetAllDayEntriesByCalendarID", mock.Anything, userID, calendarID, services.DayEntryListRequest{Order: "asc"}).
+					Return([]*services.DayEntryResponse{{ID: uuid.New()}}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "first page",
+			query: "?limit=1",
+			setupMock: func(m *MockDayEntryService) {
+				m.On("GetDayEntriesByCalendarID", mock.Anything, userID, calendarID, services.DayEntryListRequest{Limit: 1}).
+					Return(&services.DayEntryPage{Entries: []*services.DayEntryResponse{{ID: uuid.New()}}, NextCursor: "next"}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedLink:   `</api/calendars/` + calendarID.String() + `/entries?cursor=next&limit=1>; rel="next"`,
+			expectedPage:   true,
+		},
+		{
+			name:  "filters",
+			query: "?limit=10&order=asc&start=2024-01-01&end=2024-01-31&color_meaning_id=" + mood.String() + "," + sport.String() + "&has_notes=true&notes=walk",
+			setupMock: func(m *MockDayEntryService) {
+				m.On("GetDayEntriesByCalendarID", mock.Anything, userID, calendarID, services.DayEntryListRequest{
+					Limit:           10,
+					Order:           "asc",
+					StartDate:       "2024-01-01",
+					EndDate:         "2024-01-31",
+					ColorMeaningIDs: []uuid.UUID{mood, sport},
+					HasNotes:        &hasNotes,
+					NotesQuery:      "walk",
+				}).Return(&services.DayEntryPage{Entries: []*services.DayEntryResponse{}}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedPage:   true,
+		},
+		{
+			name:  "next page keeps the filters",
+			query: "?color_meaning_id=" + mood.String() + "&cursor=old&limit=1",
+			setupMock: func(m *MockDayEntryService) {
+				m.On("GetDayEntriesByCalendarID", mock.Anything, userID, calendarID, services.DayEntryListRequest{
+					Limit:           1,
+					Cursor:          "old",
+					ColorMeaningIDs: []uuid.UUID{mood},
+				}).Return(&services.DayEntryPage{Entries: []*services.DayEntryResponse{{ID: uuid.New()}}, NextCursor: "new"}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedLink:   `</api/calendars/` + calendarID.String() + `/entries?color_meaning_id=` + mood.String() + `&cursor=new&limit=1>; rel="next"`,
+			expectedPage:   true,
+		},
+		{
+			name:           "invalid limit",
+			query:          "?limit=abc",
+			setupMock:      func(m *MockDayEntryService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid color meaning",
+			query:          "?color_meaning_id=blue",
+			setupMock:      func(m *MockDayEntryService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid has_notes",
+			query:          "?has_notes=maybe",
+			setupMock:      func(m *MockDayEntryService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "invalid cursor",
+			query: "?cursor=garbage",
+			setupMock: func(m *MockDayEntryService) {
+				m.On("GetDayEntriesByCalendarID", mock.Anything, userID, calendarID, services.DayEntryListRequest{Cursor: "garbage"}).
+					Return(nil, services.ErrInvalidCursor).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "invalid order",
+			query: "?order=sideways",
+			setupMock: func(m *MockDayEntryService) {
+				m.On("GetAllDayEntriesByCalendarID", mock.Anything, userID, calendarID, services.DayEntryListRequest{Order: "sideways"}).
+					Return(nil, services.ErrInvalidSortOrder).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "not a member",
+			query: "",
+			setupMock: func(m *MockDayEntryService) {
+				m.On("GetAllDayEntriesByCalendarID", mock.Anything, userID, calendarID, services.DayEntryListRequest{}).
+					Return(nil, services.ErrUnauthorizedCalendar).Once()
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockDayEntryService)
+			tt.setupMock(mockService)
+			handler := NewDayEntryHandler(mockService)
+
+			req := withUserID(httptest.NewRequest(http.MethodGet, "/api/calendars/"+calendarID.String()+"/entries"+tt.query, nil), userID)
+			w := httptest.NewRecorder()
+
+			handler.GetDayEntries(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedLink, w.Header().Get("Link"))
+			if tt.expectedStatus == http.StatusOK {
+				// Pages are objects; without limit or cursor the entries come as a bare array
+				assert.Equal(t, tt.expectedPage, strings.HasPrefix(w.Body.String(), "{"))
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDayEntryHandler_GetDayEntriesByDateRange(t *testing.T) {
+	mockService := new(MockDayEntryService)
+	handler := NewDayEntryHandler(mockService)
+
+	userID := uuid.New()
+
+	t.Run("passes query parameters to the service", func(t *testing.T) {
+		req := services.DateRangeRequest{StartDate: "2024-01-01", EndDate: "2024-01-31"}
+		expected := []*services.DayEntryResponse{{ID: uuid.New(), Date: "2024-01-15"}}
+
+		mockService.On("GetDayEntriesByDateRange", mock.Anything, userID, req).Return(expected, nil).Once()
+
+		httpReq := withUserID(httptest.NewRequest(http.MethodGet, "/api/entries?start=2024-01-01&end=2024-01-31", nil), userID)
+		w := httptest.NewRecorder()
+
+		handler.GetDayEntriesByDateRange(w, httpReq)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response []services.DayEntryResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+		assert.Len(t, response, 1)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("end before start", func(t *testing.T) {
+		req := services.DateRangeRequest{StartDate: "2024-02-01", EndDate: "2024-01-01"}
+
+		mockService.On("GetDayEntriesByDateRange", mock.Anything, userID, req).Return(nil, services.ErrInvalidDateRange).Once()
+
+		httpReq := withUserID(httptest.NewRequest(http.MethodGet, "/api/entries?start=2024-02-01&end=2024-01-01", nil), userID)
+		w := httptest.NewRecorder()
+
+		handler.GetDayEntriesByDateRange(w, httpReq)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestServer_CalendarEntriesRouting(t *testing.T) {
+	mockService := new(MockDayEntryService)
+	server := &Server{dayEntryHandler: NewDayEntryHandler(mockService)}
+
+	userID := uuid.New()
+	calendarID := uuid.New()
+
+	mockService.On("GetAllDayEntriesByCalendarID", mock.Anything, userID, calendarID, services.DayEntryListRequest{}).
+		Return([]*services.DayEntryResponse{}, nil).Once()
+
+	httpReq := withUserID(httptest.NewRequest(http.MethodGet, "/api/calendars/"+calendarID.String()+"/entries", nil), userID)
+	w := httptest.NewRecorder()
+	server.handleCalendarByID(w, httpReq)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	httpReq = withUserID(httptest.NewRequest(http.MethodGet, "/api/calendars/"+calendarID.String()+"/unknown", nil), userID)
+	w = httptest.NewRecorder()
+	server.handleCalendarByID(w, httpReq)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	httpReq = withUserID(httptest.NewRequest(http.MethodPatch, "/api/calendars/"+calendarID.String()+"/entries/2024-01-15", nil), userID)
+	w = httptest.NewRecorder()
+	server.handleCalendarByID(w, httpReq)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	mockService.AssertExpectations(t)
+}
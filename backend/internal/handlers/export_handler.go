@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+)
+
+type ExportHandler struct {
+	exportService services.ExportServiceInterface
+}
+
+func NewExportHandler(exportService services.ExportServiceInterface) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// Export handles GET /api/export
+//
+//	@Summary		Export user data
+//	@Description	Download all calendars, color meanings and day entries of the authenticated user. JSON is a single document with a schema version; CSV is a zip archive with one file per table and a manifest.
+//	@Tags			export
+//	@Produce		json
+//	@Produce		application/zip
+//	@Param			format	query		string	false	"Export format"	Enums(json, csv)	default(json)
+//	@Success		200		{object}	services.ExportDocument
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/export [get]
+func (h *ExportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeCalendarsRead, services.ScopeEntriesRead) {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = services.ExportFormatJSON
+	}
+
+	var contentType, extension string
+	switch format {
+	case services.ExportFormatJSON:
+		contentType, extension = "application/json", "json"
+	case services.ExportFormatCSV:
+		contentType, extension = "application/zip", "zip"
+	default:
+		writeJSONError(w, http.StatusBadRequest, services.ErrInvalidExportFormat.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("days-export-%s.%s", time.Now().UTC().Format("2006-01-02"), extension)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	body := &startedWriter{w: w}
+	if err := h.exportService.Export(r.Context(), userID, format, body); err != nil {
+		if !body.started {
+			w.Header().Del("Content-Disposition")
+			writeInternalError(w, r, err)
+			return
+		}
+		// The status line is gone already; cutting the stream short leaves the
+		// client with a truncated document or archive it will fail to read
+		slog.ErrorContext(r.Context(), "export failed mid-stream", "format", format, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// startedWriter records whether anything has been written to the response
+type startedWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func (sw *startedWriter) Write(p []byte) (int, error) {
+	sw.started = true
+	return sw.w.Write(p)
+}
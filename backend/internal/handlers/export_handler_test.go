@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockExportService implements a mock for the ExportService
+type MockExportService struct {
+	mock.Mock
+}
+
+func (m *MockExportService) Export(ctx context.Context, userID uuid.UUID, format string, w io.Writer) error {
+	args := m.Called(ctx, userID, format, w)
+	if body := args.String(0); body != "" {
+		io.WriteString(w, body)
+	}
+	return args.Error(1)
+}
+
+func TestExportHandler_Export(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name                string
+		query               string
+		format              string
+		body                string
+		serviceErr          error
+		expectedStatus      int
+		expectedContentType string
+		attachment          bool
+	}{
+		{"json by default", "", services.ExportFormatJSON, `{"schema_version":1}`, nil, http.StatusOK, "application/json", true},
+		{"csv as zip", "?format=csv", services.ExportFormatCSV, "PK", nil, http.StatusOK, "application/zip", true},
+		{"unknown format", "?format=xml", "", "", nil, http.StatusBadRequest, "application/json", false},
+		{"failure before streaming", "?format=csv", services.ExportFormatCSV, "", assert.AnError, http.StatusInternalServerError, "application/json", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockExportService)
+			handler := NewExportHandler(mockService)
+
+			if tt.format != "" {
+				mockService.On("Export", mock.Anything, userID, tt.format, mock.Anything).Return(tt.body, tt.serviceErr).Once()
+			}
+
+			req := withUserID(httptest.NewRequest(http.MethodGet, "/api/export"+tt.query, nil), userID)
+			w := httptest.NewRecorder()
+			handler.Export(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedContentType, w.Header().Get("Content-Type"))
+			if tt.attachment {
+				assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment; filename=\"days-export-")
+				assert.Equal(t, tt.body, w.Body.String())
+			} else {
+				assert.Empty(t, w.Header().Get("Content-Disposition"))
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+)
+
+type ICalHandler struct {
+	icalService services.ICalServiceInterface
+}
+
+func NewICalHandler(icalService services.ICalServiceInterface) *ICalHandler {
+	return &ICalHandler{
+		icalService: icalService,
+	}
+}
+
+// GetCalendarFeed handles GET /api/calendars/{id}/ical
+//
+//	@Summary		Calendar iCalendar feed
+//	@Description	Subscribe to a calendar from apps such as Google Calendar or Thunderbird. Each day entry is an all-day event named after its meaning with the notes as description. Authenticated by the calendar's feed token instead of a Bearer header.
+//	@Tags			calendars
+//	@Produce		text/calendar
+//	@Param			id		path		string	true	"Calendar ID"
+//	@Param			token	query		string	true	"Feed token"
+//	@Success		200		{file}		file
+//	@Success		304		"Not Modified"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/api/calendars/{id}/ical [get]
+func (h *ICalHandler) GetCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract calendar ID from URL path
+	calendarID, err := uuid.Parse(extractIDFromPath(r.URL.Path, "/api/calendars/"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid calendar ID")
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		writeJSONError(w, http.StatusNotFound, "calendar feed not found")
+		return
+	}
+
+	feed, err := h.icalService.GetCalendarFeed(r.Context(), calendarID, token)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidFeedToken), errors.Is(err, services.ErrCalendarNotFound):
+			// Wrong tokens and missing calendars look the same to callers
+			writeJSONError(w, http.StatusNotFound, "calendar feed not found")
+		default:
+			writeInternalError(w, r, err)
+		}
+		return
+	}
+
+	// Calendar apps poll feeds, so let them revalidate cheaply
+	etag := etagFor(feed)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(len(feed)))
+	w.Write(feed)
+}
+
+// CreateFeedToken handles POST /api/calendars/{id}/ical/token
+//
+//	@Summary		Create calendar feed token
+//	@Description	Enable the iCalendar feed of a calendar (user must own the calendar). Any earlier token stops working. The token is returned only once.
+//	@Tags			calendars
+//	@Produce		json
+//	@Param			id	path		string	true	"Calendar ID"
+//	@Success		201	{object}	services.FeedTokenResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/calendars/{id}/ical/token [post]
+func (h *ICalHandler) CreateFeedToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeCalendarsWrite) {
+		return
+	}
+
+	// Extract calendar ID from URL path
+	calendarID, err := uuid.Parse(extractIDFromPath(r.URL.Path, "/api/calendars/"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid calendar ID")
+		return
+	}
+
+	feedToken, err := h.icalService.CreateFeedToken(r.Context(), userID, calendarID)
+	if err != nil {
+		writeFeedTokenError(w, r, err)
+		return
+	}
+
+	feedURL := url.URL{
+		Scheme:   "http",
+		Host:     r.Host,
+		Path:     "/api/calendars/" + calendarID.String() + "/ical",
+		RawQuery: url.Values{"token": {feedToken.Token}}.Encode(),
+	}
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		feedURL.Scheme = "https"
+	}
+	feedToken.URL = feedURL.String()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(feedToken)
+}
+
+// RevokeFeedToken handles DELETE /api/calendars/{id}/ical/token
+//
+//	@Summary		Revoke calendar feed token
+//	@Description	Disable the iCalendar feed of a calendar (user must own the calendar)
+//	@Tags			calendars
+//	@Param			id	path	string	true	"Calendar ID"
+//	@Success		204	"No Content"
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/calendars/{id}/ical/token [delete]
+func (h *ICalHandler) RevokeFeedToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeCalendarsWrite) {
+		return
+	}
+
+	// Extract calendar ID from URL path
+	calendarID, err := uuid.Parse(extractIDFromPath(r.URL.Path, "/api/calendars/"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid calendar ID")
+		return
+	}
+
+	if err := h.icalService.RevokeFeedToken(r.Context(), userID, calendarID); err != nil {
+		writeFeedTokenError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeFeedTokenError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrCalendarNotFound), errors.Is(err, services.ErrFeedTokenNotFound):
+		writeJSONError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrUnauthorizedCalendar):
+		writeJSONError(w, http.StatusForbidden, err.Error())
+	default:
+		writeInternalError(w, r, err)
+	}
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockICalService implements a mock for the ICalService
+type MockICalService struct {
+	mock.Mock
+}
+
+func (m *MockICalService) CreateFeedToken(ctx context.Context, userID, calendarID uuid.UUID) (*services.FeedTokenResponse, error) {
+	args := m.Called(ctx, userID, calendarID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.FeedTokenResponse), args.Error(1)
+}
+
+func (m *MockICalService) RevokeFeedToken(ctx context.Context, userID, calendarID uuid.UUID) error {
+	args := m.Called(ctx, userID, calendarID)
+	return args.Error(0)
+}
+
+func (m *MockICalService) GetCalendarFeed(ctx context.Context, calendarID uuid.UUID, token string) ([]byte, error) {
+	args := m.Called(ctx, calendarID, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func TestICalHandler_GetCalendarFeed(t *testing.T) {
+	calendarID := uuid.New()
+	feed := []byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n")
+
+	tests := []struct {
+		name           string
+		path           string
+		token          string
+		serviceResult  []byte
+		serviceErr     error
+		ifNoneMatch    string
+		expectedStatus int
+	}{
+		{"feed", calendarID.String(), "secret", feed, nil, "", http.StatusOK},
+		{"unchanged feed", calendarID.String(), "secret", feed, nil, etagFor(feed), http.StatusNotModified},
+		{"missing token", calendarID.String(), "", nil, nil, "", http.StatusNotFound},
+		{"wrong token", calendarID.String(), "guess", nil, services.ErrInvalidFeedToken, "", http.StatusNotFound},
+		{"deleted calendar", calendarID.String(), "secret", nil, services.ErrCalendarNotFound, "", http.StatusNotFound},
+		{"invalid calendar ID", "nope", "secret", nil, nil, "", http.StatusBadRequest},
+		{"database failure", calendarID.String(), "secret", nil, assert.AnError, "", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockICalService)
+			handler := NewICalHandler(mockService)
+
+			if tt.serviceResult != nil || tt.serviceErr != nil {
+				var result any = tt.serviceResult
+				if tt.serviceResult == nil {
+					result = nil
+				}
+				mockService.On("GetCalendarFeed", mock.Anything, calendarID, tt.token).Return(result, tt.serviceErr).Once()
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/calendars/"+tt.path+"/ical?token="+tt.token, nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+			handler.GetCalendarFeed(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+				assert.Equal(t, etagFor(feed), w.Header().Get("ETag"))
+				assert.Equal(t, feed, w.Body.Bytes())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestICalHandler_CreateFeedToken(t *testing.T) {
+	userID := uuid.New()
+	calendarID := uuid.New()
+
+	t.Run("returns a subscription URL", func(t *testing.T) {
+		mockService := new(MockICalService)
+		handler := NewICalHandler(mockService)
+		mockService.On("CreateFeedToken", mock.Anything, userID, calendarID).
+			Return(&services.FeedTokenResponse{CalendarID: calendarID, Token: "abc-_123"}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/calendars/"+calendarID.String()+"/ical/token", nil)
+		req.Host = "days.example.com"
+		req.Header.Set("X-Forwarded-Proto", "https")
+		w := httptest.NewRecorder()
+		handler.CreateFeedToken(w, withUserID(req, userID))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response services.FeedTokenResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "https://days.example.com/api/calendars/"+calendarID.String()+"/ical?token=abc-_123", response.URL)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("other users' calendars", func(t *testing.T) {
+		mockService := new(MockICalService)
+		handler := NewICalHandler(mockService)
+		mockService.On("CreateFeedToken", mock.Anything, userID, calendarID).Return(nil, services.ErrUnauthorizedCalendar).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/calendars/"+calendarID.String()+"/ical/token", nil)
+		w := httptest.NewRecorder()
+		handler.CreateFeedToken(w, withUserID(req, userID))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestICalHandler_RevokeFeedToken(t *testing.T) {
+	userID := uuid.New()
+	calendarID := uuid.New()
+
+	tests := []struct {
+		name           string
+		serviceErr     error
+		expectedStatus int
+	}{
+		{"revoked", nil, http.StatusNoContent},
+		{"feed not enabled", services.ErrFeedTokenNotFound, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockICalService)
+			handler := NewICalHandler(mockService)
+			mockService.On("RevokeFeedToken", mock.Anything, userID, calendarID).Return(tt.serviceErr).Once()
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/calendars/"+calendarID.String()+"/ical/token", nil)
+			w := httptest.NewRecorder()
+			handler.RevokeFeedToken(w, withUserID(req, userID))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_CalendarFeedRouting(t *testing.T) {
+	mockService := new(MockICalService)
+	server := &Server{icalHandler: NewICalHandler(mockService)}
+	mux := server.SetupRoutes()
+
+	userID := uuid.New()
+	calendarID := uuid.New()
+	feedPath := "/api/calendars/" + calendarID.String() + "/ical"
+
+	// The feed is reachable without a session
+	mockService.On("GetCalendarFeed", mock.Anything, calendarID, "secret").Return([]byte("BEGIN:VCALENDAR\r\n"), nil).Once()
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, feedPath+"?token=secret", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Token management goes through the calendar routes
+	mockService.On("RevokeFeedToken", mock.Anything, userID, calendarID).Return(nil).Once()
+	w = httptest.NewRecorder()
+	server.handleCalendarByID(w, withUserID(httptest.NewRequest(http.MethodDelete, feedPath+"/token", nil), userID))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	server.handleCalendarByID(w, withUserID(httptest.NewRequest(http.MethodGet, feedPath+"/token", nil), userID))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = httptest.NewRecorder()
+	server.handleCalendarByID(w, withUserID(httptest.NewRequest(http.MethodGet, feedPath+"/other", nil), userID))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockService.AssertExpectations(t)
+}
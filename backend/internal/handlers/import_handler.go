@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+)
+
+type ImportHandler struct {
+	importService services.ImportServiceInterface
+}
+
+func NewImportHandler(importService services.ImportServiceInterface) *ImportHandler {
+	return &ImportHandler{
+		importService: importService,
+	}
+}
+
+// Import handles POST /api/import
+//
+//	@Summary		Import user data
+//	@Description	Recreate calendars, color meanings and day entries from a file produced by GET /api/export, in a single transaction. The format is taken from the format parameter, or else from the Content-Type (application/zip means csv). A dry run reports what would be imported without keeping anything.
+//	@Tags			export
+//	@Accept			json
+//	@Accept			application/zip
+//	@Produce		json
+//	@Param			file				body		services.ExportDocument	true	"Export document or zipped CSV archive"
+//	@Param			format				query		string					false	"Import format"											Enums(json, csv)
+//	@Param			dry_run				query		bool					false	"Report what would be imported without importing it"
+//	@Param			calendar_conflict	query		string					false	"What to do with calendars whose name is taken"		Enums(skip, rename, merge)	default(skip)
+//	@Param			entry_conflict		query		string					false	"What to do with entries of merged calendars on dates that have one"	Enums(keep, overwrite)	default(keep)
+//	@Success		200					{object}	services.ImportResult	"Dry run"
+//	@Success		201					{object}	services.ImportResult
+//	@Failure		400					{object}	ErrorResponse
+//	@Failure		401					{object}	ErrorResponse
+//	@Failure		413					{object}	ErrorResponse
+//	@Failure		500					{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/import [post]
+func (h *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeCalendarsWrite, services.ScopeEntriesWrite) {
+		return
+	}
+
+	query := r.URL.Query()
+	opts := services.ImportOptions{
+		Format:           query.Get("format"),
+		CalendarConflict: query.Get("calendar_conflict"),
+		EntryConflict:    query.Get("entry_conflict"),
+	}
+	if opts.Format == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case "application/zip", "application/x-zip-compressed":
+			opts.Format = services.ExportFormatCSV
+		default:
+			opts.Format = services.ExportFormatJSON
+		}
+	}
+	if dryRun := query.Get("dry_run"); dryRun != "" {
+		var err error
+		if opts.DryRun, err = strconv.ParseBool(dryRun); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "dry_run must be true or false")
+			return
+		}
+	}
+
+	result, err := h.importService.Import(r.Context(), userID, r.Body, opts)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			writeJSONError(w, http.StatusRequestEntityTooLarge, "import file too large")
+		case errors.Is(err, services.ErrInvalidImport), errors.Is(err, services.ErrUnsupportedSchemaVersion),
+			errors.Is(err, services.ErrInvalidImportFormat), errors.Is(err, services.ErrInvalidCalendarConflict),
+			errors.Is(err, services.ErrInvalidEntryConflict):
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+		default:
+			writeInternalError(w, r, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if result.DryRun {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(result)
+}
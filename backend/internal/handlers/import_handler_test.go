@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockImportService implements a mock for the ImportService
+type MockImportService struct {
+	mock.Mock
+}
+
+func (m *MockImportService) Import(ctx context.Context, userID uuid.UUID, r io.Reader, opts services.ImportOptions) (*services.ImportResult, error) {
+	args := m.Called(ctx, userID, r, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.ImportResult), args.Error(1)
+}
+
+func TestImportHandler_Import(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name           string
+		query          string
+		contentType    string
+		expectedOpts   *services.ImportOptions
+		result         *services.ImportResult
+		serviceErr     error
+		expectedStatus int
+	}{
+		{
+			name:           "json import",
+			contentType:    "application/json",
+			expectedOpts:   &services.ImportOptions{Format: services.ExportFormatJSON},
+			result:         &services.ImportResult{Summary: services.ImportSummary{CalendarsCreated: 1}},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "zip body is read as csv",
+			query:          "?calendar_conflict=merge&entry_conflict=overwrite",
+			contentType:    "application/zip",
+			expectedOpts:   &services.ImportOptions{Format: services.ExportFormatCSV, CalendarConflict: "merge", EntryConflict: "overwrite"},
+			result:         &services.ImportResult{},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "dry run",
+			query:          "?dry_run=true&format=csv",
+			expectedOpts:   &services.ImportOptions{Format: services.ExportFormatCSV, DryRun: true},
+			result:         &services.ImportResult{DryRun: true},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid dry run flag",
+			query:          "?dry_run=maybe",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid file",
+			expectedOpts:   &services.ImportOptions{Format: services.ExportFormatJSON},
+			serviceErr:     fmt.Errorf("%w: calendar 1: bad color", services.ErrInvalidImport),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown strategy",
+			query:          "?calendar_conflict=replace",
+			expectedOpts:   &services.ImportOptions{Format: services.ExportFormatJSON, CalendarConflict: "replace"},
+			serviceErr:     services.ErrInvalidCalendarConflict,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "body too large",
+			expectedOpts:   &services.ImportOptions{Format: services.ExportFormatJSON},
+			serviceErr:     fmt.Errorf("failed to read import: %w", &http.MaxBytesError{Limit: 1}),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "database failure",
+			expectedOpts:   &services.ImportOptions{Format: services.ExportFormatJSON},
+			serviceErr:     assert.AnError,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockImportService)
+			handler := NewImportHandler(mockService)
+
+			if tt.expectedOpts != nil {
+				var result any = tt.result
+				if tt.result == nil {
+					result = nil
+				}
+				mockService.On("Import", mock.Anything, userID, mock.Anything, *tt.expectedOpts).Return(result, tt.serviceErr).Once()
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/import"+tt.query, strings.NewReader("{}"))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			handler.Import(w, withUserID(req, userID))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.result != nil {
+				var response services.ImportResult
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, *tt.result, response)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestImportHandler_MethodNotAllowed(t *testing.T) {
+	handler := NewImportHandler(new(MockImportService))
+
+	w := httptest.NewRecorder()
+	handler.Import(w, withUserID(httptest.NewRequest(http.MethodGet, "/api/import", nil), uuid.New()))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"days/internal/auth"
+)
+
+// KeySetPublisher returns the public keys access tokens are verified with
+type KeySetPublisher interface {
+	JWKS() auth.JWKS
+}
+
+type JWKSHandler struct {
+	keys KeySetPublisher
+}
+
+func NewJWKSHandler(keys KeySetPublisher) *JWKSHandler {
+	return &JWKSHandler{
+		keys: keys,
+	}
+}
+
+// GetJWKS handles GET /.well-known/jwks.json
+//
+//	@Summary		Get token signing keys
+//	@Description	The public keys of the RS256 and EdDSA keys access tokens are signed with, as a JSON web key set. Other services verify Days tokens with the key whose kid matches the token's header. Keys stay listed after they stop signing until the tokens they signed have expired. HS256 secrets are never published, so the set is empty while tokens are signed with JWT_SECRET.
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	auth.JWKS
+//	@Router			/.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	set := auth.JWKS{Keys: []auth.JWK{}}
+	if h.keys != nil {
+		set = h.keys.JWKS()
+	}
+
+	// Verifiers refetch on an unknown kid, so a short cache is enough for rotations
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(set)
+}
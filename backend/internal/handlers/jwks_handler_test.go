@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"days/internal/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_JWKS(t *testing.T) {
+	key, err := auth.GenerateKey(auth.AlgorithmEdDSA)
+	require.NoError(t, err)
+	keys, err := auth.NewKeyring(key, auth.NewHMACKey("not-for-publishing"))
+	require.NoError(t, err)
+
+	server := &Server{jwksHandler: NewJWKSHandler(keys), tokens: keys}
+	mux := server.SetupRoutes()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+	var set auth.JWKS
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &set))
+	require.Len(t, set.Keys, 1)
+	assert.Equal(t, key.ID, set.Keys[0].Kid)
+	assert.Equal(t, "EdDSA", set.Keys[0].Alg)
+	assert.NotContains(t, w.Body.String(), "not-for-publishing")
+}
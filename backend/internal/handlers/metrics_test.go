@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"days/internal/metrics"
+	"days/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type observedRequest struct {
+	method, route string
+	status        int
+}
+
+type observedLogin struct {
+	method, result string
+}
+
+// stubRecorder keeps what it is told to record
+type stubRecorder struct {
+	requests []observedRequest
+	logins   []observedLogin
+}
+
+func (s *stubRecorder) ObserveRequest(method, route string, status int, _ time.Duration) {
+	s.requests = append(s.requests, observedRequest{method, route, status})
+}
+
+func (s *stubRecorder) ObserveLogin(method, result string) {
+	s.logins = append(s.logins, observedLogin{method, result})
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	recorder := &stubRecorder{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/calendars/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	handler := MetricsMiddleware(recorder, mux)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/calendars/123", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/nowhere", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("FOO1", "/api/calendars/123", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("FOO2", "/nowhere", nil))
+
+	assert.Equal(t, []observedRequest{
+		{http.MethodGet, "/api/calendars/", http.StatusAccepted},
+		{http.MethodPost, "unmatched", http.StatusNotFound},
+		{"other", "/api/calendars/", http.StatusAccepted},
+		{"other", "unmatched", http.StatusNotFound},
+	}, recorder.requests)
+}
+
+func TestMetricsMiddleware_WithoutRecorder(t *testing.T) {
+	next := http.NotFoundHandler()
+	w := httptest.NewRecorder()
+	MetricsMiddleware(nil, next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestLoginResult(t *testing.T) {
+	tests := []struct {
+		status   int
+		expected string
+	}{
+		{http.StatusOK, metrics.LoginSuccess},
+		{http.StatusBadRequest, metrics.LoginFailure},
+		{http.StatusUnauthorized, metrics.LoginFailure},
+		{http.StatusForbidden, metrics.LoginFailure},
+		{http.StatusTooManyRequests, metrics.LoginLocked},
+		{http.StatusInternalServerError, metrics.LoginError},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			assert.Equal(t, tt.expected, loginResult(tt.status))
+		})
+	}
+}
+
+func TestServer_LoginMetrics(t *testing.T) {
+	mockService := new(MockUserService)
+	recorder := &stubRecorder{}
+	server := &Server{userHandler: NewUserHandler(mockService), metrics: recorder}
+	mux := server.SetupRoutes()
+
+	mockService.On("Login", mock.Anything, mock.Anything).Return(&services.LoginResponse{Token: "access"}, nil).Once()
+	mockService.On("Login", mock.Anything, mock.Anything).Return(nil, services.ErrInvalidCredentials).Once()
+	mockService.On("Login", mock.Anything, mock.Anything).Return(nil, &services.AccountLockedError{RetryAfter: time.Minute}).Once()
+
+	for range 3 {
+		body := bytes.NewBufferString(`{"email":"user@example.com","password":"password123"}`)
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/auth/login", body))
+	}
+
+	// Preflight requests are not login attempts
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodOptions, "/api/auth/login", nil))
+
+	assert.Equal(t, []observedLogin{
+		{metrics.LoginPassword, metrics.LoginSuccess},
+		{metrics.LoginPassword, metrics.LoginFailure},
+		{metrics.LoginPassword, metrics.LoginLocked},
+	}, recorder.logins)
+	mockService.AssertExpectations(t)
+}
@@ -3,20 +3,47 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
 	"net/http"
-	"os"
+	"net/netip"
+	"slices"
 	"strings"
 	"time"
 
-	"days/internal/auth"
+	"days/internal/audit"
+	"days/internal/logging"
+	"days/internal/services"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // typed context key to avoid collisions
 type ctxKey string
 
-const ctxUserIDKey ctxKey = "userID"
+const (
+	ctxUserIDKey    ctxKey = "userID"
+	ctxSessionIDKey ctxKey = "sessionID"
+	ctxScopesKey    ctxKey = "scopes"
+	ctxClientIPKey  ctxKey = "clientIP"
+)
+
+// TokenVerifier checks the signature and expiry of access tokens
+type TokenVerifier interface {
+	ParseSessionToken(token string) (uuid.UUID, uuid.UUID, error)
+}
+
+// SessionChecker reports whether the session an access token was issued for is still active
+type SessionChecker interface {
+	IsSessionActive(ctx context.Context, sessionID uuid.UUID) (bool, error)
+}
+
+// APIKeyAuthenticator resolves a personal API key to the user it acts for and the scopes it grants
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*services.APIKeyPrincipal, error)
+}
 
 type errorResponse struct {
 	Error string `json:"error"`
@@ -28,8 +55,33 @@ func writeJSONError(w http.ResponseWriter, status int, msg string) {
 	_ = json.NewEncoder(w).Encode(errorResponse{Error: msg})
 }
 
+// writeInternalError logs the cause of a failed request, records it on the
+// request's span, and answers 500 without revealing it
+func writeInternalError(w http.ResponseWriter, r *http.Request, err error) {
+	slog.ErrorContext(r.Context(), "request failed", "error", err)
+	trace.SpanFromContext(r.Context()).RecordError(err)
+	writeJSONError(w, http.StatusInternalServerError, "internal server error")
+}
+
 // AuthMiddleware validates JWT Bearer tokens and injects user ID into context
-func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+func AuthMiddleware(tokens TokenVerifier, next http.HandlerFunc) http.HandlerFunc {
+	return authenticate(tokens, nil, nil, next)
+}
+
+// SessionAuthMiddleware works like AuthMiddleware but only accepts tokens bound to
+// a session, and rejects them once that session has been revoked or has expired
+func SessionAuthMiddleware(tokens TokenVerifier, sessions SessionChecker, next http.HandlerFunc) http.HandlerFunc {
+	return authenticate(tokens, sessions, nil, next)
+}
+
+// APIKeyAuthMiddleware works like SessionAuthMiddleware but also accepts personal
+// API keys. Requests made with a key are limited to its scopes, which handlers
+// check with requireScope.
+func APIKeyAuthMiddleware(tokens TokenVerifier, sessions SessionChecker, apiKeys APIKeyAuthenticator, next http.HandlerFunc) http.HandlerFunc {
+	return authenticate(tokens, sessions, apiKeys, next)
+}
+
+func authenticate(tokens TokenVerifier, sessions SessionChecker, apiKeys APIKeyAuthenticator, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
@@ -46,23 +98,76 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			writeJSONError(w, http.StatusInternalServerError, "server misconfigured: missing JWT secret")
+		if apiKeys != nil && strings.HasPrefix(token, services.APIKeyPrefix) {
+			principal, err := apiKeys.AuthenticateAPIKey(r.Context(), token)
+			if err != nil {
+				if errors.Is(err, services.ErrInvalidAPIKey) {
+					writeJSONError(w, http.StatusUnauthorized, err.Error())
+				} else {
+					writeInternalError(w, r, err)
+				}
+				return
+			}
+			logging.SetUserID(r.Context(), principal.UserID.String())
+			ctx := context.WithValue(r.Context(), ctxUserIDKey, principal.UserID)
+			ctx = context.WithValue(ctx, ctxScopesKey, principal.Scopes)
+			ctx = audit.WithClient(ctx, r.UserAgent(), clientIP(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		if tokens == nil {
+			writeJSONError(w, http.StatusInternalServerError, "server misconfigured: missing JWT keys")
 			return
 		}
 
-		userID, err := auth.ParseToken(token, secret)
+		userID, sessionID, err := tokens.ParseSessionToken(token)
 		if err != nil || userID == uuid.Nil {
 			writeJSONError(w, http.StatusUnauthorized, "invalid or expired token")
 			return
 		}
 
+		if sessions != nil {
+			if sessionID == uuid.Nil {
+				writeJSONError(w, http.StatusUnauthorized, "invalid or expired token")
+				return
+			}
+			active, err := sessions.IsSessionActive(r.Context(), sessionID)
+			if err != nil {
+				writeInternalError(w, r, err)
+				return
+			}
+			if !active {
+				writeJSONError(w, http.StatusUnauthorized, "session has been revoked")
+				return
+			}
+		}
+
+		logging.SetUserID(r.Context(), userID.String())
 		ctx := context.WithValue(r.Context(), ctxUserIDKey, userID)
+		ctx = context.WithValue(ctx, ctxSessionIDKey, sessionID)
+		ctx = audit.WithClient(ctx, r.UserAgent(), clientIP(r))
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 }
 
+// requireScope reports whether a request may go on, answering 403 when it was
+// made with an API key lacking one of the scopes. Access tokens act with the
+// user's full rights.
+func requireScope(w http.ResponseWriter, r *http.Request, scopes ...string) bool {
+	granted, ok := r.Context().Value(ctxScopesKey).([]string)
+	if !ok {
+		return true
+	}
+	for _, scope := range scopes {
+		if !services.HasScope(granted, scope) {
+			writeJSONError(w, http.StatusForbidden, "API key lacks the "+scope+" scope")
+			return false
+		}
+	}
+	return true
+}
+
 // MaxBodyBytes limits the size of request bodies to prevent DoS via large payloads
 func MaxBodyBytes(n int64, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -71,14 +176,20 @@ func MaxBodyBytes(n int64, next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-// CORS middleware for development (restrict in production via env)
-func CORSMiddleware(next http.HandlerFunc) http.HandlerFunc {
+// CORSMiddleware lets browsers on the allowed origins call next. An origin
+// of "*" allows any; otherwise the request's Origin is echoed back when it is
+// one of origins.
+func CORSMiddleware(origins []string, next http.HandlerFunc) http.HandlerFunc {
+	allowAny := len(origins) == 0 || slices.Contains(origins, "*")
 	return func(w http.ResponseWriter, r *http.Request) {
-		allowOrigin := os.Getenv("CORS_ALLOW_ORIGIN")
-		if allowOrigin == "" {
-			allowOrigin = "*"
+		if allowAny {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Add("Vary", "Origin")
+			if origin := r.Header.Get("Origin"); slices.Contains(origins, origin) {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
 		}
-		w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
@@ -99,3 +210,104 @@ func WithTimeout(d time.Duration, next http.HandlerFunc) http.HandlerFunc {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 }
+
+// bulkRoutes stream a whole account out or in, which can take far longer
+// than any other request
+var bulkRoutes = map[string]bool{
+	"/api/export": true,
+	"/api/import": true,
+}
+
+// TimeoutMiddleware gives every request a deadline of timeout, except bulk
+// routes, which get bulkTimeout. Their connection's read and write deadlines
+// move to match, or the server's ReadTimeout and WriteTimeout would still
+// cut an upload or download short.
+func TimeoutMiddleware(timeout, bulkTimeout time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !bulkRoutes[r.URL.Path] {
+			WithTimeout(timeout, next.ServeHTTP)(w, r)
+			return
+		}
+
+		// Writers without a connection, such as test recorders, have no
+		// deadlines to move
+		deadline := time.Now().Add(bulkTimeout)
+		rc := http.NewResponseController(w)
+		if err := rc.SetReadDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			slog.WarnContext(r.Context(), "failed to extend read deadline", "error", err)
+		}
+		if err := rc.SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			slog.WarnContext(r.Context(), "failed to extend write deadline", "error", err)
+		}
+		WithTimeout(bulkTimeout, next.ServeHTTP)(w, r)
+	})
+}
+
+// ClientIPMiddleware works out the address of the client that sent each
+// request, which rate limits, sessions and logs then use. It is the peer
+// address unless that is one of trustedProxies, in which case X-Forwarded-For
+// is read from the right and the first hop that is not a trusted proxy is the
+// client. With no trusted proxies X-Forwarded-For is ignored, as any client
+// can set it.
+func ClientIPMiddleware(trustedProxies []netip.Prefix, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), ctxClientIPKey, resolveClientIP(r, trustedProxies))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// clientIP returns the address of the client that sent the request, as found
+// by ClientIPMiddleware, or the peer address when it has not run
+func clientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(ctxClientIPKey).(string); ok {
+		return ip
+	}
+	return peerIP(r)
+}
+
+func resolveClientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	client := peerIP(r)
+	if !isTrustedProxy(client, trustedProxies) {
+		return client
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// Hops before a malformed one cannot be told apart from forgeries
+			break
+		}
+		client = addr.Unmap().String()
+		if !isTrustedProxy(client, trustedProxies) {
+			break
+		}
+	}
+	return client
+}
+
+func isTrustedProxy(ip string, trustedProxies []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerIP returns the address of the host at the other end of the connection
+func peerIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -2,19 +2,24 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
-	"os"
+	"net/netip"
 	"strings"
 	"testing"
 	"time"
 
+	"days/internal/audit"
 	"days/internal/auth"
+	"days/internal/services"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -69,8 +74,8 @@ func TestWriteJSONError(t *testing.T) {
 }
 
 func TestAuthMiddleware(t *testing.T) {
-	// Set up test environment
 	secret := "test-secret-for-middleware"
+	keys := secretKeyring(t, secret)
 	userID := uuid.New()
 
 	// Generate a valid token
@@ -88,84 +93,82 @@ func TestAuthMiddleware(t *testing.T) {
 			t.Errorf("expected userID %v, got %v", userID, ctxUserID)
 			return
 		}
+		// Services record audit events as coming from the client of the request
+		if _, ip := audit.ClientFromContext(r.Context()); ip != "192.0.2.1" {
+			t.Errorf("expected audit client IP 192.0.2.1, got %q", ip)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("success"))
 	}
 
 	tests := []struct {
 		name           string
-		jwtSecret      string
+		tokens         TokenVerifier
 		authHeader     string
 		expectedStatus int
 		expectedBody   string
 	}{
 		{
 			name:           "valid token",
-			jwtSecret:      secret,
+			tokens:         keys,
 			authHeader:     "Bearer " + validToken,
 			expectedStatus: http.StatusOK,
 			expectedBody:   "success",
 		},
 		{
 			name:           "missing authorization header",
-			jwtSecret:      secret,
+			tokens:         keys,
 			authHeader:     "",
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody:   `{"error":"authorization header required"}`,
 		},
 		{
 			name:           "invalid authorization format",
-			jwtSecret:      secret,
+			tokens:         keys,
 			authHeader:     "InvalidFormat " + validToken,
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody:   `{"error":"invalid authorization format"}`,
 		},
 		{
 			name:           "missing bearer token",
-			jwtSecret:      secret,
+			tokens:         keys,
 			authHeader:     "Bearer ",
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody:   `{"error":"token required"}`,
 		},
 		{
 			name:           "invalid token",
-			jwtSecret:      secret,
+			tokens:         keys,
 			authHeader:     "Bearer invalid.token.here",
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody:   `{"error":"invalid or expired token"}`,
 		},
 		{
 			name:           "wrong secret",
-			jwtSecret:      "wrong-secret",
+			tokens:         secretKeyring(t, "wrong-secret"),
 			authHeader:     "Bearer " + validToken,
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody:   `{"error":"invalid or expired token"}`,
 		},
 		{
-			name:           "missing JWT secret in env",
-			jwtSecret:      "",
+			name:           "no keyring",
+			tokens:         nil,
 			authHeader:     "Bearer " + validToken,
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   `{"error":"server misconfigured: missing JWT secret"}`,
+			expectedBody:   `{"error":"server misconfigured: missing JWT keys"}`,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Set environment variable
-			if tt.jwtSecret != "" {
-				t.Setenv("JWT_SECRET", tt.jwtSecret)
-			} else {
-				os.Unsetenv("JWT_SECRET")
-			}
-
 			req := httptest.NewRequest(http.MethodGet, "/test", nil)
 			if tt.authHeader != "" {
 				req.Header.Set("Authorization", tt.authHeader)
 			}
 			w := httptest.NewRecorder()
 
-			handler := AuthMiddleware(testHandler)
+			handler := AuthMiddleware(tt.tokens, testHandler)
 			handler(w, req)
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
@@ -185,6 +188,142 @@ func TestAuthMiddleware(t *testing.T) {
 	}
 }
 
+// secretKeyring returns a keyring that signs with an HS256 secret
+func secretKeyring(t *testing.T, secret string) *auth.Keyring {
+	t.Helper()
+	keys, err := auth.NewKeyring(auth.NewHMACKey(secret))
+	require.NoError(t, err)
+	return keys
+}
+
+type stubSessionChecker struct {
+	active map[uuid.UUID]bool
+	err    error
+}
+
+func (s stubSessionChecker) IsSessionActive(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	return s.active[sessionID], s.err
+}
+
+func TestSessionAuthMiddleware(t *testing.T) {
+	secret := "test-secret-for-middleware"
+	keys := secretKeyring(t, secret)
+
+	userID := uuid.New()
+	activeSession := uuid.New()
+	revokedSession := uuid.New()
+
+	activeToken, err := auth.GenerateSessionToken(userID, activeSession, secret, time.Hour)
+	require.NoError(t, err)
+	revokedToken, err := auth.GenerateSessionToken(userID, revokedSession, secret, time.Hour)
+	require.NoError(t, err)
+	sessionlessToken, err := auth.GenerateToken(userID, secret, time.Hour)
+	require.NoError(t, err)
+
+	checker := stubSessionChecker{active: map[uuid.UUID]bool{activeSession: true}}
+
+	testHandler := func(w http.ResponseWriter, r *http.Request) {
+		ctxSessionID, _ := r.Context().Value(ctxSessionIDKey).(uuid.UUID)
+		assert.Equal(t, activeSession, ctxSessionID)
+		w.WriteHeader(http.StatusOK)
+	}
+
+	tests := []struct {
+		name           string
+		checker        SessionChecker
+		token          string
+		expectedStatus int
+		expectedError  string
+	}{
+		{"active session", checker, activeToken, http.StatusOK, ""},
+		{"revoked session", checker, revokedToken, http.StatusUnauthorized, "session has been revoked"},
+		{"token without session", checker, sessionlessToken, http.StatusUnauthorized, "invalid or expired token"},
+		{"checker failure", stubSessionChecker{err: fmt.Errorf("db down")}, activeToken, http.StatusInternalServerError, "internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+
+			SessionAuthMiddleware(keys, tt.checker, testHandler)(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedError != "" {
+				var got errorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+				assert.Equal(t, tt.expectedError, got.Error)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuthMiddleware(t *testing.T) {
+	secret := "test-secret-for-middleware"
+	keys := secretKeyring(t, secret)
+
+	userID := uuid.New()
+	session := uuid.New()
+	sessionToken, err := auth.GenerateSessionToken(userID, session, secret, time.Hour)
+	require.NoError(t, err)
+	checker := stubSessionChecker{active: map[uuid.UUID]bool{session: true}}
+
+	validKey := services.APIKeyPrefix + "valid"
+	revokedKey := services.APIKeyPrefix + "revoked"
+	failingKey := services.APIKeyPrefix + "failing"
+	apiKeys := new(MockAPIKeyService)
+	apiKeys.On("AuthenticateAPIKey", mock.Anything, validKey).
+		Return(&services.APIKeyPrincipal{UserID: userID, Scopes: []string{services.ScopeEntriesWrite}}, nil)
+	apiKeys.On("AuthenticateAPIKey", mock.Anything, revokedKey).Return(nil, services.ErrInvalidAPIKey)
+	apiKeys.On("AuthenticateAPIKey", mock.Anything, failingKey).Return(nil, fmt.Errorf("db down"))
+
+	// Reports the scopes the request may use
+	testHandler := func(w http.ResponseWriter, r *http.Request) {
+		ctxUserID, _ := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+		assert.Equal(t, userID, ctxUserID)
+		var allowed []string
+		for _, scope := range []string{services.ScopeEntriesRead, services.ScopeEntriesWrite, services.ScopeCalendarsRead} {
+			if requireScope(httptest.NewRecorder(), r, scope) {
+				allowed = append(allowed, scope)
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(strings.Join(allowed, " ")))
+	}
+
+	tests := []struct {
+		name           string
+		apiKeys        APIKeyAuthenticator
+		token          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"access token has every scope", apiKeys, sessionToken, http.StatusOK, "entries:read entries:write calendars:read"},
+		{"API key has its scopes", apiKeys, validKey, http.StatusOK, "entries:read entries:write"},
+		{"revoked API key", apiKeys, revokedKey, http.StatusUnauthorized, `{"error":"invalid or expired API key"}`},
+		{"authenticator failure", apiKeys, failingKey, http.StatusInternalServerError, `{"error":"internal server error"}`},
+		{"API keys not accepted", nil, validKey, http.StatusUnauthorized, `{"error":"invalid or expired token"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+
+			APIKeyAuthMiddleware(keys, checker, tt.apiKeys, testHandler)(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if strings.HasPrefix(tt.expectedBody, "{") {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			} else {
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
 func TestMaxBodyBytes(t *testing.T) {
 	testHandler := func(w http.ResponseWriter, r *http.Request) {
 		// Try to read the body
@@ -256,51 +395,70 @@ func TestCORSMiddleware(t *testing.T) {
 	}
 
 	tests := []struct {
-		name            string
-		corsAllowOrigin string
-		method          string
-		expectedStatus  int
-		expectedOrigin  string
+		name           string
+		origins        []string
+		requestOrigin  string
+		method         string
+		expectedStatus int
+		expectedOrigin string
+		expectedVary   string
 	}{
 		{
-			name:            "GET request with default CORS",
-			corsAllowOrigin: "",
-			method:          http.MethodGet,
-			expectedStatus:  http.StatusOK,
-			expectedOrigin:  "*",
+			name:           "GET request with default CORS",
+			origins:        []string{"*"},
+			requestOrigin:  "https://example.com",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+			expectedOrigin: "*",
+		},
+		{
+			name:           "OPTIONS request",
+			origins:        []string{"*"},
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+			expectedOrigin: "*",
 		},
 		{
-			name:            "OPTIONS request",
-			corsAllowOrigin: "",
-			method:          http.MethodOptions,
-			expectedStatus:  http.StatusOK,
-			expectedOrigin:  "*",
+			name:           "no origins configured",
+			origins:        nil,
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+			expectedOrigin: "*",
+		},
+		{
+			name:           "allowed origin",
+			origins:        []string{"https://example.com", "https://days.example.com"},
+			requestOrigin:  "https://days.example.com",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+			expectedOrigin: "https://days.example.com",
+			expectedVary:   "Origin",
 		},
 		{
-			name:            "custom CORS origin",
-			corsAllowOrigin: "https://example.com",
-			method:          http.MethodGet,
-			expectedStatus:  http.StatusOK,
-			expectedOrigin:  "https://example.com",
+			name:           "other origin",
+			origins:        []string{"https://example.com"},
+			requestOrigin:  "https://evil.example",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+			expectedOrigin: "",
+			expectedVary:   "Origin",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.corsAllowOrigin != "" {
-				t.Setenv("CORS_ALLOW_ORIGIN", tt.corsAllowOrigin)
-			} else {
-				os.Unsetenv("CORS_ALLOW_ORIGIN")
-			}
-
 			req := httptest.NewRequest(tt.method, "/test", nil)
+			if tt.requestOrigin != "" {
+				req.Header.Set("Origin", tt.requestOrigin)
+			}
 			w := httptest.NewRecorder()
 
-			handler := CORSMiddleware(testHandler)
+			handler := CORSMiddleware(tt.origins, testHandler)
 			handler(w, req)
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			assert.Equal(t, tt.expectedOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, tt.expectedVary, w.Header().Get("Vary"))
 			assert.Equal(t, "GET, POST, PUT, DELETE, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
 			assert.Equal(t, "Content-Type, Authorization", w.Header().Get("Access-Control-Allow-Headers"))
 
@@ -364,3 +522,160 @@ func TestWithTimeout(t *testing.T) {
 		})
 	}
 }
+
+func TestTimeoutMiddleware(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	userID := uuid.New()
+
+	// The server's write timeout equals the request timeout, and every
+	// handler below takes several times as long
+	serve := func(t *testing.T, mux *http.ServeMux) *httptest.Server {
+		server := httptest.NewUnstartedServer(TimeoutMiddleware(timeout, 5*time.Second, mux))
+		server.Config.ReadTimeout = timeout
+		server.Config.WriteTimeout = timeout
+		server.Start()
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	t.Run("an export outlives the request and write timeouts", func(t *testing.T) {
+		exportService := new(MockExportService)
+		exportService.On("Export", mock.Anything, userID, services.ExportFormatJSON, mock.Anything).
+			Run(func(args mock.Arguments) {
+				io.WriteString(args.Get(3).(io.Writer), `{"calendars":[`)
+				time.Sleep(4 * timeout)
+				assert.NoError(t, args.Get(0).(context.Context).Err())
+			}).
+			Return(`]}`, nil).Once()
+		handler := NewExportHandler(exportService)
+		mux := http.NewServeMux()
+		mux.HandleFunc("/api/export", func(w http.ResponseWriter, r *http.Request) {
+			handler.Export(w, withUserID(r, userID))
+		})
+		server := serve(t, mux)
+
+		resp, err := http.Get(server.URL + "/api/export")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, `{"calendars":[]}`, string(body))
+		exportService.AssertExpectations(t)
+	})
+
+	t.Run("other routes keep the request timeout", func(t *testing.T) {
+		expired := make(chan error, 1)
+		mux := http.NewServeMux()
+		mux.HandleFunc("/api/calendars", func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+			expired <- r.Context().Err()
+		})
+		server := serve(t, mux)
+
+		resp, err := http.Get(server.URL + "/api/calendars")
+		if err == nil {
+			resp.Body.Close()
+		}
+
+		select {
+		case err := <-expired:
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		case <-time.After(time.Second):
+			t.Fatal("request deadline not applied")
+		}
+	})
+}
+
+func TestClientIPMiddleware(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("2001:db8::/32")}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		trusted    []netip.Prefix
+		expected   string
+	}{
+		{
+			name:       "direct client",
+			remoteAddr: "203.0.113.7:1234",
+			trusted:    trusted,
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "forwarded header from an untrusted peer",
+			remoteAddr: "203.0.113.7:1234",
+			forwarded:  []string{"192.0.2.1"},
+			trusted:    trusted,
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "no trusted proxies",
+			remoteAddr: "10.0.0.2:1234",
+			forwarded:  []string{"192.0.2.1"},
+			expected:   "10.0.0.2",
+		},
+		{
+			name:       "trusted proxy",
+			remoteAddr: "10.0.0.2:1234",
+			forwarded:  []string{"203.0.113.7"},
+			trusted:    trusted,
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "client prepends a forged hop",
+			remoteAddr: "10.0.0.2:1234",
+			forwarded:  []string{"192.0.2.1, 203.0.113.7"},
+			trusted:    trusted,
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "chain of trusted proxies over several headers",
+			remoteAddr: "[2001:db8::2]:1234",
+			forwarded:  []string{"192.0.2.1, 203.0.113.7", "10.0.0.3"},
+			trusted:    trusted,
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "malformed hop",
+			remoteAddr: "10.0.0.2:1234",
+			forwarded:  []string{"203.0.113.7, unknown, 10.0.0.3"},
+			trusted:    trusted,
+			expected:   "10.0.0.3",
+		},
+		{
+			name:       "only trusted hops",
+			remoteAddr: "10.0.0.2:1234",
+			forwarded:  []string{"10.0.0.3"},
+			trusted:    trusted,
+			expected:   "10.0.0.3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/calendars", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+
+			var got string
+			ClientIPMiddleware(tt.trusted, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = clientIP(r)
+			})).ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	t.Run("without the middleware", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/calendars", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		req.Header.Set("X-Forwarded-For", "192.0.2.1")
+
+		assert.Equal(t, "203.0.113.7", clientIP(req))
+	})
+}
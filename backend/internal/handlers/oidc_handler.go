@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"days/internal/services"
+)
+
+type OIDCHandler struct {
+	oidcService services.OIDCServiceInterface
+}
+
+// NewOIDCHandler creates an OIDCHandler. oidcService is nil when single
+// sign-on is not configured, and its routes then answer 404.
+func NewOIDCHandler(oidcService services.OIDCServiceInterface) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+	}
+}
+
+// StartLogin handles POST /api/auth/oidc/login
+//
+//	@Summary		Start a single sign-on login
+//	@Description	Begin an OpenID Connect login. Send the browser to the returned authorization URL; the identity provider redirects it back to the app with a code and state for the callback endpoint. A login must complete within ten minutes.
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	services.OIDCLoginResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/api/auth/oidc/login [post]
+func (h *OIDCHandler) StartLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.oidcService == nil {
+		writeJSONError(w, http.StatusNotFound, "single sign-on is not configured")
+		return
+	}
+
+	response, err := h.oidcService.StartLogin(r.Context())
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Callback handles POST /api/auth/oidc/callback
+//
+//	@Summary		Complete a single sign-on login
+//	@Description	Exchange the code and state the identity provider redirected back with for a session. The first login of an identity links it to the account with the same email address, or creates a password-less account; the provider must have verified the address. Users with two-factor authentication on get a challenge token instead, as with a password login.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		services.OIDCCallbackRequest	true	"Code and state from the provider's redirect"
+//	@Success		200		{object}	services.LoginResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/api/auth/oidc/callback [post]
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.oidcService == nil {
+		writeJSONError(w, http.StatusNotFound, "single sign-on is not configured")
+		return
+	}
+
+	var req services.OIDCCallbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	req.UserAgent = r.UserAgent()
+	req.IPAddress = clientIP(r)
+
+	loginResponse, err := h.oidcService.CompleteLogin(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidOIDCState),
+			errors.Is(err, services.ErrOIDCLoginFailed),
+			errors.Is(err, services.ErrUserNotFound):
+			writeJSONError(w, http.StatusUnauthorized, err.Error())
+		case errors.Is(err, services.ErrOIDCEmailNotVerified), errors.Is(err, services.ErrAccountDisabled):
+			writeJSONError(w, http.StatusForbidden, err.Error())
+		case errors.Is(err, services.ErrOIDCAccountNotVerified):
+			writeJSONError(w, http.StatusConflict, err.Error())
+		default:
+			writeInternalError(w, r, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(loginResponse)
+}
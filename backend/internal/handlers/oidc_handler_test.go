@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"days/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockOIDCService implements a mock for the OIDCService
+type MockOIDCService struct {
+	mock.Mock
+}
+
+func (m *MockOIDCService) StartLogin(ctx context.Context) (*services.OIDCLoginResponse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.OIDCLoginResponse), args.Error(1)
+}
+
+func (m *MockOIDCService) CompleteLogin(ctx context.Context, req services.OIDCCallbackRequest) (*services.LoginResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.LoginResponse), args.Error(1)
+}
+
+func TestOIDCHandler_StartLogin(t *testing.T) {
+	mockService := new(MockOIDCService)
+	handler := NewOIDCHandler(mockService)
+	mockService.On("StartLogin", mock.Anything).
+		Return(&services.OIDCLoginResponse{AuthorizationURL: "https://idp.example.com/authorize?state=abc"}, nil).Once()
+
+	w := httptest.NewRecorder()
+	handler.StartLogin(w, httptest.NewRequest(http.MethodPost, "/api/auth/oidc/login", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"authorization_url":"https://idp.example.com/authorize?state=abc"}`, w.Body.String())
+	mockService.AssertExpectations(t)
+}
+
+func TestOIDCHandler_Callback(t *testing.T) {
+	tests := []struct {
+		name           string
+		result         *services.LoginResponse
+		serviceErr     error
+		expectedStatus int
+	}{
+		{"signed in", &services.LoginResponse{Token: "access", RefreshToken: "refresh", ExpiresIn: 900}, nil, http.StatusOK},
+		{"two-factor challenge", &services.LoginResponse{TwoFactorRequired: true, ChallengeToken: "challenge"}, nil, http.StatusOK},
+		{"unknown state", nil, services.ErrInvalidOIDCState, http.StatusUnauthorized},
+		{"code rejected", nil, services.ErrOIDCLoginFailed, http.StatusUnauthorized},
+		{"unverified provider email", nil, services.ErrOIDCEmailNotVerified, http.StatusForbidden},
+		{"unverified local account", nil, services.ErrOIDCAccountNotVerified, http.StatusConflict},
+		{"database error", nil, errors.New("connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockOIDCService)
+			handler := NewOIDCHandler(mockService)
+			mockService.On("CompleteLogin", mock.Anything, services.OIDCCallbackRequest{
+				Code:      "code",
+				State:     "state",
+				UserAgent: "Firefox",
+				IPAddress: "192.0.2.1",
+			}).Return(tt.result, tt.serviceErr).Once()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/auth/oidc/callback", bytes.NewBufferString(`{"code":"code","state":"state"}`))
+			req.Header.Set("User-Agent", "Firefox")
+			w := httptest.NewRecorder()
+			handler.Callback(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.result != nil {
+				var response services.LoginResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, *tt.result, response)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_OIDCRoutesWithoutProvider(t *testing.T) {
+	server := &Server{oidcHandler: NewOIDCHandler(nil)}
+	mux := server.SetupRoutes()
+
+	for _, path := range []string{"/api/auth/oidc/login", "/api/auth/oidc/callback"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(`{}`)))
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+		assert.JSONEq(t, `{"error":"single sign-on is not configured"}`, w.Body.String())
+	}
+}
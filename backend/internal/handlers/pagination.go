@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// isPaginated reports whether a list request asked for pages. Without a
+// limit or cursor, list endpoints answer with a plain array of every item,
+// as they did before they had pages, so that existing clients keep working.
+func isPaginated(r *http.Request) bool {
+	query := r.URL.Query()
+	return query.Has("limit") || query.Has("cursor")
+}
+
+// parseLimit reads the limit query parameter, writing a 400 response and
+// returning false when it is not a positive number. A missing limit is 0,
+// which services take to mean their default page size.
+func parseLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return 0, true
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		writeJSONError(w, http.StatusBadRequest, "invalid limit")
+		return 0, false
+	}
+	return limit, true
+}
+
+// setNextLink points a Link header at the page after this one, which is the
+// request's own URL with cursor swapped in. Nothing is set on the last page.
+func setNextLink(w http.ResponseWriter, r *http.Request, cursor string) {
+	if cursor == "" {
+		return
+	}
+	query := r.URL.Query()
+	query.Set("cursor", cursor)
+	next := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+)
+
+// ReadinessCheck reports why a dependency cannot serve traffic, or nil when it can
+type ReadinessCheck func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check ReadinessCheck
+}
+
+// Probes answers the liveness and readiness probes of an orchestrator such
+// as Kubernetes. A live server is only restarted when it stops answering,
+// while a server that is not ready is taken out of load balancing until its
+// checks pass again.
+type Probes struct {
+	checks       []namedCheck
+	shuttingDown atomic.Bool
+}
+
+// ProbeResponse reports the state of the server and of each readiness check
+type ProbeResponse struct {
+	Status string            `json:"status" example:"ok"` // ok, unavailable or shutting_down
+	Checks map[string]string `json:"checks,omitempty"`    // "ok" or why a check failed, by check name
+}
+
+func NewProbes() *Probes {
+	return &Probes{}
+}
+
+// AddCheck adds a check that must pass for the server to be ready. Add
+// checks before serving requests.
+func (p *Probes) AddCheck(name string, check ReadinessCheck) {
+	p.checks = append(p.checks, namedCheck{name: name, check: check})
+}
+
+// SetShuttingDown makes the server report not ready from then on, so that it
+// stops getting new requests while in-flight ones drain
+func (p *Probes) SetShuttingDown() {
+	p.shuttingDown.Store(true)
+}
+
+// Livez handles GET /livez
+//
+//	@Summary		Liveness probe
+//	@Description	Reports that the server process is up and answering. It does not check dependencies, so a database outage does not get the server restarted.
+//	@Tags			health
+//	@Produce		json
+//	@Success		200	{object}	ProbeResponse
+//	@Router			/livez [get]
+func (p *Probes) Livez(w http.ResponseWriter, r *http.Request) {
+	writeProbe(w, http.StatusOK, ProbeResponse{Status: "ok"})
+}
+
+// Readyz handles GET /readyz
+//
+//	@Summary		Readiness probe
+//	@Description	Reports whether the server can serve traffic: the database answers and its schema is at the latest migration. While the server shuts down it reports not ready so that no new requests are sent to it.
+//	@Tags			health
+//	@Produce		json
+//	@Success		200	{object}	ProbeResponse
+//	@Failure		503	{object}	ProbeResponse
+//	@Router			/readyz [get]
+func (p *Probes) Readyz(w http.ResponseWriter, r *http.Request) {
+	if p.shuttingDown.Load() {
+		writeProbe(w, http.StatusServiceUnavailable, ProbeResponse{Status: "shutting_down"})
+		return
+	}
+
+	response := ProbeResponse{Status: "ok", Checks: make(map[string]string, len(p.checks))}
+	status := http.StatusOK
+	for _, c := range p.checks {
+		if err := c.check(r.Context()); err != nil {
+			slog.WarnContext(r.Context(), "readiness check failed", "check", c.name, "error", err)
+			response.Checks[c.name] = err.Error()
+			response.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+			continue
+		}
+		response.Checks[c.name] = "ok"
+	}
+	writeProbe(w, status, response)
+}
+
+func writeProbe(w http.ResponseWriter, status int, response ProbeResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
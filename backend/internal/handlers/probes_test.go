@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbes_Livez(t *testing.T) {
+	probes := NewProbes()
+	probes.AddCheck("database", func(ctx context.Context) error { return errors.New("connection refused") })
+
+	w := httptest.NewRecorder()
+	probes.Livez(w, httptest.NewRequest(http.MethodGet, "/livez", nil))
+
+	// Liveness does not depend on the database
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+}
+
+func TestProbes_Readyz(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+
+	tests := []struct {
+		name           string
+		database       ReadinessCheck
+		migrations     ReadinessCheck
+		shuttingDown   bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "ready",
+			database:       ok,
+			migrations:     ok,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"ok","checks":{"database":"ok","migrations":"ok"}}`,
+		},
+		{
+			name:           "database down",
+			database:       func(ctx context.Context) error { return errors.New("connection refused") },
+			migrations:     ok,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   `{"status":"unavailable","checks":{"database":"connection refused","migrations":"ok"}}`,
+		},
+		{
+			name:           "pending migrations",
+			database:       ok,
+			migrations:     func(ctx context.Context) error { return errors.New("database schema has pending migrations: 10") },
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   `{"status":"unavailable","checks":{"database":"ok","migrations":"database schema has pending migrations: 10"}}`,
+		},
+		{
+			name:           "shutting down",
+			database:       ok,
+			migrations:     ok,
+			shuttingDown:   true,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   `{"status":"shutting_down"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probes := NewProbes()
+			probes.AddCheck("database", tt.database)
+			probes.AddCheck("migrations", tt.migrations)
+			if tt.shuttingDown {
+				probes.SetShuttingDown()
+			}
+
+			w := httptest.NewRecorder()
+			probes.Readyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestProbes_ReadyzTimeout(t *testing.T) {
+	probes := NewProbes()
+	probes.AddCheck("database", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	// WithTimeout keeps a hanging check from outlasting the probe
+	w := httptest.NewRecorder()
+	WithTimeout(10*time.Millisecond, probes.Readyz)(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var response ProbeResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, context.DeadlineExceeded.Error(), response.Checks["database"])
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"days/internal/ratelimit"
+
+	"github.com/google/uuid"
+)
+
+// Route groups that share rate limits
+const (
+	// RateLimitAuth covers unauthenticated account routes: signup, login,
+	// token refresh, password resets and email verification
+	RateLimitAuth = "auth"
+	// RateLimitAPI covers authenticated API routes
+	RateLimitAPI = "api"
+	// RateLimitFeed covers calendar feeds polled by calendar apps
+	RateLimitFeed = "feed"
+	// RateLimitBulk covers expensive authenticated routes such as export and import
+	RateLimitBulk = "bulk"
+)
+
+// RateLimitPolicy sets the limits of a route group. The per-user limit
+// applies only to authenticated requests.
+type RateLimitPolicy struct {
+	PerIP   ratelimit.Limit
+	PerUser ratelimit.Limit
+}
+
+// DefaultRateLimitPolicies returns the limits used by the server
+func DefaultRateLimitPolicies() map[string]RateLimitPolicy {
+	return map[string]RateLimitPolicy{
+		RateLimitAuth: {PerIP: ratelimit.Limit{Requests: 20, Per: time.Minute}},
+		RateLimitAPI: {
+			PerIP:   ratelimit.Limit{Requests: 600, Per: time.Minute},
+			PerUser: ratelimit.Limit{Requests: 300, Per: time.Minute},
+		},
+		RateLimitFeed: {PerIP: ratelimit.Limit{Requests: 60, Per: time.Minute}},
+		RateLimitBulk: {
+			PerIP:   ratelimit.Limit{Requests: 20, Per: time.Minute},
+			PerUser: ratelimit.Limit{Requests: 10, Per: time.Minute},
+		},
+	}
+}
+
+// RateLimiter applies per-group rate limits to requests
+type RateLimiter struct {
+	store    ratelimit.Store
+	policies map[string]RateLimitPolicy
+	now      func() time.Time
+}
+
+func NewRateLimiter(store ratelimit.Store, policies map[string]RateLimitPolicy) *RateLimiter {
+	return &RateLimiter{
+		store:    store,
+		policies: policies,
+		now:      time.Now,
+	}
+}
+
+// RateLimitMiddleware limits requests with token buckets keyed by client IP
+// and, once AuthMiddleware has run, by user ID. Requests over a limit get 429
+// with a Retry-After header. A nil limiter or a group without a policy lets
+// every request through.
+func RateLimitMiddleware(limiter *RateLimiter, group string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if limiter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		policy, ok := limiter.policies[group]
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if wait := limiter.take(r, "ip:"+group+":"+clientIP(r), policy.PerIP); wait > 0 {
+			writeTooManyRequests(w, wait)
+			return
+		}
+		if userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID); ok {
+			if wait := limiter.take(r, "user:"+group+":"+userID.String(), policy.PerUser); wait > 0 {
+				writeTooManyRequests(w, wait)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+// take returns zero if the request may proceed, or how long to wait. A failing
+// store lets requests through, as limiting is not worth an outage.
+func (l *RateLimiter) take(r *http.Request, key string, limit ratelimit.Limit) time.Duration {
+	allowed, wait, err := l.store.Allow(r.Context(), key, limit, l.now())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "rate limit store failed", "key", key, "error", err)
+		return 0
+	}
+	if allowed {
+		return 0
+	}
+	return wait
+}
+
+// writeTooManyRequests responds 429 with the wait in whole seconds, rounded up
+func writeTooManyRequests(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(wait)))
+	writeJSONError(w, http.StatusTooManyRequests, "too many requests")
+}
+
+func retryAfterSeconds(wait time.Duration) int {
+	return int(math.Max(1, math.Ceil(wait.Seconds())))
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"days/internal/ratelimit"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingStore is a ratelimit.Store whose every call fails
+type failingStore struct{}
+
+func (failingStore) Allow(context.Context, string, ratelimit.Limit, time.Time) (bool, time.Duration, error) {
+	return false, 0, errors.New("store unavailable")
+}
+
+func (failingStore) AddFailure(context.Context, string, time.Time, time.Duration) (int, error) {
+	return 0, errors.New("store unavailable")
+}
+
+func (failingStore) Failures(context.Context, string, time.Time) (int, time.Time, error) {
+	return 0, time.Time{}, errors.New("store unavailable")
+}
+
+func (failingStore) ResetFailures(context.Context, string) error {
+	return errors.New("store unavailable")
+}
+
+func newTestRateLimiter(policies map[string]RateLimitPolicy) *RateLimiter {
+	limiter := NewRateLimiter(ratelimit.NewMemoryStore(), policies)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+	return limiter
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	okHandler := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	request := func(ip string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/calendars", nil)
+		req.RemoteAddr = ip + ":1234"
+		return req
+	}
+
+	t.Run("limits per IP", func(t *testing.T) {
+		limiter := newTestRateLimiter(map[string]RateLimitPolicy{
+			"test": {PerIP: ratelimit.Limit{Requests: 2, Per: time.Minute}},
+		})
+		handler := RateLimitMiddleware(limiter, "test", okHandler)
+
+		for i := 0; i < 2; i++ {
+			w := httptest.NewRecorder()
+			handler(w, request("203.0.113.7"))
+			assert.Equal(t, http.StatusOK, w.Code)
+		}
+
+		w := httptest.NewRecorder()
+		handler(w, request("203.0.113.7"))
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "30", w.Header().Get("Retry-After"))
+		assert.JSONEq(t, `{"error":"too many requests"}`, w.Body.String())
+
+		// Other clients have their own bucket
+		w = httptest.NewRecorder()
+		handler(w, request("198.51.100.1"))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("ignores forwarded addresses from untrusted peers", func(t *testing.T) {
+		limiter := newTestRateLimiter(map[string]RateLimitPolicy{
+			"test": {PerIP: ratelimit.Limit{Requests: 1, Per: time.Minute}},
+		})
+		handler := ClientIPMiddleware(nil, RateLimitMiddleware(limiter, "test", okHandler))
+
+		for i, status := range []int{http.StatusOK, http.StatusTooManyRequests} {
+			req := request("203.0.113.7")
+			req.Header.Set("X-Forwarded-For", fmt.Sprintf("192.0.2.%d", i))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			assert.Equal(t, status, w.Code)
+		}
+	})
+
+	t.Run("limits per user across IPs", func(t *testing.T) {
+		limiter := newTestRateLimiter(map[string]RateLimitPolicy{
+			"test": {
+				PerIP:   ratelimit.Limit{Requests: 100, Per: time.Minute},
+				PerUser: ratelimit.Limit{Requests: 1, Per: time.Minute},
+			},
+		})
+		handler := RateLimitMiddleware(limiter, "test", okHandler)
+		userID := uuid.New()
+
+		w := httptest.NewRecorder()
+		handler(w, withUserID(request("203.0.113.7"), userID))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = httptest.NewRecorder()
+		handler(w, withUserID(request("198.51.100.1"), userID))
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+		w = httptest.NewRecorder()
+		handler(w, withUserID(request("198.51.100.1"), uuid.New()))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("groups have separate buckets", func(t *testing.T) {
+		limiter := newTestRateLimiter(map[string]RateLimitPolicy{
+			"a": {PerIP: ratelimit.Limit{Requests: 1, Per: time.Minute}},
+			"b": {PerIP: ratelimit.Limit{Requests: 1, Per: time.Minute}},
+		})
+
+		w := httptest.NewRecorder()
+		RateLimitMiddleware(limiter, "a", okHandler)(w, request("203.0.113.7"))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = httptest.NewRecorder()
+		RateLimitMiddleware(limiter, "b", okHandler)(w, request("203.0.113.7"))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("passes through without limits", func(t *testing.T) {
+		limiter := newTestRateLimiter(map[string]RateLimitPolicy{})
+		failing := NewRateLimiter(failingStore{}, map[string]RateLimitPolicy{
+			"test": {PerIP: ratelimit.Limit{Requests: 1, Per: time.Minute}},
+		})
+
+		for _, handler := range []http.HandlerFunc{
+			RateLimitMiddleware(nil, "test", okHandler),
+			RateLimitMiddleware(limiter, "unknown", okHandler),
+			RateLimitMiddleware(failing, "test", okHandler),
+		} {
+			for i := 0; i < 5; i++ {
+				w := httptest.NewRecorder()
+				handler(w, request("203.0.113.7"))
+				assert.Equal(t, http.StatusOK, w.Code)
+			}
+		}
+	})
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	assert.Equal(t, 1, retryAfterSeconds(0))
+	assert.Equal(t, 1, retryAfterSeconds(100*time.Millisecond))
+	assert.Equal(t, 2, retryAfterSeconds(1500*time.Millisecond))
+	assert.Equal(t, 30, retryAfterSeconds(30*time.Second))
+}
+
+func TestServer_RateLimitedRoutes(t *testing.T) {
+	limiter := newTestRateLimiter(map[string]RateLimitPolicy{
+		RateLimitAuth: {PerIP: ratelimit.Limit{Requests: 1, Per: time.Minute}},
+	})
+	mockService := new(MockUserService)
+	server := &Server{userHandler: NewUserHandler(mockService), limiter: limiter}
+	mux := server.SetupRoutes()
+
+	// The first request uses the only token, even though it is rejected
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/auth/login", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/auth/login", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// Health checks are never limited
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+)
+
+type RenderHandler struct {
+	renderService services.RenderServiceInterface
+}
+
+func NewRenderHandler(renderService services.RenderServiceInterface) *RenderHandler {
+	return &RenderHandler{
+		renderService: renderService,
+	}
+}
+
+// RenderCalendar handles GET /api/calendars/{id}/render
+//
+//	@Summary		Render calendar year
+//	@Description	Draw a year of day entries as a grid of colored cells (user must be a member). Responses carry an ETag and If-None-Match is answered with 304.
+//	@Tags			calendars
+//	@Produce		image/svg+xml
+//	@Produce		image/png
+//	@Param			id		path		string	true	"Calendar ID"
+//	@Param			year	query		int		false	"Year to draw, defaults to the current year"
+//	@Param			format	query		string	false	"Image format"	Enums(svg, png)	default(svg)
+//	@Param			layout	query		string	false	"Grid layout: 12x31 months or 53x7 weeks"	Enums(months, weeks)	default(months)
+//	@Param			legend	query		bool	false	"Draw the color meanings below the grid"
+//	@Success		200		{file}		file
+//	@Success		304		"Not Modified"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/calendars/{id}/render [get]
+func (h *RenderHandler) RenderCalendar(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeEntriesRead) {
+		return
+	}
+
+	// Extract calendar ID from URL path
+	calendarID, err := uuid.Parse(extractIDFromPath(r.URL.Path, "/api/calendars/"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid calendar ID")
+		return
+	}
+
+	query := r.URL.Query()
+	req := services.RenderRequest{
+		Format: query.Get("format"),
+		Layout: query.Get("layout"),
+	}
+	if year := query.Get("year"); year != "" {
+		if req.Year, err = strconv.Atoi(year); err != nil {
+			writeJSONError(w, http.StatusBadRequest, services.ErrInvalidYear.Error())
+			return
+		}
+	}
+	if legend := query.Get("legend"); legend != "" {
+		if req.Legend, err = strconv.ParseBool(legend); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "legend must be true or false")
+			return
+		}
+	}
+
+	rendered, err := h.renderService.RenderCalendar(r.Context(), userID, calendarID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidYear), errors.Is(err, services.ErrInvalidRenderFormat),
+			errors.Is(err, services.ErrInvalidRenderLayout):
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrCalendarNotFound):
+			writeJSONError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, services.ErrUnauthorizedCalendar):
+			writeJSONError(w, http.StatusForbidden, err.Error())
+		default:
+			writeInternalError(w, r, err)
+		}
+		return
+	}
+
+	// Images change whenever an entry does, so clients must revalidate
+	etag := etagFor(rendered.Data)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", rendered.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(rendered.Data)))
+	w.Write(rendered.Data)
+}
+
+// etagFor returns a strong ETag identifying the exact bytes of a response
+func etagFor(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header lists etag
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRenderService implements a mock for the RenderService
+type MockRenderService struct {
+	mock.Mock
+}
+
+func (m *MockRenderService) RenderCalendar(ctx context.Context, userID, calendarID uuid.UUID, req services.RenderRequest) (*services.RenderedCalendar, error) {
+	args := m.Called(ctx, userID, calendarID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.RenderedCalendar), args.Error(1)
+}
+
+func TestRenderHandler_RenderCalendar(t *testing.T) {
+	userID := uuid.New()
+	calendarID := uuid.New()
+	path := "/api/calendars/" + calendarID.String() + "/render"
+	svg := &services.RenderedCalendar{ContentType: "image/svg+xml", Data: []byte("<svg></svg>\n")}
+
+	t.Run("serves the image with an ETag", func(t *testing.T) {
+		mockService := new(MockRenderService)
+		handler := NewRenderHandler(mockService)
+
+		expectedReq := services.RenderRequest{Year: 2026, Format: "svg", Layout: "weeks", Legend: true}
+		mockService.On("RenderCalendar", mock.Anything, userID, calendarID, expectedReq).Return(svg, nil).Twice()
+
+		req := withUserID(httptest.NewRequest(http.MethodGet, path+"?year=2026&format=svg&layout=weeks&legend=true", nil), userID)
+		w := httptest.NewRecorder()
+		handler.RenderCalendar(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+		assert.Equal(t, "<svg></svg>\n", w.Body.String())
+		etag := w.Header().Get("ETag")
+		assert.NotEmpty(t, etag)
+
+		// Revalidating with the same ETag skips the body
+		req = withUserID(httptest.NewRequest(http.MethodGet, path+"?year=2026&format=svg&layout=weeks&legend=true", nil), userID)
+		req.Header.Set("If-None-Match", `"stale", `+etag)
+		w = httptest.NewRecorder()
+		handler.RenderCalendar(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		handler := NewRenderHandler(new(MockRenderService))
+
+		for _, query := range []string{"?year=next", "?legend=maybe"} {
+			req := withUserID(httptest.NewRequest(http.MethodGet, path+query, nil), userID)
+			w := httptest.NewRecorder()
+			handler.RenderCalendar(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+
+	errorTests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{"invalid year", services.ErrInvalidYear, http.StatusBadRequest},
+		{"invalid format", services.ErrInvalidRenderFormat, http.StatusBadRequest},
+		{"invalid layout", services.ErrInvalidRenderLayout, http.StatusBadRequest},
+		{"calendar not found", services.ErrCalendarNotFound, http.StatusNotFound},
+		{"not the owner", services.ErrUnauthorizedCalendar, http.StatusForbidden},
+		{"unexpected", assert.AnError, http.StatusInternalServerError},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockRenderService)
+			handler := NewRenderHandler(mockService)
+
+			mockService.On("RenderCalendar", mock.Anything, userID, calendarID, services.RenderRequest{}).Return(nil, tt.err).Once()
+
+			req := withUserID(httptest.NewRequest(http.MethodGet, path, nil), userID)
+			w := httptest.NewRecorder()
+			handler.RenderCalendar(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	etag := etagFor([]byte("image"))
+
+	assert.True(t, etagMatches(etag, etag))
+	assert.True(t, etagMatches("W/"+etag, etag))
+	assert.True(t, etagMatches(`"other", `+etag, etag))
+	assert.True(t, etagMatches("*", etag))
+	assert.False(t, etagMatches("", etag))
+	assert.False(t, etagMatches(etagFor([]byte("other image")), etag))
+}
@@ -3,23 +3,106 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"net/netip"
 	"strings"
 
+	"days/internal/auth"
+	"days/internal/metrics"
 	"days/internal/services"
 )
 
 type Server struct {
-	userHandler     *UserHandler
-	calendarHandler *CalendarHandler
+	userHandler         *UserHandler
+	calendarHandler     *CalendarHandler
+	colorMeaningHandler *ColorMeaningHandler
+	dayEntryHandler     *DayEntryHandler
+	sessionHandler      *SessionHandler
+	statsHandler        *StatsHandler
+	renderHandler       *RenderHandler
+	exportHandler       *ExportHandler
+	importHandler       *ImportHandler
+	icalHandler         *ICalHandler
+	memberHandler       *CalendarMemberHandler
+	accountHandler      *AccountHandler
+	twoFactorHandler    *TwoFactorHandler
+	apiKeyHandler       *APIKeyHandler
+	oidcHandler         *OIDCHandler
+	auditHandler        *AuditHandler
+	jwksHandler         *JWKSHandler
+	tokens              TokenVerifier
+	sessions            SessionChecker
+	apiKeys             APIKeyAuthenticator
+	limiter             *RateLimiter
+	metrics             MetricsRecorder
+	config              Config
+}
+
+// Config holds the HTTP settings of the API
+type Config struct {
+	CORSOrigins    []string // origins browsers may call the API from, or "*" for any
+	MaxBodyBytes   int64    // largest request body accepted
+	MaxImportBytes int64    // largest import upload accepted
+
+	// TrustedProxies are the reverse proxies whose X-Forwarded-For header
+	// names the client, see ClientIPMiddleware
+	TrustedProxies []netip.Prefix
+}
+
+// DefaultConfig allows any origin, 1 MiB bodies and 32 MiB imports
+func DefaultConfig() Config {
+	return Config{
+		CORSOrigins:    []string{"*"},
+		MaxBodyBytes:   1 << 20,
+		MaxImportBytes: 32 << 20,
+	}
 }
 
 func NewServer(
 	userService services.UserServiceInterface,
 	calendarService *services.CalendarService,
+	colorMeaningService services.ColorMeaningServiceInterface,
+	dayEntryService services.DayEntryServiceInterface,
+	sessionService services.SessionServiceInterface,
+	statsService services.StatsServiceInterface,
+	renderService services.RenderServiceInterface,
+	exportService services.ExportServiceInterface,
+	importService services.ImportServiceInterface,
+	icalService services.ICalServiceInterface,
+	memberService services.CalendarMemberServiceInterface,
+	accountService services.AccountServiceInterface,
+	twoFactorService services.TwoFactorServiceInterface,
+	apiKeyService services.APIKeyServiceInterface,
+	oidcService services.OIDCServiceInterface,
+	auditService services.AuditServiceInterface,
+	keys *auth.Keyring,
+	limiter *RateLimiter,
+	recorder MetricsRecorder,
+	config Config,
 ) *Server {
 	return &Server{
-		userHandler:     NewUserHandler(userService),
-		calendarHandler: NewCalendarHandler(calendarService),
+		userHandler:         NewUserHandler(userService),
+		calendarHandler:     NewCalendarHandler(calendarService),
+		colorMeaningHandler: NewColorMeaningHandler(colorMeaningService),
+		dayEntryHandler:     NewDayEntryHandler(dayEntryService),
+		sessionHandler:      NewSessionHandler(sessionService),
+		statsHandler:        NewStatsHandler(statsService),
+		renderHandler:       NewRenderHandler(renderService),
+		exportHandler:       NewExportHandler(exportService),
+		importHandler:       NewImportHandler(importService),
+		icalHandler:         NewICalHandler(icalService),
+		memberHandler:       NewCalendarMemberHandler(memberService),
+		accountHandler:      NewAccountHandler(accountService),
+		twoFactorHandler:    NewTwoFactorHandler(twoFactorService),
+		apiKeyHandler:       NewAPIKeyHandler(apiKeyService),
+		oidcHandler:         NewOIDCHandler(oidcService),
+		auditHandler:        NewAuditHandler(auditService),
+		jwksHandler:         NewJWKSHandler(keys),
+		tokens:              keys,
+		sessions:            sessionService,
+		apiKeys:             apiKeyService,
+		limiter:             limiter,
+		metrics:             recorder,
+		config:              config,
 	}
 }
 
@@ -40,18 +123,135 @@ func (s *Server) SetupRoutes() *http.ServeMux {
 		fmt.Fprint(w, "OK")
 	})
 
+	// Public keys for services that verify our access tokens
+	mux.HandleFunc("/.well-known/jwks.json", s.cors(s.jwksHandler.GetJWKS))
+
 	// Auth routes (no auth required) with body size limits (1MB)
-	mux.HandleFunc("/api/users", CORSMiddleware(MaxBodyBytes(1<<20, s.userHandler.CreateUser)))
-	mux.HandleFunc("/api/auth/login", CORSMiddleware(MaxBodyBytes(1<<20, s.userHandler.Login)))
+	mux.HandleFunc("/api/users", s.cors(s.limit(RateLimitAuth, s.limitBody(s.userHandler.CreateUser))))
+	mux.HandleFunc("/api/auth/login", s.cors(s.countLogins(metrics.LoginPassword, s.limit(RateLimitAuth, s.limitBody(s.userHandler.Login)))))
+	mux.HandleFunc("/api/auth/refresh", s.cors(s.limit(RateLimitAuth, s.limitBody(s.sessionHandler.Refresh))))
+	mux.HandleFunc("/api/auth/forgot-password", s.cors(s.limit(RateLimitAuth, s.limitBody(s.accountHandler.ForgotPassword))))
+	mux.HandleFunc("/api/auth/reset-password", s.cors(s.limit(RateLimitAuth, s.limitBody(s.accountHandler.ResetPassword))))
+	mux.HandleFunc("/api/auth/verify-email", s.cors(s.limit(RateLimitAuth, s.limitBody(s.accountHandler.VerifyEmail))))
+	mux.HandleFunc("/api/auth/2fa/verify", s.cors(s.countLogins(metrics.LoginTwoFactor, s.limit(RateLimitAuth, s.limitBody(s.twoFactorHandler.VerifyLogin)))))
+	mux.HandleFunc("/api/auth/oidc/login", s.cors(s.limit(RateLimitAuth, s.oidcHandler.StartLogin)))
+	mux.HandleFunc("/api/auth/oidc/callback", s.cors(s.countLogins(metrics.LoginOIDC, s.limit(RateLimitAuth, s.limitBody(s.oidcHandler.Callback)))))
+
+	// Calendar feeds authenticate with their own token, as calendar apps cannot send Bearer headers
+	mux.HandleFunc("/api/calendars/{id}/ical", s.cors(s.limit(RateLimitFeed, s.icalHandler.GetCalendarFeed)))
 
 	// Protected routes
-	mux.HandleFunc("/api/users/", CORSMiddleware(AuthMiddleware(s.userHandler.GetUser)))
-	mux.HandleFunc("/api/calendars", CORSMiddleware(AuthMiddleware(MaxBodyBytes(1<<20, s.handleCalendars))))
-	mux.HandleFunc("/api/calendars/", CORSMiddleware(AuthMiddleware(MaxBodyBytes(1<<20, s.handleCalendarByID))))
+	mux.HandleFunc("/api/auth/logout", s.cors(s.requireSession(RateLimitAPI, s.sessionHandler.Logout)))
+	mux.HandleFunc("/api/auth/verify-email/resend", s.cors(s.requireSession(RateLimitAuth, s.accountHandler.ResendVerificationEmail)))
+	mux.HandleFunc("/api/auth/2fa", s.cors(s.requireSession(RateLimitAPI, s.twoFactorHandler.GetStatus)))
+	mux.HandleFunc("/api/auth/2fa/", s.cors(s.requireSession(RateLimitAuth, s.limitBody(s.handleTwoFactor))))
+	mux.HandleFunc("/api/auth/sessions", s.cors(s.requireSession(RateLimitAPI, s.sessionHandler.GetSessions)))
+	mux.HandleFunc("/api/auth/sessions/", s.cors(s.requireSession(RateLimitAPI, s.sessionHandler.RevokeSession)))
+	mux.HandleFunc("/api/auth/api-keys", s.cors(s.requireSession(RateLimitAPI, s.limitBody(s.handleAPIKeys))))
+	mux.HandleFunc("/api/auth/api-keys/", s.cors(s.requireSession(RateLimitAPI, s.apiKeyHandler.RevokeAPIKey)))
+	mux.HandleFunc("/api/users/", s.cors(s.requireSession(RateLimitAPI, s.limitBody(s.handleUserByID))))
+	mux.HandleFunc("/api/calendars", s.cors(s.requireAuth(RateLimitAPI, s.limitBody(s.handleCalendars))))
+	mux.HandleFunc("/api/calendars/", s.cors(s.requireAuth(RateLimitAPI, s.limitBody(s.handleCalendarByID))))
+	mux.HandleFunc("/api/invitations", s.cors(s.requireAuth(RateLimitAPI, s.memberHandler.GetInvitations)))
+	mux.HandleFunc("/api/invitations/", s.cors(s.requireAuth(RateLimitAPI, s.handleInvitationByID)))
+	mux.HandleFunc("/api/entries", s.cors(s.requireAuth(RateLimitAPI, s.dayEntryHandler.GetDayEntriesByDateRange)))
+	mux.HandleFunc("/api/export", s.cors(s.requireAuth(RateLimitBulk, s.exportHandler.Export)))
+	mux.HandleFunc("/api/import", s.cors(s.requireAuth(RateLimitBulk, s.limitImport(s.importHandler.Import))))
 
 	return mux
 }
 
+// requireAuth authenticates requests with session-bound access tokens or API
+// keys, then applies the rate limits of group so that they count per user as
+// well as per IP. Handlers behind it check the scopes they need.
+func (s *Server) requireAuth(group string, next http.HandlerFunc) http.HandlerFunc {
+	return APIKeyAuthMiddleware(s.tokens, s.sessions, s.apiKeys, s.limit(group, next))
+}
+
+// requireSession works like requireAuth but rejects API keys. It guards account
+// routes, so that a leaked key cannot take over the account.
+func (s *Server) requireSession(group string, next http.HandlerFunc) http.HandlerFunc {
+	return SessionAuthMiddleware(s.tokens, s.sessions, s.limit(group, next))
+}
+
+// limit applies the rate limits of a route group
+func (s *Server) limit(group string, next http.HandlerFunc) http.HandlerFunc {
+	return RateLimitMiddleware(s.limiter, group, next)
+}
+
+// cors lets browsers on the configured origins call next
+func (s *Server) cors(next http.HandlerFunc) http.HandlerFunc {
+	return CORSMiddleware(s.config.CORSOrigins, next)
+}
+
+// limitBody caps request bodies at the configured size
+func (s *Server) limitBody(next http.HandlerFunc) http.HandlerFunc {
+	return MaxBodyBytes(bodyLimit(s.config.MaxBodyBytes, DefaultConfig().MaxBodyBytes), next)
+}
+
+// limitImport caps import uploads at the configured size
+func (s *Server) limitImport(next http.HandlerFunc) http.HandlerFunc {
+	return MaxBodyBytes(bodyLimit(s.config.MaxImportBytes, DefaultConfig().MaxImportBytes), next)
+}
+
+// bodyLimit returns limit, or defaultLimit when none is configured
+func bodyLimit(limit, defaultLimit int64) int64 {
+	if limit <= 0 {
+		return defaultLimit
+	}
+	return limit
+}
+
+// countLogins counts the attempts made through a login route, including those
+// turned away by rate limits
+func (s *Server) countLogins(method string, next http.HandlerFunc) http.HandlerFunc {
+	return LoginMetricsMiddleware(s.metrics, method, next)
+}
+
+// handleTwoFactor routes requests to /api/auth/2fa/{action}
+func (s *Server) handleTwoFactor(w http.ResponseWriter, r *http.Request) {
+	switch strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/auth/2fa/"), "/") {
+	case "enroll":
+		s.twoFactorHandler.Enroll(w, r)
+	case "confirm":
+		s.twoFactorHandler.Confirm(w, r)
+	case "recovery-codes":
+		s.twoFactorHandler.RegenerateRecoveryCodes(w, r)
+	case "disable":
+		s.twoFactorHandler.Disable(w, r)
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// handleAPIKeys routes requests to /api/auth/api-keys
+func (s *Server) handleAPIKeys(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.apiKeyHandler.GetAPIKeys(w, r)
+	case http.MethodPost:
+		s.apiKeyHandler.CreateAPIKey(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleUserByID routes requests to /api/users/{id} and the /api/users/me account routes
+func (s *Server) handleUserByID(w http.ResponseWriter, r *http.Request) {
+	switch strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/users/"), "/") {
+	case "me":
+		s.userHandler.DeleteAccount(w, r)
+	case "me/password":
+		s.userHandler.ChangePassword(w, r)
+	case "me/email":
+		s.userHandler.ChangeEmail(w, r)
+	case "me/audit":
+		s.auditHandler.GetAuditEvents(w, r)
+	default:
+		s.userHandler.GetUser(w, r)
+	}
+}
+
 // handleCalendars routes requests to /api/calendars
 func (s *Server) handleCalendars(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -75,6 +275,47 @@ func (s *Server) handleCalendarByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Dispatch sub-resources such as /api/calendars/{id}/entries
+	segments := strings.Split(strings.TrimSuffix(path, "/"), "/")
+	if len(segments) > 1 {
+		switch segments[1] {
+		case "colors":
+			s.handleCalendarColors(w, r, segments[2:])
+		case "entries":
+			s.handleCalendarEntries(w, r, segments[2:])
+		case "stats":
+			if len(segments) != 2 {
+				http.Error(w, "Not found", http.StatusNotFound)
+				return
+			}
+			s.statsHandler.GetCalendarStats(w, r)
+		case "render":
+			if len(segments) != 2 {
+				http.Error(w, "Not found", http.StatusNotFound)
+				return
+			}
+			s.renderHandler.RenderCalendar(w, r)
+		case "ical":
+			// The feed itself is served without session auth, see SetupRoutes
+			if len(segments) != 3 || segments[2] != "token" {
+				http.Error(w, "Not found", http.StatusNotFound)
+				return
+			}
+			s.handleCalendarFeedToken(w, r)
+		case "members":
+			s.handleCalendarMembers(w, r, segments[2:])
+		case "invitations":
+			if len(segments) != 2 {
+				http.Error(w, "Not found", http.StatusNotFound)
+				return
+			}
+			s.handleCalendarInvitations(w, r)
+		default:
+			http.Error(w, "Not found", http.StatusNotFound)
+		}
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		s.calendarHandler.GetCalendar(w, r)
@@ -86,3 +327,124 @@ func (s *Server) handleCalendarByID(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
+
+// handleCalendarFeedToken routes requests to /api/calendars/{id}/ical/token
+func (s *Server) handleCalendarFeedToken(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.icalHandler.CreateFeedToken(w, r)
+	case http.MethodDelete:
+		s.icalHandler.RevokeFeedToken(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleCalendarMembers routes requests to /api/calendars/{id}/members[/{userId}]
+func (s *Server) handleCalendarMembers(w http.ResponseWriter, r *http.Request, rest []string) {
+	switch len(rest) {
+	case 0:
+		switch r.Method {
+		case http.MethodGet:
+			s.memberHandler.GetMembers(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case 1:
+		switch r.Method {
+		case http.MethodPut:
+			s.memberHandler.UpdateMemberRole(w, r)
+		case http.MethodDelete:
+			s.memberHandler.RemoveMember(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// handleCalendarInvitations routes requests to /api/calendars/{id}/invitations
+func (s *Server) handleCalendarInvitations(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.memberHandler.GetCalendarInvitations(w, r)
+	case http.MethodPost:
+		s.memberHandler.InviteMember(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleInvitationByID routes requests to /api/invitations/{id}[/accept]
+func (s *Server) handleInvitationByID(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/invitations/"), "/"), "/")
+	switch {
+	case len(segments) == 1 && segments[0] != "":
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.memberHandler.DeclineInvitation(w, r)
+	case len(segments) == 2 && segments[1] == "accept":
+		s.memberHandler.AcceptInvitation(w, r)
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// handleCalendarColors routes requests to /api/calendars/{id}/colors[/{colorId}]
+func (s *Server) handleCalendarColors(w http.ResponseWriter, r *http.Request, rest []string) {
+	switch len(rest) {
+	case 0:
+		switch r.Method {
+		case http.MethodGet:
+			s.colorMeaningHandler.GetColorMeanings(w, r)
+		case http.MethodPost:
+			s.colorMeaningHandler.CreateColorMeaning(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case 1:
+		switch r.Method {
+		case http.MethodGet:
+			s.colorMeaningHandler.GetColorMeaning(w, r)
+		case http.MethodPut:
+			s.colorMeaningHandler.UpdateColorMeaning(w, r)
+		case http.MethodDelete:
+			s.colorMeaningHandler.DeleteColorMeaning(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// handleCalendarEntries routes requests to /api/calendars/{id}/entries[/{date}]
+func (s *Server) handleCalendarEntries(w http.ResponseWriter, r *http.Request, rest []string) {
+	switch len(rest) {
+	case 0:
+		switch r.Method {
+		case http.MethodGet:
+			s.dayEntryHandler.GetDayEntries(w, r)
+		case http.MethodPost:
+			s.dayEntryHandler.CreateDayEntry(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case 1:
+		switch r.Method {
+		case http.MethodGet:
+			s.dayEntryHandler.GetDayEntry(w, r)
+		case http.MethodPut:
+			s.dayEntryHandler.UpdateDayEntry(w, r)
+		case http.MethodDelete:
+			s.dayEntryHandler.DeleteDayEntry(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+)
+
+type SessionHandler struct {
+	sessionService services.SessionServiceInterface
+}
+
+func NewSessionHandler(sessionService services.SessionServiceInterface) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+// Refresh handles POST /api/auth/refresh
+//
+//	@Summary		Refresh access token
+//	@Description	Exchange a refresh token for a new access token. The refresh token is rotated: the one sent is no longer valid afterwards.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			refresh	body		services.RefreshRequest	true	"Refresh token"
+//	@Success		200		{object}	services.TokenResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/api/auth/refresh [post]
+func (h *SessionHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req services.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	tokens, err := h.sessionService.Refresh(r.Context(), req.RefreshToken, services.SessionMetadata{
+		UserAgent: r.UserAgent(),
+		IPAddress: clientIP(r),
+	})
+	if err != nil {
+		switch err {
+		case services.ErrInvalidRefreshToken:
+			writeJSONError(w, http.StatusUnauthorized, err.Error())
+		default:
+			writeInternalError(w, r, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// Logout handles POST /api/auth/logout
+//
+//	@Summary		Logout
+//	@Description	Revoke the session of the access token used for the request
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Success		204	"No Content"
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/auth/logout [post]
+func (h *SessionHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user and session IDs from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	sessionID, ok := r.Context().Value(ctxSessionIDKey).(uuid.UUID)
+	if !ok || sessionID == uuid.Nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	err := h.sessionService.RevokeSession(r.Context(), userID, sessionID)
+	if err != nil && err != services.ErrSessionNotFound {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSessions handles GET /api/auth/sessions
+//
+//	@Summary		List active sessions
+//	@Description	List the devices the authenticated user is signed in on
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		services.SessionResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/auth/sessions [get]
+func (h *SessionHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	sessionID, _ := r.Context().Value(ctxSessionIDKey).(uuid.UUID)
+
+	sessions, err := h.sessionService.GetActiveSessions(r.Context(), userID, sessionID)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// RevokeSession handles DELETE /api/auth/sessions/{id}
+//
+//	@Summary		Revoke a session
+//	@Description	Sign the authenticated user out of one of their devices
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path	string	true	"Session ID"
+//	@Success		204	"No Content"
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/auth/sessions/{id} [delete]
+func (h *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// Extract session ID from URL path
+	sessionID, err := uuid.Parse(extractIDFromPath(r.URL.Path, "/api/auth/sessions/"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid session ID")
+		return
+	}
+
+	err = h.sessionService.RevokeSession(r.Context(), userID, sessionID)
+	if err != nil {
+		switch err {
+		case services.ErrSessionNotFound:
+			writeJSONError(w, http.StatusNotFound, err.Error())
+		default:
+			writeInternalError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
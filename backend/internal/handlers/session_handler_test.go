@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSessionService implements a mock for the SessionService
+type MockSessionService struct {
+	mock.Mock
+}
+
+func (m *MockSessionService) CreateSession(ctx context.Context, userID uuid.UUID, meta services.SessionMetadata) (*services.TokenResponse, error) {
+	args := m.Called(ctx, userID, meta)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.TokenResponse), args.Error(1)
+}
+
+func (m *MockSessionService) Refresh(ctx context.Context, refreshToken string, meta services.SessionMetadata) (*services.TokenResponse, error) {
+	args := m.Called(ctx, refreshToken, meta)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.TokenResponse), args.Error(1)
+}
+
+func (m *MockSessionService) GetActiveSessions(ctx context.Context, userID, currentSessionID uuid.UUID) ([]*services.SessionResponse, error) {
+	args := m.Called(ctx, userID, currentSessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*services.SessionResponse), args.Error(1)
+}
+
+func (m *MockSessionService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockSessionService) IsSessionActive(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, sessionID)
+	return args.Bool(0), args.Error(1)
+}
+
+func withSession(r *http.Request, userID, sessionID uuid.UUID) *http.Request {
+	ctx := context.WithValue(r.Context(), ctxUserIDKey, userID)
+	ctx = context.WithValue(ctx, ctxSessionIDKey, sessionID)
+	return r.WithContext(ctx)
+}
+
+func TestSessionHandler_Refresh(t *testing.T) {
+	mockService := new(MockSessionService)
+	handler := NewSessionHandler(mockService)
+
+	meta := services.SessionMetadata{UserAgent: "days-test", IPAddress: "192.0.2.1"}
+
+	t.Run("successful refresh", func(t *testing.T) {
+		expected := &services.TokenResponse{SessionID: uuid.New(), Token: "access", RefreshToken: "rotated", ExpiresIn: 900}
+		mockService.On("Refresh", mock.Anything, "current", meta).Return(expected, nil).Once()
+
+		body, _ := json.Marshal(services.RefreshRequest{RefreshToken: "current"})
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/refresh", bytes.NewBuffer(body))
+		req.Header.Set("User-Agent", "days-test")
+		w := httptest.NewRecorder()
+
+		handler.Refresh(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response services.TokenResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "rotated", response.RefreshToken)
+	})
+
+	t.Run("invalid refresh token", func(t *testing.T) {
+		mockService.On("Refresh", mock.Anything, "stale", meta).Return(nil, services.ErrInvalidRefreshToken).Once()
+
+		body, _ := json.Marshal(services.RefreshRequest{RefreshToken: "stale"})
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/refresh", bytes.NewBuffer(body))
+		req.Header.Set("User-Agent", "days-test")
+		w := httptest.NewRecorder()
+
+		handler.Refresh(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/refresh", bytes.NewBufferString("{"))
+		w := httptest.NewRecorder()
+
+		handler.Refresh(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	mockService.AssertExpectations(t)
+}
+
+func TestSessionHandler_Logout(t *testing.T) {
+	mockService := new(MockSessionService)
+	handler := NewSessionHandler(mockService)
+
+	userID := uuid.New()
+	sessionID := uuid.New()
+
+	mockService.On("RevokeSession", mock.Anything, userID, sessionID).Return(nil).Once()
+
+	req := withSession(httptest.NewRequest(http.MethodPost, "/api/auth/logout", nil), userID, sessionID)
+	w := httptest.NewRecorder()
+
+	handler.Logout(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestSessionHandler_RevokeSession(t *testing.T) {
+	mockService := new(MockSessionService)
+	handler := NewSessionHandler(mockService)
+
+	userID := uuid.New()
+	sessionID := uuid.New()
+
+	tests := []struct {
+		name           string
+		path           string
+		serviceErr     error
+		expectedStatus int
+	}{
+		{"revoked", "/api/auth/sessions/" + sessionID.String(), nil, http.StatusNoContent},
+		{"not found", "/api/auth/sessions/" + sessionID.String(), services.ErrSessionNotFound, http.StatusNotFound},
+		{"invalid ID", "/api/auth/sessions/not-a-uuid", nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.expectedStatus != http.StatusBadRequest {
+				mockService.On("RevokeSession", mock.Anything, userID, sessionID).Return(tt.serviceErr).Once()
+			}
+
+			req := withUserID(httptest.NewRequest(http.MethodDelete, tt.path, nil), userID)
+			w := httptest.NewRecorder()
+
+			handler.RevokeSession(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+)
+
+type StatsHandler struct {
+	statsService services.StatsServiceInterface
+}
+
+func NewStatsHandler(statsService services.StatsServiceInterface) *StatsHandler {
+	return &StatsHandler{
+		statsService: statsService,
+	}
+}
+
+// GetCalendarStats handles GET /api/calendars/{id}/stats
+//
+//	@Summary		Get calendar statistics
+//	@Description	Compute streaks, the longest gap and entry counts per meaning, weekday and month over a date range (user must be a member)
+//	@Tags			stats
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string	true	"Calendar ID"
+//	@Param			from	query		string	false	"Start date (YYYY-MM-DD), defaults to the first entry; ranges span at most 100 years"
+//	@Param			to		query		string	false	"End date (YYYY-MM-DD), defaults to today"
+//	@Param			meaning	query		string	false	"Color meaning ID the streaks are computed for, defaults to any entry"
+//	@Success		200		{object}	services.CalendarStatsResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/calendars/{id}/stats [get]
+func (h *StatsHandler) GetCalendarStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Extract user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !requireScope(w, r, services.ScopeEntriesRead) {
+		return
+	}
+
+	// Extract calendar ID from URL path
+	calendarID, err := uuid.Parse(extractIDFromPath(r.URL.Path, "/api/calendars/"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid calendar ID")
+		return
+	}
+
+	query := r.URL.Query()
+	req := services.StatsRequest{
+		From: query.Get("from"),
+		To:   query.Get("to"),
+	}
+	if meaning := query.Get("meaning"); meaning != "" {
+		colorMeaningID, err := uuid.Parse(meaning)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid color meaning ID")
+			return
+		}
+		req.ColorMeaningID = &colorMeaningID
+	}
+
+	stats, err := h.statsService.GetCalendarStats(r.Context(), userID, calendarID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidDate), errors.Is(err, services.ErrInvalidDateRange),
+			errors.Is(err, services.ErrDateRangeTooLong), errors.Is(err, services.ErrColorMeaningMismatch):
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrCalendarNotFound):
+			writeJSONError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, services.ErrUnauthorizedCalendar):
+			writeJSONError(w, http.StatusForbidden, err.Error())
+		default:
+			writeInternalError(w, r, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
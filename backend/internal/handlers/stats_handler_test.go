@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockStatsService implements a mock for the StatsService
+type MockStatsService struct {
+	mock.Mock
+}
+
+func (m *MockStatsService) GetCalendarStats(ctx context.Context, userID, calendarID uuid.UUID, req services.StatsRequest) (*services.CalendarStatsResponse, error) {
+	args := m.Called(ctx, userID, calendarID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.CalendarStatsResponse), args.Error(1)
+}
+
+func TestStatsHandler_GetCalendarStats(t *testing.T) {
+	userID := uuid.New()
+	calendarID := uuid.New()
+	meaningID := uuid.New()
+	path := "/api/calendars/" + calendarID.String() + "/stats"
+
+	t.Run("passes the query to the service", func(t *testing.T) {
+		mockService := new(MockStatsService)
+		handler := NewStatsHandler(mockService)
+
+		expectedReq := services.StatsRequest{From: "2024-01-01", To: "2024-01-31", ColorMeaningID: &meaningID}
+		mockService.On("GetCalendarStats", mock.Anything, userID, calendarID, expectedReq).Return(&services.CalendarStatsResponse{
+			CalendarID:    calendarID,
+			CurrentStreak: services.StreakStats{Days: 5},
+		}, nil).Once()
+
+		req := withUserID(httptest.NewRequest(http.MethodGet, path+"?from=2024-01-01&to=2024-01-31&meaning="+meaningID.String(), nil), userID)
+		w := httptest.NewRecorder()
+
+		handler.GetCalendarStats(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response services.CalendarStatsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 5, response.CurrentStreak.Days)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid meaning", func(t *testing.T) {
+		handler := NewStatsHandler(new(MockStatsService))
+
+		req := withUserID(httptest.NewRequest(http.MethodGet, path+"?meaning=green", nil), userID)
+		w := httptest.NewRecorder()
+
+		handler.GetCalendarStats(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	errorTests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{"invalid date", services.ErrInvalidDate, http.StatusBadRequest},
+		{"invalid range", services.ErrInvalidDateRange, http.StatusBadRequest},
+		{"range too long", services.ErrDateRangeTooLong, http.StatusBadRequest},
+		{"meaning mismatch", services.ErrColorMeaningMismatch, http.StatusBadRequest},
+		{"calendar not found", services.ErrCalendarNotFound, http.StatusNotFound},
+		{"not the owner", services.ErrUnauthorizedCalendar, http.StatusForbidden},
+		{"unexpected", assert.AnError, http.StatusInternalServerError},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockStatsService)
+			handler := NewStatsHandler(mockService)
+
+			mockService.On("GetCalendarStats", mock.Anything, userID, calendarID, services.StatsRequest{}).Return(nil, tt.err).Once()
+
+			req := withUserID(httptest.NewRequest(http.MethodGet, path, nil), userID)
+			w := httptest.NewRecorder()
+
+			handler.GetCalendarStats(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestServer_CalendarStatsRouting(t *testing.T) {
+	mockService := new(MockStatsService)
+	server := &Server{statsHandler: NewStatsHandler(mockService)}
+
+	userID := uuid.New()
+	calendarID := uuid.New()
+
+	mockService.On("GetCalendarStats", mock.Anything, userID, calendarID, services.StatsRequest{}).Return(&services.CalendarStatsResponse{}, nil).Once()
+
+	req := withUserID(httptest.NewRequest(http.MethodGet, "/api/calendars/"+calendarID.String()+"/stats", nil), userID)
+	w := httptest.NewRecorder()
+	server.handleCalendarByID(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req = withUserID(httptest.NewRequest(http.MethodGet, "/api/calendars/"+calendarID.String()+"/stats/extra", nil), userID)
+	w = httptest.NewRecorder()
+	server.handleCalendarByID(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// TracingMiddleware starts a span for each request, continuing the trace of
+// the caller when the request carries W3C trace context. Spans are named
+// after the route that matched, once the mux has found it.
+func TracingMiddleware(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http.request", otelhttp.WithSpanNameFormatter(spanName))
+}
+
+// spanName names a request span "METHOD route", or "METHOD" before routing
+// and for requests that match no route
+func spanName(_ string, r *http.Request) string {
+	switch {
+	case r.Pattern == "":
+		return r.Method
+	case strings.HasPrefix(r.Pattern, r.Method+" "):
+		return r.Pattern
+	default:
+		return r.Method + " " + r.Pattern
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider recording the spans of the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return recorder
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	spans := recordSpans(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/calendars/", func(w http.ResponseWriter, r *http.Request) {
+		writeInternalError(w, r, errors.New("connection refused"))
+	})
+	handler := TracingMiddleware(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/calendars/123", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	ended := spans.Ended()
+	require.Len(t, ended, 1)
+	span := ended[0]
+	assert.Equal(t, "GET /api/calendars/", span.Name())
+
+	// The span continues the caller's trace
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+
+	// The cause of the 500 is on the span
+	assert.Equal(t, codes.Error, span.Status().Code)
+	require.NotEmpty(t, span.Events())
+	assert.Equal(t, "exception", span.Events()[0].Name)
+}
+
+func TestSpanName(t *testing.T) {
+	tests := []struct {
+		method, pattern string
+		expected        string
+	}{
+		{http.MethodGet, "", "GET"},
+		{http.MethodPost, "/api/calendars", "POST /api/calendars"},
+		{http.MethodGet, "GET /metrics", "GET /metrics"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, "/", nil)
+		r.Pattern = tt.pattern
+		assert.Equal(t, tt.expected, spanName("http.request", r))
+	}
+}
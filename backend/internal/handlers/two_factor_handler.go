@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService services.TwoFactorServiceInterface
+}
+
+func NewTwoFactorHandler(twoFactorService services.TwoFactorServiceInterface) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+	}
+}
+
+// GetStatus handles GET /api/auth/2fa
+//
+//	@Summary		Get two-factor status
+//	@Description	Report whether the authenticated user has two-factor authentication on and how many recovery codes are left
+//	@Tags			2fa
+//	@Produce		json
+//	@Success		200	{object}	services.TwoFactorStatusResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/auth/2fa [get]
+func (h *TwoFactorHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	status, err := h.twoFactorService.GetStatus(r.Context(), userID)
+	if err != nil {
+		writeTwoFactorError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// Enroll handles POST /api/auth/2fa/enroll
+//
+//	@Summary		Start two-factor enrollment
+//	@Description	Generate a TOTP secret and its otpauth URI for an authenticator app. Two-factor authentication stays off until confirmed with a code; enrolling again replaces the secret.
+//	@Tags			2fa
+//	@Produce		json
+//	@Success		200	{object}	services.TOTPEnrollmentResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		409	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/auth/2fa/enroll [post]
+func (h *TwoFactorHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	enrollment, err := h.twoFactorService.Enroll(r.Context(), userID)
+	if err != nil {
+		writeTwoFactorError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(enrollment)
+}
+
+// Confirm handles POST /api/auth/2fa/confirm
+//
+//	@Summary		Confirm two-factor enrollment
+//	@Description	Turn two-factor authentication on with a code from the authenticator app. The response holds single-use recovery codes, which are shown only once.
+//	@Tags			2fa
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		services.TwoFactorCodeRequest	true	"Authenticator code"
+//	@Success		200		{object}	services.RecoveryCodesResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/auth/2fa/confirm [post]
+func (h *TwoFactorHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req services.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	codes, err := h.twoFactorService.Confirm(r.Context(), userID, req)
+	if err != nil {
+		writeTwoFactorError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(codes)
+}
+
+// RegenerateRecoveryCodes handles POST /api/auth/2fa/recovery-codes
+//
+//	@Summary		Regenerate recovery codes
+//	@Description	Replace all recovery codes with new ones, given a current authenticator or recovery code
+//	@Tags			2fa
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		services.TwoFactorCodeRequest	true	"Authenticator or recovery code"
+//	@Success		200		{object}	services.RecoveryCodesResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/auth/2fa/recovery-codes [post]
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req services.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(r.Context(), userID, req)
+	if err != nil {
+		writeTwoFactorError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(codes)
+}
+
+// Disable handles POST /api/auth/2fa/disable
+//
+//	@Summary		Disable two-factor authentication
+//	@Description	Turn two-factor authentication off. Requires the password and an authenticator or recovery code; all recovery codes are deleted.
+//	@Tags			2fa
+//	@Accept			json
+//	@Param			request	body	services.DisableTwoFactorRequest	true	"Password and code"
+//	@Success		204		"No Content"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/auth/2fa/disable [post]
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req services.DisableTwoFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	if err := h.twoFactorService.Disable(r.Context(), userID, req); err != nil {
+		writeTwoFactorError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifyLogin handles POST /api/auth/2fa/verify
+//
+//	@Summary		Complete a two-factor login
+//	@Description	Exchange the challenge token from a login with two_factor_required, together with an authenticator or recovery code, for a session. A challenge expires after five minutes or five wrong codes, and repeated wrong codes across challenges lock the account's second step for a while.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		services.TwoFactorLoginRequest	true	"Challenge token and code"
+//	@Success		200		{object}	services.LoginResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		429		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/api/auth/2fa/verify [post]
+func (h *TwoFactorHandler) VerifyLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req services.TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	req.UserAgent = r.UserAgent()
+	req.IPAddress = clientIP(r)
+
+	loginResponse, err := h.twoFactorService.VerifyLogin(r.Context(), req)
+	if err != nil {
+		var locked *services.AccountLockedError
+		switch {
+		case errors.Is(err, services.ErrInvalidChallenge), errors.Is(err, services.ErrInvalidTwoFactorCode):
+			writeJSONError(w, http.StatusUnauthorized, err.Error())
+		case errors.Is(err, services.ErrAccountDisabled):
+			writeJSONError(w, http.StatusForbidden, err.Error())
+		case errors.As(err, &locked):
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(locked.RetryAfter)))
+			writeJSONError(w, http.StatusTooManyRequests, err.Error())
+		default:
+			writeTwoFactorError(w, r, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(loginResponse)
+}
+
+// writeTwoFactorError maps two-factor service errors to HTTP responses
+func writeTwoFactorError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrUserNotFound):
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+	case errors.Is(err, services.ErrIncorrectPassword):
+		writeJSONError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrTwoFactorAlreadyEnabled),
+		errors.Is(err, services.ErrTwoFactorNotEnrolled),
+		errors.Is(err, services.ErrTwoFactorNotEnabled),
+		errors.Is(err, services.ErrPasswordNotSet):
+		writeJSONError(w, http.StatusConflict, err.Error())
+	default:
+		writeInternalError(w, r, err)
+	}
+}
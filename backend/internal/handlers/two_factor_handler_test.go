@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"days/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockTwoFactorService implements a mock for the TwoFactorService
+type MockTwoFactorService struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorService) CreateChallenge(ctx context.Context, userID uuid.UUID) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTwoFactorService) GetStatus(ctx context.Context, userID uuid.UUID) (*services.TwoFactorStatusResponse, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.TwoFactorStatusResponse), args.Error(1)
+}
+
+func (m *MockTwoFactorService) Enroll(ctx context.Context, userID uuid.UUID) (*services.TOTPEnrollmentResponse, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.TOTPEnrollmentResponse), args.Error(1)
+}
+
+func (m *MockTwoFactorService) Confirm(ctx context.Context, userID uuid.UUID, req services.TwoFactorCodeRequest) (*services.RecoveryCodesResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.RecoveryCodesResponse), args.Error(1)
+}
+
+func (m *MockTwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, req services.TwoFactorCodeRequest) (*services.RecoveryCodesResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.RecoveryCodesResponse), args.Error(1)
+}
+
+func (m *MockTwoFactorService) Disable(ctx context.Context, userID uuid.UUID, req services.DisableTwoFactorRequest) error {
+	args := m.Called(ctx, userID, req)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorService) VerifyLogin(ctx context.Context, req services.TwoFactorLoginRequest) (*services.LoginResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.LoginResponse), args.Error(1)
+}
+
+func TestTwoFactorHandler_Enroll(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name           string
+		result         *services.TOTPEnrollmentResponse
+		serviceErr     error
+		expectedStatus int
+	}{
+		{"enrolled", &services.TOTPEnrollmentResponse{Secret: "SECRET", URI: "otpauth://totp/Days:a@b.c?secret=SECRET"}, nil, http.StatusOK},
+		{"already enabled", nil, services.ErrTwoFactorAlreadyEnabled, http.StatusConflict},
+		{"database error", nil, errors.New("connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockTwoFactorService)
+			handler := NewTwoFactorHandler(mockService)
+			mockService.On("Enroll", mock.Anything, userID).Return(tt.result, tt.serviceErr).Once()
+
+			w := httptest.NewRecorder()
+			handler.Enroll(w, withUserID(httptest.NewRequest(http.MethodPost, "/api/auth/2fa/enroll", nil), userID))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.result != nil {
+				var response services.TOTPEnrollmentResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, *tt.result, response)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTwoFactorHandler_Confirm(t *testing.T) {
+	userID := uuid.New()
+	req := services.TwoFactorCodeRequest{Code: "123456"}
+
+	tests := []struct {
+		name           string
+		result         *services.RecoveryCodesResponse
+		serviceErr     error
+		expectedStatus int
+	}{
+		{"confirmed", &services.RecoveryCodesResponse{RecoveryCodes: []string{"k7d2q-x9m4a"}}, nil, http.StatusOK},
+		{"wrong code", nil, services.ErrInvalidTwoFactorCode, http.StatusBadRequest},
+		{"not enrolled", nil, services.ErrTwoFactorNotEnrolled, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockTwoFactorService)
+			handler := NewTwoFactorHandler(mockService)
+			mockService.On("Confirm", mock.Anything, userID, req).Return(tt.result, tt.serviceErr).Once()
+
+			body, _ := json.Marshal(req)
+			w := httptest.NewRecorder()
+			handler.Confirm(w, withUserID(httptest.NewRequest(http.MethodPost, "/api/auth/2fa/confirm", bytes.NewReader(body)), userID))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTwoFactorHandler_Disable(t *testing.T) {
+	userID := uuid.New()
+	req := services.DisableTwoFactorRequest{Password: "password123", Code: "123456"}
+
+	tests := []struct {
+		name           string
+		serviceErr     error
+		expectedStatus int
+	}{
+		{"disabled", nil, http.StatusNoContent},
+		{"wrong password", services.ErrIncorrectPassword, http.StatusForbidden},
+		{"not enabled", services.ErrTwoFactorNotEnabled, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockTwoFactorService)
+			handler := NewTwoFactorHandler(mockService)
+			mockService.On("Disable", mock.Anything, userID, req).Return(tt.serviceErr).Once()
+
+			body, _ := json.Marshal(req)
+			w := httptest.NewRecorder()
+			handler.Disable(w, withUserID(httptest.NewRequest(http.MethodPost, "/api/auth/2fa/disable", bytes.NewReader(body)), userID))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTwoFactorHandler_VerifyLogin(t *testing.T) {
+	// The handler records the client address on the session (httptest default)
+	req := services.TwoFactorLoginRequest{ChallengeToken: "challenge", Code: "123456", IPAddress: "192.0.2.1"}
+
+	tests := []struct {
+		name           string
+		result         *services.LoginResponse
+		serviceErr     error
+		expectedStatus int
+	}{
+		{"verified", &services.LoginResponse{Token: "access", RefreshToken: "refresh", ExpiresIn: 900}, nil, http.StatusOK},
+		{"wrong code", nil, services.ErrInvalidTwoFactorCode, http.StatusUnauthorized},
+		{"expired challenge", nil, services.ErrInvalidChallenge, http.StatusUnauthorized},
+		{"locked", nil, &services.AccountLockedError{RetryAfter: 30 * time.Second}, http.StatusTooManyRequests},
+		{"database error", nil, errors.New("connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockTwoFactorService)
+			handler := NewTwoFactorHandler(mockService)
+			mockService.On("VerifyLogin", mock.Anything, req).Return(tt.result, tt.serviceErr).Once()
+
+			w := httptest.NewRecorder()
+			handler.VerifyLogin(w, httptest.NewRequest(http.MethodPost, "/api/auth/2fa/verify",
+				bytes.NewBufferString(`{"challenge_token":"challenge","code":"123456"}`)))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.result != nil {
+				var response services.LoginResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, "access", response.Token)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_TwoFactorRouting(t *testing.T) {
+	mockService := new(MockTwoFactorService)
+	server := &Server{twoFactorHandler: NewTwoFactorHandler(mockService)}
+	userID := uuid.New()
+
+	mockService.On("Enroll", mock.Anything, userID).Return(&services.TOTPEnrollmentResponse{}, nil).Once()
+	w := httptest.NewRecorder()
+	server.handleTwoFactor(w, withUserID(httptest.NewRequest(http.MethodPost, "/api/auth/2fa/enroll", nil), userID))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	server.handleTwoFactor(w, withUserID(httptest.NewRequest(http.MethodGet, "/api/auth/2fa/enroll", nil), userID))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = httptest.NewRecorder()
+	server.handleTwoFactor(w, withUserID(httptest.NewRequest(http.MethodPost, "/api/auth/2fa/other", nil), userID))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Completing a login needs no session
+	mockService.On("VerifyLogin", mock.Anything, mock.Anything).Return(nil, services.ErrInvalidChallenge).Once()
+	w = httptest.NewRecorder()
+	server.SetupRoutes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/auth/2fa/verify", bytes.NewBufferString(`{}`)))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	mockService.AssertExpectations(t)
+}
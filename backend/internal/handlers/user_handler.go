@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"days/internal/services"
 
@@ -48,6 +50,8 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
 		return
 	}
+	req.UserAgent = r.UserAgent()
+	req.IPAddress = clientIP(r)
 
 	user, err := h.userService.CreateUser(r.Context(), req)
 	if err != nil {
@@ -59,7 +63,7 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		case services.ErrEmailExists:
 			writeJSONError(w, http.StatusConflict, err.Error())
 		default:
-			writeJSONError(w, http.StatusInternalServerError, "internal server error")
+			writeInternalError(w, r, err)
 		}
 		return
 	}
@@ -72,7 +76,7 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 // Login handles POST /api/auth/login
 //
 //	@Summary		User login
-//	@Description	Authenticate user and return JWT token
+//	@Description	Authenticate user and return a short-lived JWT access token with a refresh token. For accounts with two-factor authentication on, the response instead has two_factor_required and a challenge_token to complete at /api/auth/2fa/verify. After repeated failed attempts the account is locked for a growing time, answered with 429 and Retry-After. Disabled accounts are answered with 403.
 //	@Tags			auth
 //	@Accept			json
 //	@Produce		json
@@ -80,6 +84,8 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 //	@Success		200			{object}	services.LoginResponse
 //	@Failure		400			{object}	ErrorResponse
 //	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		429			{object}	ErrorResponse
 //	@Failure		500			{object}	ErrorResponse
 //	@Router			/api/auth/login [post]
 func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
@@ -93,14 +99,22 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
 		return
 	}
+	req.UserAgent = r.UserAgent()
+	req.IPAddress = clientIP(r)
 
 	loginResponse, err := h.userService.Login(r.Context(), req)
 	if err != nil {
-		switch err {
-		case services.ErrInvalidCredentials:
+		var locked *services.AccountLockedError
+		switch {
+		case errors.Is(err, services.ErrInvalidCredentials):
 			writeJSONError(w, http.StatusUnauthorized, err.Error())
+		case errors.Is(err, services.ErrAccountDisabled):
+			writeJSONError(w, http.StatusForbidden, err.Error())
+		case errors.As(err, &locked):
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(locked.RetryAfter)))
+			writeJSONError(w, http.StatusTooManyRequests, err.Error())
 		default:
-			writeJSONError(w, http.StatusInternalServerError, "internal server error")
+			writeInternalError(w, r, err)
 		}
 		return
 	}
@@ -152,7 +166,7 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 		case services.ErrUserNotFound:
 			writeJSONError(w, http.StatusNotFound, err.Error())
 		default:
-			writeJSONError(w, http.StatusInternalServerError, "internal server error")
+			writeInternalError(w, r, err)
 		}
 		return
 	}
@@ -160,3 +174,154 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
 }
+
+// ChangePassword handles PUT /api/users/me/password
+//
+//	@Summary		Change password
+//	@Description	Set a new password, given the current one. Accounts created through single sign-on have none and set one with a password reset instead. Every session is signed out, including the current one; the response holds the tokens of a new session.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		services.ChangePasswordRequest	true	"Current and new password"
+//	@Success		200		{object}	services.TokenResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/users/me/password [put]
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req services.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	req.UserAgent = r.UserAgent()
+	req.IPAddress = clientIP(r)
+
+	tokens, err := h.userService.ChangePassword(r.Context(), userID, req)
+	if err != nil {
+		writeUserError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// ChangeEmail handles PUT /api/users/me/email
+//
+//	@Summary		Change email address
+//	@Description	Move the account to a new email address, given the password. The new address starts unverified and is sent a verification email.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		services.ChangeEmailRequest	true	"New email address and password"
+//	@Success		200		{object}	services.UserResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/users/me/email [put]
+func (h *UserHandler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req services.ChangeEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	req.UserAgent = r.UserAgent()
+	req.IPAddress = clientIP(r)
+
+	user, err := h.userService.ChangeEmail(r.Context(), userID, req)
+	if err != nil {
+		writeUserError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// DeleteAccount handles DELETE /api/users/me
+//
+//	@Summary		Delete account
+//	@Description	Permanently delete the account with its calendars, entries and sessions, given the password
+//	@Tags			users
+//	@Accept			json
+//	@Param			request	body	services.DeleteAccountRequest	true	"Password"
+//	@Success		204		"No Content"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/users/me [delete]
+func (h *UserHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(ctxUserIDKey).(uuid.UUID)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req services.DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	req.UserAgent = r.UserAgent()
+	req.IPAddress = clientIP(r)
+
+	if err := h.userService.DeleteAccount(r.Context(), userID, req); err != nil {
+		writeUserError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeUserError maps account self-service errors to HTTP responses
+func writeUserError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidEmail), errors.Is(err, services.ErrWeakPassword):
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrUserNotFound):
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+	case errors.Is(err, services.ErrIncorrectPassword):
+		writeJSONError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrEmailExists), errors.Is(err, services.ErrPasswordNotSet):
+		writeJSONError(w, http.StatusConflict, err.Error())
+	default:
+		writeInternalError(w, r, err)
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"days/internal/services"
 
@@ -46,14 +48,36 @@ func (m *MockUserService) Login(ctx context.Context, req services.LoginRequest)
 	return args.Get(0).(*services.LoginResponse), args.Error(1)
 }
 
+func (m *MockUserService) ChangePassword(ctx context.Context, userID uuid.UUID, req services.ChangePasswordRequest) (*services.TokenResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.TokenResponse), args.Error(1)
+}
+
+func (m *MockUserService) ChangeEmail(ctx context.Context, userID uuid.UUID, req services.ChangeEmailRequest) (*services.UserResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.UserResponse), args.Error(1)
+}
+
+func (m *MockUserService) DeleteAccount(ctx context.Context, userID uuid.UUID, req services.DeleteAccountRequest) error {
+	args := m.Called(ctx, userID, req)
+	return args.Error(0)
+}
+
 func TestUserHandler_CreateUser(t *testing.T) {
 	mockService := new(MockUserService)
 	handler := NewUserHandler(mockService)
 
 	t.Run("successful user creation", func(t *testing.T) {
 		req := services.CreateUserRequest{
-			Email:    "test@example.com",
-			Password: "password123",
+			Email:     "test@example.com",
+			Password:  "password123",
+			IPAddress: "192.0.2.1",
 		}
 		userID := uuid.New()
 		expectedResponse := &services.UserResponse{
@@ -114,8 +138,9 @@ func TestUserHandler_CreateUser(t *testing.T) {
 
 	t.Run("invalid email", func(t *testing.T) {
 		req := services.CreateUserRequest{
-			Email:    "invalid-email",
-			Password: "password123",
+			Email:     "invalid-email",
+			Password:  "password123",
+			IPAddress: "192.0.2.1",
 		}
 
 		mockService.On("CreateUser", mock.Anything, req).Return(nil, services.ErrInvalidEmail).Once()
@@ -138,8 +163,9 @@ func TestUserHandler_CreateUser(t *testing.T) {
 
 	t.Run("weak password", func(t *testing.T) {
 		req := services.CreateUserRequest{
-			Email:    "test@example.com",
-			Password: "123",
+			Email:     "test@example.com",
+			Password:  "123",
+			IPAddress: "192.0.2.1",
 		}
 
 		mockService.On("CreateUser", mock.Anything, req).Return(nil, services.ErrWeakPassword).Once()
@@ -162,8 +188,9 @@ func TestUserHandler_CreateUser(t *testing.T) {
 
 	t.Run("email already exists", func(t *testing.T) {
 		req := services.CreateUserRequest{
-			Email:    "existing@example.com",
-			Password: "password123",
+			Email:     "existing@example.com",
+			Password:  "password123",
+			IPAddress: "192.0.2.1",
 		}
 
 		mockService.On("CreateUser", mock.Anything, req).Return(nil, services.ErrEmailExists).Once()
@@ -195,16 +222,22 @@ func TestUserHandler_Login(t *testing.T) {
 			Password: "password123",
 		}
 		userID := uuid.New()
+
+		// The handler records the client address on the session (httptest default)
+		expectedReq := req
+		expectedReq.IPAddress = "192.0.2.1"
 		expectedResponse := &services.LoginResponse{
-			User: services.UserResponse{
+			User: &services.UserResponse{
 				ID:        userID,
 				Email:     "test@example.com",
 				CreatedAt: "2023-01-01T00:00:00Z",
 			},
-			Token: "jwt.token.here",
+			Token:        "jwt.token.here",
+			RefreshToken: "refresh.token.here",
+			ExpiresIn:    900,
 		}
 
-		mockService.On("Login", mock.Anything, req).Return(expectedResponse, nil).Once()
+		mockService.On("Login", mock.Anything, expectedReq).Return(expectedResponse, nil).Once()
 
 		reqBody, _ := json.Marshal(req)
 		httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBuffer(reqBody))
@@ -220,14 +253,16 @@ func TestUserHandler_Login(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, expectedResponse.User.ID, response.User.ID)
 		assert.Equal(t, expectedResponse.Token, response.Token)
+		assert.Equal(t, expectedResponse.RefreshToken, response.RefreshToken)
 
 		mockService.AssertExpectations(t)
 	})
 
 	t.Run("invalid credentials", func(t *testing.T) {
 		req := services.LoginRequest{
-			Email:    "test@example.com",
-			Password: "wrongpassword",
+			Email:     "test@example.com",
+			Password:  "wrongpassword",
+			IPAddress: "192.0.2.1",
 		}
 
 		mockService.On("Login", mock.Anything, req).Return(nil, services.ErrInvalidCredentials).Once()
@@ -247,6 +282,42 @@ func TestUserHandler_Login(t *testing.T) {
 
 		mockService.AssertExpectations(t)
 	})
+
+	t.Run("account locked", func(t *testing.T) {
+		req := services.LoginRequest{
+			Email:     "locked@example.com",
+			Password:  "password123",
+			IPAddress: "192.0.2.1",
+		}
+
+		mockService.On("Login", mock.Anything, req).
+			Return(nil, &services.AccountLockedError{RetryAfter: 90*time.Second + time.Millisecond}).Once()
+
+		reqBody, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		handler.Login(w, httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBuffer(reqBody)))
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "91", w.Header().Get("Retry-After"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("account disabled", func(t *testing.T) {
+		req := services.LoginRequest{
+			Email:     "disabled@example.com",
+			Password:  "password123",
+			IPAddress: "192.0.2.1",
+		}
+
+		mockService.On("Login", mock.Anything, req).Return(nil, services.ErrAccountDisabled).Once()
+
+		reqBody, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		handler.Login(w, httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBuffer(reqBody)))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockService.AssertExpectations(t)
+	})
 }
 
 func TestUserHandler_GetUser(t *testing.T) {
@@ -353,3 +424,156 @@ func TestUserHandler_GetUser(t *testing.T) {
 		assert.Equal(t, "forbidden: can only access own user record", errorResp.Error)
 	})
 }
+
+func TestUserHandler_ChangePassword(t *testing.T) {
+	userID := uuid.New()
+	// The handler records the client address (httptest default)
+	req := services.ChangePasswordRequest{CurrentPassword: "oldpassword", NewPassword: "newpassword", IPAddress: "192.0.2.1"}
+
+	tests := []struct {
+		name           string
+		result         *services.TokenResponse
+		serviceErr     error
+		expectedStatus int
+	}{
+		{"changed", &services.TokenResponse{Token: "access", RefreshToken: "refresh", ExpiresIn: 900}, nil, http.StatusOK},
+		{"wrong password", nil, services.ErrIncorrectPassword, http.StatusForbidden},
+		{"weak password", nil, services.ErrWeakPassword, http.StatusBadRequest},
+		{"user gone", nil, services.ErrUserNotFound, http.StatusUnauthorized},
+		{"database error", nil, errors.New("connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockUserService)
+			handler := NewUserHandler(mockService)
+			mockService.On("ChangePassword", mock.Anything, userID, req).Return(tt.result, tt.serviceErr).Once()
+
+			w := httptest.NewRecorder()
+			handler.ChangePassword(w, withUserID(httptest.NewRequest(http.MethodPut, "/api/users/me/password",
+				bytes.NewBufferString(`{"current_password":"oldpassword","new_password":"newpassword"}`)), userID))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.result != nil {
+				var response services.TokenResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, *tt.result, response)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserHandler_ChangeEmail(t *testing.T) {
+	userID := uuid.New()
+	req := services.ChangeEmailRequest{Email: "new@example.com", Password: "password123", IPAddress: "192.0.2.1"}
+
+	tests := []struct {
+		name           string
+		result         *services.UserResponse
+		serviceErr     error
+		expectedStatus int
+	}{
+		{"changed", &services.UserResponse{ID: userID, Email: "new@example.com"}, nil, http.StatusOK},
+		{"wrong password", nil, services.ErrIncorrectPassword, http.StatusForbidden},
+		{"invalid email", nil, services.ErrInvalidEmail, http.StatusBadRequest},
+		{"email taken", nil, services.ErrEmailExists, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockUserService)
+			handler := NewUserHandler(mockService)
+			mockService.On("ChangeEmail", mock.Anything, userID, req).Return(tt.result, tt.serviceErr).Once()
+
+			w := httptest.NewRecorder()
+			handler.ChangeEmail(w, withUserID(httptest.NewRequest(http.MethodPut, "/api/users/me/email",
+				bytes.NewBufferString(`{"email":"new@example.com","password":"password123"}`)), userID))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.result != nil {
+				var response services.UserResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, "new@example.com", response.Email)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserHandler_DeleteAccount(t *testing.T) {
+	userID := uuid.New()
+	req := services.DeleteAccountRequest{Password: "password123", IPAddress: "192.0.2.1"}
+
+	tests := []struct {
+		name           string
+		serviceErr     error
+		expectedStatus int
+	}{
+		{"deleted", nil, http.StatusNoContent},
+		{"wrong password", services.ErrIncorrectPassword, http.StatusForbidden},
+		{"database error", errors.New("connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockUserService)
+			handler := NewUserHandler(mockService)
+			mockService.On("DeleteAccount", mock.Anything, userID, req).Return(tt.serviceErr).Once()
+
+			w := httptest.NewRecorder()
+			handler.DeleteAccount(w, withUserID(httptest.NewRequest(http.MethodDelete, "/api/users/me",
+				bytes.NewBufferString(`{"password":"password123"}`)), userID))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+
+	t.Run("missing user ID in context", func(t *testing.T) {
+		handler := NewUserHandler(new(MockUserService))
+		w := httptest.NewRecorder()
+		handler.DeleteAccount(w, httptest.NewRequest(http.MethodDelete, "/api/users/me", bytes.NewBufferString(`{}`)))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestServer_UserRouting(t *testing.T) {
+	mockService := new(MockUserService)
+	mockAudit := new(MockAuditService)
+	server := &Server{userHandler: NewUserHandler(mockService), auditHandler: NewAuditHandler(mockAudit)}
+	userID := uuid.New()
+
+	mockService.On("DeleteAccount", mock.Anything, userID, mock.Anything).Return(nil).Once()
+	w := httptest.NewRecorder()
+	server.handleUserByID(w, withUserID(httptest.NewRequest(http.MethodDelete, "/api/users/me", bytes.NewBufferString(`{}`)), userID))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	mockService.On("ChangePassword", mock.Anything, userID, mock.Anything).Return(&services.TokenResponse{}, nil).Once()
+	w = httptest.NewRecorder()
+	server.handleUserByID(w, withUserID(httptest.NewRequest(http.MethodPut, "/api/users/me/password", bytes.NewBufferString(`{}`)), userID))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mockService.On("ChangeEmail", mock.Anything, userID, mock.Anything).Return(&services.UserResponse{}, nil).Once()
+	w = httptest.NewRecorder()
+	server.handleUserByID(w, withUserID(httptest.NewRequest(http.MethodPut, "/api/users/me/email", bytes.NewBufferString(`{}`)), userID))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mockAudit.On("GetAuditEvents", mock.Anything, userID, "", 0).Return(&services.AuditEventPage{}, nil).Once()
+	w = httptest.NewRecorder()
+	server.handleUserByID(w, withUserID(httptest.NewRequest(http.MethodGet, "/api/users/me/audit", nil), userID))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	server.handleUserByID(w, withUserID(httptest.NewRequest(http.MethodGet, "/api/users/me/password", nil), userID))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	// Other paths still look up a user by ID
+	mockService.On("GetUserByID", mock.Anything, userID).Return(&services.UserResponse{ID: userID}, nil).Once()
+	w = httptest.NewRecorder()
+	server.handleUserByID(w, withUserID(httptest.NewRequest(http.MethodGet, "/api/users/"+userID.String(), nil), userID))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mockService.AssertExpectations(t)
+	mockAudit.AssertExpectations(t)
+}
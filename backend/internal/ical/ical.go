@@ -0,0 +1,155 @@
+// Package ical writes iCalendar (RFC 5545) documents made of all-day events.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultProdID identifies the product that created a document
+const DefaultProdID = "-//days//days calendar//EN"
+
+// maxLineOctets is the longest a content line may be before it is folded
+const maxLineOctets = 75
+
+var ErrMissingUID = errors.New("event UID is required")
+
+// Calendar is a VCALENDAR object
+type Calendar struct {
+	ProdID      string // defaults to DefaultProdID
+	Name        string // shown by clients as the calendar name (X-WR-CALNAME)
+	Description string
+	Events      []Event
+}
+
+// Event is an all-day VEVENT
+type Event struct {
+	UID          string    // globally unique and stable across documents
+	Date         time.Time // only the year, month and day are used
+	Summary      string
+	Description  string
+	Stamp        time.Time // DTSTAMP; when this version of the event was created
+	LastModified time.Time // optional
+}
+
+// Write encodes c as an iCalendar document
+func Write(w io.Writer, c Calendar) error {
+	for _, event := range c.Events {
+		if event.UID == "" {
+			return ErrMissingUID
+		}
+	}
+
+	prodID := c.ProdID
+	if prodID == "" {
+		prodID = DefaultProdID
+	}
+
+	e := &encoder{w: bufio.NewWriter(w)}
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", prodID)
+	e.line("CALSCALE", "GREGORIAN")
+	e.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		e.line("X-WR-CALNAME", escapeText(c.Name))
+	}
+	if c.Description != "" {
+		e.line("X-WR-CALDESC", escapeText(c.Description))
+	}
+
+	for _, event := range c.Events {
+		day := time.Date(event.Date.Year(), event.Date.Month(), event.Date.Day(), 0, 0, 0, 0, time.UTC)
+
+		e.line("BEGIN", "VEVENT")
+		e.line("UID", escapeText(event.UID))
+		e.line("DTSTAMP", formatDateTime(event.Stamp))
+		e.line("DTSTART;VALUE=DATE", formatDate(day))
+		e.line("DTEND;VALUE=DATE", formatDate(day.AddDate(0, 0, 1)))
+		if event.Summary != "" {
+			e.line("SUMMARY", escapeText(event.Summary))
+		}
+		if event.Description != "" {
+			e.line("DESCRIPTION", escapeText(event.Description))
+		}
+		if !event.LastModified.IsZero() {
+			e.line("LAST-MODIFIED", formatDateTime(event.LastModified))
+		}
+		// All-day markers should not show the day as busy
+		e.line("TRANSP", "TRANSPARENT")
+		e.line("END", "VEVENT")
+	}
+
+	e.line("END", "VCALENDAR")
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// encoder writes content lines, remembering the first error
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line writes "name:value" folded into lines of at most 75 octets, each
+// continuation starting with a space. Folds never split a UTF-8 sequence.
+func (e *encoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+
+	s := name + ":" + value
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		e.write(s[:cut])
+		e.write("\r\n ")
+		s = s[cut:]
+		limit = maxLineOctets - 1 // the leading space counts
+	}
+	e.write(s)
+	e.write("\r\n")
+}
+
+func (e *encoder) write(s string) {
+	if e.err == nil {
+		_, e.err = e.w.WriteString(s)
+	}
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`;`, `\;`,
+	`,`, `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// escapeText escapes a TEXT value and drops control characters it may not contain
+func escapeText(s string) string {
+	s = textEscaper.Replace(s)
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' || r == 0x7F {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func formatDate(t time.Time) string {
+	return t.Format("20060102")
+}
+
+func formatDateTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
@@ -0,0 +1,125 @@
+package ical
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+var stamp = time.Date(2024, 2, 1, 12, 30, 0, 0, time.UTC)
+
+func TestWriteGolden(t *testing.T) {
+	tests := []struct {
+		name     string
+		calendar Calendar
+	}{
+		{
+			name:     "empty",
+			calendar: Calendar{},
+		},
+		{
+			name: "entries",
+			calendar: Calendar{
+				Name:        "Mood",
+				Description: "How I felt, day by day",
+				Events: []Event{
+					{
+						UID:          "6f1c1a52-8d5e-4a43-9b8e-0c6c7d1d2f10@days",
+						Date:         time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+						Summary:      "good",
+						Description:  "Long walk; then dinner, with friends\nSlept well",
+						Stamp:        stamp,
+						LastModified: time.Date(2024, 1, 16, 8, 0, 0, 0, time.FixedZone("CET", 3600)),
+					},
+					{
+						// Events end the next day, across month and year ends
+						UID:     "2b3e5f40-11aa-4c0e-8f00-3f1d2a9b7c55@days",
+						Date:    time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+						Summary: `tired\exhausted`,
+						Stamp:   stamp,
+					},
+				},
+			},
+		},
+		{
+			name: "folding",
+			calendar: Calendar{
+				Name: "Sport",
+				Events: []Event{
+					{
+						UID:         "c0ffee00-0000-4000-8000-000000000001@days",
+						Date:        time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+						Summary:     "run",
+						Description: strings.Repeat("Ran along the river ", 6) + strings.Repeat("äöü€", 12),
+						Stamp:       stamp,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Write(&buf, tt.calendar))
+
+			golden := filepath.Join("testdata", tt.name+".ics")
+			if *update {
+				require.NoError(t, os.WriteFile(golden, buf.Bytes(), 0o644))
+			}
+
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), buf.String())
+		})
+	}
+}
+
+func TestWriteFolding(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, Calendar{Events: []Event{{
+		UID:         "uid",
+		Summary:     strings.Repeat("€", 100),
+		Description: strings.Repeat("x", 300),
+		Stamp:       stamp,
+	}}}))
+
+	document := buf.String()
+	require.True(t, strings.HasSuffix(document, "END:VCALENDAR\r\n"))
+
+	lines := strings.Split(strings.TrimSuffix(document, "\r\n"), "\r\n")
+	var unfolded []string
+	for _, line := range lines {
+		assert.LessOrEqual(t, len(line), maxLineOctets, line)
+		assert.NotContains(t, line, "\n")
+		if strings.HasPrefix(line, " ") {
+			unfolded[len(unfolded)-1] += line[1:]
+			continue
+		}
+		unfolded = append(unfolded, line)
+	}
+
+	assert.Contains(t, unfolded, "SUMMARY:"+strings.Repeat("€", 100))
+	assert.Contains(t, unfolded, "DESCRIPTION:"+strings.Repeat("x", 300))
+}
+
+func TestEscapeText(t *testing.T) {
+	assert.Equal(t, `a\;b\,c\\d\ne\nf`, escapeText("a;b,c\\d\r\ne\nf"))
+	assert.Equal(t, "bell\tgone", escapeText("bell\a\tgone"))
+}
+
+func TestWriteRequiresUID(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, Calendar{Events: []Event{{Summary: "no uid"}}})
+	assert.ErrorIs(t, err, ErrMissingUID)
+	assert.Zero(t, buf.Len())
+}
@@ -0,0 +1,129 @@
+// Package logging sets up the structured logger of the server.
+//
+// Logs are written through log/slog as JSON or text lines. A request's
+// context carries its request ID, and the user ID once authenticated, and
+// every line logged with that context includes them, along with the trace
+// the line belongs to.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+var ErrInvalidConfig = errors.New("invalid logging config")
+
+// Config selects the level and format of logs
+type Config struct {
+	Level  string `yaml:"level" toml:"level"`   // debug, info, warn or error
+	Format string `yaml:"format" toml:"format"` // json or text
+}
+
+// DefaultConfig logs JSON lines at info level
+func DefaultConfig() Config {
+	return Config{Level: "info", Format: "json"}
+}
+
+// New returns a logger writing to w as config selects
+func New(w io.Writer, config *Config) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(config.Level)); err != nil {
+		return nil, fmt.Errorf("%w: LOG_LEVEL %q", ErrInvalidConfig, config.Level)
+	}
+	options := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch strings.ToLower(config.Format) {
+	case "json":
+		handler = slog.NewJSONHandler(w, options)
+	case "text":
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return nil, fmt.Errorf("%w: LOG_FORMAT %q", ErrInvalidConfig, config.Format)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
+
+// Setup makes a logger writing to stderr the default, which the stdlib
+// log package then writes through as well
+func Setup(config *Config) (*slog.Logger, error) {
+	logger, err := New(os.Stderr, config)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(logger)
+	return logger, nil
+}
+
+type ctxKey struct{}
+
+// request is what a request's context tells its log lines. The user ID is
+// set by authentication, deeper in the handler chain than the request ID,
+// so it is shared by pointer rather than added to a new context.
+type request struct {
+	id string
+
+	mu     sync.Mutex
+	userID string
+}
+
+// WithRequestID returns a context whose log lines carry the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &request{id: requestID})
+}
+
+// RequestID returns the request ID of the context, or "" outside requests
+func RequestID(ctx context.Context) string {
+	if req, ok := ctx.Value(ctxKey{}).(*request); ok {
+		return req.id
+	}
+	return ""
+}
+
+// SetUserID records who made the request. Later log lines of the request
+// carry the user ID, including those logged by outer middleware.
+func SetUserID(ctx context.Context, userID string) {
+	if req, ok := ctx.Value(ctxKey{}).(*request); ok {
+		req.mu.Lock()
+		req.userID = userID
+		req.mu.Unlock()
+	}
+}
+
+// contextHandler adds the request and user IDs of the context to each
+// record, and the trace and span IDs when the context carries a span
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if req, ok := ctx.Value(ctxKey{}).(*request); ok {
+		record.AddAttrs(slog.String("request_id", req.id))
+		req.mu.Lock()
+		userID := req.userID
+		req.mu.Unlock()
+		if userID != "" {
+			record.AddAttrs(slog.String("user_id", userID))
+		}
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
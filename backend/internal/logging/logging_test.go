@@ -0,0 +1,122 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"json", Config{Level: "info", Format: "json"}, false},
+		{"text", Config{Level: "debug", Format: "text"}, false},
+		{"format is case-insensitive", Config{Level: "WARN", Format: "JSON"}, false},
+		{"unknown level", Config{Level: "verbose", Format: "json"}, true},
+		{"unknown format", Config{Level: "info", Format: "xml"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, err := New(&bytes.Buffer{}, &tt.config)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidConfig)
+				assert.Nil(t, logger)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, logger)
+		})
+	}
+}
+
+func TestNew_Level(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, &Config{Level: "warn", Format: "text"})
+	require.NoError(t, err)
+
+	logger.Info("dropped")
+	logger.Warn("kept")
+
+	assert.NotContains(t, buf.String(), "dropped")
+	assert.Contains(t, buf.String(), "kept")
+}
+
+func TestContextHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, &Config{Level: "info", Format: "json"})
+	require.NoError(t, err)
+
+	readLine := func() map[string]any {
+		t.Helper()
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+		buf.Reset()
+		return line
+	}
+
+	// Outside a request nothing is added
+	logger.InfoContext(context.Background(), "startup")
+	line := readLine()
+	assert.NotContains(t, line, "request_id")
+	assert.NotContains(t, line, "user_id")
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	assert.Equal(t, "req-1", RequestID(ctx))
+	logger.InfoContext(ctx, "before auth")
+	line = readLine()
+	assert.Equal(t, "req-1", line["request_id"])
+	assert.NotContains(t, line, "user_id")
+
+	// The user ID shows up on the same context once set
+	SetUserID(ctx, "user-1")
+	logger.With("component", "test").InfoContext(ctx, "after auth")
+	line = readLine()
+	assert.Equal(t, "req-1", line["request_id"])
+	assert.Equal(t, "user-1", line["user_id"])
+	assert.Equal(t, "test", line["component"])
+}
+
+func TestSetUserID_OutsideRequest(t *testing.T) {
+	// Nothing to record to, and nothing to fail
+	SetUserID(context.Background(), "user-1")
+	assert.Empty(t, RequestID(context.Background()))
+}
+
+func TestDefaultConfig(t *testing.T) {
+	config := DefaultConfig()
+	assert.Equal(t, Config{Level: "info", Format: "json"}, config)
+
+	_, err := New(io.Discard, &config)
+	assert.NoError(t, err)
+}
+
+func TestContextHandler_Trace(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, &Config{Level: "info", Format: "json"})
+	require.NoError(t, err)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	logger.InfoContext(ctx, "traced")
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", line["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", line["span_id"])
+}
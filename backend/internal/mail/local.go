@@ -0,0 +1,74 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// FileMailer writes every message to a directory as an .eml file instead of
+// sending it. Files sort in the order they were sent.
+type FileMailer struct {
+	dir  string
+	from string
+	now  func() time.Time
+}
+
+// NewFileMailer returns a FileMailer writing to dir, creating it if needed
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	return &FileMailer{
+		dir:  dir,
+		from: from,
+		now:  time.Now,
+	}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	now := m.now()
+	data, err := format(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(m.dir, now.UTC().Format("20060102T150405.000000000Z")+"-*.eml")
+	if err != nil {
+		return fmt.Errorf("failed to create message file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write message file: %w", err)
+	}
+	return f.Close()
+}
+
+// LogMailer logs messages instead of sending them. It suits development,
+// where links in the log are as good as links in an inbox.
+type LogMailer struct {
+	logger *log.Logger
+	from   string
+}
+
+// NewLogMailer returns a LogMailer writing to logger, or the standard logger when nil
+func NewLogMailer(logger *log.Logger, from string) *LogMailer {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogMailer{
+		logger: logger,
+		from:   from,
+	}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	// Reject what the other mailers reject, so that development behaves the same
+	if _, err := format(m.from, msg, time.Now()); err != nil {
+		return err
+	}
+	m.logger.Printf("mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,112 @@
+// Package mail sends the plain-text emails of account flows such as password
+// resets and email verification.
+//
+// Mailer has three implementations: SMTPMailer delivers through a mail
+// server, FileMailer writes each message to a directory as an .eml file so
+// that tests and local setups can read them back, and LogMailer only logs.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidAddress = errors.New("invalid email address")
+	ErrUnknownDriver  = errors.New("unknown mail driver")
+)
+
+// Message is a plain-text email to a single recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config selects and configures a Mailer
+type Config struct {
+	Driver       string `yaml:"driver" toml:"driver"` // smtp, file or log
+	From         string `yaml:"from" toml:"from"`
+	SMTPHost     string `yaml:"smtp_host" toml:"smtp_host"`
+	SMTPPort     string `yaml:"smtp_port" toml:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username" toml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password" toml:"smtp_password"`
+	Dir          string `yaml:"dir" toml:"dir"` // where the file driver writes messages
+}
+
+// DefaultConfig only logs messages
+func DefaultConfig() Config {
+	return Config{
+		Driver:   "log",
+		From:     "Days <no-reply@localhost>",
+		SMTPHost: "localhost",
+		SMTPPort: "587",
+		Dir:      "mail",
+	}
+}
+
+// New returns the Mailer selected by config.Driver
+func New(config *Config) (Mailer, error) {
+	if _, err := netmail.ParseAddress(config.From); err != nil {
+		return nil, fmt.Errorf("%w: MAIL_FROM %q", ErrInvalidAddress, config.From)
+	}
+
+	switch config.Driver {
+	case "smtp":
+		return NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.From), nil
+	case "file":
+		return NewFileMailer(config.Dir, config.From)
+	case "log":
+		return NewLogMailer(nil, config.From), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownDriver, config.Driver)
+	}
+}
+
+// format renders a message as an RFC 5322 document with CRLF line endings
+func format(from string, msg Message, date time.Time) ([]byte, error) {
+	fromAddr, err := netmail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAddress, from)
+	}
+	toAddr, err := netmail.ParseAddress(msg.To)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAddress, msg.To)
+	}
+
+	var buf bytes.Buffer
+	// Q-encoding also covers control characters, so a subject cannot inject headers
+	fmt.Fprintf(&buf, "From: %s\r\n", fromAddr.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", toAddr.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	buf.WriteString("\r\n")
+
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	if !strings.HasSuffix(body, "\n") {
+		body += "\n"
+	}
+	qp := quotedprintable.NewWriter(&buf)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
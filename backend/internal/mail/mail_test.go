@@ -0,0 +1,182 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"mime/quotedprintable"
+	"net"
+	netmail "net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const from = "Days <no-reply@days.example>"
+
+var sentAt = time.Date(2024, 2, 1, 12, 30, 0, 0, time.UTC)
+
+// readMessage parses a formatted message and decodes its body
+func readMessage(t *testing.T, data []byte) (*netmail.Message, string) {
+	t.Helper()
+	msg, err := netmail.ReadMessage(bytes.NewReader(data))
+	require.NoError(t, err)
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	require.NoError(t, err)
+	return msg, string(body)
+}
+
+func TestFormat(t *testing.T) {
+	data, err := format(from, Message{
+		To:      "Ann <ann@example.com>",
+		Subject: "Grüße",
+		Body:    "Open this link:\nhttps://days.example/reset-password?token=" + strings.Repeat("a", 80) + "\n",
+	}, sentAt)
+	require.NoError(t, err)
+
+	assert.NotContains(t, strings.ReplaceAll(string(data), "\r\n", ""), "\n")
+	msg, body := readMessage(t, data)
+	assert.Equal(t, `"Days" <no-reply@days.example>`, msg.Header.Get("From"))
+	assert.Equal(t, `"Ann" <ann@example.com>`, msg.Header.Get("To"))
+	assert.Equal(t, "=?utf-8?q?Gr=C3=BC=C3=9Fe?=", msg.Header.Get("Subject"))
+	assert.Equal(t, "Thu, 01 Feb 2024 12:30:00 +0000", msg.Header.Get("Date"))
+	// Long lines are soft-wrapped on the wire and come back whole
+	assert.Equal(t, "Open this link:\r\nhttps://days.example/reset-password?token="+strings.Repeat("a", 80)+"\r\n", body)
+}
+
+func TestFormatRejectsHeaderInjection(t *testing.T) {
+	_, err := format(from, Message{To: "ann@example.com\r\nBcc: eve@example.com", Subject: "hi"}, sentAt)
+	assert.ErrorIs(t, err, ErrInvalidAddress)
+
+	data, err := format(from, Message{To: "ann@example.com", Subject: "hi\r\nBcc: eve@example.com"}, sentAt)
+	require.NoError(t, err)
+	msg, _ := readMessage(t, data)
+	assert.Empty(t, msg.Header.Get("Bcc"))
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	mailer, err := NewFileMailer(dir, from)
+	require.NoError(t, err)
+
+	for _, to := range []string{"first@example.com", "second@example.com"} {
+		require.NoError(t, mailer.Send(context.Background(), Message{To: to, Subject: "Hello", Body: "Hi " + to}))
+	}
+	assert.ErrorIs(t, mailer.Send(context.Background(), Message{To: "nobody"}), ErrInvalidAddress)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	// Files sort in sending order
+	for i, to := range []string{"first@example.com", "second@example.com"} {
+		data, err := os.ReadFile(files[i])
+		require.NoError(t, err)
+		msg, body := readMessage(t, data)
+		assert.Equal(t, "<"+to+">", msg.Header.Get("To"))
+		assert.Equal(t, "Hi "+to+"\r\n", body)
+	}
+}
+
+func TestLogMailer(t *testing.T) {
+	var buf bytes.Buffer
+	mailer := NewLogMailer(log.New(&buf, "", 0), from)
+
+	require.NoError(t, mailer.Send(context.Background(), Message{To: "ann@example.com", Subject: "Hello", Body: "https://days.example/verify"}))
+	assert.Contains(t, buf.String(), "mail to ann@example.com: Hello")
+	assert.Contains(t, buf.String(), "https://days.example/verify")
+}
+
+// fakeSMTPServer accepts a single SMTP session and records the message sent in it
+func fakeSMTPServer(t *testing.T) (host, port string, received <-chan []byte) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan []byte, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			switch verb := strings.ToUpper(strings.Fields(line)[0]); verb {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "MAIL", "RCPT":
+				tp.PrintfLine("250 OK")
+			case "DATA":
+				tp.PrintfLine("354 Go ahead")
+				data, err := tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				messages <- data
+				tp.PrintfLine("250 Queued")
+			case "QUIT":
+				tp.PrintfLine("221 Bye")
+				return
+			default:
+				tp.PrintfLine("502 Not implemented")
+			}
+		}
+	}()
+
+	host, port, err = net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	return host, port, messages
+}
+
+func TestSMTPMailer(t *testing.T) {
+	host, port, received := fakeSMTPServer(t)
+	mailer := NewSMTPMailer(host, port, "", "", from)
+	mailer.now = func() time.Time { return sentAt }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, mailer.Send(ctx, Message{To: "ann@example.com", Subject: "Reset", Body: "Your link"}))
+
+	select {
+	case data := <-received:
+		msg, body := readMessage(t, data)
+		assert.Equal(t, "Reset", msg.Header.Get("Subject"))
+		// The server side of DATA hands lines back with bare LF endings
+		assert.Equal(t, "Your link\n", body)
+	case <-ctx.Done():
+		t.Fatal("server received no message")
+	}
+}
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+
+	mailer, err := New(&Config{Driver: "file", From: from, Dir: dir})
+	require.NoError(t, err)
+	assert.IsType(t, &FileMailer{}, mailer)
+
+	mailer, err = New(&Config{Driver: "smtp", From: from, SMTPHost: "localhost", SMTPPort: "25"})
+	require.NoError(t, err)
+	assert.IsType(t, &SMTPMailer{}, mailer)
+
+	_, err = New(&Config{Driver: "pigeon", From: from})
+	assert.ErrorIs(t, err, ErrUnknownDriver)
+
+	_, err = New(&Config{Driver: "log", From: "not an address"})
+	assert.ErrorIs(t, err, ErrInvalidAddress)
+}
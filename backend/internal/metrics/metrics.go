@@ -0,0 +1,165 @@
+// Package metrics exposes the server's Prometheus metrics.
+//
+// Besides the Go runtime and process metrics, the registry holds request
+// counts and latencies by route and status, login outcomes, the state of the
+// database connection pool, and usage gauges read from the database at
+// scrape time.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"days/internal/db"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes the names of the metrics specific to this server
+const namespace = "days"
+
+// usageTimeout bounds the database query made for each scrape
+const usageTimeout = 5 * time.Second
+
+// Login methods and results counted by ObserveLogin
+const (
+	LoginPassword  = "password"
+	LoginTwoFactor = "two_factor"
+	LoginOIDC      = "oidc"
+
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+	LoginLocked  = "locked"
+	LoginError   = "error"
+)
+
+// UsageSource reads the usage figures published as gauges
+type UsageSource interface {
+	GetUsageMetrics(ctx context.Context) (db.GetUsageMetricsRow, error)
+}
+
+// Metrics holds the registry and the metrics recorded by the server
+type Metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	logins          *prometheus.CounterVec
+}
+
+// New creates the metrics of the server. The pool statistics of sqlDB and the
+// figures of usage are collected on each scrape; either may be nil.
+func New(sqlDB *sql.DB, usage UsageSource) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests served, by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to serve HTTP requests, by method, route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Login attempts, by method and result.",
+		}, []string{"method", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.logins,
+	)
+	if sqlDB != nil {
+		m.registry.MustRegister(collectors.NewDBStatsCollector(sqlDB, namespace))
+	}
+	if usage != nil {
+		m.registry.MustRegister(newUsageCollector(usage))
+	}
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		ErrorLog: slog.NewLogLogger(slog.Default().Handler(), slog.LevelError),
+	})
+}
+
+// ObserveRequest records a served request. route is the pattern that matched
+// rather than the path, so that the number of series stays bounded.
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(method, route, code).Inc()
+	m.requestDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// ObserveLogin records a login attempt, as one of the Login constants
+func (m *Metrics) ObserveLogin(method, result string) {
+	m.logins.WithLabelValues(method, result).Inc()
+}
+
+// usageCollector publishes figures read from the database. They are shared by
+// every instance of the server, which therefore all report the same values.
+type usageCollector struct {
+	source UsageSource
+
+	users                    *prometheus.Desc
+	calendars                *prometheus.Desc
+	dayEntries               *prometheus.Desc
+	dayEntriesCreatedLastDay *prometheus.Desc
+	activeSessions           *prometheus.Desc
+}
+
+func newUsageCollector(source UsageSource) *usageCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, nil, nil)
+	}
+	return &usageCollector{
+		source:                   source,
+		users:                    desc("users", "Registered users."),
+		calendars:                desc("calendars", "Calendars."),
+		dayEntries:               desc("day_entries", "Day entries."),
+		dayEntriesCreatedLastDay: desc("day_entries_created_last_day", "Day entries created in the last 24 hours."),
+		activeSessions:           desc("active_sessions", "Sessions that are neither revoked nor expired."),
+	}
+}
+
+func (c *usageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.users
+	ch <- c.calendars
+	ch <- c.dayEntries
+	ch <- c.dayEntriesCreatedLastDay
+	ch <- c.activeSessions
+}
+
+// Collect leaves the gauges out of a scrape when the database cannot be read,
+// rather than failing the other metrics with them
+func (c *usageCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), usageTimeout)
+	defer cancel()
+
+	usage, err := c.source.GetUsageMetrics(ctx)
+	if err != nil {
+		slog.Error("failed to read usage metrics", "error", err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.users, prometheus.GaugeValue, float64(usage.Users))
+	ch <- prometheus.MustNewConstMetric(c.calendars, prometheus.GaugeValue, float64(usage.Calendars))
+	ch <- prometheus.MustNewConstMetric(c.dayEntries, prometheus.GaugeValue, float64(usage.DayEntries))
+	ch <- prometheus.MustNewConstMetric(c.dayEntriesCreatedLastDay, prometheus.GaugeValue, float64(usage.DayEntriesCreatedLastDay))
+	ch <- prometheus.MustNewConstMetric(c.activeSessions, prometheus.GaugeValue, float64(usage.ActiveSessions))
+}
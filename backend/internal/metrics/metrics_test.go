@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"days/internal/db"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubUsage struct {
+	row db.GetUsageMetricsRow
+	err error
+}
+
+func (s stubUsage) GetUsageMetrics(context.Context) (db.GetUsageMetricsRow, error) {
+	return s.row, s.err
+}
+
+// scrape returns the metrics served by m in the text format
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	return w.Body.String()
+}
+
+func TestMetrics_Requests(t *testing.T) {
+	m := New(nil, nil)
+
+	m.ObserveRequest(http.MethodGet, "/api/calendars", http.StatusOK, 30*time.Millisecond)
+	m.ObserveRequest(http.MethodGet, "/api/calendars", http.StatusOK, 20*time.Millisecond)
+	m.ObserveRequest(http.MethodPost, "/api/auth/login", http.StatusUnauthorized, time.Millisecond)
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `days_http_requests_total{method="GET",route="/api/calendars",status="200"} 2`)
+	assert.Contains(t, body, `days_http_requests_total{method="POST",route="/api/auth/login",status="401"} 1`)
+	assert.Contains(t, body, `days_http_request_duration_seconds_count{method="GET",route="/api/calendars",status="200"} 2`)
+	assert.Contains(t, body, `days_http_request_duration_seconds_bucket{method="GET",route="/api/calendars",status="200",le="0.025"} 1`)
+	assert.Contains(t, body, "go_goroutines")
+}
+
+func TestMetrics_Logins(t *testing.T) {
+	m := New(nil, nil)
+
+	m.ObserveLogin(LoginPassword, LoginSuccess)
+	m.ObserveLogin(LoginPassword, LoginFailure)
+	m.ObserveLogin(LoginPassword, LoginFailure)
+	m.ObserveLogin(LoginOIDC, LoginLocked)
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `days_logins_total{method="password",result="success"} 1`)
+	assert.Contains(t, body, `days_logins_total{method="password",result="failure"} 2`)
+	assert.Contains(t, body, `days_logins_total{method="oidc",result="locked"} 1`)
+}
+
+func TestMetrics_Database(t *testing.T) {
+	// Opening does not connect, and the pool statistics need no connection
+	sqlDB, err := sql.Open("postgres", "host=localhost")
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	t.Run("usage", func(t *testing.T) {
+		m := New(sqlDB, stubUsage{row: db.GetUsageMetricsRow{
+			Users:                    3,
+			Calendars:                4,
+			DayEntries:               120,
+			DayEntriesCreatedLastDay: 7,
+			ActiveSessions:           2,
+		}})
+
+		body := scrape(t, m)
+		assert.Contains(t, body, `go_sql_max_open_connections{db_name="days"} 0`)
+		assert.Contains(t, body, "days_users 3")
+		assert.Contains(t, body, "days_calendars 4")
+		assert.Contains(t, body, "days_day_entries 120")
+		assert.Contains(t, body, "days_day_entries_created_last_day 7")
+		assert.Contains(t, body, "days_active_sessions 2")
+	})
+
+	t.Run("usage unavailable", func(t *testing.T) {
+		m := New(sqlDB, stubUsage{err: errors.New("connection refused")})
+
+		// The other metrics are still served
+		body := scrape(t, m)
+		assert.NotContains(t, body, "days_users")
+		assert.Contains(t, body, `go_sql_max_open_connections{db_name="days"}`)
+	})
+}
@@ -0,0 +1,95 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"days/db/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("pairs up and down scripts sorted by version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"010_later.up.sql":     {Data: []byte("CREATE TABLE later ();")},
+			"002_second.up.sql":    {Data: []byte("CREATE TABLE second ();")},
+			"002_second.down.sql":  {Data: []byte("DROP TABLE second;")},
+			"001_initial.up.sql":   {Data: []byte("CREATE TABLE initial ();")},
+			"001_initial.down.sql": {Data: []byte("DROP TABLE initial;")},
+			"README.md":            {Data: []byte("not a migration")},
+			"migrations.go":        {Data: []byte("package migrations")},
+		}
+
+		loaded, err := Load(fsys)
+		require.NoError(t, err)
+		require.Len(t, loaded, 3)
+
+		assert.Equal(t, int64(1), loaded[0].Version)
+		assert.Equal(t, "initial", loaded[0].Name)
+		assert.Equal(t, "DROP TABLE initial;", loaded[0].Down)
+		assert.Equal(t, int64(2), loaded[1].Version)
+		assert.Equal(t, int64(10), loaded[2].Version)
+		assert.Equal(t, "later", loaded[2].Name)
+		assert.Empty(t, loaded[2].Down)
+	})
+
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{
+			name: "no migrations",
+			fsys: fstest.MapFS{"README.md": {Data: []byte("nothing here")}},
+		},
+		{
+			name: "missing name",
+			fsys: fstest.MapFS{"001.up.sql": {Data: []byte("SELECT 1;")}},
+		},
+		{
+			name: "non-numeric version",
+			fsys: fstest.MapFS{"abc_initial.up.sql": {Data: []byte("SELECT 1;")}},
+		},
+		{
+			name: "down without up",
+			fsys: fstest.MapFS{"001_initial.down.sql": {Data: []byte("SELECT 1;")}},
+		},
+		{
+			name: "conflicting names for one version",
+			fsys: fstest.MapFS{
+				"001_initial.up.sql": {Data: []byte("SELECT 1;")},
+				"001_other.down.sql": {Data: []byte("SELECT 1;")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.fsys)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadEmbeddedMigrations(t *testing.T) {
+	loaded, err := Load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, loaded)
+
+	for i, migration := range loaded {
+		// Versions are contiguous so a gap is noticed in review
+		assert.Equal(t, int64(i+1), migration.Version)
+		assert.NotEmpty(t, migration.Down, "migration %d (%s) has no down script", migration.Version, migration.Name)
+	}
+}
+
+func TestMigratorTo_UnknownVersion(t *testing.T) {
+	m, err := New(nil, fstest.MapFS{"001_initial.up.sql": {Data: []byte("SELECT 1;")}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), m.Latest())
+
+	err = m.To(context.Background(), 5)
+	assert.ErrorIs(t, err, ErrUnknownVersion)
+}
@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// LockoutPolicy describes how a key is locked after repeated failures. The
+// failure that brings the count to Threshold locks the key for Base, and each
+// further failure doubles the lock, up to Max.
+type LockoutPolicy struct {
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+	// Window is how long after the latest failure the count starts over
+	Window time.Duration
+}
+
+// DefaultLockoutPolicy allows five attempts, then locks for 30 seconds, one
+// minute, two minutes and so on, up to 15 minutes.
+var DefaultLockoutPolicy = LockoutPolicy{
+	Threshold: 5,
+	Base:      30 * time.Second,
+	Max:       15 * time.Minute,
+	Window:    time.Hour,
+}
+
+// Lockout locks keys, such as account names, after repeated failures
+type Lockout struct {
+	store  Store
+	policy LockoutPolicy
+	now    func() time.Time
+}
+
+func NewLockout(store Store, policy LockoutPolicy) *Lockout {
+	return &Lockout{
+		store:  store,
+		policy: policy,
+		now:    time.Now,
+	}
+}
+
+// Check returns how much longer key is locked, or zero if it is not
+func (l *Lockout) Check(ctx context.Context, key string) (time.Duration, error) {
+	now := l.now()
+	count, last, err := l.store.Failures(ctx, key, now)
+	if err != nil {
+		return 0, err
+	}
+
+	until := last.Add(l.policy.lockFor(count))
+	if !now.Before(until) {
+		return 0, nil
+	}
+	return until.Sub(now), nil
+}
+
+// Fail records a failure for key and returns how long key is now locked
+func (l *Lockout) Fail(ctx context.Context, key string) (time.Duration, error) {
+	count, err := l.store.AddFailure(ctx, key, l.now(), l.policy.Window)
+	if err != nil {
+		return 0, err
+	}
+	return l.policy.lockFor(count), nil
+}
+
+// Reset clears the failures of key, typically after a success
+func (l *Lockout) Reset(ctx context.Context, key string) error {
+	return l.store.ResetFailures(ctx, key)
+}
+
+// lockFor returns how long a key is locked after failures consecutive failures
+func (p LockoutPolicy) lockFor(failures int) time.Duration {
+	if failures < p.Threshold || p.Base <= 0 {
+		return 0
+	}
+
+	d := p.Base
+	for i := p.Threshold; i < failures && d < p.Max; i++ {
+		d *= 2
+	}
+	if p.Max > 0 && d > p.Max {
+		d = p.Max
+	}
+	return d
+}
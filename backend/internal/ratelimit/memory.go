@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often MemoryStore drops state that no longer matters
+const sweepInterval = time.Minute
+
+// MemoryStore is a Store that keeps state in process memory. Limits apply per
+// server instance and reset when it restarts.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	failures  map[string]*failureRecord
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// full is when the bucket will have refilled, after which it can be dropped
+	full time.Time
+}
+
+type failureRecord struct {
+	count   int
+	last    time.Time
+	expires time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:  make(map[string]*bucket),
+		failures: make(map[string]*failureRecord),
+	}
+}
+
+func (s *MemoryStore) Allow(ctx context.Context, key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	if limit.Unlimited() {
+		return true, 0, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	burst := float64(limit.Requests)
+	rate := limit.rate()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, updated: now}
+		s.buckets[key] = b
+	} else if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed*rate)
+		b.updated = now
+	}
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+		return false, wait, nil
+	}
+
+	b.tokens--
+	b.full = now.Add(time.Duration((burst - b.tokens) / rate * float64(time.Second)))
+	return true, 0, nil
+}
+
+func (s *MemoryStore) AddFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	f, ok := s.failures[key]
+	if !ok || !now.Before(f.expires) {
+		f = &failureRecord{}
+		s.failures[key] = f
+	}
+	f.count++
+	f.last = now
+	f.expires = now.Add(window)
+	return f.count, nil
+}
+
+func (s *MemoryStore) Failures(ctx context.Context, key string, now time.Time) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.failures[key]
+	if !ok || !now.Before(f.expires) {
+		return 0, time.Time{}, nil
+	}
+	return f.count, f.last, nil
+}
+
+func (s *MemoryStore) ResetFailures(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, key)
+	return nil
+}
+
+// sweep drops full buckets and expired failures so that memory use follows
+// the number of recently active clients. Callers must hold s.mu.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, f := range s.failures {
+		if !now.Before(f.expires) {
+			delete(s.failures, key)
+		}
+	}
+}
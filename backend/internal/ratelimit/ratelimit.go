@@ -0,0 +1,49 @@
+// Package ratelimit provides token bucket rate limiting and progressive
+// lockouts after repeated failures. State lives in a Store: MemoryStore keeps
+// it in process, while a shared store lets several server instances enforce
+// one limit.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limit allows Requests requests per Per. A full bucket allows a burst of
+// Requests at once, and it refills evenly over Per. The zero Limit is
+// unlimited.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// Unlimited reports whether the limit lets every request through
+func (l Limit) Unlimited() bool {
+	return l.Requests <= 0 || l.Per <= 0
+}
+
+// rate returns the refill rate in tokens per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// Store keeps rate limiting state. Implementations must be safe for
+// concurrent use and apply each call atomically.
+type Store interface {
+	// Allow takes a token from the bucket at key, which starts full and
+	// refills at limit's rate. When the bucket is empty it returns false and
+	// how long until a token is available.
+	Allow(ctx context.Context, key string, limit Limit, now time.Time) (bool, time.Duration, error)
+
+	// AddFailure records a failure for key and returns the number of failures
+	// since the last reset. Failures are forgotten once window has passed
+	// since the latest one.
+	AddFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, error)
+
+	// Failures returns the number of failures recorded for key and the time of
+	// the latest one
+	Failures(ctx context.Context, key string, now time.Time) (int, time.Time, error)
+
+	// ResetFailures forgets the failures recorded for key
+	ResetFailures(ctx context.Context, key string) error
+}
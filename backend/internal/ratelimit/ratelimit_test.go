@@ -0,0 +1,176 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestMemoryStore_Allow(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	limit := Limit{Requests: 3, Per: time.Minute} // one token every 20s
+
+	// A full bucket allows a burst
+	for i := 0; i < 3; i++ {
+		allowed, _, err := store.Allow(ctx, "k", limit, start)
+		require.NoError(t, err)
+		assert.True(t, allowed, "request %d", i)
+	}
+
+	allowed, wait, err := store.Allow(ctx, "k", limit, start)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 20*time.Second, wait)
+
+	// Half a token later the wait has halved
+	allowed, wait, _ = store.Allow(ctx, "k", limit, start.Add(10*time.Second))
+	assert.False(t, allowed)
+	assert.Equal(t, 10*time.Second, wait)
+
+	allowed, _, _ = store.Allow(ctx, "k", limit, start.Add(20*time.Second))
+	assert.True(t, allowed)
+
+	// Keys have separate buckets
+	allowed, _, _ = store.Allow(ctx, "other", limit, start)
+	assert.True(t, allowed)
+
+	// The bucket never holds more than the burst
+	for i := 0; i < 3; i++ {
+		allowed, _, _ = store.Allow(ctx, "k", limit, start.Add(time.Hour))
+		assert.True(t, allowed)
+	}
+	allowed, _, _ = store.Allow(ctx, "k", limit, start.Add(time.Hour))
+	assert.False(t, allowed)
+}
+
+func TestMemoryStore_AllowUnlimited(t *testing.T) {
+	store := NewMemoryStore()
+	for i := 0; i < 100; i++ {
+		allowed, _, err := store.Allow(context.Background(), "k", Limit{}, start)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	assert.Empty(t, store.buckets)
+}
+
+func TestMemoryStore_AllowConcurrent(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Requests: 50, Per: time.Hour}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, _, _ := store.Allow(context.Background(), "k", limit, start)
+			if ok {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 50, allowed)
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	limit := Limit{Requests: 2, Per: time.Minute}
+
+	store.Allow(ctx, "idle", limit, start)
+	store.AddFailure(ctx, "old", start, time.Minute)
+	store.Allow(ctx, "busy", limit, start.Add(2*time.Minute))
+
+	assert.NotContains(t, store.buckets, "idle")
+	assert.Contains(t, store.buckets, "busy")
+	assert.NotContains(t, store.failures, "old")
+}
+
+func TestMemoryStore_Failures(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	for i := 1; i <= 3; i++ {
+		count, err := store.AddFailure(ctx, "k", start.Add(time.Duration(i)*time.Minute), time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, i, count)
+	}
+
+	count, last, err := store.Failures(ctx, "k", start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, start.Add(3*time.Minute), last)
+
+	// The window runs from the latest failure
+	count, _, _ = store.Failures(ctx, "k", start.Add(63*time.Minute))
+	assert.Equal(t, 0, count)
+	count, _ = store.AddFailure(ctx, "k", start.Add(63*time.Minute), time.Hour)
+	assert.Equal(t, 1, count)
+
+	require.NoError(t, store.ResetFailures(ctx, "k"))
+	count, _, _ = store.Failures(ctx, "k", start.Add(63*time.Minute))
+	assert.Equal(t, 0, count)
+}
+
+func TestLockout(t *testing.T) {
+	ctx := context.Background()
+	now := start
+	lockout := NewLockout(NewMemoryStore(), DefaultLockoutPolicy)
+	lockout.now = func() time.Time { return now }
+
+	// Four failures are free
+	for i := 0; i < 4; i++ {
+		locked, err := lockout.Fail(ctx, "ann")
+		require.NoError(t, err)
+		assert.Zero(t, locked)
+	}
+	wait, err := lockout.Check(ctx, "ann")
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+
+	// The fifth locks, and each further one doubles the lock
+	for _, want := range []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute} {
+		locked, err := lockout.Fail(ctx, "ann")
+		require.NoError(t, err)
+		assert.Equal(t, want, locked)
+
+		wait, err := lockout.Check(ctx, "ann")
+		require.NoError(t, err)
+		assert.Equal(t, want, wait)
+
+		now = now.Add(want)
+		wait, _ = lockout.Check(ctx, "ann")
+		assert.Zero(t, wait)
+	}
+
+	// Other keys are unaffected
+	wait, _ = lockout.Check(ctx, "bob")
+	assert.Zero(t, wait)
+
+	require.NoError(t, lockout.Reset(ctx, "ann"))
+	locked, _ := lockout.Fail(ctx, "ann")
+	assert.Zero(t, locked)
+}
+
+func TestLockoutPolicy_lockFor(t *testing.T) {
+	p := DefaultLockoutPolicy
+
+	assert.Zero(t, p.lockFor(0))
+	assert.Zero(t, p.lockFor(4))
+	assert.Equal(t, 30*time.Second, p.lockFor(5))
+	assert.Equal(t, 8*time.Minute, p.lockFor(9))
+	assert.Equal(t, 15*time.Minute, p.lockFor(10))
+	assert.Equal(t, 15*time.Minute, p.lockFor(1000))
+}
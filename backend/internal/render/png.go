@@ -0,0 +1,48 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+)
+
+// PNG writes the calendar as a PNG image
+func PNG(w io.Writer, c Calendar) error {
+	d, err := layout(c)
+	if err != nil {
+		return err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, d.width, d.height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(BackgroundColor), image.Point{}, draw.Src)
+
+	for _, r := range d.rects {
+		draw.Draw(img, image.Rect(r.x, r.y, r.x+r.w, r.y+r.h), image.NewUniform(r.color), image.Point{}, draw.Src)
+	}
+
+	for _, t := range d.texts {
+		drawText(img, t.x, t.y, t.s, TextColor)
+	}
+
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	return encoder.Encode(w, img)
+}
+
+func drawText(img draw.Image, x, y int, s string, c color.Color) {
+	fill := image.NewUniform(c)
+	for _, r := range s {
+		g := glyph(r)
+		for row, line := range g {
+			for col, pixel := range line {
+				if pixel != '#' {
+					continue
+				}
+				px, py := x+col*glyphScale, y+row*glyphScale
+				draw.Draw(img, image.Rect(px, py, px+glyphScale, py+glyphScale), fill, image.Point{}, draw.Src)
+			}
+		}
+		x += glyphAdvance
+	}
+}
@@ -0,0 +1,180 @@
+// Package render draws a year of day entries as a grid of colored cells, the
+// "year in pixels" view of a calendar, using only the standard library.
+//
+// Two layouts are supported: LayoutMonths puts the months in 12 columns of up
+// to 31 days, and LayoutWeeks draws a GitHub-style grid of week columns with
+// one row per weekday starting on Sunday. Both layouts can be encoded as SVG
+// or PNG and produce the same geometry.
+package render
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+type Layout string
+
+const (
+	LayoutMonths Layout = "months"
+	LayoutWeeks  Layout = "weeks"
+)
+
+var ErrInvalidColor = errors.New("invalid color")
+
+// Geometry in pixels
+const (
+	cellSize    = 12
+	cellGap     = 2
+	cellPitch   = cellSize + cellGap
+	margin      = 16
+	labelHeight = textHeight + 4
+	legendPitch = cellSize + 4
+)
+
+var (
+	// EmptyColor fills days without an entry
+	EmptyColor = color.RGBA{R: 0xEB, G: 0xED, B: 0xF0, A: 0xFF}
+	// BackgroundColor fills the image behind the grid
+	BackgroundColor = color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+	// TextColor is used for month and legend labels
+	TextColor = color.RGBA{R: 0x24, G: 0x29, B: 0x2F, A: 0xFF}
+)
+
+// LegendItem explains what one color means
+type LegendItem struct {
+	Color color.RGBA
+	Label string
+}
+
+// Calendar is everything needed to draw one year
+type Calendar struct {
+	Year   int
+	Layout Layout
+	Days   map[string]color.RGBA // keyed by date, YYYY-MM-DD
+	Legend []LegendItem          // drawn below the grid when not empty
+}
+
+// ParseHexColor parses colors in the #RRGGBB format used by color meanings
+func ParseHexColor(hex string) (color.RGBA, error) {
+	if len(hex) != 7 || hex[0] != '#' {
+		return color.RGBA{}, fmt.Errorf("%w: %q", ErrInvalidColor, hex)
+	}
+
+	value, err := strconv.ParseUint(hex[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("%w: %q", ErrInvalidColor, hex)
+	}
+
+	return color.RGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 0xFF}, nil
+}
+
+// rect is a filled rectangle; title names the day for cells
+type rect struct {
+	x, y, w, h int
+	color      color.RGBA
+	title      string
+}
+
+// text is a single line of text whose top-left corner is at x, y
+type text struct {
+	x, y int
+	s    string
+}
+
+// drawing is the geometry shared by the SVG and PNG encoders
+type drawing struct {
+	width, height int
+	rects         []rect
+	texts         []text
+}
+
+func layout(c Calendar) (*drawing, error) {
+	if c.Year < 1 || c.Year > 9999 {
+		return nil, fmt.Errorf("year %d out of range", c.Year)
+	}
+
+	d := &drawing{}
+	gridTop := margin + labelHeight
+
+	var columns, rows int
+	switch c.Layout {
+	case LayoutMonths:
+		columns, rows = 12, 31
+		for month := time.January; month <= time.December; month++ {
+			x := margin + int(month-1)*cellPitch
+			d.texts = append(d.texts, text{x: x + (cellSize-textWidth("M"))/2, y: margin, s: month.String()[:1]})
+
+			for day := 1; day <= daysIn(c.Year, month); day++ {
+				date := time.Date(c.Year, month, day, 0, 0, 0, 0, time.UTC)
+				d.rects = append(d.rects, dayRect(c, date, x, gridTop+(day-1)*cellPitch))
+			}
+		}
+
+	case LayoutWeeks:
+		first := time.Date(c.Year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		offset := int(first.Weekday())
+		days := time.Date(c.Year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+		columns, rows = (days+offset+6)/7, 7
+
+		for i := 0; i < days; i++ {
+			date := first.AddDate(0, 0, i)
+			column, row := (i+offset)/7, (i+offset)%7
+			x := margin + column*cellPitch
+
+			if date.Day() == 1 {
+				d.texts = append(d.texts, text{x: x, y: margin, s: date.Month().String()[:3]})
+			}
+			d.rects = append(d.rects, dayRect(c, date, x, gridTop+row*cellPitch))
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown layout %q", c.Layout)
+	}
+
+	d.width = 2*margin + columns*cellPitch - cellGap
+	d.height = gridTop + rows*cellPitch - cellGap + margin
+
+	if len(c.Legend) > 0 {
+		top := d.height
+		for i, item := range c.Legend {
+			y := top + i*legendPitch
+			labelX := margin + cellSize + 6
+			d.rects = append(d.rects, rect{x: margin, y: y, w: cellSize, h: cellSize, color: item.Color})
+			d.texts = append(d.texts, text{x: labelX, y: y + (cellSize-textHeight)/2, s: item.Label})
+			d.width = max(d.width, labelX+textWidth(item.Label)+margin)
+		}
+		d.height = top + len(c.Legend)*legendPitch - (legendPitch - cellSize) + margin
+	}
+
+	return d, nil
+}
+
+func dayRect(c Calendar, date time.Time, x, y int) rect {
+	key := date.Format("2006-01-02")
+	fill, ok := c.Days[key]
+	if !ok {
+		fill = EmptyColor
+	}
+	return rect{x: x, y: y, w: cellSize, h: cellSize, color: fill, title: key}
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// textWidth is the width of s when drawn with the built-in bitmap font
+func textWidth(s string) int {
+	n := utf8.RuneCountInString(s)
+	if n == 0 {
+		return 0
+	}
+	return n*glyphAdvance - glyphScale
+}
@@ -0,0 +1,141 @@
+package render
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var green = color.RGBA{R: 0x00, G: 0xFF, B: 0x00, A: 0xFF}
+
+func TestParseHexColor(t *testing.T) {
+	c, err := ParseHexColor("#12AbEf")
+	require.NoError(t, err)
+	assert.Equal(t, color.RGBA{R: 0x12, G: 0xAB, B: 0xEF, A: 0xFF}, c)
+
+	for _, invalid := range []string{"", "12ABEF", "#12ABE", "#12ABEG", "#12ABEF0"} {
+		_, err := ParseHexColor(invalid)
+		assert.ErrorIs(t, err, ErrInvalidColor, invalid)
+	}
+}
+
+func TestLayout(t *testing.T) {
+	t.Run("months", func(t *testing.T) {
+		d, err := layout(Calendar{Year: 2024, Layout: LayoutMonths})
+		require.NoError(t, err)
+
+		// 2024 is a leap year
+		assert.Len(t, d.rects, 366)
+		assert.Len(t, d.texts, 12)
+		assert.Equal(t, 2*margin+12*cellPitch-cellGap, d.width)
+		assert.Equal(t, margin+labelHeight+31*cellPitch-cellGap+margin, d.height)
+
+		// March 1st is the first cell of the third column
+		march := d.rects[31+29]
+		assert.Equal(t, "2024-03-01", march.title)
+		assert.Equal(t, margin+2*cellPitch, march.x)
+		assert.Equal(t, margin+labelHeight, march.y)
+	})
+
+	t.Run("weeks", func(t *testing.T) {
+		// 2026 starts on a Thursday
+		d, err := layout(Calendar{Year: 2026, Layout: LayoutWeeks})
+		require.NoError(t, err)
+
+		assert.Len(t, d.rects, 365)
+		assert.Equal(t, 2*margin+53*cellPitch-cellGap, d.width)
+		assert.Equal(t, margin+labelHeight+4*cellPitch, d.rects[0].y)
+		assert.Equal(t, "Jan", d.texts[0].s)
+
+		// Sunday January 4th starts the second column
+		assert.Equal(t, "2026-01-04", d.rects[3].title)
+		assert.Equal(t, margin+cellPitch, d.rects[3].x)
+		assert.Equal(t, margin+labelHeight, d.rects[3].y)
+	})
+
+	t.Run("leap year starting on Saturday needs 54 weeks", func(t *testing.T) {
+		d, err := layout(Calendar{Year: 2028, Layout: LayoutWeeks})
+		require.NoError(t, err)
+		assert.Equal(t, 2*margin+54*cellPitch-cellGap, d.width)
+	})
+
+	t.Run("legend grows the image", func(t *testing.T) {
+		plain, err := layout(Calendar{Year: 2024, Layout: LayoutMonths})
+		require.NoError(t, err)
+
+		label := "a rather long meaning for a small grid"
+		d, err := layout(Calendar{Year: 2024, Layout: LayoutMonths, Legend: []LegendItem{
+			{Color: green, Label: "good"},
+			{Color: color.RGBA{R: 0xFF, A: 0xFF}, Label: label},
+		}})
+		require.NoError(t, err)
+
+		assert.Equal(t, plain.height+2*legendPitch-(legendPitch-cellSize)+margin, d.height)
+		assert.Equal(t, margin+cellSize+6+textWidth(label)+margin, d.width)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		_, err := layout(Calendar{Year: 2024, Layout: "spiral"})
+		assert.Error(t, err)
+
+		_, err = layout(Calendar{Year: 0, Layout: LayoutMonths})
+		assert.Error(t, err)
+	})
+}
+
+func TestSVG(t *testing.T) {
+	var buf bytes.Buffer
+	err := SVG(&buf, Calendar{
+		Year:   2024,
+		Layout: LayoutMonths,
+		Days:   map[string]color.RGBA{"2024-01-15": green},
+		Legend: []LegendItem{{Color: green, Label: "calm & <rested>"}},
+	})
+	require.NoError(t, err)
+
+	svg := buf.String()
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg"`))
+	assert.Contains(t, svg, `fill="#00FF00"><title>2024-01-15</title>`)
+	assert.Contains(t, svg, `fill="#EBEDF0"><title>2024-01-16</title>`)
+	assert.Contains(t, svg, "calm &amp; &lt;rested&gt;")
+	assert.True(t, strings.HasSuffix(svg, "</svg>\n"))
+}
+
+func TestPNG(t *testing.T) {
+	c := Calendar{
+		Year:   2024,
+		Layout: LayoutMonths,
+		Days:   map[string]color.RGBA{"2024-01-01": green},
+		Legend: []LegendItem{{Color: green, Label: "good"}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, PNG(&buf, c))
+
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+
+	d, err := layout(c)
+	require.NoError(t, err)
+	assert.Equal(t, d.width, img.Bounds().Dx())
+	assert.Equal(t, d.height, img.Bounds().Dy())
+
+	gridTop := margin + labelHeight
+	assert.Equal(t, color.RGBAModel.Convert(green), color.RGBAModel.Convert(img.At(margin+1, gridTop+1)))
+	assert.Equal(t, color.RGBAModel.Convert(EmptyColor), color.RGBAModel.Convert(img.At(margin+1, gridTop+cellPitch+1)))
+	assert.Equal(t, color.RGBAModel.Convert(BackgroundColor), color.RGBAModel.Convert(img.At(0, 0)))
+}
+
+func TestRenderingIsDeterministic(t *testing.T) {
+	c := Calendar{Year: 2025, Layout: LayoutWeeks, Days: map[string]color.RGBA{"2025-06-01": green}}
+
+	var first, second bytes.Buffer
+	require.NoError(t, PNG(&first, c))
+	require.NoError(t, PNG(&second, c))
+	assert.Equal(t, first.Bytes(), second.Bytes())
+}
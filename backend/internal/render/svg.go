@@ -0,0 +1,40 @@
+package render
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// SVG writes the calendar as an SVG document
+func SVG(w io.Writer, c Calendar) error {
+	d, err := layout(c)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		d.width, d.height, d.width, d.height)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="%s"/>`+"\n", d.width, d.height, hexColor(BackgroundColor))
+
+	for _, r := range d.rects {
+		fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" rx="2" fill="%s">`, r.x, r.y, r.w, r.h, hexColor(r.color))
+		if r.title != "" {
+			fmt.Fprintf(bw, "<title>%s</title>", r.title)
+		}
+		bw.WriteString("</rect>\n")
+	}
+
+	for _, t := range d.texts {
+		// SVG positions text by its baseline
+		fmt.Fprintf(bw, `<text x="%d" y="%d" font-family="sans-serif" font-size="%d" fill="%s">`,
+			t.x, t.y+textHeight, textHeight, hexColor(TextColor))
+		xml.EscapeText(bw, []byte(t.s))
+		bw.WriteString("</text>\n")
+	}
+
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"days/internal/audit"
+	"days/internal/auth"
+	"days/internal/db"
+	"days/internal/mail"
+
+	"github.com/google/uuid"
+)
+
+// Purposes of account tokens, matching the account_tokens.purpose check
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+)
+
+const (
+	passwordResetTTL     = time.Hour
+	emailVerificationTTL = 48 * time.Hour
+)
+
+var (
+	ErrInvalidAccountToken  = errors.New("invalid or expired token")
+	ErrEmailAlreadyVerified = errors.New("email address is already verified")
+)
+
+// AccountService runs the account flows that prove control of an email
+// address: password resets and email verification. Both mail the user a
+// single-use link whose token is stored only as a hash.
+type AccountService struct {
+	queries AccountRepository
+	mailer  mail.Mailer
+	appURL  string
+	audit   *audit.Recorder
+	now     func() time.Time
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" example:"user@example.com" binding:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" example:"3q2-7wAAAAA..." binding:"required"`
+	Password string `json:"password" example:"newpassword123" binding:"required,min=8"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" example:"3q2-7wAAAAA..." binding:"required"`
+}
+
+// NewAccountService returns an AccountService whose mails link to pages
+// under appURL, such as {appURL}/reset-password?token=...
+func NewAccountService(queries AccountRepository, mailer mail.Mailer, appURL string) *AccountService {
+	return &AccountService{
+		queries: queries,
+		mailer:  mailer,
+		appURL:  strings.TrimSuffix(appURL, "/"),
+		audit:   audit.NewRecorder(queries),
+		now:     time.Now,
+	}
+}
+
+// ForgotPassword mails a password reset link to the owner of an email
+// address. Unknown addresses succeed silently so that callers cannot probe
+// which addresses have accounts.
+func (s *AccountService) ForgotPassword(ctx context.Context, req ForgotPasswordRequest) error {
+	ctx, span := tracer.Start(ctx, "AccountService.ForgotPassword")
+	defer span.End()
+
+	user, err := s.queries.GetUserByEmail(ctx, strings.ToLower(strings.TrimSpace(req.Email)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	token, err := s.issueToken(ctx, user, TokenPurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, user.Email, "Reset your Days password", fmt.Sprintf(
+		"Someone asked to reset the password of your Days account.\n\n"+
+			"Open this link within an hour to choose a new password:\n%s\n\n"+
+			"If that was not you, ignore this email and your password stays the same.\n",
+		s.link("/reset-password", token),
+	))
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword and
+// signs the user out everywhere
+func (s *AccountService) ResetPassword(ctx context.Context, req ResetPasswordRequest) error {
+	ctx, span := tracer.Start(ctx, "AccountService.ResetPassword")
+	defer span.End()
+
+	if err := validatePassword(req.Password); err != nil {
+		return err
+	}
+
+	accountToken, err := s.consumeToken(ctx, req.Token, TokenPurposePasswordReset)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := hashPassword(req.Password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := s.queries.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		ID:           accountToken.UserID,
+		PasswordHash: hashedPassword,
+	}); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	// Whoever knew the old password must not stay signed in
+	if err := s.queries.RevokeSessionsByUserID(ctx, accountToken.UserID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	s.audit.Record(ctx, audit.Event{
+		UserID: accountToken.UserID,
+		Action: AuditActionPasswordChanged,
+	})
+
+	// The reset link reached the inbox, which proves the address it was sent
+	// to, but only that one: the account may have changed email since
+	user, err := s.queries.GetUserByID(ctx, accountToken.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.EmailVerifiedAt.Valid || !accountToken.Email.Valid || accountToken.Email.String != user.Email {
+		return nil
+	}
+	if err := s.queries.MarkUserEmailVerified(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+	return nil
+}
+
+// SendVerificationEmail mails a user a link that verifies their email
+// address. Earlier links stop working.
+func (s *AccountService) SendVerificationEmail(ctx context.Context, userID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "AccountService.SendVerificationEmail")
+	defer span.End()
+
+	user, err := s.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.EmailVerifiedAt.Valid {
+		return ErrEmailAlreadyVerified
+	}
+
+	token, err := s.issueToken(ctx, user, TokenPurposeEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, user.Email, "Verify your email address for Days", fmt.Sprintf(
+		"Welcome to Days!\n\n"+
+			"Open this link within two days to verify your email address:\n%s\n\n"+
+			"If you did not sign up, ignore this email.\n",
+		s.link("/verify-email", token),
+	))
+}
+
+// VerifyEmail marks a user's email address verified with a token from SendVerificationEmail
+func (s *AccountService) VerifyEmail(ctx context.Context, req VerifyEmailRequest) error {
+	ctx, span := tracer.Start(ctx, "AccountService.VerifyEmail")
+	defer span.End()
+
+	accountToken, err := s.consumeToken(ctx, req.Token, TokenPurposeEmailVerification)
+	if err != nil {
+		return err
+	}
+
+	if err := s.queries.MarkUserEmailVerified(ctx, accountToken.UserID); err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+	return nil
+}
+
+// Helper methods
+
+// issueToken replaces a user's outstanding tokens for purpose with a new one,
+// bound to the address it is about to be mailed to
+func (s *AccountService) issueToken(ctx context.Context, user db.User, purpose string, ttl time.Duration) (string, error) {
+	if err := s.queries.InvalidateAccountTokens(ctx, db.InvalidateAccountTokensParams{
+		UserID:  user.ID,
+		Purpose: purpose,
+	}); err != nil {
+		return "", fmt.Errorf("failed to invalidate earlier tokens: %w", err)
+	}
+
+	token, hash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	if _, err := s.queries.CreateAccountToken(ctx, db.CreateAccountTokenParams{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		ExpiresAt: s.now().Add(ttl),
+		Email:     sql.NullString{String: user.Email, Valid: true},
+	}); err != nil {
+		return "", fmt.Errorf("failed to store token: %w", err)
+	}
+	return token, nil
+}
+
+// consumeToken uses up a token; unknown, expired and used tokens all fail alike
+func (s *AccountService) consumeToken(ctx context.Context, token, purpose string) (db.AccountToken, error) {
+	if token == "" {
+		return db.AccountToken{}, ErrInvalidAccountToken
+	}
+
+	accountToken, err := s.queries.ConsumeAccountToken(ctx, db.ConsumeAccountTokenParams{
+		TokenHash: auth.HashOpaqueToken(token),
+		Purpose:   purpose,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.AccountToken{}, ErrInvalidAccountToken
+		}
+		return db.AccountToken{}, fmt.Errorf("failed to use token: %w", err)
+	}
+	return accountToken, nil
+}
+
+func (s *AccountService) link(path, token string) string {
+	return s.appURL + path + "?" + url.Values{"token": {token}}.Encode()
+}
+
+func (s *AccountService) send(ctx context.Context, to, subject, body string) error {
+	if err := s.mailer.Send(ctx, mail.Message{To: to, Subject: subject, Body: body}); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
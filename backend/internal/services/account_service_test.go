@@ -0,0 +1,238 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"days/internal/auth"
+	"days/internal/db"
+	"days/internal/mail"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAccountRepository implements a mock for the AccountRepository interface.
+// The UserRepository methods come from MockQueries.
+type MockAccountRepository struct {
+	MockQueries
+}
+
+func (m *MockAccountRepository) MarkUserEmailVerified(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAccountRepository) CreateAccountToken(ctx context.Context, arg db.CreateAccountTokenParams) (db.AccountToken, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.AccountToken), args.Error(1)
+}
+
+func (m *MockAccountRepository) ConsumeAccountToken(ctx context.Context, arg db.ConsumeAccountTokenParams) (db.AccountToken, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.AccountToken), args.Error(1)
+}
+
+// newAccountService returns an AccountService that writes its mail to a temporary directory
+func newAccountService(t *testing.T) (*AccountService, *MockAccountRepository, string) {
+	t.Helper()
+	dir := t.TempDir()
+	mailer, err := mail.NewFileMailer(dir, "Days <no-reply@days.example>")
+	require.NoError(t, err)
+
+	mockQueries := new(MockAccountRepository)
+	service := NewAccountService(mockQueries, mailer, "https://days.example/")
+	service.now = func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+	return service, mockQueries, dir
+}
+
+var linkPattern = regexp.MustCompile(`https://\S+`)
+
+// sentLinks returns the recipient and link of every message in an outbox directory
+func sentLinks(t *testing.T, dir string) map[string]*url.URL {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+
+	links := make(map[string]*url.URL)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		msg, err := netmail.ReadMessage(bytes.NewReader(data))
+		require.NoError(t, err)
+		body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+		require.NoError(t, err)
+
+		link, err := url.Parse(linkPattern.FindString(string(body)))
+		require.NoError(t, err)
+		links[msg.Header.Get("To")] = link
+	}
+	return links
+}
+
+func TestAccountService_ForgotPassword(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("mails a reset link", func(t *testing.T) {
+		service, mockQueries, outbox := newAccountService(t)
+		user := createTestUser(uuid.New(), "ann@example.com")
+
+		var stored db.CreateAccountTokenParams
+		mockQueries.On("GetUserByEmail", mock.Anything, "ann@example.com").Return(user, nil).Once()
+		mockQueries.On("InvalidateAccountTokens", mock.Anything, db.InvalidateAccountTokensParams{UserID: user.ID, Purpose: TokenPurposePasswordReset}).Return(nil).Once()
+		mockQueries.On("CreateAccountToken", mock.Anything, mock.AnythingOfType("db.CreateAccountTokenParams")).
+			Run(func(args mock.Arguments) { stored = args.Get(1).(db.CreateAccountTokenParams) }).
+			Return(db.AccountToken{}, nil).Once()
+
+		require.NoError(t, service.ForgotPassword(ctx, ForgotPasswordRequest{Email: " Ann@Example.com "}))
+		mockQueries.AssertExpectations(t)
+
+		links := sentLinks(t, outbox)
+		require.Contains(t, links, "<ann@example.com>")
+		link := links["<ann@example.com>"]
+		assert.Equal(t, "days.example", link.Host)
+		assert.Equal(t, "/reset-password", link.Path)
+
+		// Only the hash is stored, expiring an hour from now
+		assert.Equal(t, auth.HashOpaqueToken(link.Query().Get("token")), stored.TokenHash)
+		assert.Equal(t, TokenPurposePasswordReset, stored.Purpose)
+		assert.Equal(t, sql.NullString{String: "ann@example.com", Valid: true}, stored.Email)
+		assert.Equal(t, time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC), stored.ExpiresAt)
+	})
+
+	t.Run("unknown email", func(t *testing.T) {
+		service, mockQueries, outbox := newAccountService(t)
+		mockQueries.On("GetUserByEmail", mock.Anything, "nobody@example.com").Return(db.User{}, sql.ErrNoRows).Once()
+
+		require.NoError(t, service.ForgotPassword(ctx, ForgotPasswordRequest{Email: "nobody@example.com"}))
+		assert.Empty(t, sentLinks(t, outbox))
+		mockQueries.AssertNotCalled(t, "CreateAccountToken", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAccountService_ResetPassword(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	token := "reset-token"
+	consume := db.ConsumeAccountTokenParams{TokenHash: auth.HashOpaqueToken(token), Purpose: TokenPurposePasswordReset}
+
+	t.Run("sets the password and signs out everywhere", func(t *testing.T) {
+		service, mockQueries, _ := newAccountService(t)
+		sentTo := sql.NullString{String: "ann@example.com", Valid: true}
+
+		var params db.UpdateUserPasswordParams
+		mockQueries.On("ConsumeAccountToken", mock.Anything, consume).Return(db.AccountToken{UserID: userID, Email: sentTo}, nil).Once()
+		mockQueries.On("UpdateUserPassword", mock.Anything, mock.AnythingOfType("db.UpdateUserPasswordParams")).
+			Run(func(args mock.Arguments) { params = args.Get(1).(db.UpdateUserPasswordParams) }).
+			Return(nil).Once()
+		mockQueries.On("RevokeSessionsByUserID", mock.Anything, userID).Return(nil).Once()
+		expectAuditEvent(&mockQueries.MockQueries, userID, AuditActionPasswordChanged)
+		mockQueries.On("GetUserByID", mock.Anything, userID).Return(createTestUser(userID, "ann@example.com"), nil).Once()
+		mockQueries.On("MarkUserEmailVerified", mock.Anything, userID).Return(nil).Once()
+
+		require.NoError(t, service.ResetPassword(ctx, ResetPasswordRequest{Token: token, Password: "newpassword123"}))
+		mockQueries.AssertExpectations(t)
+
+		assert.Equal(t, userID, params.ID)
+		assert.True(t, verifyPassword("newpassword123", params.PasswordHash))
+	})
+
+	// A token proves only the address it was mailed to, and older tokens
+	// were not bound to any
+	for name, sentTo := range map[string]sql.NullString{
+		"address changed since the link was sent": {String: "ann@old.example", Valid: true},
+		"token without an address":                {},
+	} {
+		t.Run(name, func(t *testing.T) {
+			service, mockQueries, _ := newAccountService(t)
+			mockQueries.On("ConsumeAccountToken", mock.Anything, consume).Return(db.AccountToken{UserID: userID, Email: sentTo}, nil).Once()
+			mockQueries.On("UpdateUserPassword", mock.Anything, mock.AnythingOfType("db.UpdateUserPasswordParams")).Return(nil).Once()
+			mockQueries.On("RevokeSessionsByUserID", mock.Anything, userID).Return(nil).Once()
+			expectAuditEvent(&mockQueries.MockQueries, userID, AuditActionPasswordChanged)
+			mockQueries.On("GetUserByID", mock.Anything, userID).Return(createTestUser(userID, "ann@example.com"), nil).Once()
+
+			require.NoError(t, service.ResetPassword(ctx, ResetPasswordRequest{Token: token, Password: "newpassword123"}))
+			mockQueries.AssertExpectations(t)
+			mockQueries.AssertNotCalled(t, "MarkUserEmailVerified", mock.Anything, mock.Anything)
+		})
+	}
+
+	tests := []struct {
+		name        string
+		req         ResetPasswordRequest
+		expectedErr error
+	}{
+		{"weak password", ResetPasswordRequest{Token: token, Password: "short"}, ErrWeakPassword},
+		{"missing token", ResetPasswordRequest{Password: "newpassword123"}, ErrInvalidAccountToken},
+		{"used, expired or unknown token", ResetPasswordRequest{Token: token, Password: "newpassword123"}, ErrInvalidAccountToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockQueries, _ := newAccountService(t)
+			mockQueries.On("ConsumeAccountToken", mock.Anything, consume).Return(db.AccountToken{}, sql.ErrNoRows).Maybe()
+
+			assert.Equal(t, tt.expectedErr, service.ResetPassword(ctx, tt.req))
+			mockQueries.AssertNotCalled(t, "UpdateUserPassword", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestAccountService_EmailVerification(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("send then verify", func(t *testing.T) {
+		service, mockQueries, outbox := newAccountService(t)
+		user := createTestUser(uuid.New(), "ann@example.com")
+
+		var stored db.CreateAccountTokenParams
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+		mockQueries.On("InvalidateAccountTokens", mock.Anything, db.InvalidateAccountTokensParams{UserID: user.ID, Purpose: TokenPurposeEmailVerification}).Return(nil).Once()
+		mockQueries.On("CreateAccountToken", mock.Anything, mock.AnythingOfType("db.CreateAccountTokenParams")).
+			Run(func(args mock.Arguments) { stored = args.Get(1).(db.CreateAccountTokenParams) }).
+			Return(db.AccountToken{}, nil).Once()
+
+		require.NoError(t, service.SendVerificationEmail(ctx, user.ID))
+		link := sentLinks(t, outbox)["<ann@example.com>"]
+		require.NotNil(t, link)
+		assert.Equal(t, "/verify-email", link.Path)
+		assert.Equal(t, time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC), stored.ExpiresAt)
+
+		mockQueries.On("ConsumeAccountToken", mock.Anything, db.ConsumeAccountTokenParams{TokenHash: stored.TokenHash, Purpose: TokenPurposeEmailVerification}).
+			Return(db.AccountToken{UserID: user.ID}, nil).Once()
+		mockQueries.On("MarkUserEmailVerified", mock.Anything, user.ID).Return(nil).Once()
+
+		require.NoError(t, service.VerifyEmail(ctx, VerifyEmailRequest{Token: link.Query().Get("token")}))
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("already verified", func(t *testing.T) {
+		service, mockQueries, _ := newAccountService(t)
+		user := createTestUser(uuid.New(), "ann@example.com")
+		user.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+
+		assert.Equal(t, ErrEmailAlreadyVerified, service.SendVerificationEmail(ctx, user.ID))
+	})
+
+	t.Run("a reset token does not verify", func(t *testing.T) {
+		service, mockQueries, _ := newAccountService(t)
+		mockQueries.On("ConsumeAccountToken", mock.Anything, db.ConsumeAccountTokenParams{TokenHash: auth.HashOpaqueToken("reset-token"), Purpose: TokenPurposeEmailVerification}).
+			Return(db.AccountToken{}, sql.ErrNoRows).Once()
+
+		assert.Equal(t, ErrInvalidAccountToken, service.VerifyEmail(ctx, VerifyEmailRequest{Token: "reset-token"}))
+		mockQueries.AssertNotCalled(t, "MarkUserEmailVerified", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"days/internal/audit"
+	"days/internal/db"
+
+	"github.com/google/uuid"
+)
+
+var ErrCalendarAlreadyOwned = errors.New("calendar is already owned by this user")
+
+// Actions recorded in the audit log for changes operators make
+const (
+	AuditActionAccountDisabled     = "user.disabled"
+	AuditActionAccountEnabled      = "user.enabled"
+	AuditActionCalendarTransferred = "calendar.transferred"
+)
+
+// auditActorAdmin marks the events of changes an operator made to an
+// account, as opposed to the user themselves
+var auditActorAdmin = map[string]string{"actor": "admin"}
+
+// AdminService runs the operator tasks behind daysctl. Its methods act on any
+// account without checking who asks, so it is never exposed over HTTP.
+type AdminService struct {
+	queries AdminRepository
+	users   *UserService
+	audit   *audit.Recorder
+}
+
+// AdminUserResponse describes an account as operators see it
+type AdminUserResponse struct {
+	ID            uuid.UUID `json:"id"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	TwoFactor     bool      `json:"two_factor"`
+	Disabled      bool      `json:"disabled"`
+	Calendars     int64     `json:"calendars"` // calendars the user owns
+	CreatedAt     string    `json:"created_at"`
+}
+
+// IntegrityProblem is a row that breaks an invariant the services rely on
+type IntegrityProblem struct {
+	Check      string    `json:"check"` // e.g., "calendar_owner"
+	ResourceID uuid.UUID `json:"resource_id"`
+	Detail     string    `json:"detail"`
+}
+
+// NewAdminService returns an AdminService. Accounts are created and
+// passwords set through a UserService without a mailer, so nobody is emailed.
+func NewAdminService(queries AdminRepository) *AdminService {
+	return &AdminService{
+		queries: queries,
+		users:   NewUserService(queries, nil, nil, nil, nil),
+		audit:   audit.NewRecorder(queries),
+	}
+}
+
+// ListUsers returns every account, oldest first
+func (s *AdminService) ListUsers(ctx context.Context) ([]*AdminUserResponse, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.ListUsers")
+	defer span.End()
+
+	users, err := s.queries.ListUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	responses := make([]*AdminUserResponse, 0, len(users))
+	for _, user := range users {
+		var createdAt string
+		if user.CreatedAt.Valid {
+			createdAt = user.CreatedAt.Time.Format("2006-01-02T15:04:05Z")
+		}
+		responses = append(responses, &AdminUserResponse{
+			ID:            user.ID,
+			Email:         user.Email,
+			EmailVerified: user.EmailVerifiedAt.Valid,
+			TwoFactor:     user.TotpEnabledAt.Valid,
+			Disabled:      user.DisabledAt.Valid,
+			Calendars:     user.CalendarCount,
+			CreatedAt:     createdAt,
+		})
+	}
+	return responses, nil
+}
+
+// FindUser looks a user up by ID or, failing that, by email address
+func (s *AdminService) FindUser(ctx context.Context, ref string) (*UserResponse, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.FindUser")
+	defer span.End()
+
+	if id, err := uuid.Parse(ref); err == nil {
+		return s.users.GetUserByID(ctx, id)
+	}
+	return s.users.GetUserByEmail(ctx, ref)
+}
+
+// CreateUser creates an account with password, or with a generated one when
+// password is empty. It returns the password the account was created with.
+func (s *AdminService) CreateUser(ctx context.Context, email, password string) (*UserResponse, string, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.CreateUser")
+	defer span.End()
+
+	if password == "" {
+		var err error
+		if password, err = generatePassword(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	user, err := s.users.CreateUser(ctx, CreateUserRequest{Email: email, Password: password})
+	if err != nil {
+		return nil, "", err
+	}
+	return user, password, nil
+}
+
+// DisableUser keeps a user from logging in or using API keys and signs out
+// every session. Disabling a disabled user changes nothing.
+func (s *AdminService) DisableUser(ctx context.Context, userID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "AdminService.DisableUser")
+	defer span.End()
+
+	if _, err := s.users.getUser(ctx, userID); err != nil {
+		return err
+	}
+
+	disabled, err := s.queries.DisableUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to disable user: %w", err)
+	}
+	if err := s.queries.RevokeSessionsByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	if disabled > 0 {
+		s.audit.Record(ctx, audit.Event{
+			UserID:   userID,
+			Action:   AuditActionAccountDisabled,
+			Metadata: auditActorAdmin,
+		})
+	}
+	return nil
+}
+
+// EnableUser lets a disabled user log in again
+func (s *AdminService) EnableUser(ctx context.Context, userID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "AdminService.EnableUser")
+	defer span.End()
+
+	if _, err := s.users.getUser(ctx, userID); err != nil {
+		return err
+	}
+
+	enabled, err := s.queries.EnableUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to enable user: %w", err)
+	}
+	if enabled > 0 {
+		s.audit.Record(ctx, audit.Event{
+			UserID:   userID,
+			Action:   AuditActionAccountEnabled,
+			Metadata: auditActorAdmin,
+		})
+	}
+	return nil
+}
+
+// DeleteUser deletes a user and everything they own, as DeleteAccount does,
+// without asking for their password
+func (s *AdminService) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "AdminService.DeleteUser")
+	defer span.End()
+
+	if _, err := s.users.getUser(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.queries.DeleteUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	s.audit.Record(ctx, audit.Event{
+		UserID:   userID,
+		Action:   AuditActionAccountDeleted,
+		Metadata: auditActorAdmin,
+	})
+	return nil
+}
+
+// ResetPassword sets a user's password, or a generated one when password is
+// empty, and returns it. As with ChangePassword every session is signed out
+// and pending reset links stop working.
+func (s *AdminService) ResetPassword(ctx context.Context, userID uuid.UUID, password string) (string, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.ResetPassword")
+	defer span.End()
+
+	if _, err := s.users.getUser(ctx, userID); err != nil {
+		return "", err
+	}
+	if password == "" {
+		var err error
+		if password, err = generatePassword(); err != nil {
+			return "", err
+		}
+	}
+	if err := validatePassword(password); err != nil {
+		return "", err
+	}
+
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := s.queries.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		ID:           userID,
+		PasswordHash: hashedPassword,
+	}); err != nil {
+		return "", fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if err := s.queries.RevokeSessionsByUserID(ctx, userID); err != nil {
+		return "", fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	if err := s.queries.InvalidateAccountTokens(ctx, db.InvalidateAccountTokensParams{
+		UserID:  userID,
+		Purpose: TokenPurposePasswordReset,
+	}); err != nil {
+		return "", fmt.Errorf("failed to invalidate password reset tokens: %w", err)
+	}
+	s.audit.Record(ctx, audit.Event{
+		UserID:   userID,
+		Action:   AuditActionPasswordChanged,
+		Metadata: auditActorAdmin,
+	})
+
+	return password, nil
+}
+
+// MoveCalendar makes another user the owner of a calendar. The previous owner
+// loses access to it and its feed token is revoked; other members keep theirs.
+func (s *AdminService) MoveCalendar(ctx context.Context, calendarID, newOwnerID uuid.UUID) (*CalendarResponse, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.MoveCalendar")
+	defer span.End()
+
+	calendar, err := s.queries.GetCalendarByID(ctx, calendarID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCalendarNotFound
+		}
+		return nil, fmt.Errorf("failed to get calendar: %w", err)
+	}
+	if calendar.UserID == newOwnerID {
+		return nil, ErrCalendarAlreadyOwned
+	}
+	if _, err := s.users.getUser(ctx, newOwnerID); err != nil {
+		return nil, err
+	}
+
+	// Names are unique among a user's calendars
+	owned, err := s.queries.GetCalendarsByUserID(ctx, newOwnerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing calendars: %w", err)
+	}
+	for _, other := range owned {
+		if strings.EqualFold(other.Name, calendar.Name) {
+			return nil, ErrCalendarNameExists
+		}
+	}
+
+	moved, err := s.queries.TransferCalendar(ctx, db.TransferCalendarParams{
+		ID:         calendarID,
+		NewOwnerID: newOwnerID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCalendarNotFound
+		}
+		return nil, fmt.Errorf("failed to move calendar: %w", err)
+	}
+
+	// Both owners see the move in their audit log
+	changes := map[string]audit.Change{"owner": {Before: calendar.UserID, After: newOwnerID}}
+	for _, userID := range []uuid.UUID{calendar.UserID, newOwnerID} {
+		s.audit.Record(ctx, audit.Event{
+			UserID:       userID,
+			Action:       AuditActionCalendarTransferred,
+			ResourceType: AuditResourceCalendar,
+			ResourceID:   calendarID,
+			Metadata:     auditActorAdmin,
+			Changes:      changes,
+		})
+	}
+
+	return toCalendarResponse(db.Calendar(moved), RoleOwner), nil
+}
+
+// VerifyIntegrity returns the rows that break an invariant the services keep
+// but the schema does not enforce. An empty result means none were found.
+func (s *AdminService) VerifyIntegrity(ctx context.Context) ([]IntegrityProblem, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.VerifyIntegrity")
+	defer span.End()
+
+	rows, err := s.queries.ListIntegrityProblems(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check data integrity: %w", err)
+	}
+
+	problems := make([]IntegrityProblem, 0, len(rows))
+	for _, row := range rows {
+		problems = append(problems, IntegrityProblem{
+			Check:      row.CheckName,
+			ResourceID: row.ResourceID,
+			Detail:     row.Detail,
+		})
+	}
+	return problems, nil
+}
+
+// generatePassword returns a random password for an operator to hand over
+func generatePassword() (string, error) {
+	b := make([]byte, 15)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,387 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"days/internal/db"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAdminRepository implements a mock for the AdminRepository interface.
+// The UserRepository methods come from MockQueries.
+type MockAdminRepository struct {
+	MockQueries
+}
+
+func (m *MockAdminRepository) ListUsers(ctx context.Context) ([]db.ListUsersRow, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]db.ListUsersRow), args.Error(1)
+}
+
+func (m *MockAdminRepository) DisableUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAdminRepository) EnableUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAdminRepository) GetCalendarByID(ctx context.Context, id uuid.UUID) (db.Calendar, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(db.Calendar), args.Error(1)
+}
+
+func (m *MockAdminRepository) GetCalendarsByUserID(ctx context.Context, userID uuid.UUID) ([]db.Calendar, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]db.Calendar), args.Error(1)
+}
+
+func (m *MockAdminRepository) TransferCalendar(ctx context.Context, arg db.TransferCalendarParams) (db.TransferCalendarRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.TransferCalendarRow), args.Error(1)
+}
+
+func (m *MockAdminRepository) ListIntegrityProblems(ctx context.Context) ([]db.ListIntegrityProblemsRow, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]db.ListIntegrityProblemsRow), args.Error(1)
+}
+
+func TestAdminService_ListUsers(t *testing.T) {
+	mockQueries := new(MockAdminRepository)
+	service := NewAdminService(mockQueries)
+
+	created := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	active := db.ListUsersRow{ID: uuid.New(), Email: "ann@example.com", EmailVerifiedAt: sql.NullTime{Time: created, Valid: true}, CreatedAt: sql.NullTime{Time: created, Valid: true}, CalendarCount: 2}
+	disabled := db.ListUsersRow{ID: uuid.New(), Email: "bob@example.com", TotpEnabledAt: sql.NullTime{Time: created, Valid: true}, DisabledAt: sql.NullTime{Time: created, Valid: true}}
+	mockQueries.On("ListUsers", mock.Anything).Return([]db.ListUsersRow{active, disabled}, nil).Once()
+
+	users, err := service.ListUsers(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []*AdminUserResponse{
+		{ID: active.ID, Email: "ann@example.com", EmailVerified: true, Calendars: 2, CreatedAt: "2024-01-01T09:00:00Z"},
+		{ID: disabled.ID, Email: "bob@example.com", TwoFactor: true, Disabled: true},
+	}, users)
+	mockQueries.AssertExpectations(t)
+}
+
+func TestAdminService_FindUser(t *testing.T) {
+	user := createTestUser(uuid.New(), "ann@example.com")
+
+	tests := []struct {
+		name      string
+		ref       string
+		setupMock func(*MockAdminRepository)
+		wantErr   error
+	}{
+		{
+			name: "by ID",
+			ref:  user.ID.String(),
+			setupMock: func(m *MockAdminRepository) {
+				m.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+			},
+		},
+		{
+			name: "by email",
+			ref:  " Ann@Example.com",
+			setupMock: func(m *MockAdminRepository) {
+				m.On("GetUserByEmail", mock.Anything, "ann@example.com").Return(user, nil).Once()
+			},
+		},
+		{
+			name: "not found",
+			ref:  "nobody@example.com",
+			setupMock: func(m *MockAdminRepository) {
+				m.On("GetUserByEmail", mock.Anything, "nobody@example.com").Return(db.User{}, sql.ErrNoRows).Once()
+			},
+			wantErr: ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := new(MockAdminRepository)
+			tt.setupMock(mockQueries)
+			service := NewAdminService(mockQueries)
+
+			result, err := service.FindUser(context.Background(), tt.ref)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, user.ID, result.ID)
+			}
+			mockQueries.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAdminService_CreateUser(t *testing.T) {
+	t.Run("generated password", func(t *testing.T) {
+		mockQueries := new(MockAdminRepository)
+		service := NewAdminService(mockQueries)
+		user := createTestUser(uuid.New(), "ann@example.com")
+
+		var hash string
+		mockQueries.On("GetUserByEmail", mock.Anything, "ann@example.com").Return(db.User{}, sql.ErrNoRows).Once()
+		mockQueries.On("CreateUser", mock.Anything, mock.MatchedBy(func(arg db.CreateUserParams) bool {
+			hash = arg.PasswordHash
+			return arg.Email == "ann@example.com"
+		})).Return(user, nil).Once()
+		expectAuditEvent(&mockQueries.MockQueries, user.ID, AuditActionSignup)
+
+		result, password, err := service.CreateUser(context.Background(), "ann@example.com", "")
+
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, result.ID)
+		assert.Len(t, password, 20)
+		assert.True(t, verifyPassword(password, hash))
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("email exists", func(t *testing.T) {
+		mockQueries := new(MockAdminRepository)
+		service := NewAdminService(mockQueries)
+		mockQueries.On("GetUserByEmail", mock.Anything, "ann@example.com").Return(createTestUser(uuid.New(), "ann@example.com"), nil).Once()
+
+		_, _, err := service.CreateUser(context.Background(), "ann@example.com", "password123")
+
+		assert.ErrorIs(t, err, ErrEmailExists)
+		mockQueries.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAdminService_DisableUser(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name      string
+		setupMock func(*MockAdminRepository)
+		wantErr   error
+	}{
+		{
+			name: "disables and signs out",
+			setupMock: func(m *MockAdminRepository) {
+				m.On("GetUserByID", mock.Anything, userID).Return(createTestUser(userID, "ann@example.com"), nil).Once()
+				m.On("DisableUser", mock.Anything, userID).Return(int64(1), nil).Once()
+				m.On("RevokeSessionsByUserID", mock.Anything, userID).Return(nil).Once()
+				expectAuditEvent(&m.MockQueries, userID, AuditActionAccountDisabled)
+			},
+		},
+		{
+			name: "already disabled",
+			setupMock: func(m *MockAdminRepository) {
+				m.On("GetUserByID", mock.Anything, userID).Return(createTestUser(userID, "ann@example.com"), nil).Once()
+				m.On("DisableUser", mock.Anything, userID).Return(int64(0), nil).Once()
+				m.On("RevokeSessionsByUserID", mock.Anything, userID).Return(nil).Once()
+			},
+		},
+		{
+			name: "user not found",
+			setupMock: func(m *MockAdminRepository) {
+				m.On("GetUserByID", mock.Anything, userID).Return(db.User{}, sql.ErrNoRows).Once()
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name: "database error",
+			setupMock: func(m *MockAdminRepository) {
+				m.On("GetUserByID", mock.Anything, userID).Return(createTestUser(userID, "ann@example.com"), nil).Once()
+				m.On("DisableUser", mock.Anything, userID).Return(int64(0), errors.New("connection refused")).Once()
+			},
+			wantErr: errors.New("failed to disable user: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := new(MockAdminRepository)
+			tt.setupMock(mockQueries)
+			service := NewAdminService(mockQueries)
+
+			err := service.DisableUser(context.Background(), userID)
+
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			mockQueries.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAdminService_EnableUser(t *testing.T) {
+	mockQueries := new(MockAdminRepository)
+	service := NewAdminService(mockQueries)
+	userID := uuid.New()
+
+	mockQueries.On("GetUserByID", mock.Anything, userID).Return(createTestUser(userID, "ann@example.com"), nil).Once()
+	mockQueries.On("EnableUser", mock.Anything, userID).Return(int64(1), nil).Once()
+	expectAuditEvent(&mockQueries.MockQueries, userID, AuditActionAccountEnabled)
+
+	require.NoError(t, service.EnableUser(context.Background(), userID))
+	mockQueries.AssertExpectations(t)
+}
+
+func TestAdminService_DeleteUser(t *testing.T) {
+	mockQueries := new(MockAdminRepository)
+	service := NewAdminService(mockQueries)
+	userID := uuid.New()
+
+	mockQueries.On("GetUserByID", mock.Anything, userID).Return(createTestUser(userID, "ann@example.com"), nil).Once()
+	mockQueries.On("DeleteUser", mock.Anything, userID).Return(nil).Once()
+	mockQueries.On("CreateAuditEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateAuditEventParams) bool {
+		return arg.UserID == userID && arg.Action == AuditActionAccountDeleted && string(arg.Metadata) == `{"actor":"admin"}`
+	})).Return(nil).Once()
+
+	require.NoError(t, service.DeleteUser(context.Background(), userID))
+	mockQueries.AssertExpectations(t)
+}
+
+func TestAdminService_ResetPassword(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("generated password", func(t *testing.T) {
+		mockQueries := new(MockAdminRepository)
+		service := NewAdminService(mockQueries)
+
+		var hash string
+		mockQueries.On("GetUserByID", mock.Anything, userID).Return(createTestUser(userID, "ann@example.com"), nil).Once()
+		mockQueries.On("UpdateUserPassword", mock.Anything, mock.MatchedBy(func(arg db.UpdateUserPasswordParams) bool {
+			hash = arg.PasswordHash
+			return arg.ID == userID
+		})).Return(nil).Once()
+		mockQueries.On("RevokeSessionsByUserID", mock.Anything, userID).Return(nil).Once()
+		mockQueries.On("InvalidateAccountTokens", mock.Anything, db.InvalidateAccountTokensParams{UserID: userID, Purpose: TokenPurposePasswordReset}).Return(nil).Once()
+		expectAuditEvent(&mockQueries.MockQueries, userID, AuditActionPasswordChanged)
+
+		password, err := service.ResetPassword(context.Background(), userID, "")
+
+		require.NoError(t, err)
+		assert.True(t, verifyPassword(password, hash))
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("weak password", func(t *testing.T) {
+		mockQueries := new(MockAdminRepository)
+		service := NewAdminService(mockQueries)
+		mockQueries.On("GetUserByID", mock.Anything, userID).Return(createTestUser(userID, "ann@example.com"), nil).Once()
+
+		_, err := service.ResetPassword(context.Background(), userID, "short")
+
+		assert.ErrorIs(t, err, ErrWeakPassword)
+		mockQueries.AssertNotCalled(t, "UpdateUserPassword", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAdminService_MoveCalendar(t *testing.T) {
+	oldOwnerID := uuid.New()
+	newOwnerID := uuid.New()
+	calendar := db.Calendar{ID: uuid.New(), UserID: oldOwnerID, Name: "Mood"}
+
+	tests := []struct {
+		name      string
+		newOwner  uuid.UUID
+		setupMock func(*MockAdminRepository)
+		wantErr   error
+	}{
+		{
+			name:     "moves the calendar",
+			newOwner: newOwnerID,
+			setupMock: func(m *MockAdminRepository) {
+				m.On("GetCalendarByID", mock.Anything, calendar.ID).Return(calendar, nil).Once()
+				m.On("GetUserByID", mock.Anything, newOwnerID).Return(createTestUser(newOwnerID, "bob@example.com"), nil).Once()
+				m.On("GetCalendarsByUserID", mock.Anything, newOwnerID).Return([]db.Calendar{{Name: "Sport"}}, nil).Once()
+				m.On("TransferCalendar", mock.Anything, db.TransferCalendarParams{ID: calendar.ID, NewOwnerID: newOwnerID}).
+					Return(db.TransferCalendarRow{ID: calendar.ID, UserID: newOwnerID, Name: "Mood"}, nil).Once()
+				expectAuditEvent(&m.MockQueries, oldOwnerID, AuditActionCalendarTransferred)
+				expectAuditEvent(&m.MockQueries, newOwnerID, AuditActionCalendarTransferred)
+			},
+		},
+		{
+			name:     "calendar not found",
+			newOwner: newOwnerID,
+			setupMock: func(m *MockAdminRepository) {
+				m.On("GetCalendarByID", mock.Anything, calendar.ID).Return(db.Calendar{}, sql.ErrNoRows).Once()
+			},
+			wantErr: ErrCalendarNotFound,
+		},
+		{
+			name:     "already the owner",
+			newOwner: oldOwnerID,
+			setupMock: func(m *MockAdminRepository) {
+				m.On("GetCalendarByID", mock.Anything, calendar.ID).Return(calendar, nil).Once()
+			},
+			wantErr: ErrCalendarAlreadyOwned,
+		},
+		{
+			name:     "new owner not found",
+			newOwner: newOwnerID,
+			setupMock: func(m *MockAdminRepository) {
+				m.On("GetCalendarByID", mock.Anything, calendar.ID).Return(calendar, nil).Once()
+				m.On("GetUserByID", mock.Anything, newOwnerID).Return(db.User{}, sql.ErrNoRows).Once()
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name:     "new owner has a calendar of that name",
+			newOwner: newOwnerID,
+			setupMock: func(m *MockAdminRepository) {
+				m.On("GetCalendarByID", mock.Anything, calendar.ID).Return(calendar, nil).Once()
+				m.On("GetUserByID", mock.Anything, newOwnerID).Return(createTestUser(newOwnerID, "bob@example.com"), nil).Once()
+				m.On("GetCalendarsByUserID", mock.Anything, newOwnerID).Return([]db.Calendar{{Name: "mood"}}, nil).Once()
+			},
+			wantErr: ErrCalendarNameExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := new(MockAdminRepository)
+			tt.setupMock(mockQueries)
+			service := NewAdminService(mockQueries)
+
+			result, err := service.MoveCalendar(context.Background(), calendar.ID, tt.newOwner)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				mockQueries.AssertNotCalled(t, "TransferCalendar", mock.Anything, mock.Anything)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, newOwnerID, result.UserID)
+				assert.Equal(t, RoleOwner, result.Role)
+			}
+			mockQueries.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAdminService_VerifyIntegrity(t *testing.T) {
+	mockQueries := new(MockAdminRepository)
+	service := NewAdminService(mockQueries)
+	entryID := uuid.New()
+
+	mockQueries.On("ListIntegrityProblems", mock.Anything).Return([]db.ListIntegrityProblemsRow{
+		{CheckName: "day_entry_color", ResourceID: entryID, Detail: "entry uses a color meaning of another calendar"},
+	}, nil).Once()
+
+	problems, err := service.VerifyIntegrity(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []IntegrityProblem{
+		{Check: "day_entry_color", ResourceID: entryID, Detail: "entry uses a color meaning of another calendar"},
+	}, problems)
+	mockQueries.AssertExpectations(t)
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"days/internal/auth"
+	"days/internal/db"
+
+	"github.com/google/uuid"
+)
+
+// Scopes limit what a request authenticated with an API key may do. A write
+// scope includes the matching read scope.
+const (
+	ScopeCalendarsRead  = "calendars:read"
+	ScopeCalendarsWrite = "calendars:write"
+	ScopeEntriesRead    = "entries:read"
+	ScopeEntriesWrite   = "entries:write"
+)
+
+const (
+	// APIKeyPrefix starts every API key, telling them apart from JWTs
+	APIKeyPrefix = "days_"
+	// apiKeyDisplayLength is how much of a key is stored in the clear to identify it
+	apiKeyDisplayLength = len(APIKeyPrefix) + 6
+	// apiKeyTouchInterval limits how often last_used_at is written for a busy key
+	apiKeyTouchInterval = time.Minute
+)
+
+// APIKeyScopes lists every scope an API key can be granted
+var APIKeyScopes = []string{ScopeCalendarsRead, ScopeCalendarsWrite, ScopeEntriesRead, ScopeEntriesWrite}
+
+var (
+	ErrAPIKeyNotFound      = errors.New("API key not found")
+	ErrInvalidAPIKey       = errors.New("invalid or expired API key")
+	ErrInvalidAPIKeyName   = errors.New("API key name must be 1 to 100 characters")
+	ErrInvalidAPIKeyScope  = errors.New("API key needs at least one known scope")
+	ErrInvalidAPIKeyExpiry = errors.New("API key expiry must be in the future")
+)
+
+type APIKeyService struct {
+	queries APIKeyRepository
+	now     func() time.Time
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" example:"Home Assistant" binding:"required"`
+	Scopes    []string   `json:"scopes" example:"entries:write" binding:"required"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2024-12-31T00:00:00Z"` // omit for a key that never expires
+}
+
+type APIKeyResponse struct {
+	ID         uuid.UUID `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Name       string    `json:"name" example:"Home Assistant"`
+	Prefix     string    `json:"prefix" example:"days_3q2-7w"`
+	Scopes     []string  `json:"scopes" example:"entries:write"`
+	CreatedAt  string    `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	ExpiresAt  *string   `json:"expires_at,omitempty" example:"2024-12-31T00:00:00Z"`
+	LastUsedAt *string   `json:"last_used_at,omitempty" example:"2023-01-02T00:00:00Z"`
+}
+
+// CreatedAPIKeyResponse carries a new API key. The key is only ever shown
+// here; the server keeps just its hash.
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key" example:"days_3q2-7wAAAAA..."`
+}
+
+// APIKeyPrincipal is the user a request authenticated with an API key acts
+// for, and the scopes the key grants
+type APIKeyPrincipal struct {
+	KeyID  uuid.UUID
+	UserID uuid.UUID
+	Scopes []string
+}
+
+func NewAPIKeyService(queries APIKeyRepository) *APIKeyService {
+	return &APIKeyService{
+		queries: queries,
+		now:     time.Now,
+	}
+}
+
+// CreateAPIKey issues a new API key for a user
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, userID uuid.UUID, req CreateAPIKeyRequest) (*CreatedAPIKeyResponse, error) {
+	ctx, span := tracer.Start(ctx, "APIKeyService.CreateAPIKey")
+	defer span.End()
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 100 {
+		return nil, ErrInvalidAPIKeyName
+	}
+
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	var expiresAt sql.NullTime
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(s.now()) {
+			return nil, ErrInvalidAPIKeyExpiry
+		}
+		expiresAt = sql.NullTime{Time: *req.ExpiresAt, Valid: true}
+	}
+
+	token, _, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key := APIKeyPrefix + token
+
+	apiKey, err := s.queries.CreateAPIKey(ctx, db.CreateAPIKeyParams{
+		UserID:    userID,
+		Name:      name,
+		KeyPrefix: key[:apiKeyDisplayLength],
+		KeyHash:   auth.HashOpaqueToken(key),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return &CreatedAPIKeyResponse{
+		APIKeyResponse: *s.toAPIKeyResponse(apiKey),
+		Key:            key,
+	}, nil
+}
+
+// GetAPIKeys lists a user's API keys, including expired ones
+func (s *APIKeyService) GetAPIKeys(ctx context.Context, userID uuid.UUID) ([]*APIKeyResponse, error) {
+	ctx, span := tracer.Start(ctx, "APIKeyService.GetAPIKeys")
+	defer span.End()
+
+	apiKeys, err := s.queries.GetAPIKeysByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API keys: %w", err)
+	}
+
+	responses := make([]*APIKeyResponse, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		responses = append(responses, s.toAPIKeyResponse(apiKey))
+	}
+	return responses, nil
+}
+
+// RevokeAPIKey deletes one of a user's API keys. Other users' keys are
+// reported as missing.
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "APIKeyService.RevokeAPIKey")
+	defer span.End()
+
+	deleted, err := s.queries.DeleteAPIKey(ctx, db.DeleteAPIKeyParams{
+		ID:     keyID,
+		UserID: userID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	if deleted == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// AuthenticateAPIKey resolves an API key sent as a Bearer token and records
+// that it was used
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, key string) (*APIKeyPrincipal, error) {
+	ctx, span := tracer.Start(ctx, "APIKeyService.AuthenticateAPIKey")
+	defer span.End()
+
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.queries.GetActiveAPIKeyByHash(ctx, auth.HashOpaqueToken(key))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	// Failing to record use is not worth failing the request
+	if !apiKey.LastUsedAt.Valid || s.now().Sub(apiKey.LastUsedAt.Time) >= apiKeyTouchInterval {
+		if err := s.queries.TouchAPIKey(ctx, apiKey.ID); err != nil {
+			slog.ErrorContext(ctx, "failed to record use of API key", "api_key_id", apiKey.ID, "error", err)
+		}
+	}
+
+	return &APIKeyPrincipal{
+		KeyID:  apiKey.ID,
+		UserID: apiKey.UserID,
+		Scopes: apiKey.Scopes,
+	}, nil
+}
+
+// HasScope reports whether granted scopes allow an action needing scope
+func HasScope(granted []string, scope string) bool {
+	if slices.Contains(granted, scope) {
+		return true
+	}
+	resource, access, _ := strings.Cut(scope, ":")
+	return access == "read" && slices.Contains(granted, resource+":write")
+}
+
+// Helper methods
+
+// normalizeScopes checks requested scopes and returns them sorted without duplicates
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, ErrInvalidAPIKeyScope
+	}
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !slices.Contains(APIKeyScopes, scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidAPIKeyScope, scope)
+		}
+		normalized = append(normalized, scope)
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized), nil
+}
+
+func (s *APIKeyService) toAPIKeyResponse(apiKey db.ApiKey) *APIKeyResponse {
+	response := &APIKeyResponse{
+		ID:        apiKey.ID,
+		Name:      apiKey.Name,
+		Prefix:    apiKey.KeyPrefix,
+		Scopes:    apiKey.Scopes,
+		CreatedAt: apiKey.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if apiKey.ExpiresAt.Valid {
+		expiresAt := apiKey.ExpiresAt.Time.UTC().Format(time.RFC3339)
+		response.ExpiresAt = &expiresAt
+	}
+	if apiKey.LastUsedAt.Valid {
+		lastUsedAt := apiKey.LastUsedAt.Time.UTC().Format(time.RFC3339)
+		response.LastUsedAt = &lastUsedAt
+	}
+	return response
+}
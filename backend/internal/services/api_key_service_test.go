@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"days/internal/auth"
+	"days/internal/db"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAPIKeyRepository implements a mock for the APIKeyRepository interface
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) CreateAPIKey(ctx context.Context, arg db.CreateAPIKeyParams) (db.ApiKey, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.ApiKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) GetAPIKeysByUserID(ctx context.Context, userID uuid.UUID) ([]db.ApiKey, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]db.ApiKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (db.ApiKey, error) {
+	args := m.Called(ctx, keyHash)
+	return args.Get(0).(db.ApiKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) DeleteAPIKey(ctx context.Context, arg db.DeleteAPIKeyParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func newTestAPIKeyService(queries APIKeyRepository, now time.Time) *APIKeyService {
+	service := NewAPIKeyService(queries)
+	service.now = func() time.Time { return now }
+	return service
+}
+
+func TestAPIKeyService_CreateAPIKey(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("stores only the hash", func(t *testing.T) {
+		mockQueries := new(MockAPIKeyRepository)
+		service := newTestAPIKeyService(mockQueries, now)
+		expiresAt := now.Add(30 * 24 * time.Hour)
+
+		var stored db.CreateAPIKeyParams
+		mockQueries.On("CreateAPIKey", mock.Anything, mock.AnythingOfType("db.CreateAPIKeyParams")).
+			Run(func(args mock.Arguments) { stored = args.Get(1).(db.CreateAPIKeyParams) }).
+			Return(db.ApiKey{ID: uuid.New(), UserID: userID, Name: "Home Assistant", Scopes: []string{ScopeEntriesWrite}, CreatedAt: now,
+				ExpiresAt: sql.NullTime{Time: expiresAt, Valid: true}}, nil).Once()
+
+		created, err := service.CreateAPIKey(ctx, userID, CreateAPIKeyRequest{
+			Name:      "  Home Assistant ",
+			Scopes:    []string{ScopeEntriesWrite, ScopeEntriesWrite},
+			ExpiresAt: &expiresAt,
+		})
+		require.NoError(t, err)
+
+		assert.True(t, strings.HasPrefix(created.Key, APIKeyPrefix))
+		assert.Equal(t, auth.HashOpaqueToken(created.Key), stored.KeyHash)
+		assert.Equal(t, created.Key[:apiKeyDisplayLength], stored.KeyPrefix)
+		assert.Equal(t, "Home Assistant", stored.Name)
+		assert.Equal(t, []string{ScopeEntriesWrite}, stored.Scopes)
+		assert.Equal(t, sql.NullTime{Time: expiresAt, Valid: true}, stored.ExpiresAt)
+		require.NotNil(t, created.ExpiresAt)
+		assert.Equal(t, "2024-01-31T12:00:00Z", *created.ExpiresAt)
+		assert.Nil(t, created.LastUsedAt)
+		mockQueries.AssertExpectations(t)
+	})
+
+	past := now.Add(-time.Hour)
+	tests := []struct {
+		name        string
+		req         CreateAPIKeyRequest
+		expectedErr error
+	}{
+		{"blank name", CreateAPIKeyRequest{Name: " ", Scopes: []string{ScopeEntriesRead}}, ErrInvalidAPIKeyName},
+		{"long name", CreateAPIKeyRequest{Name: strings.Repeat("a", 101), Scopes: []string{ScopeEntriesRead}}, ErrInvalidAPIKeyName},
+		{"no scopes", CreateAPIKeyRequest{Name: "script"}, ErrInvalidAPIKeyScope},
+		{"unknown scope", CreateAPIKeyRequest{Name: "script", Scopes: []string{"admin"}}, ErrInvalidAPIKeyScope},
+		{"expired", CreateAPIKeyRequest{Name: "script", Scopes: []string{ScopeEntriesRead}, ExpiresAt: &past}, ErrInvalidAPIKeyExpiry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := new(MockAPIKeyRepository)
+			service := newTestAPIKeyService(mockQueries, now)
+
+			_, err := service.CreateAPIKey(ctx, userID, tt.req)
+			assert.ErrorIs(t, err, tt.expectedErr)
+			mockQueries.AssertNotCalled(t, "CreateAPIKey", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestAPIKeyService_RevokeAPIKey(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	keyID := uuid.New()
+
+	mockQueries := new(MockAPIKeyRepository)
+	service := NewAPIKeyService(mockQueries)
+	params := db.DeleteAPIKeyParams{ID: keyID, UserID: userID}
+
+	mockQueries.On("DeleteAPIKey", mock.Anything, params).Return(int64(1), nil).Once()
+	assert.NoError(t, service.RevokeAPIKey(ctx, userID, keyID))
+
+	// Keys of other users match no rows
+	mockQueries.On("DeleteAPIKey", mock.Anything, params).Return(int64(0), nil).Once()
+	assert.ErrorIs(t, service.RevokeAPIKey(ctx, userID, keyID), ErrAPIKeyNotFound)
+
+	mockQueries.AssertExpectations(t)
+}
+
+func TestAPIKeyService_AuthenticateAPIKey(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	key := APIKeyPrefix + "3q2-7wAAAAA"
+	apiKey := db.ApiKey{ID: uuid.New(), UserID: uuid.New(), Scopes: []string{ScopeCalendarsRead}}
+
+	t.Run("resolves the key and records use", func(t *testing.T) {
+		mockQueries := new(MockAPIKeyRepository)
+		service := newTestAPIKeyService(mockQueries, now)
+		mockQueries.On("GetActiveAPIKeyByHash", mock.Anything, auth.HashOpaqueToken(key)).Return(apiKey, nil).Once()
+		mockQueries.On("TouchAPIKey", mock.Anything, apiKey.ID).Return(nil).Once()
+
+		principal, err := service.AuthenticateAPIKey(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, &APIKeyPrincipal{KeyID: apiKey.ID, UserID: apiKey.UserID, Scopes: apiKey.Scopes}, principal)
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("records use at most once a minute", func(t *testing.T) {
+		mockQueries := new(MockAPIKeyRepository)
+		service := newTestAPIKeyService(mockQueries, now)
+		recent := apiKey
+		recent.LastUsedAt = sql.NullTime{Time: now.Add(-30 * time.Second), Valid: true}
+		mockQueries.On("GetActiveAPIKeyByHash", mock.Anything, auth.HashOpaqueToken(key)).Return(recent, nil).Once()
+
+		_, err := service.AuthenticateAPIKey(ctx, key)
+		require.NoError(t, err)
+		mockQueries.AssertNotCalled(t, "TouchAPIKey", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failing to record use still authenticates", func(t *testing.T) {
+		mockQueries := new(MockAPIKeyRepository)
+		service := newTestAPIKeyService(mockQueries, now)
+		mockQueries.On("GetActiveAPIKeyByHash", mock.Anything, auth.HashOpaqueToken(key)).Return(apiKey, nil).Once()
+		mockQueries.On("TouchAPIKey", mock.Anything, apiKey.ID).Return(errors.New("connection refused")).Once()
+
+		_, err := service.AuthenticateAPIKey(ctx, key)
+		assert.NoError(t, err)
+	})
+
+	t.Run("unknown or expired key", func(t *testing.T) {
+		mockQueries := new(MockAPIKeyRepository)
+		service := newTestAPIKeyService(mockQueries, now)
+		mockQueries.On("GetActiveAPIKeyByHash", mock.Anything, auth.HashOpaqueToken(key)).Return(db.ApiKey{}, sql.ErrNoRows).Once()
+
+		_, err := service.AuthenticateAPIKey(ctx, key)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	})
+
+	t.Run("not an API key", func(t *testing.T) {
+		service := newTestAPIKeyService(new(MockAPIKeyRepository), now)
+		_, err := service.AuthenticateAPIKey(ctx, "eyJhbGciOiJIUzI1NiJ9.e30.sig")
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	})
+}
+
+func TestHasScope(t *testing.T) {
+	assert.True(t, HasScope([]string{ScopeEntriesRead}, ScopeEntriesRead))
+	assert.True(t, HasScope([]string{ScopeEntriesWrite}, ScopeEntriesRead))
+	assert.False(t, HasScope([]string{ScopeEntriesRead}, ScopeEntriesWrite))
+	assert.False(t, HasScope([]string{ScopeEntriesWrite}, ScopeCalendarsRead))
+	assert.False(t, HasScope(nil, ScopeCalendarsRead))
+}
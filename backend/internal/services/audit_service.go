@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"days/internal/db"
+
+	"github.com/google/uuid"
+)
+
+// Resources the audit log records changes to
+const (
+	AuditResourceCalendar     = "calendar"
+	AuditResourceColorMeaning = "color_meaning"
+	AuditResourceDayEntry     = "day_entry"
+)
+
+// Actions recorded in the audit log for changes to calendars and their contents
+const (
+	AuditActionCalendarCreated     = "calendar.created"
+	AuditActionCalendarUpdated     = "calendar.updated"
+	AuditActionCalendarDeleted     = "calendar.deleted"
+	AuditActionColorMeaningCreated = "color_meaning.created"
+	AuditActionColorMeaningUpdated = "color_meaning.updated"
+	AuditActionColorMeaningDeleted = "color_meaning.deleted"
+	AuditActionDayEntryCreated     = "day_entry.created"
+	AuditActionDayEntryUpdated     = "day_entry.updated"
+	AuditActionDayEntryDeleted     = "day_entry.deleted"
+)
+
+const (
+	// DefaultAuditPageSize is how many events a page holds unless asked otherwise
+	DefaultAuditPageSize = 50
+	// MaxAuditPageSize caps the page size clients can ask for
+	MaxAuditPageSize = 200
+)
+
+type AuditService struct {
+	queries AuditRepository
+}
+
+type AuditEventResponse struct {
+	ID           uuid.UUID                  `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Action       string                     `json:"action" example:"calendar.updated"`
+	ResourceType string                     `json:"resource_type,omitempty" example:"calendar"`
+	ResourceID   *uuid.UUID                 `json:"resource_id,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Metadata     map[string]string          `json:"metadata,omitempty"`
+	Changes      map[string]json.RawMessage `json:"changes,omitempty" swaggertype:"object"` // each field's before and after values
+	UserAgent    string                     `json:"user_agent" example:"Mozilla/5.0 (Android 14)"`
+	IPAddress    string                     `json:"ip_address" example:"203.0.113.7"`
+	CreatedAt    string                     `json:"created_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// AuditEventPage is a page of events, newest first. NextCursor is empty on
+// the last page.
+type AuditEventPage struct {
+	Events     []*AuditEventResponse `json:"events"`
+	NextCursor string                `json:"next_cursor,omitempty" example:"eyJ0IjoiMjAyMy0wMS0wMVQwMDowMDowMFoiLCJpZCI6IjEyMyJ9"`
+}
+
+// auditCursor is the position of the last event on a page
+type auditCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+}
+
+func NewAuditService(queries AuditRepository) *AuditService {
+	return &AuditService{queries: queries}
+}
+
+// GetAuditEvents returns a page of the events recorded for a user, newest
+// first, starting after cursor when one is given
+func (s *AuditService) GetAuditEvents(ctx context.Context, userID uuid.UUID, cursor string, limit int) (*AuditEventPage, error) {
+	ctx, span := tracer.Start(ctx, "AuditService.GetAuditEvents")
+	defer span.End()
+
+	limit = pageSize(limit, DefaultAuditPageSize, MaxAuditPageSize)
+
+	params := db.ListAuditEventsByUserParams{
+		UserID:   userID,
+		RowLimit: int32(limit + 1), // one more tells whether there is a next page
+	}
+	if cursor != "" {
+		var position auditCursor
+		if err := decodeCursor(cursor, &position); err != nil {
+			return nil, err
+		}
+		params.CursorCreatedAt = sql.NullTime{Time: position.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: position.ID, Valid: true}
+	}
+
+	events, err := s.queries.ListAuditEventsByUser(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+
+	page := &AuditEventPage{Events: make([]*AuditEventResponse, 0, min(len(events), limit))}
+	if len(events) > limit {
+		events = events[:limit]
+		last := events[limit-1]
+		if page.NextCursor, err = encodeCursor(auditCursor{CreatedAt: last.CreatedAt, ID: last.ID}); err != nil {
+			return nil, fmt.Errorf("failed to encode cursor: %w", err)
+		}
+	}
+	for _, event := range events {
+		page.Events = append(page.Events, toAuditEventResponse(event))
+	}
+	return page, nil
+}
+
+func toAuditEventResponse(event db.AuditEvent) *AuditEventResponse {
+	response := &AuditEventResponse{
+		ID:           event.ID,
+		Action:       event.Action,
+		ResourceType: event.ResourceType,
+		UserAgent:    event.UserAgent,
+		IPAddress:    event.IpAddress,
+		CreatedAt:    event.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+	}
+	if event.ResourceID.Valid {
+		response.ResourceID = &event.ResourceID.UUID
+	}
+	// Both columns hold JSON objects written by audit.Recorder
+	_ = json.Unmarshal(event.Metadata, &response.Metadata)
+	_ = json.Unmarshal(event.Changes, &response.Changes)
+	return response
+}
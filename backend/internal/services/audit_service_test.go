@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"days/internal/db"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAuditRepository implements a mock for the AuditRepository interface
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepository) ListAuditEventsByUser(ctx context.Context, arg db.ListAuditEventsByUserParams) ([]db.AuditEvent, error) {
+	args := m.Called(ctx, arg)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]db.AuditEvent), args.Error(1)
+}
+
+// auditEvents returns n events, newest first, a minute apart
+func auditEvents(userID uuid.UUID, n int) []db.AuditEvent {
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	events := make([]db.AuditEvent, n)
+	for i := range events {
+		events[i] = db.AuditEvent{
+			ID:        uuid.New(),
+			UserID:    userID,
+			Action:    AuditActionCalendarUpdated,
+			Metadata:  json.RawMessage("{}"),
+			Changes:   json.RawMessage(`{"name":{"before":"Mood","after":"Moods"}}`),
+			CreatedAt: start.Add(-time.Duration(i) * time.Minute),
+		}
+	}
+	return events
+}
+
+func TestAuditService_GetAuditEvents(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	t.Run("pages through events with a cursor", func(t *testing.T) {
+		mockQueries := new(MockAuditRepository)
+		service := NewAuditService(mockQueries)
+		events := auditEvents(userID, 3)
+		calendarID := uuid.New()
+		events[0].ResourceType = AuditResourceCalendar
+		events[0].ResourceID = uuid.NullUUID{UUID: calendarID, Valid: true}
+
+		mockQueries.On("ListAuditEventsByUser", mock.Anything, db.ListAuditEventsByUserParams{UserID: userID, RowLimit: 3}).
+			Return(events, nil).Once()
+
+		page, err := service.GetAuditEvents(ctx, userID, "", 2)
+
+		require.NoError(t, err)
+		require.Len(t, page.Events, 2)
+		assert.Equal(t, events[0].ID, page.Events[0].ID)
+		assert.Equal(t, AuditResourceCalendar, page.Events[0].ResourceType)
+		assert.Equal(t, &calendarID, page.Events[0].ResourceID)
+		assert.JSONEq(t, `{"before":"Mood","after":"Moods"}`, string(page.Events[0].Changes["name"]))
+		assert.Equal(t, "2024-06-01T12:00:00Z", page.Events[0].CreatedAt)
+		require.NotEmpty(t, page.NextCursor)
+
+		// The next page starts after the last event returned
+		last := events[1]
+		mockQueries.On("ListAuditEventsByUser", mock.Anything, db.ListAuditEventsByUserParams{
+			UserID:          userID,
+			CursorCreatedAt: sql.NullTime{Time: last.CreatedAt, Valid: true},
+			CursorID:        uuid.NullUUID{UUID: last.ID, Valid: true},
+			RowLimit:        3,
+		}).Return(events[2:], nil).Once()
+
+		page, err = service.GetAuditEvents(ctx, userID, page.NextCursor, 2)
+
+		require.NoError(t, err)
+		require.Len(t, page.Events, 1)
+		assert.Equal(t, events[2].ID, page.Events[0].ID)
+		assert.Empty(t, page.NextCursor)
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("limits the page size", func(t *testing.T) {
+		for limit, rowLimit := range map[int]int32{0: DefaultAuditPageSize + 1, 1000: MaxAuditPageSize + 1} {
+			mockQueries := new(MockAuditRepository)
+			service := NewAuditService(mockQueries)
+			mockQueries.On("ListAuditEventsByUser", mock.Anything, db.ListAuditEventsByUserParams{UserID: userID, RowLimit: rowLimit}).
+				Return([]db.AuditEvent{}, nil).Once()
+
+			page, err := service.GetAuditEvents(ctx, userID, "", limit)
+
+			require.NoError(t, err)
+			assert.NotNil(t, page.Events)
+			mockQueries.AssertExpectations(t)
+		}
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		mockQueries := new(MockAuditRepository)
+		service := NewAuditService(mockQueries)
+
+		for _, cursor := range []string{"not base64!", "bm90IGpzb24"} {
+			_, err := service.GetAuditEvents(ctx, userID, cursor, 10)
+			assert.ErrorIs(t, err, ErrInvalidCursor)
+		}
+		mockQueries.AssertNotCalled(t, "ListAuditEventsByUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mockQueries := new(MockAuditRepository)
+		service := NewAuditService(mockQueries)
+		mockQueries.On("ListAuditEventsByUser", mock.Anything, mock.Anything).Return(nil, errors.New("database down")).Once()
+
+		_, err := service.GetAuditEvents(ctx, userID, "", 10)
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,401 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"days/internal/db"
+
+	"github.com/google/uuid"
+)
+
+// CalendarRole is what a member may do with a shared calendar
+type CalendarRole string
+
+const (
+	// RoleOwner created the calendar and manages its members
+	RoleOwner CalendarRole = "owner"
+	// RoleEditor records day entries and edits the legend
+	RoleEditor CalendarRole = "editor"
+	// RoleViewer only reads
+	RoleViewer CalendarRole = "viewer"
+)
+
+var (
+	ErrInvalidRole          = errors.New("role must be editor or viewer")
+	ErrAlreadyMember        = errors.New("user is already a member of this calendar")
+	ErrMemberNotFound       = errors.New("calendar member not found")
+	ErrCannotChangeOwner    = errors.New("the calendar owner cannot be changed or removed")
+	ErrInvitationNotFound   = errors.New("invitation not found")
+	ErrUnauthorizedRoleEdit = errors.New("only the calendar owner can manage members")
+	ErrEmailNotVerified     = errors.New("verify your email address to answer invitations sent to it")
+)
+
+// allows reports whether a member with role r may do what required grants
+func (r CalendarRole) allows(required CalendarRole) bool {
+	rank := map[CalendarRole]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+	return rank[r] >= rank[required] && rank[required] > 0
+}
+
+// authorizeCalendar loads a calendar and checks that userID is a member with
+// at least the required role. Services share it so that every calendar
+// resource follows the same rules.
+func authorizeCalendar(ctx context.Context, queries CalendarAccessRepository, userID, calendarID uuid.UUID, required CalendarRole) (db.Calendar, CalendarRole, error) {
+	row, err := queries.GetCalendarWithRole(ctx, db.GetCalendarWithRoleParams{
+		ID:     calendarID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.Calendar{}, "", ErrCalendarNotFound
+		}
+		return db.Calendar{}, "", fmt.Errorf("failed to get calendar: %w", err)
+	}
+
+	role := CalendarRole(row.Role.String)
+	if !row.Role.Valid || !role.allows(required) {
+		return db.Calendar{}, "", ErrUnauthorizedCalendar
+	}
+
+	return row.Calendar, role, nil
+}
+
+type CalendarMemberService struct {
+	queries CalendarMemberRepository
+}
+
+type InviteMemberRequest struct {
+	Email string       `json:"email" example:"friend@example.com" binding:"required"`
+	Role  CalendarRole `json:"role" example:"editor" binding:"required"` // editor or viewer
+}
+
+type UpdateMemberRoleRequest struct {
+	Role CalendarRole `json:"role" example:"viewer" binding:"required"` // editor or viewer
+}
+
+type CalendarMemberResponse struct {
+	CalendarID uuid.UUID    `json:"calendar_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	UserID     uuid.UUID    `json:"user_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Email      string       `json:"email,omitempty" example:"friend@example.com"`
+	Role       CalendarRole `json:"role" example:"editor"`
+	CreatedAt  string       `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	UpdatedAt  string       `json:"updated_at" example:"2023-01-01T00:00:00Z"`
+}
+
+type InvitationResponse struct {
+	ID             uuid.UUID    `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	CalendarID     uuid.UUID    `json:"calendar_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	CalendarName   string       `json:"calendar_name,omitempty" example:"On-call"`
+	Email          string       `json:"email" example:"friend@example.com"`
+	Role           CalendarRole `json:"role" example:"editor"`
+	InvitedBy      uuid.UUID    `json:"invited_by" example:"123e4567-e89b-12d3-a456-426614174000"`
+	InvitedByEmail string       `json:"invited_by_email,omitempty" example:"owner@example.com"`
+	CreatedAt      string       `json:"created_at" example:"2023-01-01T00:00:00Z"`
+}
+
+func NewCalendarMemberService(queries CalendarMemberRepository) *CalendarMemberService {
+	return &CalendarMemberService{
+		queries: queries,
+	}
+}
+
+// InviteMember invites someone by email to a calendar. Inviting the same
+// address again replaces the pending invitation's role.
+func (s *CalendarMemberService) InviteMember(ctx context.Context, userID, calendarID uuid.UUID, req InviteMemberRequest) (*InvitationResponse, error) {
+	ctx, span := tracer.Start(ctx, "CalendarMemberService.InviteMember")
+	defer span.End()
+
+	if err := s.validateRole(req.Role); err != nil {
+		return nil, err
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if err := validateEmail(email); err != nil {
+		return nil, err
+	}
+
+	if err := s.requireOwner(ctx, userID, calendarID); err != nil {
+		return nil, err
+	}
+
+	// Members cannot be invited again; their role is changed instead
+	members, err := s.queries.GetCalendarMembers(ctx, calendarID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar members: %w", err)
+	}
+	for _, member := range members {
+		if member.Email == email {
+			return nil, ErrAlreadyMember
+		}
+	}
+
+	invitation, err := s.queries.UpsertCalendarInvitation(ctx, db.UpsertCalendarInvitationParams{
+		CalendarID: calendarID,
+		Email:      email,
+		Role:       string(req.Role),
+		InvitedBy:  userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	return &InvitationResponse{
+		ID:         invitation.ID,
+		CalendarID: invitation.CalendarID,
+		Email:      invitation.Email,
+		Role:       CalendarRole(invitation.Role),
+		InvitedBy:  invitation.InvitedBy,
+		CreatedAt:  invitation.CreatedAt.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// GetCalendarInvitations lists the pending invitations of a calendar
+func (s *CalendarMemberService) GetCalendarInvitations(ctx context.Context, userID, calendarID uuid.UUID) ([]*InvitationResponse, error) {
+	ctx, span := tracer.Start(ctx, "CalendarMemberService.GetCalendarInvitations")
+	defer span.End()
+
+	if err := s.requireOwner(ctx, userID, calendarID); err != nil {
+		return nil, err
+	}
+
+	invitations, err := s.queries.GetCalendarInvitationsByCalendarID(ctx, calendarID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitations: %w", err)
+	}
+
+	responses := make([]*InvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		responses = append(responses, &InvitationResponse{
+			ID:         invitation.ID,
+			CalendarID: invitation.CalendarID,
+			Email:      invitation.Email,
+			Role:       CalendarRole(invitation.Role),
+			InvitedBy:  invitation.InvitedBy,
+			CreatedAt:  invitation.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	return responses, nil
+}
+
+// GetInvitations lists the pending invitations addressed to a user's email.
+// Until the address is verified the user has not shown it is theirs, so the
+// list is empty.
+func (s *CalendarMemberService) GetInvitations(ctx context.Context, userID uuid.UUID) ([]*InvitationResponse, error) {
+	ctx, span := tracer.Start(ctx, "CalendarMemberService.GetInvitations")
+	defer span.End()
+
+	user, err := s.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.EmailVerifiedAt.Valid {
+		return []*InvitationResponse{}, nil
+	}
+
+	invitations, err := s.queries.GetCalendarInvitationsByEmail(ctx, user.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitations: %w", err)
+	}
+
+	responses := make([]*InvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		responses = append(responses, &InvitationResponse{
+			ID:             invitation.ID,
+			CalendarID:     invitation.CalendarID,
+			CalendarName:   invitation.CalendarName,
+			Email:          invitation.Email,
+			Role:           CalendarRole(invitation.Role),
+			InvitedBy:      invitation.InvitedBy,
+			InvitedByEmail: invitation.InvitedByEmail,
+			CreatedAt:      invitation.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	return responses, nil
+}
+
+// AcceptInvitation makes a user a member of the calendar they were invited
+// to. Invitations go to an email address, so only a user who has verified
+// that address may accept them.
+func (s *CalendarMemberService) AcceptInvitation(ctx context.Context, userID, invitationID uuid.UUID) (*CalendarMemberResponse, error) {
+	ctx, span := tracer.Start(ctx, "CalendarMemberService.AcceptInvitation")
+	defer span.End()
+
+	user, err := s.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.EmailVerifiedAt.Valid {
+		return nil, ErrEmailNotVerified
+	}
+
+	member, err := s.queries.AcceptCalendarInvitation(ctx, db.AcceptCalendarInvitationParams{
+		ID:     invitationID,
+		Email:  user.Email,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+
+	return &CalendarMemberResponse{
+		CalendarID: member.CalendarID,
+		UserID:     member.UserID,
+		Email:      user.Email,
+		Role:       CalendarRole(member.Role),
+		CreatedAt:  member.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:  member.UpdatedAt.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// DeclineInvitation deletes an invitation addressed to a user's verified email
+func (s *CalendarMemberService) DeclineInvitation(ctx context.Context, userID, invitationID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "CalendarMemberService.DeclineInvitation")
+	defer span.End()
+
+	user, err := s.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.EmailVerifiedAt.Valid {
+		return ErrEmailNotVerified
+	}
+
+	deleted, err := s.queries.DeleteCalendarInvitation(ctx, db.DeleteCalendarInvitationParams{
+		ID:    invitationID,
+		Email: user.Email,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to decline invitation: %w", err)
+	}
+	if deleted == 0 {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// GetMembers lists the members of a calendar, owner first. Any member may see them.
+func (s *CalendarMemberService) GetMembers(ctx context.Context, userID, calendarID uuid.UUID) ([]*CalendarMemberResponse, error) {
+	ctx, span := tracer.Start(ctx, "CalendarMemberService.GetMembers")
+	defer span.End()
+
+	if _, _, err := authorizeCalendar(ctx, s.queries, userID, calendarID, RoleViewer); err != nil {
+		return nil, err
+	}
+
+	members, err := s.queries.GetCalendarMembers(ctx, calendarID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar members: %w", err)
+	}
+
+	responses := make([]*CalendarMemberResponse, 0, len(members))
+	for _, member := range members {
+		responses = append(responses, &CalendarMemberResponse{
+			CalendarID: member.CalendarID,
+			UserID:     member.UserID,
+			Email:      member.Email,
+			Role:       CalendarRole(member.Role),
+			CreatedAt:  member.CreatedAt.UTC().Format(time.RFC3339),
+			UpdatedAt:  member.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	return responses, nil
+}
+
+// UpdateMemberRole switches a member between editor and viewer
+func (s *CalendarMemberService) UpdateMemberRole(ctx context.Context, userID, calendarID, memberID uuid.UUID, req UpdateMemberRoleRequest) (*CalendarMemberResponse, error) {
+	ctx, span := tracer.Start(ctx, "CalendarMemberService.UpdateMemberRole")
+	defer span.End()
+
+	if err := s.validateRole(req.Role); err != nil {
+		return nil, err
+	}
+	if err := s.requireOwner(ctx, userID, calendarID); err != nil {
+		return nil, err
+	}
+	if memberID == userID {
+		return nil, ErrCannotChangeOwner
+	}
+
+	member, err := s.queries.UpdateCalendarMemberRole(ctx, db.UpdateCalendarMemberRoleParams{
+		CalendarID: calendarID,
+		UserID:     memberID,
+		Role:       string(req.Role),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMemberNotFound
+		}
+		return nil, fmt.Errorf("failed to update member role: %w", err)
+	}
+
+	return &CalendarMemberResponse{
+		CalendarID: member.CalendarID,
+		UserID:     member.UserID,
+		Role:       CalendarRole(member.Role),
+		CreatedAt:  member.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:  member.UpdatedAt.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// RemoveMember takes a member off a calendar. Owners remove others; every
+// other member may remove themselves to leave a calendar.
+func (s *CalendarMemberService) RemoveMember(ctx context.Context, userID, calendarID, memberID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "CalendarMemberService.RemoveMember")
+	defer span.End()
+
+	_, role, err := authorizeCalendar(ctx, s.queries, userID, calendarID, RoleViewer)
+	if err != nil {
+		return err
+	}
+	if role == RoleOwner && memberID == userID {
+		return ErrCannotChangeOwner
+	}
+	if role != RoleOwner && memberID != userID {
+		return ErrUnauthorizedRoleEdit
+	}
+
+	deleted, err := s.queries.DeleteCalendarMember(ctx, db.DeleteCalendarMemberParams{
+		CalendarID: calendarID,
+		UserID:     memberID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+	if deleted == 0 {
+		return ErrMemberNotFound
+	}
+	return nil
+}
+
+// Helper methods
+
+func (s *CalendarMemberService) requireOwner(ctx context.Context, userID, calendarID uuid.UUID) error {
+	_, err := authorizeOwner(ctx, s.queries, userID, calendarID)
+	return err
+}
+
+// authorizeOwner is authorizeCalendar for owner-only actions, reporting
+// other members' attempts as ErrUnauthorizedRoleEdit
+func authorizeOwner(ctx context.Context, queries CalendarAccessRepository, userID, calendarID uuid.UUID) (db.Calendar, error) {
+	calendar, role, err := authorizeCalendar(ctx, queries, userID, calendarID, RoleViewer)
+	if err != nil {
+		return db.Calendar{}, err
+	}
+	if role != RoleOwner {
+		return db.Calendar{}, ErrUnauthorizedRoleEdit
+	}
+	return calendar, nil
+}
+
+func (s *CalendarMemberService) validateRole(role CalendarRole) error {
+	if role != RoleEditor && role != RoleViewer {
+		return ErrInvalidRole
+	}
+	return nil
+}
@@ -0,0 +1,361 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"days/internal/db"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockCalendarMemberRepository implements a mock for the CalendarMemberRepository interface
+type MockCalendarMemberRepository struct {
+	mock.Mock
+}
+
+func (m *MockCalendarMemberRepository) GetCalendarWithRole(ctx context.Context, arg db.GetCalendarWithRoleParams) (db.GetCalendarWithRoleRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.GetCalendarWithRoleRow), args.Error(1)
+}
+
+func (m *MockCalendarMemberRepository) GetUserByID(ctx context.Context, id uuid.UUID) (db.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(db.User), args.Error(1)
+}
+
+func (m *MockCalendarMemberRepository) GetCalendarMembers(ctx context.Context, calendarID uuid.UUID) ([]db.GetCalendarMembersRow, error) {
+	args := m.Called(ctx, calendarID)
+	return args.Get(0).([]db.GetCalendarMembersRow), args.Error(1)
+}
+
+func (m *MockCalendarMemberRepository) UpdateCalendarMemberRole(ctx context.Context, arg db.UpdateCalendarMemberRoleParams) (db.CalendarMember, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.CalendarMember), args.Error(1)
+}
+
+func (m *MockCalendarMemberRepository) DeleteCalendarMember(ctx context.Context, arg db.DeleteCalendarMemberParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCalendarMemberRepository) UpsertCalendarInvitation(ctx context.Context, arg db.UpsertCalendarInvitationParams) (db.CalendarInvitation, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.CalendarInvitation), args.Error(1)
+}
+
+func (m *MockCalendarMemberRepository) GetCalendarInvitationsByCalendarID(ctx context.Context, calendarID uuid.UUID) ([]db.CalendarInvitation, error) {
+	args := m.Called(ctx, calendarID)
+	return args.Get(0).([]db.CalendarInvitation), args.Error(1)
+}
+
+func (m *MockCalendarMemberRepository) GetCalendarInvitationsByEmail(ctx context.Context, email string) ([]db.GetCalendarInvitationsByEmailRow, error) {
+	args := m.Called(ctx, email)
+	return args.Get(0).([]db.GetCalendarInvitationsByEmailRow), args.Error(1)
+}
+
+func (m *MockCalendarMemberRepository) AcceptCalendarInvitation(ctx context.Context, arg db.AcceptCalendarInvitationParams) (db.CalendarMember, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.CalendarMember), args.Error(1)
+}
+
+func (m *MockCalendarMemberRepository) DeleteCalendarInvitation(ctx context.Context, arg db.DeleteCalendarInvitationParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// calendarAccess builds the membership lookup of a user and calendar
+func calendarAccess(userID, calendarID uuid.UUID) db.GetCalendarWithRoleParams {
+	return db.GetCalendarWithRoleParams{ID: calendarID, UserID: userID}
+}
+
+// calendarWithRole builds a membership lookup result; an empty role means the caller is no member
+func calendarWithRole(calendarID, ownerID uuid.UUID, role CalendarRole) db.GetCalendarWithRoleRow {
+	return db.GetCalendarWithRoleRow{
+		Calendar: db.Calendar{ID: calendarID, UserID: ownerID, Name: "Shared"},
+		Role:     sql.NullString{String: string(role), Valid: role != ""},
+	}
+}
+
+func TestCalendarRole_allows(t *testing.T) {
+	tests := []struct {
+		role     CalendarRole
+		required CalendarRole
+		want     bool
+	}{
+		{RoleOwner, RoleOwner, true},
+		{RoleOwner, RoleViewer, true},
+		{RoleEditor, RoleEditor, true},
+		{RoleEditor, RoleViewer, true},
+		{RoleEditor, RoleOwner, false},
+		{RoleViewer, RoleViewer, true},
+		{RoleViewer, RoleEditor, false},
+		{"", RoleViewer, false},
+		{"admin", RoleViewer, false},
+		{RoleOwner, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role)+"/"+string(tt.required), func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.role.allows(tt.required))
+		})
+	}
+}
+
+func TestAuthorizeCalendar(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	calendarID := uuid.New()
+
+	tests := []struct {
+		name        string
+		row         db.GetCalendarWithRoleRow
+		rowErr      error
+		required    CalendarRole
+		expectedErr error
+	}{
+		{"owner", calendarWithRole(calendarID, userID, RoleOwner), nil, RoleOwner, nil},
+		{"editor writes", calendarWithRole(calendarID, uuid.New(), RoleEditor), nil, RoleEditor, nil},
+		{"viewer reads", calendarWithRole(calendarID, uuid.New(), RoleViewer), nil, RoleViewer, nil},
+		{"viewer writes", calendarWithRole(calendarID, uuid.New(), RoleViewer), nil, RoleEditor, ErrUnauthorizedCalendar},
+		{"not a member", calendarWithRole(calendarID, uuid.New(), ""), nil, RoleViewer, ErrUnauthorizedCalendar},
+		{"calendar not found", db.GetCalendarWithRoleRow{}, sql.ErrNoRows, RoleViewer, ErrCalendarNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := new(MockCalendarMemberRepository)
+			mockQueries.On("GetCalendarWithRole", mock.Anything, calendarAccess(userID, calendarID)).Return(tt.row, tt.rowErr).Once()
+
+			calendar, role, err := authorizeCalendar(ctx, mockQueries, userID, calendarID, tt.required)
+			assert.Equal(t, tt.expectedErr, err)
+			if tt.expectedErr == nil {
+				assert.Equal(t, calendarID, calendar.ID)
+				assert.Equal(t, CalendarRole(tt.row.Role.String), role)
+			}
+		})
+	}
+}
+
+func TestCalendarMemberService_InviteMember(t *testing.T) {
+	ctx := context.Background()
+	ownerID := uuid.New()
+	calendarID := uuid.New()
+	members := []db.GetCalendarMembersRow{
+		{CalendarID: calendarID, UserID: ownerID, Email: "owner@example.com", Role: string(RoleOwner)},
+	}
+
+	t.Run("stores a lowercased invitation", func(t *testing.T) {
+		mockQueries := new(MockCalendarMemberRepository)
+		service := NewCalendarMemberService(mockQueries)
+
+		params := db.UpsertCalendarInvitationParams{
+			CalendarID: calendarID,
+			Email:      "friend@example.com",
+			Role:       string(RoleEditor),
+			InvitedBy:  ownerID,
+		}
+		mockQueries.On("GetCalendarWithRole", mock.Anything, calendarAccess(ownerID, calendarID)).Return(calendarWithRole(calendarID, ownerID, RoleOwner), nil).Once()
+		mockQueries.On("GetCalendarMembers", mock.Anything, calendarID).Return(members, nil).Once()
+		mockQueries.On("UpsertCalendarInvitation", mock.Anything, params).Return(db.CalendarInvitation{
+			ID:         uuid.New(),
+			CalendarID: calendarID,
+			Email:      params.Email,
+			Role:       params.Role,
+			InvitedBy:  ownerID,
+			CreatedAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		}, nil).Once()
+
+		invitation, err := service.InviteMember(ctx, ownerID, calendarID, InviteMemberRequest{Email: " Friend@Example.com ", Role: RoleEditor})
+		require.NoError(t, err)
+		assert.Equal(t, "friend@example.com", invitation.Email)
+		assert.Equal(t, RoleEditor, invitation.Role)
+		assert.Equal(t, "2024-01-01T00:00:00Z", invitation.CreatedAt)
+		mockQueries.AssertExpectations(t)
+	})
+
+	tests := []struct {
+		name        string
+		req         InviteMemberRequest
+		role        CalendarRole
+		expectedErr error
+	}{
+		{"owner role", InviteMemberRequest{Email: "friend@example.com", Role: RoleOwner}, RoleOwner, ErrInvalidRole},
+		{"unknown role", InviteMemberRequest{Email: "friend@example.com", Role: "admin"}, RoleOwner, ErrInvalidRole},
+		{"invalid email", InviteMemberRequest{Email: "friend", Role: RoleViewer}, RoleOwner, ErrInvalidEmail},
+		{"editor invites", InviteMemberRequest{Email: "friend@example.com", Role: RoleViewer}, RoleEditor, ErrUnauthorizedRoleEdit},
+		{"not a member", InviteMemberRequest{Email: "friend@example.com", Role: RoleViewer}, "", ErrUnauthorizedCalendar},
+		{"already a member", InviteMemberRequest{Email: "OWNER@example.com", Role: RoleViewer}, RoleOwner, ErrAlreadyMember},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := new(MockCalendarMemberRepository)
+			service := NewCalendarMemberService(mockQueries)
+			mockQueries.On("GetCalendarWithRole", mock.Anything, calendarAccess(ownerID, calendarID)).Return(calendarWithRole(calendarID, ownerID, tt.role), nil).Maybe()
+			mockQueries.On("GetCalendarMembers", mock.Anything, calendarID).Return(members, nil).Maybe()
+
+			invitation, err := service.InviteMember(ctx, ownerID, calendarID, tt.req)
+			assert.Nil(t, invitation)
+			assert.ErrorIs(t, err, tt.expectedErr)
+			mockQueries.AssertNotCalled(t, "UpsertCalendarInvitation", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCalendarMemberService_AcceptInvitation(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	invitationID := uuid.New()
+	user := createTestUser(userID, "friend@example.com")
+	user.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	params := db.AcceptCalendarInvitationParams{ID: invitationID, Email: user.Email, UserID: userID}
+
+	t.Run("joins the calendar", func(t *testing.T) {
+		mockQueries := new(MockCalendarMemberRepository)
+		service := NewCalendarMemberService(mockQueries)
+		calendarID := uuid.New()
+
+		mockQueries.On("GetUserByID", mock.Anything, userID).Return(user, nil).Once()
+		mockQueries.On("AcceptCalendarInvitation", mock.Anything, params).Return(db.CalendarMember{
+			CalendarID: calendarID,
+			UserID:     userID,
+			Role:       string(RoleViewer),
+		}, nil).Once()
+
+		member, err := service.AcceptInvitation(ctx, userID, invitationID)
+		require.NoError(t, err)
+		assert.Equal(t, calendarID, member.CalendarID)
+		assert.Equal(t, RoleViewer, member.Role)
+		assert.Equal(t, user.Email, member.Email)
+	})
+
+	t.Run("invitation of someone else", func(t *testing.T) {
+		mockQueries := new(MockCalendarMemberRepository)
+		service := NewCalendarMemberService(mockQueries)
+
+		mockQueries.On("GetUserByID", mock.Anything, userID).Return(user, nil).Once()
+		mockQueries.On("AcceptCalendarInvitation", mock.Anything, params).Return(db.CalendarMember{}, sql.ErrNoRows).Once()
+
+		member, err := service.AcceptInvitation(ctx, userID, invitationID)
+		assert.Nil(t, member)
+		assert.Equal(t, ErrInvitationNotFound, err)
+	})
+
+	t.Run("unverified email", func(t *testing.T) {
+		mockQueries := new(MockCalendarMemberRepository)
+		service := NewCalendarMemberService(mockQueries)
+
+		// Anyone can sign up with, or change to, an invitee's address
+		unverified := createTestUser(userID, "friend@example.com")
+		mockQueries.On("GetUserByID", mock.Anything, userID).Return(unverified, nil).Times(3)
+
+		member, err := service.AcceptInvitation(ctx, userID, invitationID)
+		assert.Nil(t, member)
+		assert.Equal(t, ErrEmailNotVerified, err)
+		assert.Equal(t, ErrEmailNotVerified, service.DeclineInvitation(ctx, userID, invitationID))
+
+		invitations, err := service.GetInvitations(ctx, userID)
+		require.NoError(t, err)
+		assert.Empty(t, invitations)
+
+		mockQueries.AssertExpectations(t)
+		mockQueries.AssertNotCalled(t, "AcceptCalendarInvitation", mock.Anything, mock.Anything)
+		mockQueries.AssertNotCalled(t, "DeleteCalendarInvitation", mock.Anything, mock.Anything)
+		mockQueries.AssertNotCalled(t, "GetCalendarInvitationsByEmail", mock.Anything, mock.Anything)
+	})
+
+	t.Run("decline", func(t *testing.T) {
+		mockQueries := new(MockCalendarMemberRepository)
+		service := NewCalendarMemberService(mockQueries)
+
+		mockQueries.On("GetUserByID", mock.Anything, userID).Return(user, nil).Twice()
+		mockQueries.On("DeleteCalendarInvitation", mock.Anything, db.DeleteCalendarInvitationParams{ID: invitationID, Email: user.Email}).Return(int64(1), nil).Once()
+		mockQueries.On("DeleteCalendarInvitation", mock.Anything, db.DeleteCalendarInvitationParams{ID: invitationID, Email: user.Email}).Return(int64(0), nil).Once()
+
+		assert.NoError(t, service.DeclineInvitation(ctx, userID, invitationID))
+		assert.Equal(t, ErrInvitationNotFound, service.DeclineInvitation(ctx, userID, invitationID))
+	})
+}
+
+func TestCalendarMemberService_UpdateMemberRole(t *testing.T) {
+	ctx := context.Background()
+	ownerID := uuid.New()
+	memberID := uuid.New()
+	calendarID := uuid.New()
+	params := db.UpdateCalendarMemberRoleParams{CalendarID: calendarID, UserID: memberID, Role: string(RoleViewer)}
+
+	tests := []struct {
+		name        string
+		callerID    uuid.UUID
+		callerRole  CalendarRole
+		memberID    uuid.UUID
+		role        CalendarRole
+		updateErr   error
+		expectedErr error
+	}{
+		{"owner demotes an editor", ownerID, RoleOwner, memberID, RoleViewer, nil, nil},
+		{"member not found", ownerID, RoleOwner, memberID, RoleViewer, sql.ErrNoRows, ErrMemberNotFound},
+		{"owner demotes themselves", ownerID, RoleOwner, ownerID, RoleViewer, nil, ErrCannotChangeOwner},
+		{"promote to owner", ownerID, RoleOwner, memberID, RoleOwner, nil, ErrInvalidRole},
+		{"editor changes roles", memberID, RoleEditor, memberID, RoleViewer, nil, ErrUnauthorizedRoleEdit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := new(MockCalendarMemberRepository)
+			service := NewCalendarMemberService(mockQueries)
+			mockQueries.On("GetCalendarWithRole", mock.Anything, calendarAccess(tt.callerID, calendarID)).Return(calendarWithRole(calendarID, ownerID, tt.callerRole), nil).Maybe()
+			mockQueries.On("UpdateCalendarMemberRole", mock.Anything, params).Return(db.CalendarMember{CalendarID: calendarID, UserID: memberID, Role: params.Role}, tt.updateErr).Maybe()
+
+			member, err := service.UpdateMemberRole(ctx, tt.callerID, calendarID, tt.memberID, UpdateMemberRoleRequest{Role: tt.role})
+			assert.Equal(t, tt.expectedErr, err)
+			if tt.expectedErr == nil {
+				assert.Equal(t, RoleViewer, member.Role)
+			}
+		})
+	}
+}
+
+func TestCalendarMemberService_RemoveMember(t *testing.T) {
+	ctx := context.Background()
+	ownerID := uuid.New()
+	memberID := uuid.New()
+	calendarID := uuid.New()
+
+	tests := []struct {
+		name        string
+		callerID    uuid.UUID
+		callerRole  CalendarRole
+		memberID    uuid.UUID
+		deleted     int64
+		expectedErr error
+	}{
+		{"owner removes a member", ownerID, RoleOwner, memberID, 1, nil},
+		{"member leaves", memberID, RoleViewer, memberID, 1, nil},
+		{"member not found", ownerID, RoleOwner, memberID, 0, ErrMemberNotFound},
+		{"owner leaves", ownerID, RoleOwner, ownerID, 0, ErrCannotChangeOwner},
+		{"editor removes someone else", memberID, RoleEditor, ownerID, 0, ErrUnauthorizedRoleEdit},
+		{"not a member", memberID, "", memberID, 0, ErrUnauthorizedCalendar},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := new(MockCalendarMemberRepository)
+			service := NewCalendarMemberService(mockQueries)
+			mockQueries.On("GetCalendarWithRole", mock.Anything, calendarAccess(tt.callerID, calendarID)).Return(calendarWithRole(calendarID, ownerID, tt.callerRole), nil).Once()
+			mockQueries.On("DeleteCalendarMember", mock.Anything, db.DeleteCalendarMemberParams{CalendarID: calendarID, UserID: tt.memberID}).Return(tt.deleted, nil).Maybe()
+
+			err := service.RemoveMember(ctx, tt.callerID, calendarID, tt.memberID)
+			assert.Equal(t, tt.expectedErr, err)
+			if tt.deleted == 0 && tt.expectedErr != ErrMemberNotFound {
+				mockQueries.AssertNotCalled(t, "DeleteCalendarMember", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"days/internal/audit"
 	"days/internal/db"
 
 	"github.com/google/uuid"
@@ -21,6 +23,7 @@ var (
 
 type CalendarService struct {
 	queries *db.Queries
+	audit   *audit.Recorder
 }
 
 type CreateCalendarRequest struct {
@@ -34,24 +37,56 @@ type UpdateCalendarRequest struct {
 }
 
 type CalendarResponse struct {
-	ID          uuid.UUID `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
-	UserID      uuid.UUID `json:"user_id" example:"123e4567-e89b-12d3-a456-426614174000"`
-	Name        string    `json:"name" example:"My Personal Calendar"`
-	Description *string   `json:"description,omitempty" example:"Calendar for personal events"`
-	CreatedAt   string    `json:"created_at" example:"2023-01-01T00:00:00Z"`
-	UpdatedAt   string    `json:"updated_at" example:"2023-01-01T00:00:00Z"`
+	ID          uuid.UUID    `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	UserID      uuid.UUID    `json:"user_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Name        string       `json:"name" example:"My Personal Calendar"`
+	Description *string      `json:"description,omitempty" example:"Calendar for personal events"`
+	Role        CalendarRole `json:"role" example:"owner"` // the caller's role: owner, editor or viewer
+	CreatedAt   string       `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	UpdatedAt   string       `json:"updated_at" example:"2023-01-01T00:00:00Z"`
+}
+
+const (
+	// DefaultCalendarPageSize is how many calendars a page holds unless asked otherwise
+	DefaultCalendarPageSize = 50
+	// MaxCalendarPageSize caps the page size clients can ask for
+	MaxCalendarPageSize = 200
+)
+
+// CalendarListRequest selects a page of calendars. Order is "asc" (the
+// default) or "desc" by creation time.
+type CalendarListRequest struct {
+	Limit  int
+	Cursor string
+	Order  string
+}
+
+// CalendarPage is a page of calendars. NextCursor is empty on the last page.
+type CalendarPage struct {
+	Calendars  []*CalendarResponse `json:"calendars"`
+	NextCursor string              `json:"next_cursor,omitempty" example:"eyJ0IjoiMjAyMy0wMS0wMVQwMDowMDowMFoiLCJpZCI6IjEyMyJ9"`
+}
+
+// calendarCursor is the position of the last calendar on a page
+type calendarCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
 }
 
 func NewCalendarService(queries *db.Queries) *CalendarService {
 	return &CalendarService{
 		queries: queries,
+		audit:   audit.NewRecorder(queries),
 	}
 }
 
 // CreateCalendar creates a new calendar for a user
 func (s *CalendarService) CreateCalendar(ctx context.Context, userID uuid.UUID, req CreateCalendarRequest) (*CalendarResponse, error) {
+	ctx, span := tracer.Start(ctx, "CalendarService.CreateCalendar")
+	defer span.End()
+
 	// Validate input
-	if err := s.validateCalendarName(req.Name); err != nil {
+	if err := validateCalendarName(req.Name); err != nil {
 		return nil, err
 	}
 
@@ -82,52 +117,147 @@ func (s *CalendarService) CreateCalendar(ctx context.Context, userID uuid.UUID,
 	if err != nil {
 		return nil, fmt.Errorf("failed to create calendar: %w", err)
 	}
+	s.audit.Record(ctx, audit.Event{
+		UserID:       userID,
+		Action:       AuditActionCalendarCreated,
+		ResourceType: AuditResourceCalendar,
+		ResourceID:   calendar.ID,
+		Changes:      audit.Diff(nil, calendarSnapshot(calendar)),
+	})
 
-	return s.toCalendarResponse(calendar), nil
+	return toCalendarResponse(calendar, RoleOwner), nil
 }
 
-// GetCalendarsByUserID retrieves all calendars for a user
-func (s *CalendarService) GetCalendarsByUserID(ctx context.Context, userID uuid.UUID) ([]*CalendarResponse, error) {
-	calendars, err := s.queries.GetCalendarsByUserID(ctx, userID)
+// GetCalendarsByUserID returns a page of the calendars a user owns or is a
+// member of, oldest first unless asked otherwise, starting after the cursor
+// when one is given
+func (s *CalendarService) GetCalendarsByUserID(ctx context.Context, userID uuid.UUID, req CalendarListRequest) (*CalendarPage, error) {
+	ctx, span := tracer.Start(ctx, "CalendarService.GetCalendarsByUserID")
+	defer span.End()
+
+	params, descending, limit, err := calendarListParams(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	calendars, err := s.listCalendars(ctx, params, descending)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user calendars: %w", err)
 	}
 
-	var responses []*CalendarResponse
+	page := &CalendarPage{Calendars: make([]*CalendarResponse, 0, min(len(calendars), limit))}
+	if len(calendars) > limit {
+		calendars = calendars[:limit]
+		last := calendars[limit-1].Calendar
+		if page.NextCursor, err = encodeCursor(calendarCursor{CreatedAt: last.CreatedAt.Time, ID: last.ID}); err != nil {
+			return nil, fmt.Errorf("failed to encode cursor: %w", err)
+		}
+	}
 	for _, calendar := range calendars {
-		responses = append(responses, s.toCalendarResponse(calendar))
+		page.Calendars = append(page.Calendars, toCalendarResponse(calendar.Calendar, CalendarRole(calendar.Role)))
 	}
+	return page, nil
+}
 
-	return responses, nil
+// GetAllCalendarsByUserID returns every calendar a user owns or is a member
+// of, in the request's order. The request's limit and cursor are ignored.
+func (s *CalendarService) GetAllCalendarsByUserID(ctx context.Context, userID uuid.UUID, req CalendarListRequest) ([]*CalendarResponse, error) {
+	ctx, span := tracer.Start(ctx, "CalendarService.GetAllCalendarsByUserID")
+	defer span.End()
+
+	req.Cursor = ""
+	params, descending, _, err := calendarListParams(userID, req)
+	if err != nil {
+		return nil, err
+	}
+	params.RowLimit = MaxCalendarPageSize
+
+	// Reads the calendars a page at a time, each page after the last calendar of the one before
+	responses := []*CalendarResponse{}
+	for {
+		calendars, err := s.listCalendars(ctx, params, descending)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user calendars: %w", err)
+		}
+		for _, calendar := range calendars {
+			responses = append(responses, toCalendarResponse(calendar.Calendar, CalendarRole(calendar.Role)))
+		}
+		if len(calendars) < int(params.RowLimit) {
+			return responses, nil
+		}
+		last := calendars[len(calendars)-1].Calendar
+		params.CursorCreatedAt = sql.NullTime{Time: last.CreatedAt.Time, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: last.ID, Valid: true}
+	}
 }
 
-// GetCalendarByID retrieves a calendar by ID and verifies user ownership
-func (s *CalendarService) GetCalendarByID(ctx context.Context, userID, calendarID uuid.UUID) (*CalendarResponse, error) {
-	calendar, err := s.queries.GetCalendarByID(ctx, calendarID)
+// listCalendars runs the page query for the sort order. Each order has a
+// query of its own so that neither compares or sorts through a CASE.
+func (s *CalendarService) listCalendars(ctx context.Context, params db.ListCalendarsByMemberIDParams, descending bool) ([]db.ListCalendarsByMemberIDRow, error) {
+	if !descending {
+		return s.queries.ListCalendarsByMemberID(ctx, params)
+	}
+
+	rows, err := s.queries.ListCalendarsByMemberIDDesc(ctx, db.ListCalendarsByMemberIDDescParams(params))
+	if err != nil {
+		return nil, err
+	}
+	calendars := make([]db.ListCalendarsByMemberIDRow, 0, len(rows))
+	for _, row := range rows {
+		calendars = append(calendars, db.ListCalendarsByMemberIDRow(row))
+	}
+	return calendars, nil
+}
+
+// calendarListParams turns a list request into query parameters, whether
+// they sort descending and the number of calendars the page holds
+func calendarListParams(userID uuid.UUID, req CalendarListRequest) (db.ListCalendarsByMemberIDParams, bool, int, error) {
+	limit := pageSize(req.Limit, DefaultCalendarPageSize, MaxCalendarPageSize)
+	descending, err := isDescending(req.Order, SortAscending)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, ErrCalendarNotFound
+		return db.ListCalendarsByMemberIDParams{}, false, 0, err
+	}
+
+	params := db.ListCalendarsByMemberIDParams{
+		UserID:   userID,
+		RowLimit: int32(limit + 1), // one more tells whether there is a next page
+	}
+	if req.Cursor != "" {
+		var position calendarCursor
+		if err := decodeCursor(req.Cursor, &position); err != nil {
+			return db.ListCalendarsByMemberIDParams{}, false, 0, err
 		}
-		return nil, fmt.Errorf("failed to get calendar: %w", err)
+		params.CursorCreatedAt = sql.NullTime{Time: position.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: position.ID, Valid: true}
 	}
+	return params, descending, limit, nil
+}
 
-	// Check ownership
-	if calendar.UserID != userID {
-		return nil, ErrUnauthorizedCalendar
+// GetCalendarByID retrieves a calendar by ID and verifies the user is a member
+func (s *CalendarService) GetCalendarByID(ctx context.Context, userID, calendarID uuid.UUID) (*CalendarResponse, error) {
+	ctx, span := tracer.Start(ctx, "CalendarService.GetCalendarByID")
+	defer span.End()
+
+	calendar, role, err := s.authorize(ctx, userID, calendarID, RoleViewer)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.toCalendarResponse(calendar), nil
+	return toCalendarResponse(calendar, role), nil
 }
 
 // UpdateCalendar updates a calendar's name and description
 func (s *CalendarService) UpdateCalendar(ctx context.Context, userID, calendarID uuid.UUID, req UpdateCalendarRequest) (*CalendarResponse, error) {
+	ctx, span := tracer.Start(ctx, "CalendarService.UpdateCalendar")
+	defer span.End()
+
 	// Validate input
-	if err := s.validateCalendarName(req.Name); err != nil {
+	if err := validateCalendarName(req.Name); err != nil {
 		return nil, err
 	}
 
 	// Check calendar exists and user owns it
-	_, err := s.GetCalendarByID(ctx, userID, calendarID)
+	calendar, _, err := s.authorize(ctx, userID, calendarID, RoleOwner)
 	if err != nil {
 		return nil, err
 	}
@@ -138,8 +268,8 @@ func (s *CalendarService) UpdateCalendar(ctx context.Context, userID, calendarID
 		return nil, fmt.Errorf("failed to check existing calendars: %w", err)
 	}
 
-	for _, calendar := range userCalendars {
-		if calendar.ID != calendarID && strings.EqualFold(calendar.Name, req.Name) {
+	for _, other := range userCalendars {
+		if other.ID != calendarID && strings.EqualFold(other.Name, req.Name) {
 			return nil, ErrCalendarNameExists
 		}
 	}
@@ -164,14 +294,24 @@ func (s *CalendarService) UpdateCalendar(ctx context.Context, userID, calendarID
 	if updatedCalendar.UserID != userID {
 		return nil, ErrUnauthorizedCalendar
 	}
+	s.audit.Record(ctx, audit.Event{
+		UserID:       userID,
+		Action:       AuditActionCalendarUpdated,
+		ResourceType: AuditResourceCalendar,
+		ResourceID:   calendarID,
+		Changes:      audit.Diff(calendarSnapshot(calendar), calendarSnapshot(updatedCalendar)),
+	})
 
-	return s.toCalendarResponse(updatedCalendar), nil
+	return toCalendarResponse(updatedCalendar, RoleOwner), nil
 }
 
 // DeleteCalendar deletes a calendar and all associated data
 func (s *CalendarService) DeleteCalendar(ctx context.Context, userID, calendarID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "CalendarService.DeleteCalendar")
+	defer span.End()
+
 	// Check calendar exists and user owns it
-	_, err := s.GetCalendarByID(ctx, userID, calendarID)
+	calendar, _, err := s.authorize(ctx, userID, calendarID, RoleOwner)
 	if err != nil {
 		return err
 	}
@@ -181,13 +321,25 @@ func (s *CalendarService) DeleteCalendar(ctx context.Context, userID, calendarID
 	if err != nil {
 		return fmt.Errorf("failed to delete calendar: %w", err)
 	}
+	s.audit.Record(ctx, audit.Event{
+		UserID:       userID,
+		Action:       AuditActionCalendarDeleted,
+		ResourceType: AuditResourceCalendar,
+		ResourceID:   calendarID,
+		Changes:      audit.Diff(calendarSnapshot(calendar), nil),
+	})
 
 	return nil
 }
 
 // Helper methods
 
-func (s *CalendarService) validateCalendarName(name string) error {
+// authorize checks that the user is a member of the calendar with at least the required role
+func (s *CalendarService) authorize(ctx context.Context, userID, calendarID uuid.UUID, required CalendarRole) (db.Calendar, CalendarRole, error) {
+	return authorizeCalendar(ctx, s.queries, userID, calendarID, required)
+}
+
+func validateCalendarName(name string) error {
 	name = strings.TrimSpace(name)
 	if name == "" {
 		return ErrCalendarNameEmpty
@@ -198,7 +350,16 @@ func (s *CalendarService) validateCalendarName(name string) error {
 	return nil
 }
 
-func (s *CalendarService) toCalendarResponse(calendar db.Calendar) *CalendarResponse {
+// calendarSnapshot returns the fields of a calendar its audit events diff
+func calendarSnapshot(calendar db.Calendar) map[string]any {
+	snapshot := map[string]any{"name": calendar.Name}
+	if calendar.Description.Valid {
+		snapshot["description"] = calendar.Description.String
+	}
+	return snapshot
+}
+
+func toCalendarResponse(calendar db.Calendar, role CalendarRole) *CalendarResponse {
 	var description *string
 	if calendar.Description.Valid {
 		description = &calendar.Description.String
@@ -217,6 +378,7 @@ func (s *CalendarService) toCalendarResponse(calendar db.Calendar) *CalendarResp
 		UserID:      calendar.UserID,
 		Name:        calendar.Name,
 		Description: description,
+		Role:        role,
 		CreatedAt:   createdAt,
 		UpdatedAt:   updatedAt,
 	}
@@ -414,3 +414,40 @@ func TestCalendarService_IntegrationPatterns(t *testing.T) {
 		}
 	})
 }
+
+func TestCalendarService_calendarListParams(t *testing.T) {
+	userID := uuid.New()
+	createdAt := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	cursor, err := encodeCursor(calendarCursor{CreatedAt: createdAt, ID: userID})
+	assert.NoError(t, err)
+
+	t.Run("defaults", func(t *testing.T) {
+		params, descending, limit, err := calendarListParams(userID, CalendarListRequest{})
+		assert.NoError(t, err)
+		assert.False(t, descending)
+		assert.Equal(t, DefaultCalendarPageSize, limit)
+		assert.Equal(t, db.ListCalendarsByMemberIDParams{
+			UserID:   userID,
+			RowLimit: int32(DefaultCalendarPageSize + 1),
+		}, params)
+	})
+
+	t.Run("cursor and order", func(t *testing.T) {
+		params, descending, limit, err := calendarListParams(userID, CalendarListRequest{Limit: 1000, Cursor: cursor, Order: SortDescending})
+		assert.NoError(t, err)
+		assert.Equal(t, MaxCalendarPageSize, limit)
+		assert.True(t, descending)
+		assert.Equal(t, sql.NullTime{Time: createdAt, Valid: true}, params.CursorCreatedAt)
+		assert.Equal(t, uuid.NullUUID{UUID: userID, Valid: true}, params.CursorID)
+	})
+
+	t.Run("invalid order", func(t *testing.T) {
+		_, _, _, err := calendarListParams(userID, CalendarListRequest{Order: "newest"})
+		assert.ErrorIs(t, err, ErrInvalidSortOrder)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		_, _, _, err := calendarListParams(userID, CalendarListRequest{Cursor: "garbage"})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strings"
 
+	"days/internal/audit"
 	"days/internal/db"
 
 	"github.com/google/uuid"
@@ -17,52 +18,60 @@ var (
 	ErrColorMeaningNotFound     = errors.New("color meaning not found")
 	ErrInvalidColorHex          = errors.New("invalid color hex format")
 	ErrMeaningEmpty             = errors.New("meaning cannot be empty")
+	ErrMeaningTooLong           = errors.New("meaning cannot exceed 50 characters")
 	ErrColorMeaningExists       = errors.New("color or meaning already exists for this calendar")
+	ErrColorExists              = errors.New("color already exists for this calendar")
+	ErrMeaningExists            = errors.New("meaning already exists for this calendar")
 	ErrUnauthorizedColorMeaning = errors.New("not authorized to access this color meaning")
 )
 
 type ColorMeaningService struct {
 	queries         *db.Queries
 	calendarService *CalendarService
+	audit           *audit.Recorder
 }
 
 type CreateColorMeaningRequest struct {
-	ColorHex string `json:"color_hex"`
-	Meaning  string `json:"meaning"`
+	ColorHex string `json:"color_hex" example:"#00FF00" binding:"required"`
+	Meaning  string `json:"meaning" example:"relaxed" binding:"required"`
 }
 
 type UpdateColorMeaningRequest struct {
-	ColorHex string `json:"color_hex"`
-	Meaning  string `json:"meaning"`
+	ColorHex string `json:"color_hex" example:"#00FF00" binding:"required"`
+	Meaning  string `json:"meaning" example:"relaxed" binding:"required"`
 }
 
 type ColorMeaningResponse struct {
-	ID         uuid.UUID `json:"id"`
-	CalendarID uuid.UUID `json:"calendar_id"`
-	ColorHex   string    `json:"color_hex"`
-	Meaning    string    `json:"meaning"`
-	CreatedAt  string    `json:"created_at"`
+	ID         uuid.UUID `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	CalendarID uuid.UUID `json:"calendar_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	ColorHex   string    `json:"color_hex" example:"#00FF00"`
+	Meaning    string    `json:"meaning" example:"relaxed"`
+	CreatedAt  string    `json:"created_at" example:"2023-01-01T00:00:00Z"`
 }
 
 func NewColorMeaningService(queries *db.Queries, calendarService *CalendarService) *ColorMeaningService {
 	return &ColorMeaningService{
 		queries:         queries,
 		calendarService: calendarService,
+		audit:           audit.NewRecorder(queries),
 	}
 }
 
 // CreateColorMeaning creates a new color meaning for a calendar
 func (s *ColorMeaningService) CreateColorMeaning(ctx context.Context, userID, calendarID uuid.UUID, req CreateColorMeaningRequest) (*ColorMeaningResponse, error) {
+	ctx, span := tracer.Start(ctx, "ColorMeaningService.CreateColorMeaning")
+	defer span.End()
+
 	// Validate input
-	if err := s.validateColorHex(req.ColorHex); err != nil {
+	if err := validateColorHex(req.ColorHex); err != nil {
 		return nil, err
 	}
-	if err := s.validateMeaning(req.Meaning); err != nil {
+	if err := validateMeaning(req.Meaning); err != nil {
 		return nil, err
 	}
 
-	// Check user owns the calendar
-	_, err := s.calendarService.GetCalendarByID(ctx, userID, calendarID)
+	// Check user may edit the calendar
+	_, _, err := s.calendarService.authorize(ctx, userID, calendarID, RoleEditor)
 	if err != nil {
 		return nil, err
 	}
@@ -73,15 +82,15 @@ func (s *ColorMeaningService) CreateColorMeaning(ctx context.Context, userID, ca
 		return nil, fmt.Errorf("failed to check existing color meanings: %w", err)
 	}
 
-	normalizedColorHex := s.normalizeColorHex(req.ColorHex)
+	normalizedColorHex := normalizeColorHex(req.ColorHex)
 	normalizedMeaning := strings.TrimSpace(req.Meaning)
 
 	for _, cm := range existingColorMeanings {
-		if s.normalizeColorHex(cm.ColorHex) == normalizedColorHex {
-			return nil, errors.New("color already exists for this calendar")
+		if normalizeColorHex(cm.ColorHex) == normalizedColorHex {
+			return nil, ErrColorExists
 		}
 		if strings.EqualFold(cm.Meaning, normalizedMeaning) {
-			return nil, errors.New("meaning already exists for this calendar")
+			return nil, ErrMeaningExists
 		}
 	}
 
@@ -94,13 +103,18 @@ func (s *ColorMeaningService) CreateColorMeaning(ctx context.Context, userID, ca
 	if err != nil {
 		return nil, fmt.Errorf("failed to create color meaning: %w", err)
 	}
+	created := s.toColorMeaningResponse(colorMeaning)
+	s.recordChange(ctx, userID, AuditActionColorMeaningCreated, nil, created)
 
-	return s.toColorMeaningResponse(colorMeaning), nil
+	return created, nil
 }
 
 // GetColorMeaningsByCalendarID retrieves all color meanings for a calendar
 func (s *ColorMeaningService) GetColorMeaningsByCalendarID(ctx context.Context, userID, calendarID uuid.UUID) ([]*ColorMeaningResponse, error) {
-	// Check user owns the calendar
+	ctx, span := tracer.Start(ctx, "ColorMeaningService.GetColorMeaningsByCalendarID")
+	defer span.End()
+
+	// Check user is a member of the calendar
 	_, err := s.calendarService.GetCalendarByID(ctx, userID, calendarID)
 	if err != nil {
 		return nil, err
@@ -121,6 +135,9 @@ func (s *ColorMeaningService) GetColorMeaningsByCalendarID(ctx context.Context,
 
 // GetColorMeaningByID retrieves a color meaning by ID and verifies user access
 func (s *ColorMeaningService) GetColorMeaningByID(ctx context.Context, userID, colorMeaningID uuid.UUID) (*ColorMeaningResponse, error) {
+	ctx, span := tracer.Start(ctx, "ColorMeaningService.GetColorMeaningByID")
+	defer span.End()
+
 	colorMeaning, err := s.queries.GetColorMeaningByID(ctx, colorMeaningID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -129,7 +146,7 @@ func (s *ColorMeaningService) GetColorMeaningByID(ctx context.Context, userID, c
 		return nil, fmt.Errorf("failed to get color meaning: %w", err)
 	}
 
-	// Check user owns the calendar
+	// Check user is a member of the calendar
 	_, err = s.calendarService.GetCalendarByID(ctx, userID, colorMeaning.CalendarID)
 	if err != nil {
 		if errors.Is(err, ErrUnauthorizedCalendar) {
@@ -141,18 +158,44 @@ func (s *ColorMeaningService) GetColorMeaningByID(ctx context.Context, userID, c
 	return s.toColorMeaningResponse(colorMeaning), nil
 }
 
+// GetCalendarColorMeaning retrieves a color meaning and verifies it belongs to the given calendar
+func (s *ColorMeaningService) GetCalendarColorMeaning(ctx context.Context, userID, calendarID, colorMeaningID uuid.UUID) (*ColorMeaningResponse, error) {
+	ctx, span := tracer.Start(ctx, "ColorMeaningService.GetCalendarColorMeaning")
+	defer span.End()
+
+	colorMeaning, err := s.GetColorMeaningByID(ctx, userID, colorMeaningID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A color meaning addressed through another calendar is treated as missing
+	if colorMeaning.CalendarID != calendarID {
+		return nil, ErrColorMeaningNotFound
+	}
+
+	return colorMeaning, nil
+}
+
 // UpdateColorMeaning updates a color meaning
-func (s *ColorMeaningService) UpdateColorMeaning(ctx context.Context, userID, colorMeaningID uuid.UUID, req UpdateColorMeaningRequest) (*ColorMeaningResponse, error) {
+func (s *ColorMeaningService) UpdateColorMeaning(ctx context.Context, userID, calendarID, colorMeaningID uuid.UUID, req UpdateColorMeaningRequest) (*ColorMeaningResponse, error) {
+	ctx, span := tracer.Start(ctx, "ColorMeaningService.UpdateColorMeaning")
+	defer span.End()
+
 	// Validate input
-	if err := s.validateColorHex(req.ColorHex); err != nil {
+	if err := validateColorHex(req.ColorHex); err != nil {
 		return nil, err
 	}
-	if err := s.validateMeaning(req.Meaning); err != nil {
+	if err := validateMeaning(req.Meaning); err != nil {
+		return nil, err
+	}
+
+	// Check user may edit the calendar
+	if _, _, err := s.calendarService.authorize(ctx, userID, calendarID, RoleEditor); err != nil {
 		return nil, err
 	}
 
 	// Get existing color meaning and verify access
-	existingColorMeaning, err := s.GetColorMeaningByID(ctx, userID, colorMeaningID)
+	existingColorMeaning, err := s.GetCalendarColorMeaning(ctx, userID, calendarID, colorMeaningID)
 	if err != nil {
 		return nil, err
 	}
@@ -163,16 +206,16 @@ func (s *ColorMeaningService) UpdateColorMeaning(ctx context.Context, userID, co
 		return nil, fmt.Errorf("failed to check existing color meanings: %w", err)
 	}
 
-	normalizedColorHex := s.normalizeColorHex(req.ColorHex)
+	normalizedColorHex := normalizeColorHex(req.ColorHex)
 	normalizedMeaning := strings.TrimSpace(req.Meaning)
 
 	for _, cm := range calendarColorMeanings {
 		if cm.ID != colorMeaningID {
-			if s.normalizeColorHex(cm.ColorHex) == normalizedColorHex {
-				return nil, errors.New("color already exists for this calendar")
+			if normalizeColorHex(cm.ColorHex) == normalizedColorHex {
+				return nil, ErrColorExists
 			}
 			if strings.EqualFold(cm.Meaning, normalizedMeaning) {
-				return nil, errors.New("meaning already exists for this calendar")
+				return nil, ErrMeaningExists
 			}
 		}
 	}
@@ -186,14 +229,25 @@ func (s *ColorMeaningService) UpdateColorMeaning(ctx context.Context, userID, co
 	if err != nil {
 		return nil, fmt.Errorf("failed to update color meaning: %w", err)
 	}
+	updated := s.toColorMeaningResponse(updatedColorMeaning)
+	s.recordChange(ctx, userID, AuditActionColorMeaningUpdated, existingColorMeaning, updated)
 
-	return s.toColorMeaningResponse(updatedColorMeaning), nil
+	return updated, nil
 }
 
 // DeleteColorMeaning deletes a color meaning
-func (s *ColorMeaningService) DeleteColorMeaning(ctx context.Context, userID, colorMeaningID uuid.UUID) error {
+func (s *ColorMeaningService) DeleteColorMeaning(ctx context.Context, userID, calendarID, colorMeaningID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "ColorMeaningService.DeleteColorMeaning")
+	defer span.End()
+
+	// Check user may edit the calendar
+	_, _, err := s.calendarService.authorize(ctx, userID, calendarID, RoleEditor)
+	if err != nil {
+		return err
+	}
+
 	// Get color meaning and verify access
-	_, err := s.GetColorMeaningByID(ctx, userID, colorMeaningID)
+	colorMeaning, err := s.GetCalendarColorMeaning(ctx, userID, calendarID, colorMeaningID)
 	if err != nil {
 		return err
 	}
@@ -203,13 +257,42 @@ func (s *ColorMeaningService) DeleteColorMeaning(ctx context.Context, userID, co
 	if err != nil {
 		return fmt.Errorf("failed to delete color meaning: %w", err)
 	}
+	s.recordChange(ctx, userID, AuditActionColorMeaningDeleted, colorMeaning, nil)
 
 	return nil
 }
 
 // Helper methods
 
-func (s *ColorMeaningService) validateColorHex(colorHex string) error {
+// recordChange records an audit event for a color meaning going from before
+// to after; either is nil when it was created or deleted
+func (s *ColorMeaningService) recordChange(ctx context.Context, userID uuid.UUID, action string, before, after *ColorMeaningResponse) {
+	colorMeaning := after
+	if colorMeaning == nil {
+		colorMeaning = before
+	}
+	s.audit.Record(ctx, audit.Event{
+		UserID:       userID,
+		Action:       action,
+		ResourceType: AuditResourceColorMeaning,
+		ResourceID:   colorMeaning.ID,
+		Metadata:     map[string]string{"calendar_id": colorMeaning.CalendarID.String()},
+		Changes:      audit.Diff(colorMeaningSnapshot(before), colorMeaningSnapshot(after)),
+	})
+}
+
+// colorMeaningSnapshot returns the fields of a color meaning its audit events diff
+func colorMeaningSnapshot(cm *ColorMeaningResponse) map[string]any {
+	if cm == nil {
+		return nil
+	}
+	return map[string]any{
+		"color_hex": cm.ColorHex,
+		"meaning":   cm.Meaning,
+	}
+}
+
+func validateColorHex(colorHex string) error {
 	if colorHex == "" {
 		return ErrInvalidColorHex
 	}
@@ -226,18 +309,18 @@ func (s *ColorMeaningService) validateColorHex(colorHex string) error {
 	return nil
 }
 
-func (s *ColorMeaningService) validateMeaning(meaning string) error {
+func validateMeaning(meaning string) error {
 	meaning = strings.TrimSpace(meaning)
 	if meaning == "" {
 		return ErrMeaningEmpty
 	}
 	if len(meaning) > 50 {
-		return errors.New("meaning cannot exceed 50 characters")
+		return ErrMeaningTooLong
 	}
 	return nil
 }
 
-func (s *ColorMeaningService) normalizeColorHex(colorHex string) string {
+func normalizeColorHex(colorHex string) string {
 	// Remove # if present and convert to uppercase
 	colorHex = strings.TrimPrefix(colorHex, "#")
 	colorHex = strings.ToUpper(colorHex)
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -214,6 +215,17 @@ func TestColorMeaningService_ErrorConstants(t *testing.T) {
 	assert.Contains(t, ErrColorMeaningNotFound.Error(), "color meaning not found")
 	assert.Contains(t, ErrColorMeaningExists.Error(), "color or meaning already exists")
 	assert.Contains(t, ErrUnauthorizedColorMeaning.Error(), "not authorized")
+
+	// Duplicate checks report which field collided
+	assert.Contains(t, ErrColorExists.Error(), "color already exists")
+	assert.Contains(t, ErrMeaningExists.Error(), "meaning already exists")
+	assert.NotEqual(t, ErrColorExists, ErrMeaningExists)
+}
+
+func TestValidateMeaning(t *testing.T) {
+	assert.NoError(t, validateMeaning("relaxed"))
+	assert.Equal(t, ErrMeaningEmpty, validateMeaning("   "))
+	assert.Equal(t, ErrMeaningTooLong, validateMeaning(strings.Repeat("a", 51)))
 }
 
 // Test request validation structures
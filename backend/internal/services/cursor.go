@@ -0,0 +1,64 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// Orders paginated lists can be sorted in
+const (
+	SortAscending  = "asc"
+	SortDescending = "desc"
+)
+
+var (
+	ErrInvalidCursor    = errors.New("invalid cursor")
+	ErrInvalidSortOrder = errors.New("order must be asc or desc")
+)
+
+// pageSize returns limit capped at max, or def when limit is not positive
+func pageSize(limit, def, max int) int {
+	if limit <= 0 {
+		return def
+	}
+	return min(limit, max)
+}
+
+// isDescending reports whether order, or def when order is empty, sorts
+// descending
+func isDescending(order, def string) (bool, error) {
+	if order == "" {
+		order = def
+	}
+	switch order {
+	case SortAscending:
+		return false, nil
+	case SortDescending:
+		return true, nil
+	default:
+		return false, ErrInvalidSortOrder
+	}
+}
+
+// encodeCursor returns the opaque token clients pass back to get the next
+// page, holding the position of the last item returned
+func encodeCursor(position any) (string, error) {
+	data, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor reads a token from encodeCursor into position
+func decodeCursor(cursor string, position any) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, position); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"days/internal/audit"
 	"days/internal/db"
 
 	"github.com/google/uuid"
@@ -18,37 +19,76 @@ var (
 	ErrInvalidDate          = errors.New("invalid date format")
 	ErrDayEntryExists       = errors.New("day entry already exists for this date")
 	ErrUnauthorizedDayEntry = errors.New("not authorized to access this day entry")
+	ErrInvalidDateRange     = errors.New("end date cannot be before start date")
+	ErrColorMeaningMismatch = errors.New("color meaning does not belong to this calendar")
 )
 
 type DayEntryService struct {
 	queries             *db.Queries
 	calendarService     *CalendarService
 	colorMeaningService *ColorMeaningService
+	audit               *audit.Recorder
 }
 
 type CreateDayEntryRequest struct {
-	Date           string    `json:"date"` // YYYY-MM-DD format
-	ColorMeaningID uuid.UUID `json:"color_meaning_id"`
-	Notes          *string   `json:"notes,omitempty"`
+	Date           string    `json:"date" example:"2024-01-15" binding:"required"` // YYYY-MM-DD format
+	ColorMeaningID uuid.UUID `json:"color_meaning_id" example:"123e4567-e89b-12d3-a456-426614174000" binding:"required"`
+	Notes          *string   `json:"notes,omitempty" example:"Went for a long walk"`
 }
 
 type UpdateDayEntryRequest struct {
-	ColorMeaningID uuid.UUID `json:"color_meaning_id"`
-	Notes          *string   `json:"notes,omitempty"`
+	ColorMeaningID uuid.UUID `json:"color_meaning_id" example:"123e4567-e89b-12d3-a456-426614174000" binding:"required"`
+	Notes          *string   `json:"notes,omitempty" example:"Went for a long walk"`
 }
 
 type DayEntryResponse struct {
-	ID             uuid.UUID `json:"id"`
-	CalendarID     uuid.UUID `json:"calendar_id"`
-	Date           string    `json:"date"`
-	ColorMeaningID uuid.UUID `json:"color_meaning_id"`
-	ColorHex       string    `json:"color_hex"`
-	Meaning        string    `json:"meaning"`
-	Notes          *string   `json:"notes,omitempty"`
-	CreatedAt      string    `json:"created_at"`
-	UpdatedAt      string    `json:"updated_at"`
+	ID             uuid.UUID `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	CalendarID     uuid.UUID `json:"calendar_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Date           string    `json:"date" example:"2024-01-15"`
+	ColorMeaningID uuid.UUID `json:"color_meaning_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	ColorHex       string    `json:"color_hex" example:"#00FF00"`
+	Meaning        string    `json:"meaning" example:"relaxed"`
+	Notes          *string   `json:"notes,omitempty" example:"Went for a long walk"`
+	CreatedAt      string    `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	UpdatedAt      string    `json:"updated_at" example:"2023-01-01T00:00:00Z"`
 }
 
+const (
+	// DefaultDayEntryPageSize is how many entries a page holds unless asked otherwise
+	DefaultDayEntryPageSize = 100
+	// MaxDayEntryPageSize caps the page size clients can ask for
+	MaxDayEntryPageSize = 1000
+)
+
+// DayEntryListRequest selects a page of a calendar's entries. Order is
+// "desc" (the default) or "asc" by date, and empty filters match every entry.
+type DayEntryListRequest struct {
+	Limit           int
+	Cursor          string
+	Order           string
+	StartDate       string // YYYY-MM-DD format, inclusive
+	EndDate         string // YYYY-MM-DD format, inclusive
+	ColorMeaningIDs []uuid.UUID
+	HasNotes        *bool
+	NotesQuery      string // matched case-insensitively anywhere in the notes
+}
+
+// DayEntryPage is a page of day entries. NextCursor is empty on the last page.
+type DayEntryPage struct {
+	Entries    []*DayEntryResponse `json:"entries"`
+	NextCursor string              `json:"next_cursor,omitempty" example:"eyJkIjoiMjAyNC0wMS0xNSIsImlkIjoiMTIzIn0"`
+}
+
+// dayEntryCursor is the position of the last entry on a page
+type dayEntryCursor struct {
+	Date string    `json:"d"`
+	ID   uuid.UUID `json:"id"`
+}
+
+// likeEscaper escapes the characters LIKE patterns treat specially, so that
+// notes queries match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 type DateRangeRequest struct {
 	StartDate string `json:"start_date"` // YYYY-MM-DD format
 	EndDate   string `json:"end_date"`   // YYYY-MM-DD format
@@ -59,19 +99,23 @@ func NewDayEntryService(queries *db.Queries, calendarService *CalendarService, c
 		queries:             queries,
 		calendarService:     calendarService,
 		colorMeaningService: colorMeaningService,
+		audit:               audit.NewRecorder(queries),
 	}
 }
 
 // CreateDayEntry creates a new day entry for a calendar
 func (s *DayEntryService) CreateDayEntry(ctx context.Context, userID, calendarID uuid.UUID, req CreateDayEntryRequest) (*DayEntryResponse, error) {
+	ctx, span := tracer.Start(ctx, "DayEntryService.CreateDayEntry")
+	defer span.End()
+
 	// Validate date
 	date, err := s.parseDate(req.Date)
 	if err != nil {
 		return nil, err
 	}
 
-	// Check user owns the calendar
-	_, err = s.calendarService.GetCalendarByID(ctx, userID, calendarID)
+	// Check user may edit the calendar
+	_, _, err = s.calendarService.authorize(ctx, userID, calendarID, RoleEditor)
 	if err != nil {
 		return nil, err
 	}
@@ -82,7 +126,7 @@ func (s *DayEntryService) CreateDayEntry(ctx context.Context, userID, calendarID
 		return nil, fmt.Errorf("invalid color meaning: %w", err)
 	}
 	if colorMeaning.CalendarID != calendarID {
-		return nil, errors.New("color meaning does not belong to this calendar")
+		return nil, ErrColorMeaningMismatch
 	}
 
 	// Check if day entry already exists for this date
@@ -115,32 +159,166 @@ func (s *DayEntryService) CreateDayEntry(ctx context.Context, userID, calendarID
 	}
 
 	// Get the full day entry with color meaning details
-	return s.getDayEntryWithColorMeaning(ctx, dayEntry.CalendarID, dayEntry.Date)
+	created, err := s.getDayEntryWithColorMeaning(ctx, dayEntry.CalendarID, dayEntry.Date)
+	if err != nil {
+		return nil, err
+	}
+	s.recordChange(ctx, userID, AuditActionDayEntryCreated, nil, created)
+
+	return created, nil
 }
 
-// GetDayEntriesByCalendarID retrieves all day entries for a calendar
-func (s *DayEntryService) GetDayEntriesByCalendarID(ctx context.Context, userID, calendarID uuid.UUID) ([]*DayEntryResponse, error) {
-	// Check user owns the calendar
-	_, err := s.calendarService.GetCalendarByID(ctx, userID, calendarID)
+// GetDayEntriesByCalendarID returns a page of a calendar's day entries
+// matching the request's filters, newest first unless asked otherwise,
+// starting after the cursor when one is given
+func (s *DayEntryService) GetDayEntriesByCalendarID(ctx context.Context, userID, calendarID uuid.UUID, req DayEntryListRequest) (*DayEntryPage, error) {
+	ctx, span := tracer.Start(ctx, "DayEntryService.GetDayEntriesByCalendarID")
+	defer span.End()
+
+	params, descending, limit, err := s.dayEntryListParams(calendarID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check user is a member of the calendar
+	_, err = s.calendarService.GetCalendarByID(ctx, userID, calendarID)
 	if err != nil {
 		return nil, err
 	}
 
-	dayEntries, err := s.queries.GetDayEntriesByCalendarID(ctx, calendarID)
+	dayEntries, err := s.listDayEntries(ctx, params, descending)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get day entries: %w", err)
 	}
 
-	var responses []*DayEntryResponse
+	page := &DayEntryPage{Entries: make([]*DayEntryResponse, 0, min(len(dayEntries), limit))}
+	if len(dayEntries) > limit {
+		dayEntries = dayEntries[:limit]
+		last := dayEntries[limit-1]
+		if page.NextCursor, err = encodeCursor(dayEntryCursor{Date: last.Date.Format("2006-01-02"), ID: last.ID}); err != nil {
+			return nil, fmt.Errorf("failed to encode cursor: %w", err)
+		}
+	}
 	for _, de := range dayEntries {
-		responses = append(responses, s.toDayEntryResponse(de))
+		page.Entries = append(page.Entries, s.toDayEntryResponse(de))
 	}
+	return page, nil
+}
 
-	return responses, nil
+// GetAllDayEntriesByCalendarID returns every day entry of a calendar
+// matching the request's filters, in the request's order. The request's
+// limit and cursor are ignored.
+func (s *DayEntryService) GetAllDayEntriesByCalendarID(ctx context.Context, userID, calendarID uuid.UUID, req DayEntryListRequest) ([]*DayEntryResponse, error) {
+	ctx, span := tracer.Start(ctx, "DayEntryService.GetAllDayEntriesByCalendarID")
+	defer span.End()
+
+	req.Cursor = ""
+	params, descending, _, err := s.dayEntryListParams(calendarID, req)
+	if err != nil {
+		return nil, err
+	}
+	params.RowLimit = MaxDayEntryPageSize
+
+	// Check user is a member of the calendar
+	_, err = s.calendarService.GetCalendarByID(ctx, userID, calendarID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reads the entries a page at a time, each page after the last entry of the one before
+	entries := []*DayEntryResponse{}
+	for {
+		dayEntries, err := s.listDayEntries(ctx, params, descending)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get day entries: %w", err)
+		}
+		for _, de := range dayEntries {
+			entries = append(entries, s.toDayEntryResponse(de))
+		}
+		if len(dayEntries) < int(params.RowLimit) {
+			return entries, nil
+		}
+		last := dayEntries[len(dayEntries)-1]
+		params.CursorDate = sql.NullTime{Time: last.Date, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: last.ID, Valid: true}
+	}
+}
+
+// listDayEntries runs the page query for the sort order. Each order has a
+// query of its own so that both read the index rather than sort.
+func (s *DayEntryService) listDayEntries(ctx context.Context, params db.ListDayEntriesByCalendarParams, descending bool) ([]db.ListDayEntriesByCalendarRow, error) {
+	if !descending {
+		return s.queries.ListDayEntriesByCalendar(ctx, params)
+	}
+
+	rows, err := s.queries.ListDayEntriesByCalendarDesc(ctx, db.ListDayEntriesByCalendarDescParams(params))
+	if err != nil {
+		return nil, err
+	}
+	dayEntries := make([]db.ListDayEntriesByCalendarRow, 0, len(rows))
+	for _, row := range rows {
+		dayEntries = append(dayEntries, db.ListDayEntriesByCalendarRow(row))
+	}
+	return dayEntries, nil
+}
+
+// dayEntryListParams turns a list request into query parameters, whether
+// they sort descending and the number of entries the page holds
+func (s *DayEntryService) dayEntryListParams(calendarID uuid.UUID, req DayEntryListRequest) (db.ListDayEntriesByCalendarParams, bool, int, error) {
+	limit := pageSize(req.Limit, DefaultDayEntryPageSize, MaxDayEntryPageSize)
+	descending, err := isDescending(req.Order, SortDescending)
+	if err != nil {
+		return db.ListDayEntriesByCalendarParams{}, false, 0, err
+	}
+
+	params := db.ListDayEntriesByCalendarParams{
+		CalendarID:      calendarID,
+		ColorMeaningIds: req.ColorMeaningIDs,
+		RowLimit:        int32(limit + 1), // one more tells whether there is a next page
+	}
+	if req.StartDate != "" {
+		startDate, err := s.parseDate(req.StartDate)
+		if err != nil {
+			return db.ListDayEntriesByCalendarParams{}, false, 0, fmt.Errorf("invalid start date: %w", err)
+		}
+		params.StartDate = sql.NullTime{Time: startDate, Valid: true}
+	}
+	if req.EndDate != "" {
+		endDate, err := s.parseDate(req.EndDate)
+		if err != nil {
+			return db.ListDayEntriesByCalendarParams{}, false, 0, fmt.Errorf("invalid end date: %w", err)
+		}
+		params.EndDate = sql.NullTime{Time: endDate, Valid: true}
+	}
+	if params.StartDate.Valid && params.EndDate.Valid && params.EndDate.Time.Before(params.StartDate.Time) {
+		return db.ListDayEntriesByCalendarParams{}, false, 0, ErrInvalidDateRange
+	}
+	if req.HasNotes != nil {
+		params.HasNotes = sql.NullBool{Bool: *req.HasNotes, Valid: true}
+	}
+	if req.NotesQuery != "" {
+		params.NotesPattern = sql.NullString{String: "%" + likeEscaper.Replace(req.NotesQuery) + "%", Valid: true}
+	}
+	if req.Cursor != "" {
+		var position dayEntryCursor
+		if err := decodeCursor(req.Cursor, &position); err != nil {
+			return db.ListDayEntriesByCalendarParams{}, false, 0, err
+		}
+		date, err := time.Parse("2006-01-02", position.Date)
+		if err != nil {
+			return db.ListDayEntriesByCalendarParams{}, false, 0, ErrInvalidCursor
+		}
+		params.CursorDate = sql.NullTime{Time: date, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: position.ID, Valid: true}
+	}
+	return params, descending, limit, nil
 }
 
-// GetDayEntriesByDateRange retrieves all day entries for a user within a date range
+// GetDayEntriesByDateRange retrieves the day entries of every calendar a user is a member of within a date range
 func (s *DayEntryService) GetDayEntriesByDateRange(ctx context.Context, userID uuid.UUID, req DateRangeRequest) ([]*DayEntryResponse, error) {
+	ctx, span := tracer.Start(ctx, "DayEntryService.GetDayEntriesByDateRange")
+	defer span.End()
+
 	startDate, err := s.parseDate(req.StartDate)
 	if err != nil {
 		return nil, fmt.Errorf("invalid start date: %w", err)
@@ -152,7 +330,7 @@ func (s *DayEntryService) GetDayEntriesByDateRange(ctx context.Context, userID u
 	}
 
 	if endDate.Before(startDate) {
-		return nil, errors.New("end date cannot be before start date")
+		return nil, ErrInvalidDateRange
 	}
 
 	dayEntries, err := s.queries.GetDayEntriesByDateRange(ctx, db.GetDayEntriesByDateRangeParams{
@@ -194,13 +372,16 @@ func (s *DayEntryService) GetDayEntriesByDateRange(ctx context.Context, userID u
 
 // GetDayEntryByCalendarAndDate retrieves a specific day entry
 func (s *DayEntryService) GetDayEntryByCalendarAndDate(ctx context.Context, userID, calendarID uuid.UUID, dateStr string) (*DayEntryResponse, error) {
+	ctx, span := tracer.Start(ctx, "DayEntryService.GetDayEntryByCalendarAndDate")
+	defer span.End()
+
 	// Validate date
 	date, err := s.parseDate(dateStr)
 	if err != nil {
 		return nil, err
 	}
 
-	// Check user owns the calendar
+	// Check user is a member of the calendar
 	_, err = s.calendarService.GetCalendarByID(ctx, userID, calendarID)
 	if err != nil {
 		return nil, err
@@ -211,20 +392,23 @@ func (s *DayEntryService) GetDayEntryByCalendarAndDate(ctx context.Context, user
 
 // UpdateDayEntry updates an existing day entry
 func (s *DayEntryService) UpdateDayEntry(ctx context.Context, userID, calendarID uuid.UUID, dateStr string, req UpdateDayEntryRequest) (*DayEntryResponse, error) {
+	ctx, span := tracer.Start(ctx, "DayEntryService.UpdateDayEntry")
+	defer span.End()
+
 	// Validate date
 	date, err := s.parseDate(dateStr)
 	if err != nil {
 		return nil, err
 	}
 
-	// Check user owns the calendar
-	_, err = s.calendarService.GetCalendarByID(ctx, userID, calendarID)
+	// Check user may edit the calendar
+	_, _, err = s.calendarService.authorize(ctx, userID, calendarID, RoleEditor)
 	if err != nil {
 		return nil, err
 	}
 
 	// Check day entry exists
-	_, err = s.getDayEntryWithColorMeaning(ctx, calendarID, date)
+	existing, err := s.getDayEntryWithColorMeaning(ctx, calendarID, date)
 	if err != nil {
 		return nil, err
 	}
@@ -235,7 +419,7 @@ func (s *DayEntryService) UpdateDayEntry(ctx context.Context, userID, calendarID
 		return nil, fmt.Errorf("invalid color meaning: %w", err)
 	}
 	if colorMeaning.CalendarID != calendarID {
-		return nil, errors.New("color meaning does not belong to this calendar")
+		return nil, ErrColorMeaningMismatch
 	}
 
 	// Prepare notes
@@ -256,25 +440,34 @@ func (s *DayEntryService) UpdateDayEntry(ctx context.Context, userID, calendarID
 	}
 
 	// Return updated day entry
-	return s.getDayEntryWithColorMeaning(ctx, calendarID, date)
+	updated, err := s.getDayEntryWithColorMeaning(ctx, calendarID, date)
+	if err != nil {
+		return nil, err
+	}
+	s.recordChange(ctx, userID, AuditActionDayEntryUpdated, existing, updated)
+
+	return updated, nil
 }
 
 // DeleteDayEntry deletes a day entry
 func (s *DayEntryService) DeleteDayEntry(ctx context.Context, userID, calendarID uuid.UUID, dateStr string) error {
+	ctx, span := tracer.Start(ctx, "DayEntryService.DeleteDayEntry")
+	defer span.End()
+
 	// Validate date
 	date, err := s.parseDate(dateStr)
 	if err != nil {
 		return err
 	}
 
-	// Check user owns the calendar
-	_, err = s.calendarService.GetCalendarByID(ctx, userID, calendarID)
+	// Check user may edit the calendar
+	_, _, err = s.calendarService.authorize(ctx, userID, calendarID, RoleEditor)
 	if err != nil {
 		return err
 	}
 
 	// Check day entry exists
-	_, err = s.getDayEntryWithColorMeaning(ctx, calendarID, date)
+	existing, err := s.getDayEntryWithColorMeaning(ctx, calendarID, date)
 	if err != nil {
 		return err
 	}
@@ -287,6 +480,7 @@ func (s *DayEntryService) DeleteDayEntry(ctx context.Context, userID, calendarID
 	if err != nil {
 		return fmt.Errorf("failed to delete day entry: %w", err)
 	}
+	s.recordChange(ctx, userID, AuditActionDayEntryDeleted, existing, nil)
 
 	return nil
 }
@@ -306,6 +500,42 @@ func (s *DayEntryService) parseDate(dateStr string) (time.Time, error) {
 	return date, nil
 }
 
+// recordChange records an audit event for a day entry going from before to
+// after; either is nil when it was created or deleted
+func (s *DayEntryService) recordChange(ctx context.Context, userID uuid.UUID, action string, before, after *DayEntryResponse) {
+	dayEntry := after
+	if dayEntry == nil {
+		dayEntry = before
+	}
+	s.audit.Record(ctx, audit.Event{
+		UserID:       userID,
+		Action:       action,
+		ResourceType: AuditResourceDayEntry,
+		ResourceID:   dayEntry.ID,
+		Metadata: map[string]string{
+			"calendar_id": dayEntry.CalendarID.String(),
+			"date":        dayEntry.Date,
+		},
+		Changes: audit.Diff(dayEntrySnapshot(before), dayEntrySnapshot(after)),
+	})
+}
+
+// dayEntrySnapshot returns the fields of a day entry its audit events diff
+func dayEntrySnapshot(entry *DayEntryResponse) map[string]any {
+	if entry == nil {
+		return nil
+	}
+	snapshot := map[string]any{
+		"date":             entry.Date,
+		"color_meaning_id": entry.ColorMeaningID.String(),
+		"meaning":          entry.Meaning,
+	}
+	if entry.Notes != nil {
+		snapshot["notes"] = *entry.Notes
+	}
+	return snapshot
+}
+
 func (s *DayEntryService) getDayEntryWithColorMeaning(ctx context.Context, calendarID uuid.UUID, date time.Time) (*DayEntryResponse, error) {
 	dayEntry, err := s.queries.GetDayEntryByCalendarAndDate(ctx, db.GetDayEntryByCalendarAndDateParams{
 		CalendarID: calendarID,
@@ -346,6 +576,9 @@ func (s *DayEntryService) toDayEntryResponse(de interface{}) *DayEntryResponse {
 
 		return response
 
+	case db.ListDayEntriesByCalendarRow:
+		return s.toDayEntryResponse(db.GetDayEntriesByCalendarIDRow(entry))
+
 	case db.GetDayEntryByCalendarAndDateRow:
 		response := &DayEntryResponse{
 			ID:             entry.ID,
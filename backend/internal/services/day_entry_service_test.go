@@ -1,6 +1,7 @@
 package services
 
 import (
+	"database/sql"
 	"testing"
 
 	"github.com/google/uuid"
@@ -243,3 +244,67 @@ func BenchmarkDayEntryService_parseDate_Empty(b *testing.B) {
 func dayEntryStringPtr(s string) *string {
 	return &s
 }
+
+func TestDayEntryService_dayEntryListParams(t *testing.T) {
+	service := &DayEntryService{}
+	calendarID := uuid.New()
+	hasNotes := false
+
+	cursor, err := encodeCursor(dayEntryCursor{Date: "2024-01-15", ID: calendarID})
+	require.NoError(t, err)
+
+	t.Run("defaults", func(t *testing.T) {
+		params, descending, limit, err := service.dayEntryListParams(calendarID, DayEntryListRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, DefaultDayEntryPageSize, limit)
+		assert.Equal(t, int32(DefaultDayEntryPageSize+1), params.RowLimit)
+		assert.True(t, descending)
+		assert.False(t, params.StartDate.Valid)
+		assert.False(t, params.EndDate.Valid)
+		assert.False(t, params.HasNotes.Valid)
+		assert.False(t, params.NotesPattern.Valid)
+		assert.False(t, params.CursorDate.Valid)
+	})
+
+	t.Run("filters and cursor", func(t *testing.T) {
+		colorMeaningIDs := []uuid.UUID{uuid.New()}
+		params, descending, limit, err := service.dayEntryListParams(calendarID, DayEntryListRequest{
+			Limit:           5000,
+			Cursor:          cursor,
+			Order:           SortAscending,
+			StartDate:       "2024-01-01",
+			EndDate:         "2024-01-31",
+			ColorMeaningIDs: colorMeaningIDs,
+			HasNotes:        &hasNotes,
+			NotesQuery:      `50%_off\`,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, MaxDayEntryPageSize, limit)
+		assert.False(t, descending)
+		assert.Equal(t, "2024-01-01", params.StartDate.Time.Format("2006-01-02"))
+		assert.Equal(t, "2024-01-31", params.EndDate.Time.Format("2006-01-02"))
+		assert.Equal(t, colorMeaningIDs, params.ColorMeaningIds)
+		assert.Equal(t, sql.NullBool{Bool: false, Valid: true}, params.HasNotes)
+		assert.Equal(t, `%50\%\_off\\%`, params.NotesPattern.String)
+		assert.Equal(t, "2024-01-15", params.CursorDate.Time.Format("2006-01-02"))
+		assert.Equal(t, calendarID, params.CursorID.UUID)
+	})
+
+	errorTests := []struct {
+		name          string
+		req           DayEntryListRequest
+		expectedError error
+	}{
+		{name: "invalid order", req: DayEntryListRequest{Order: "sideways"}, expectedError: ErrInvalidSortOrder},
+		{name: "invalid cursor", req: DayEntryListRequest{Cursor: "garbage"}, expectedError: ErrInvalidCursor},
+		{name: "invalid start date", req: DayEntryListRequest{StartDate: "01/01/2024"}, expectedError: ErrInvalidDate},
+		{name: "end before start", req: DayEntryListRequest{StartDate: "2024-02-01", EndDate: "2024-01-01"}, expectedError: ErrInvalidDateRange},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := service.dayEntryListParams(calendarID, tt.req)
+			assert.ErrorIs(t, err, tt.expectedError)
+		})
+	}
+}
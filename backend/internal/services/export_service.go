@@ -0,0 +1,318 @@
+package services
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"days/internal/db"
+
+	"github.com/google/uuid"
+)
+
+// ExportSchemaVersion is bumped whenever the layout of exported data changes
+const ExportSchemaVersion = 1
+
+const (
+	ExportFormatJSON = "json"
+	ExportFormatCSV  = "csv"
+)
+
+// Files of a CSV export archive
+const (
+	ExportManifestFile      = "manifest.json"
+	ExportCalendarsFile     = "calendars.csv"
+	ExportColorMeaningsFile = "color_meanings.csv"
+	ExportDayEntriesFile    = "day_entries.csv"
+)
+
+var ErrInvalidExportFormat = errors.New("format must be json or csv")
+
+// Column headers of the CSV export files
+var (
+	exportCalendarColumns     = []string{"id", "name", "description", "created_at", "updated_at"}
+	exportColorMeaningColumns = []string{"id", "calendar_id", "color_hex", "meaning", "created_at"}
+	exportDayEntryColumns     = []string{"id", "calendar_id", "date", "color_meaning_id", "notes", "created_at", "updated_at"}
+)
+
+type ExportService struct {
+	queries ExportRepository
+	now     func() time.Time
+}
+
+// ExportDocument is the JSON export format. Calendars carry their own legend and entries.
+type ExportDocument struct {
+	SchemaVersion int              `json:"schema_version" example:"1"`
+	ExportedAt    string           `json:"exported_at" example:"2024-01-31T12:00:00Z"`
+	Calendars     []ExportCalendar `json:"calendars"`
+}
+
+type ExportCalendar struct {
+	ID            uuid.UUID            `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Name          string               `json:"name" example:"Mood"`
+	Description   *string              `json:"description,omitempty" example:"How I felt each day"`
+	CreatedAt     string               `json:"created_at,omitempty" example:"2023-01-01T00:00:00Z"`
+	UpdatedAt     string               `json:"updated_at,omitempty" example:"2023-01-01T00:00:00Z"`
+	ColorMeanings []ExportColorMeaning `json:"color_meanings"`
+	DayEntries    []ExportDayEntry     `json:"day_entries"`
+}
+
+type ExportColorMeaning struct {
+	ID        uuid.UUID `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	ColorHex  string    `json:"color_hex" example:"#00FF00"`
+	Meaning   string    `json:"meaning" example:"relaxed"`
+	CreatedAt string    `json:"created_at,omitempty" example:"2023-01-01T00:00:00Z"`
+}
+
+type ExportDayEntry struct {
+	ID             uuid.UUID `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Date           string    `json:"date" example:"2024-01-15"`
+	ColorMeaningID uuid.UUID `json:"color_meaning_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Notes          *string   `json:"notes,omitempty" example:"Went for a long walk"`
+	CreatedAt      string    `json:"created_at,omitempty" example:"2023-01-01T00:00:00Z"`
+	UpdatedAt      string    `json:"updated_at,omitempty" example:"2023-01-01T00:00:00Z"`
+}
+
+// ExportManifest describes a CSV export archive
+type ExportManifest struct {
+	SchemaVersion int      `json:"schema_version"`
+	ExportedAt    string   `json:"exported_at"`
+	Files         []string `json:"files"`
+}
+
+func NewExportService(queries ExportRepository) *ExportService {
+	return &ExportService{
+		queries: queries,
+		now:     time.Now,
+	}
+}
+
+// Export writes all calendars, color meanings and day entries of a user to w.
+// Data is read and written one calendar at a time so that the whole data set
+// is never held in memory. Nothing is written to w when the first read fails.
+func (s *ExportService) Export(ctx context.Context, userID uuid.UUID, format string, w io.Writer) error {
+	ctx, span := tracer.Start(ctx, "ExportService.Export")
+	defer span.End()
+
+	if format != ExportFormatJSON && format != ExportFormatCSV {
+		return ErrInvalidExportFormat
+	}
+
+	calendars, err := s.queries.GetCalendarsByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get calendars: %w", err)
+	}
+
+	exportedAt := formatExportTime(sql.NullTime{Time: s.now(), Valid: true})
+
+	if format == ExportFormatCSV {
+		return s.exportCSV(ctx, calendars, exportedAt, w)
+	}
+	return s.exportJSON(ctx, calendars, exportedAt, w)
+}
+
+// Helper methods
+
+func (s *ExportService) exportJSON(ctx context.Context, calendars []db.Calendar, exportedAt string, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	// The document is written by hand around the calendars array so that each
+	// calendar can be encoded and flushed on its own
+	fmt.Fprintf(bw, `{"schema_version":%d,"exported_at":%q,"calendars":[`, ExportSchemaVersion, exportedAt)
+
+	for i, calendar := range calendars {
+		exported, err := s.exportCalendar(ctx, calendar)
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(exported)
+		if err != nil {
+			return fmt.Errorf("failed to encode calendar: %w", err)
+		}
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		if _, err := bw.Write(data); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+	}
+
+	bw.WriteString("]}\n")
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return nil
+}
+
+func (s *ExportService) exportCalendar(ctx context.Context, calendar db.Calendar) (*ExportCalendar, error) {
+	exported := &ExportCalendar{
+		ID:            calendar.ID,
+		Name:          calendar.Name,
+		CreatedAt:     formatExportTime(calendar.CreatedAt),
+		UpdatedAt:     formatExportTime(calendar.UpdatedAt),
+		ColorMeanings: []ExportColorMeaning{},
+		DayEntries:    []ExportDayEntry{},
+	}
+	if calendar.Description.Valid {
+		exported.Description = &calendar.Description.String
+	}
+
+	colorMeanings, err := s.queries.GetColorMeaningsByCalendarID(ctx, calendar.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get color meanings: %w", err)
+	}
+	for _, cm := range colorMeanings {
+		exported.ColorMeanings = append(exported.ColorMeanings, ExportColorMeaning{
+			ID:        cm.ID,
+			ColorHex:  cm.ColorHex,
+			Meaning:   cm.Meaning,
+			CreatedAt: formatExportTime(cm.CreatedAt),
+		})
+	}
+
+	dayEntries, err := s.queries.GetDayEntriesByCalendarID(ctx, calendar.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get day entries: %w", err)
+	}
+	for _, de := range dayEntries {
+		entry := ExportDayEntry{
+			ID:             de.ID,
+			Date:           de.Date.Format("2006-01-02"),
+			ColorMeaningID: de.ColorMeaningID,
+			CreatedAt:      formatExportTime(de.CreatedAt),
+			UpdatedAt:      formatExportTime(de.UpdatedAt),
+		}
+		if de.Notes.Valid {
+			entry.Notes = &de.Notes.String
+		}
+		exported.DayEntries = append(exported.DayEntries, entry)
+	}
+
+	return exported, nil
+}
+
+// exportCSV writes a zip archive with one CSV file per table. Zip entries are
+// written one after the other, so each table is a separate pass over the
+// calendars.
+func (s *ExportService) exportCSV(ctx context.Context, calendars []db.Calendar, exportedAt string, w io.Writer) error {
+	archive := zip.NewWriter(w)
+
+	manifest, err := archive.Create(ExportManifestFile)
+	if err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	err = json.NewEncoder(manifest).Encode(ExportManifest{
+		SchemaVersion: ExportSchemaVersion,
+		ExportedAt:    exportedAt,
+		Files:         []string{ExportCalendarsFile, ExportColorMeaningsFile, ExportDayEntriesFile},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	err = writeCSVFile(archive, ExportCalendarsFile, exportCalendarColumns, func(cw *csv.Writer) error {
+		for _, calendar := range calendars {
+			description := ""
+			if calendar.Description.Valid {
+				description = calendar.Description.String
+			}
+			cw.Write([]string{
+				calendar.ID.String(),
+				calendar.Name,
+				description,
+				formatExportTime(calendar.CreatedAt),
+				formatExportTime(calendar.UpdatedAt),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeCSVFile(archive, ExportColorMeaningsFile, exportColorMeaningColumns, func(cw *csv.Writer) error {
+		for _, calendar := range calendars {
+			colorMeanings, err := s.queries.GetColorMeaningsByCalendarID(ctx, calendar.ID)
+			if err != nil {
+				return fmt.Errorf("failed to get color meanings: %w", err)
+			}
+			for _, cm := range colorMeanings {
+				cw.Write([]string{
+					cm.ID.String(),
+					cm.CalendarID.String(),
+					cm.ColorHex,
+					cm.Meaning,
+					formatExportTime(cm.CreatedAt),
+				})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeCSVFile(archive, ExportDayEntriesFile, exportDayEntryColumns, func(cw *csv.Writer) error {
+		for _, calendar := range calendars {
+			dayEntries, err := s.queries.GetDayEntriesByCalendarID(ctx, calendar.ID)
+			if err != nil {
+				return fmt.Errorf("failed to get day entries: %w", err)
+			}
+			for _, de := range dayEntries {
+				cw.Write([]string{
+					de.ID.String(),
+					de.CalendarID.String(),
+					de.Date.Format("2006-01-02"),
+					de.ColorMeaningID.String(),
+					de.Notes.String,
+					formatExportTime(de.CreatedAt),
+					formatExportTime(de.UpdatedAt),
+				})
+			}
+			// Flush per calendar so rows leave memory as they are produced
+			cw.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return nil
+}
+
+func writeCSVFile(archive *zip.Writer, name string, columns []string, writeRows func(cw *csv.Writer) error) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	cw := csv.NewWriter(file)
+	cw.Write(columns)
+	if err := writeRows(cw); err != nil {
+		return err
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return nil
+}
+
+func formatExportTime(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"days/internal/db"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockExportRepository implements a mock for the ExportRepository interface
+type MockExportRepository struct {
+	mock.Mock
+}
+
+func (m *MockExportRepository) GetCalendarsByUserID(ctx context.Context, userID uuid.UUID) ([]db.Calendar, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]db.Calendar), args.Error(1)
+}
+
+func (m *MockExportRepository) GetColorMeaningsByCalendarID(ctx context.Context, calendarID uuid.UUID) ([]db.ColorMeaning, error) {
+	args := m.Called(ctx, calendarID)
+	return args.Get(0).([]db.ColorMeaning), args.Error(1)
+}
+
+func (m *MockExportRepository) GetDayEntriesByCalendarID(ctx context.Context, calendarID uuid.UUID) ([]db.GetDayEntriesByCalendarIDRow, error) {
+	args := m.Called(ctx, calendarID)
+	return args.Get(0).([]db.GetDayEntriesByCalendarIDRow), args.Error(1)
+}
+
+func TestExportService_Export(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	created := sql.NullTime{Time: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), Valid: true}
+
+	mood := db.Calendar{ID: uuid.New(), UserID: userID, Name: "Mood", Description: sql.NullString{String: "How I felt", Valid: true}, CreatedAt: created}
+	sport := db.Calendar{ID: uuid.New(), UserID: userID, Name: "Sport, \"daily\""}
+	good := db.ColorMeaning{ID: uuid.New(), CalendarID: mood.ID, ColorHex: "#00FF00", Meaning: "good", CreatedAt: created}
+	entry := db.GetDayEntriesByCalendarIDRow{
+		ID:             uuid.New(),
+		CalendarID:     mood.ID,
+		Date:           time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		ColorMeaningID: good.ID,
+		Notes:          sql.NullString{String: "line one\nline two", Valid: true},
+	}
+
+	newService := func() (*ExportService, *MockExportRepository) {
+		mockQueries := new(MockExportRepository)
+		mockQueries.On("GetCalendarsByUserID", mock.Anything, userID).Return([]db.Calendar{mood, sport}, nil).Once()
+		mockQueries.On("GetColorMeaningsByCalendarID", mock.Anything, mood.ID).Return([]db.ColorMeaning{good}, nil).Once()
+		mockQueries.On("GetColorMeaningsByCalendarID", mock.Anything, sport.ID).Return([]db.ColorMeaning{}, nil).Once()
+		mockQueries.On("GetDayEntriesByCalendarID", mock.Anything, mood.ID).Return([]db.GetDayEntriesByCalendarIDRow{entry}, nil).Once()
+		mockQueries.On("GetDayEntriesByCalendarID", mock.Anything, sport.ID).Return([]db.GetDayEntriesByCalendarIDRow{}, nil).Once()
+
+		service := NewExportService(mockQueries)
+		service.now = func() time.Time { return time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC) }
+		return service, mockQueries
+	}
+
+	t.Run("json", func(t *testing.T) {
+		service, mockQueries := newService()
+
+		var buf bytes.Buffer
+		require.NoError(t, service.Export(ctx, userID, ExportFormatJSON, &buf))
+
+		var document ExportDocument
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &document))
+
+		assert.Equal(t, ExportSchemaVersion, document.SchemaVersion)
+		assert.Equal(t, "2024-02-01T12:00:00Z", document.ExportedAt)
+		require.Len(t, document.Calendars, 2)
+
+		exported := document.Calendars[0]
+		assert.Equal(t, mood.ID, exported.ID)
+		assert.Equal(t, "How I felt", *exported.Description)
+		assert.Equal(t, "2024-01-01T09:00:00Z", exported.CreatedAt)
+		assert.Equal(t, []ExportColorMeaning{{ID: good.ID, ColorHex: "#00FF00", Meaning: "good", CreatedAt: "2024-01-01T09:00:00Z"}}, exported.ColorMeanings)
+		require.Len(t, exported.DayEntries, 1)
+		assert.Equal(t, "2024-01-15", exported.DayEntries[0].Date)
+		assert.Equal(t, "line one\nline two", *exported.DayEntries[0].Notes)
+
+		assert.Nil(t, document.Calendars[1].Description)
+		assert.Empty(t, document.Calendars[1].DayEntries)
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		service, mockQueries := newService()
+
+		var buf bytes.Buffer
+		require.NoError(t, service.Export(ctx, userID, ExportFormatCSV, &buf))
+
+		archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+
+		files := make(map[string][]byte)
+		for _, file := range archive.File {
+			rc, err := file.Open()
+			require.NoError(t, err)
+			files[file.Name], err = io.ReadAll(rc)
+			require.NoError(t, err)
+			rc.Close()
+		}
+
+		var manifest ExportManifest
+		require.NoError(t, json.Unmarshal(files[ExportManifestFile], &manifest))
+		assert.Equal(t, ExportSchemaVersion, manifest.SchemaVersion)
+		assert.Equal(t, []string{ExportCalendarsFile, ExportColorMeaningsFile, ExportDayEntriesFile}, manifest.Files)
+
+		calendars, err := csv.NewReader(bytes.NewReader(files[ExportCalendarsFile])).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{
+			exportCalendarColumns,
+			{mood.ID.String(), "Mood", "How I felt", "2024-01-01T09:00:00Z", ""},
+			{sport.ID.String(), "Sport, \"daily\"", "", "", ""},
+		}, calendars)
+
+		colorMeanings, err := csv.NewReader(bytes.NewReader(files[ExportColorMeaningsFile])).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{
+			exportColorMeaningColumns,
+			{good.ID.String(), mood.ID.String(), "#00FF00", "good", "2024-01-01T09:00:00Z"},
+		}, colorMeanings)
+
+		dayEntries, err := csv.NewReader(bytes.NewReader(files[ExportDayEntriesFile])).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{
+			exportDayEntryColumns,
+			{entry.ID.String(), mood.ID.String(), "2024-01-15", good.ID.String(), "line one\nline two", "", ""},
+		}, dayEntries)
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		service := NewExportService(new(MockExportRepository))
+
+		var buf bytes.Buffer
+		err := service.Export(ctx, userID, "xml", &buf)
+		assert.Equal(t, ErrInvalidExportFormat, err)
+		assert.Zero(t, buf.Len())
+	})
+
+	t.Run("nothing is written when calendars cannot be read", func(t *testing.T) {
+		mockQueries := new(MockExportRepository)
+		service := NewExportService(mockQueries)
+		mockQueries.On("GetCalendarsByUserID", mock.Anything, userID).Return([]db.Calendar(nil), assert.AnError).Once()
+
+		var buf bytes.Buffer
+		err := service.Export(ctx, userID, ExportFormatJSON, &buf)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Zero(t, buf.Len())
+	})
+}
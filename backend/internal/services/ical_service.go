@@ -0,0 +1,166 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"days/internal/auth"
+	"days/internal/db"
+	"days/internal/ical"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrFeedTokenNotFound = errors.New("calendar feed is not enabled")
+	ErrInvalidFeedToken  = errors.New("invalid calendar feed token")
+)
+
+type ICalService struct {
+	queries ICalRepository
+	now     func() time.Time
+}
+
+// FeedTokenResponse carries a feed token. The token is only ever shown here;
+// the server keeps just its hash.
+type FeedTokenResponse struct {
+	CalendarID uuid.UUID `json:"calendar_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Token      string    `json:"token" example:"3q2-7wAAAAA..."`
+	URL        string    `json:"url" example:"https://days.example.com/api/calendars/123e4567-e89b-12d3-a456-426614174000/ical?token=3q2-7wAAAAA..."`
+	CreatedAt  string    `json:"created_at" example:"2023-01-01T00:00:00Z"`
+}
+
+func NewICalService(queries ICalRepository) *ICalService {
+	return &ICalService{
+		queries: queries,
+		now:     time.Now,
+	}
+}
+
+// CreateFeedToken enables the iCalendar feed of a calendar, replacing any
+// earlier token so that old subscriptions stop working
+func (s *ICalService) CreateFeedToken(ctx context.Context, userID, calendarID uuid.UUID) (*FeedTokenResponse, error) {
+	ctx, span := tracer.Start(ctx, "ICalService.CreateFeedToken")
+	defer span.End()
+
+	if err := s.checkOwner(ctx, userID, calendarID); err != nil {
+		return nil, err
+	}
+
+	token, hash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate feed token: %w", err)
+	}
+
+	feedToken, err := s.queries.UpsertCalendarFeedToken(ctx, db.UpsertCalendarFeedTokenParams{
+		CalendarID: calendarID,
+		TokenHash:  hash,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store feed token: %w", err)
+	}
+
+	return &FeedTokenResponse{
+		CalendarID: calendarID,
+		Token:      token,
+		CreatedAt:  feedToken.CreatedAt.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// RevokeFeedToken disables the iCalendar feed of a calendar
+func (s *ICalService) RevokeFeedToken(ctx context.Context, userID, calendarID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "ICalService.RevokeFeedToken")
+	defer span.End()
+
+	if err := s.checkOwner(ctx, userID, calendarID); err != nil {
+		return err
+	}
+
+	deleted, err := s.queries.DeleteCalendarFeedToken(ctx, calendarID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke feed token: %w", err)
+	}
+	if deleted == 0 {
+		return ErrFeedTokenNotFound
+	}
+	return nil
+}
+
+// GetCalendarFeed returns a calendar as an iCalendar document with one
+// all-day event per day entry. The feed token stands in for the user.
+func (s *ICalService) GetCalendarFeed(ctx context.Context, calendarID uuid.UUID, token string) ([]byte, error) {
+	ctx, span := tracer.Start(ctx, "ICalService.GetCalendarFeed")
+	defer span.End()
+
+	feedToken, err := s.queries.GetCalendarFeedToken(ctx, calendarID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidFeedToken
+		}
+		return nil, fmt.Errorf("failed to get feed token: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(auth.HashOpaqueToken(token)), []byte(feedToken.TokenHash)) != 1 {
+		return nil, ErrInvalidFeedToken
+	}
+
+	calendar, err := s.queries.GetCalendarByID(ctx, calendarID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCalendarNotFound
+		}
+		return nil, fmt.Errorf("failed to get calendar: %w", err)
+	}
+
+	entries, err := s.queries.GetDayEntriesByCalendarID(ctx, calendarID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get day entries: %w", err)
+	}
+
+	feed := ical.Calendar{
+		Name:        calendar.Name,
+		Description: calendar.Description.String,
+		Events:      make([]ical.Event, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		event := ical.Event{
+			UID:         entry.ID.String() + "@days",
+			Date:        entry.Date,
+			Summary:     entry.Meaning,
+			Description: entry.Notes.String,
+			Stamp:       s.now(),
+		}
+		// Stable stamps keep the feed byte-identical until something changes
+		if entry.UpdatedAt.Valid {
+			event.Stamp = entry.UpdatedAt.Time
+			event.LastModified = entry.UpdatedAt.Time
+		} else if entry.CreatedAt.Valid {
+			event.Stamp = entry.CreatedAt.Time
+		}
+		feed.Events = append(feed.Events, event)
+	}
+
+	var buf bytes.Buffer
+	if err := ical.Write(&buf, feed); err != nil {
+		return nil, fmt.Errorf("failed to write calendar feed: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Helper methods
+
+func (s *ICalService) checkOwner(ctx context.Context, userID, calendarID uuid.UUID) error {
+	_, role, err := authorizeCalendar(ctx, s.queries, userID, calendarID, RoleViewer)
+	if err != nil {
+		return err
+	}
+	// Feeds expose the whole calendar, so only its owner hands them out
+	if role != RoleOwner {
+		return ErrUnauthorizedCalendar
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"days/internal/auth"
+	"days/internal/db"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockICalRepository implements a mock for the ICalRepository interface
+type MockICalRepository struct {
+	mock.Mock
+}
+
+func (m *MockICalRepository) GetCalendarByID(ctx context.Context, id uuid.UUID) (db.Calendar, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(db.Calendar), args.Error(1)
+}
+
+func (m *MockICalRepository) GetCalendarWithRole(ctx context.Context, arg db.GetCalendarWithRoleParams) (db.GetCalendarWithRoleRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.GetCalendarWithRoleRow), args.Error(1)
+}
+
+func (m *MockICalRepository) GetDayEntriesByCalendarID(ctx context.Context, calendarID uuid.UUID) ([]db.GetDayEntriesByCalendarIDRow, error) {
+	args := m.Called(ctx, calendarID)
+	return args.Get(0).([]db.GetDayEntriesByCalendarIDRow), args.Error(1)
+}
+
+func (m *MockICalRepository) UpsertCalendarFeedToken(ctx context.Context, arg db.UpsertCalendarFeedTokenParams) (db.CalendarFeedToken, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.CalendarFeedToken), args.Error(1)
+}
+
+func (m *MockICalRepository) GetCalendarFeedToken(ctx context.Context, calendarID uuid.UUID) (db.CalendarFeedToken, error) {
+	args := m.Called(ctx, calendarID)
+	return args.Get(0).(db.CalendarFeedToken), args.Error(1)
+}
+
+func (m *MockICalRepository) DeleteCalendarFeedToken(ctx context.Context, calendarID uuid.UUID) (int64, error) {
+	args := m.Called(ctx, calendarID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestICalService_CreateFeedToken(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	calendarID := uuid.New()
+
+	t.Run("stores only the hash", func(t *testing.T) {
+		mockQueries := new(MockICalRepository)
+		service := NewICalService(mockQueries)
+
+		var stored db.UpsertCalendarFeedTokenParams
+		createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		mockQueries.On("GetCalendarWithRole", mock.Anything, calendarAccess(userID, calendarID)).Return(calendarWithRole(calendarID, userID, RoleOwner), nil).Once()
+		mockQueries.On("UpsertCalendarFeedToken", mock.Anything, mock.AnythingOfType("db.UpsertCalendarFeedTokenParams")).
+			Run(func(args mock.Arguments) { stored = args.Get(1).(db.UpsertCalendarFeedTokenParams) }).
+			Return(db.CalendarFeedToken{CalendarID: calendarID, CreatedAt: createdAt}, nil).Once()
+
+		feedToken, err := service.CreateFeedToken(ctx, userID, calendarID)
+		require.NoError(t, err)
+
+		assert.NotEmpty(t, feedToken.Token)
+		assert.Equal(t, calendarID, stored.CalendarID)
+		assert.Equal(t, auth.HashOpaqueToken(feedToken.Token), stored.TokenHash)
+		assert.Equal(t, "2024-01-01T00:00:00Z", feedToken.CreatedAt)
+		mockQueries.AssertExpectations(t)
+	})
+
+	for _, role := range []CalendarRole{"", RoleViewer, RoleEditor} {
+		t.Run("other users' calendars as "+string(role), func(t *testing.T) {
+			mockQueries := new(MockICalRepository)
+			service := NewICalService(mockQueries)
+			mockQueries.On("GetCalendarWithRole", mock.Anything, calendarAccess(userID, calendarID)).Return(calendarWithRole(calendarID, uuid.New(), role), nil).Once()
+
+			_, err := service.CreateFeedToken(ctx, userID, calendarID)
+			assert.Equal(t, ErrUnauthorizedCalendar, err)
+			mockQueries.AssertNotCalled(t, "UpsertCalendarFeedToken", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestICalService_RevokeFeedToken(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	calendarID := uuid.New()
+
+	tests := []struct {
+		name          string
+		deleted       int64
+		expectedError error
+	}{
+		{"revoked", 1, nil},
+		{"feed not enabled", 0, ErrFeedTokenNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := new(MockICalRepository)
+			service := NewICalService(mockQueries)
+			mockQueries.On("GetCalendarWithRole", mock.Anything, calendarAccess(userID, calendarID)).Return(calendarWithRole(calendarID, userID, RoleOwner), nil).Once()
+			mockQueries.On("DeleteCalendarFeedToken", mock.Anything, calendarID).Return(tt.deleted, nil).Once()
+
+			err := service.RevokeFeedToken(ctx, userID, calendarID)
+			assert.Equal(t, tt.expectedError, err)
+			mockQueries.AssertExpectations(t)
+		})
+	}
+}
+
+func TestICalService_GetCalendarFeed(t *testing.T) {
+	ctx := context.Background()
+	calendarID := uuid.New()
+	token, hash, err := auth.GenerateOpaqueToken()
+	require.NoError(t, err)
+
+	t.Run("valid token", func(t *testing.T) {
+		mockQueries := new(MockICalRepository)
+		service := NewICalService(mockQueries)
+
+		entryID := uuid.New()
+		updated := time.Date(2024, 1, 16, 8, 0, 0, 0, time.UTC)
+		mockQueries.On("GetCalendarFeedToken", mock.Anything, calendarID).Return(db.CalendarFeedToken{CalendarID: calendarID, TokenHash: hash}, nil).Once()
+		mockQueries.On("GetCalendarByID", mock.Anything, calendarID).Return(db.Calendar{ID: calendarID, Name: "Mood"}, nil).Once()
+		mockQueries.On("GetDayEntriesByCalendarID", mock.Anything, calendarID).Return([]db.GetDayEntriesByCalendarIDRow{{
+			ID:        entryID,
+			Date:      time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+			Meaning:   "relaxed",
+			Notes:     sql.NullString{String: "long walk", Valid: true},
+			UpdatedAt: sql.NullTime{Time: updated, Valid: true},
+		}}, nil).Once()
+
+		feed, err := service.GetCalendarFeed(ctx, calendarID, token)
+		require.NoError(t, err)
+
+		document := string(feed)
+		assert.True(t, strings.HasPrefix(document, "BEGIN:VCALENDAR\r\n"))
+		assert.Contains(t, document, "X-WR-CALNAME:Mood\r\n")
+		assert.Contains(t, document, "UID:"+entryID.String()+"@days\r\n")
+		assert.Contains(t, document, "DTSTAMP:20240116T080000Z\r\n")
+		assert.Contains(t, document, "DTSTART;VALUE=DATE:20240115\r\n")
+		assert.Contains(t, document, "SUMMARY:relaxed\r\n")
+		assert.Contains(t, document, "DESCRIPTION:long walk\r\n")
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("wrong token", func(t *testing.T) {
+		mockQueries := new(MockICalRepository)
+		service := NewICalService(mockQueries)
+		mockQueries.On("GetCalendarFeedToken", mock.Anything, calendarID).Return(db.CalendarFeedToken{CalendarID: calendarID, TokenHash: hash}, nil).Once()
+
+		_, err := service.GetCalendarFeed(ctx, calendarID, token+"x")
+		assert.Equal(t, ErrInvalidFeedToken, err)
+		mockQueries.AssertNotCalled(t, "GetDayEntriesByCalendarID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("revoked feed", func(t *testing.T) {
+		mockQueries := new(MockICalRepository)
+		service := NewICalService(mockQueries)
+		mockQueries.On("GetCalendarFeedToken", mock.Anything, calendarID).Return(db.CalendarFeedToken{}, sql.ErrNoRows).Once()
+
+		_, err := service.GetCalendarFeed(ctx, calendarID, token)
+		assert.Equal(t, ErrInvalidFeedToken, err)
+	})
+}
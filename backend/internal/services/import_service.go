@@ -0,0 +1,678 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"days/internal/audit"
+	"days/internal/db"
+	"days/internal/tracing"
+
+	"github.com/google/uuid"
+)
+
+// Strategies for calendars whose name is already taken by one of the user's calendars
+const (
+	ImportCalendarConflictSkip   = "skip"
+	ImportCalendarConflictRename = "rename"
+	ImportCalendarConflictMerge  = "merge"
+)
+
+// Strategies for imported entries on a date that already has an entry
+const (
+	ImportEntryConflictKeep      = "keep"
+	ImportEntryConflictOverwrite = "overwrite"
+)
+
+// What happened to an imported calendar
+const (
+	ImportActionCreated = "created"
+	ImportActionRenamed = "renamed"
+	ImportActionMerged  = "merged"
+	ImportActionSkipped = "skipped"
+)
+
+// AuditActionDataImported is recorded in the audit log for each import kept
+const AuditActionDataImported = "user.data_imported"
+
+// maxImportFileSize caps each uncompressed file of a CSV archive
+const maxImportFileSize = 64 << 20
+
+var (
+	ErrInvalidImport                = errors.New("invalid import file")
+	ErrUnsupportedSchemaVersion     = errors.New("unsupported export schema version")
+	ErrInvalidImportFormat          = errors.New("format must be json or csv")
+	ErrInvalidCalendarConflict      = errors.New("calendar_conflict must be skip, rename or merge")
+	ErrInvalidEntryConflict         = errors.New("entry_conflict must be keep or overwrite")
+	errImportDryRun                 = errors.New("dry run")
+	errImportCalendarNameUnresolved = errors.New("no free calendar name")
+)
+
+type ImportService struct {
+	tx    ImportTransactor
+	audit *audit.Recorder
+}
+
+type ImportOptions struct {
+	Format           string // json (default) or csv
+	DryRun           bool   // report what would be imported without keeping it
+	CalendarConflict string // skip (default), rename or merge
+	EntryConflict    string // keep (default) or overwrite
+}
+
+type ImportResult struct {
+	DryRun    bool                   `json:"dry_run" example:"false"`
+	Summary   ImportSummary          `json:"summary"`
+	Calendars []ImportCalendarResult `json:"calendars"`
+}
+
+type ImportSummary struct {
+	CalendarsCreated      int `json:"calendars_created" example:"1"`
+	CalendarsMerged       int `json:"calendars_merged" example:"0"`
+	CalendarsSkipped      int `json:"calendars_skipped" example:"0"`
+	ColorMeaningsCreated  int `json:"color_meanings_created" example:"3"`
+	DayEntriesCreated     int `json:"day_entries_created" example:"120"`
+	DayEntriesOverwritten int `json:"day_entries_overwritten" example:"0"`
+	DayEntriesKept        int `json:"day_entries_kept" example:"0"`
+}
+
+type ImportCalendarResult struct {
+	Name                  string     `json:"name" example:"Mood"`
+	Action                string     `json:"action" example:"created"`
+	ImportedAs            string     `json:"imported_as,omitempty" example:"Mood (2)"`
+	CalendarID            *uuid.UUID `json:"calendar_id,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+	ColorMeaningsCreated  int        `json:"color_meanings_created" example:"3"`
+	DayEntriesCreated     int        `json:"day_entries_created" example:"120"`
+	DayEntriesOverwritten int        `json:"day_entries_overwritten" example:"0"`
+	DayEntriesKept        int        `json:"day_entries_kept" example:"0"`
+}
+
+// SQLImportTransactor runs imports in database transactions, binding the
+// generated queries to each transaction. Statements in the transaction are
+// traced like any others.
+type SQLImportTransactor struct {
+	db *sql.DB
+}
+
+func NewSQLImportTransactor(database *sql.DB) *SQLImportTransactor {
+	return &SQLImportTransactor{
+		db: database,
+	}
+}
+
+// InTx commits the transaction when fn succeeds and rolls it back otherwise
+func (t *SQLImportTransactor) InTx(ctx context.Context, fn func(q ImportRepository) error) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(db.New(tracing.WrapDBTX(tx))); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func NewImportService(tx ImportTransactor, events audit.Store) *ImportService {
+	return &ImportService{
+		tx:    tx,
+		audit: audit.NewRecorder(events),
+	}
+}
+
+// Import recreates calendars, color meanings and day entries from a JSON
+// document or CSV archive written by Export. Everything is written in one
+// transaction; a dry run performs the same writes and rolls them back, so its
+// report is exactly what a real import would do. IDs and timestamps from the
+// file are not kept: imported rows get new ones.
+func (s *ImportService) Import(ctx context.Context, userID uuid.UUID, r io.Reader, opts ImportOptions) (*ImportResult, error) {
+	ctx, span := tracer.Start(ctx, "ImportService.Import")
+	defer span.End()
+
+	if opts.Format == "" {
+		opts.Format = ExportFormatJSON
+	}
+	if opts.CalendarConflict == "" {
+		opts.CalendarConflict = ImportCalendarConflictSkip
+	}
+	if opts.EntryConflict == "" {
+		opts.EntryConflict = ImportEntryConflictKeep
+	}
+
+	switch opts.CalendarConflict {
+	case ImportCalendarConflictSkip, ImportCalendarConflictRename, ImportCalendarConflictMerge:
+	default:
+		return nil, ErrInvalidCalendarConflict
+	}
+	switch opts.EntryConflict {
+	case ImportEntryConflictKeep, ImportEntryConflictOverwrite:
+	default:
+		return nil, ErrInvalidEntryConflict
+	}
+
+	var document *ExportDocument
+	var err error
+	switch opts.Format {
+	case ExportFormatJSON:
+		document, err = s.readJSON(r)
+	case ExportFormatCSV:
+		document, err = s.readCSV(r)
+	default:
+		return nil, ErrInvalidImportFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateDocument(document); err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{DryRun: opts.DryRun}
+	err = s.tx.InTx(ctx, func(q ImportRepository) error {
+		calendars, err := s.importCalendars(ctx, q, userID, document, opts)
+		if err != nil {
+			return err
+		}
+		result.Calendars = calendars
+		if opts.DryRun {
+			return errImportDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportDryRun) {
+		return nil, err
+	}
+
+	for i := range result.Calendars {
+		calendar := &result.Calendars[i]
+		switch calendar.Action {
+		case ImportActionCreated, ImportActionRenamed:
+			result.Summary.CalendarsCreated++
+			if opts.DryRun {
+				// The calendar was rolled back with the rest of the dry run
+				calendar.CalendarID = nil
+			}
+		case ImportActionMerged:
+			result.Summary.CalendarsMerged++
+		case ImportActionSkipped:
+			result.Summary.CalendarsSkipped++
+		}
+		result.Summary.ColorMeaningsCreated += calendar.ColorMeaningsCreated
+		result.Summary.DayEntriesCreated += calendar.DayEntriesCreated
+		result.Summary.DayEntriesOverwritten += calendar.DayEntriesOverwritten
+		result.Summary.DayEntriesKept += calendar.DayEntriesKept
+	}
+
+	if !opts.DryRun {
+		s.audit.Record(ctx, audit.Event{
+			UserID: userID,
+			Action: AuditActionDataImported,
+			Metadata: map[string]string{
+				"format":                  opts.Format,
+				"calendars_created":       strconv.Itoa(result.Summary.CalendarsCreated),
+				"calendars_merged":        strconv.Itoa(result.Summary.CalendarsMerged),
+				"calendars_skipped":       strconv.Itoa(result.Summary.CalendarsSkipped),
+				"color_meanings_created":  strconv.Itoa(result.Summary.ColorMeaningsCreated),
+				"day_entries_created":     strconv.Itoa(result.Summary.DayEntriesCreated),
+				"day_entries_overwritten": strconv.Itoa(result.Summary.DayEntriesOverwritten),
+				"day_entries_kept":        strconv.Itoa(result.Summary.DayEntriesKept),
+			},
+		})
+	}
+
+	return result, nil
+}
+
+// Helper methods
+
+func (s *ImportService) importCalendars(ctx context.Context, q ImportRepository, userID uuid.UUID, document *ExportDocument, opts ImportOptions) ([]ImportCalendarResult, error) {
+	existing, err := q.GetCalendarsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendars: %w", err)
+	}
+
+	// Calendar names are unique per user regardless of case
+	byName := make(map[string]db.Calendar, len(existing))
+	for _, calendar := range existing {
+		byName[strings.ToLower(calendar.Name)] = calendar
+	}
+
+	results := make([]ImportCalendarResult, 0, len(document.Calendars))
+	for _, imported := range document.Calendars {
+		result := ImportCalendarResult{Name: imported.Name}
+		name := strings.TrimSpace(imported.Name)
+
+		target, exists := byName[strings.ToLower(name)]
+		var legend []db.ColorMeaning
+		var entries map[string]db.GetDayEntriesByCalendarIDRow
+
+		switch {
+		case exists && opts.CalendarConflict == ImportCalendarConflictSkip:
+			result.Action = ImportActionSkipped
+			result.CalendarID = &target.ID
+			results = append(results, result)
+			continue
+
+		case exists && opts.CalendarConflict == ImportCalendarConflictMerge:
+			result.Action = ImportActionMerged
+
+			legend, err = q.GetColorMeaningsByCalendarID(ctx, target.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get color meanings: %w", err)
+			}
+
+			rows, err := q.GetDayEntriesByCalendarID(ctx, target.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get day entries: %w", err)
+			}
+			entries = make(map[string]db.GetDayEntriesByCalendarIDRow, len(rows))
+			for _, row := range rows {
+				entries[row.Date.Format("2006-01-02")] = row
+			}
+
+		default:
+			result.Action = ImportActionCreated
+			if exists {
+				result.Action = ImportActionRenamed
+				if name, err = s.freeCalendarName(name, byName); err != nil {
+					return nil, fmt.Errorf("%w: calendar %q: %v", ErrInvalidImport, imported.Name, err)
+				}
+			}
+
+			var description sql.NullString
+			if imported.Description != nil {
+				description = sql.NullString{String: *imported.Description, Valid: true}
+			}
+			target, err = q.CreateCalendar(ctx, db.CreateCalendarParams{
+				UserID:      userID,
+				Name:        name,
+				Description: description,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to create calendar: %w", err)
+			}
+			byName[strings.ToLower(name)] = target
+		}
+
+		result.ImportedAs = target.Name
+		result.CalendarID = &target.ID
+
+		// Map color meaning IDs of the file to the meanings they end up as
+		colorMeaningIDs := make(map[uuid.UUID]uuid.UUID, len(imported.ColorMeanings))
+		for _, cm := range imported.ColorMeanings {
+			if match, ok := s.matchColorMeaning(legend, cm); ok {
+				colorMeaningIDs[cm.ID] = match.ID
+				continue
+			}
+
+			created, err := q.CreateColorMeaning(ctx, db.CreateColorMeaningParams{
+				CalendarID: target.ID,
+				ColorHex:   normalizeColorHex(cm.ColorHex),
+				Meaning:    strings.TrimSpace(cm.Meaning),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to create color meaning: %w", err)
+			}
+			legend = append(legend, created)
+			colorMeaningIDs[cm.ID] = created.ID
+			result.ColorMeaningsCreated++
+		}
+
+		for _, entry := range imported.DayEntries {
+			date, _ := time.Parse("2006-01-02", entry.Date) // checked by validateDocument
+
+			var notes sql.NullString
+			if entry.Notes != nil {
+				notes = sql.NullString{String: *entry.Notes, Valid: true}
+			}
+
+			if _, found := entries[entry.Date]; found {
+				if opts.EntryConflict == ImportEntryConflictKeep {
+					result.DayEntriesKept++
+					continue
+				}
+
+				_, err := q.UpdateDayEntry(ctx, db.UpdateDayEntryParams{
+					CalendarID:     target.ID,
+					ColorMeaningID: colorMeaningIDs[entry.ColorMeaningID],
+					Notes:          notes,
+					Date:           date,
+				})
+				if err != nil {
+					return nil, fmt.Errorf("failed to update day entry: %w", err)
+				}
+				result.DayEntriesOverwritten++
+				continue
+			}
+
+			_, err := q.CreateDayEntry(ctx, db.CreateDayEntryParams{
+				CalendarID:     target.ID,
+				Date:           date,
+				ColorMeaningID: colorMeaningIDs[entry.ColorMeaningID],
+				Notes:          notes,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to create day entry: %w", err)
+			}
+			result.DayEntriesCreated++
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// matchColorMeaning finds the existing meaning a merged color meaning maps
+// to: one with the same meaning, or failing that one with the same color.
+// The existing legend wins when the two disagree.
+func (s *ImportService) matchColorMeaning(legend []db.ColorMeaning, cm ExportColorMeaning) (db.ColorMeaning, bool) {
+	meaning := strings.TrimSpace(cm.Meaning)
+	for _, existing := range legend {
+		if strings.EqualFold(existing.Meaning, meaning) {
+			return existing, true
+		}
+	}
+
+	colorHex := normalizeColorHex(cm.ColorHex)
+	for _, existing := range legend {
+		if normalizeColorHex(existing.ColorHex) == colorHex {
+			return existing, true
+		}
+	}
+
+	return db.ColorMeaning{}, false
+}
+
+// freeCalendarName returns the first of "name (2)", "name (3)", ... that is
+// not taken, shortening name if needed to stay within 100 bytes
+func (s *ImportService) freeCalendarName(name string, taken map[string]db.Calendar) (string, error) {
+	for n := 2; n < 1000; n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+
+		base := name
+		for len(base)+len(suffix) > 100 {
+			_, size := utf8.DecodeLastRuneInString(base)
+			base = base[:len(base)-size]
+		}
+
+		candidate := strings.TrimSpace(base) + suffix
+		if _, exists := taken[strings.ToLower(candidate)]; !exists {
+			return candidate, nil
+		}
+	}
+	return "", errImportCalendarNameUnresolved
+}
+
+func (s *ImportService) readJSON(r io.Reader) (*ExportDocument, error) {
+	// Reading first keeps failures of the body apart from invalid documents
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import: %w", err)
+	}
+
+	var document ExportDocument
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	return &document, nil
+}
+
+// readCSV reads a CSV archive back into the document it was written from
+func (s *ImportService) readCSV(r io.Reader) (*ExportDocument, error) {
+	// Zip archives keep their directory at the end, so the whole body is needed
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import: %w", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	var manifest ExportManifest
+	if err := s.readArchiveFile(files, ExportManifestFile, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&manifest)
+	}); err != nil {
+		return nil, err
+	}
+
+	document := &ExportDocument{
+		SchemaVersion: manifest.SchemaVersion,
+		ExportedAt:    manifest.ExportedAt,
+		Calendars:     []ExportCalendar{},
+	}
+	if manifest.SchemaVersion < 1 || manifest.SchemaVersion > ExportSchemaVersion {
+		// Later versions may lay out their files differently
+		return document, nil
+	}
+
+	calendarIndex := make(map[uuid.UUID]int)
+	calendarFor := func(field string) (*ExportCalendar, error) {
+		id, err := uuid.Parse(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid calendar_id %q", field)
+		}
+		i, ok := calendarIndex[id]
+		if !ok {
+			return nil, fmt.Errorf("unknown calendar_id %s", id)
+		}
+		return &document.Calendars[i], nil
+	}
+
+	err = s.readCSVFile(files, ExportCalendarsFile, exportCalendarColumns, func(record []string) error {
+		id, err := uuid.Parse(record[0])
+		if err != nil {
+			return fmt.Errorf("invalid id %q", record[0])
+		}
+		if _, exists := calendarIndex[id]; exists {
+			return fmt.Errorf("duplicate id %s", id)
+		}
+
+		calendar := ExportCalendar{
+			ID:            id,
+			Name:          record[1],
+			CreatedAt:     record[3],
+			UpdatedAt:     record[4],
+			ColorMeanings: []ExportColorMeaning{},
+			DayEntries:    []ExportDayEntry{},
+		}
+		if record[2] != "" {
+			calendar.Description = &record[2]
+		}
+		calendarIndex[id] = len(document.Calendars)
+		document.Calendars = append(document.Calendars, calendar)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.readCSVFile(files, ExportColorMeaningsFile, exportColorMeaningColumns, func(record []string) error {
+		id, err := uuid.Parse(record[0])
+		if err != nil {
+			return fmt.Errorf("invalid id %q", record[0])
+		}
+		calendar, err := calendarFor(record[1])
+		if err != nil {
+			return err
+		}
+		calendar.ColorMeanings = append(calendar.ColorMeanings, ExportColorMeaning{
+			ID:        id,
+			ColorHex:  record[2],
+			Meaning:   record[3],
+			CreatedAt: record[4],
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.readCSVFile(files, ExportDayEntriesFile, exportDayEntryColumns, func(record []string) error {
+		id, err := uuid.Parse(record[0])
+		if err != nil {
+			return fmt.Errorf("invalid id %q", record[0])
+		}
+		calendar, err := calendarFor(record[1])
+		if err != nil {
+			return err
+		}
+		colorMeaningID, err := uuid.Parse(record[3])
+		if err != nil {
+			return fmt.Errorf("invalid color_meaning_id %q", record[3])
+		}
+
+		entry := ExportDayEntry{
+			ID:             id,
+			Date:           record[2],
+			ColorMeaningID: colorMeaningID,
+			CreatedAt:      record[5],
+			UpdatedAt:      record[6],
+		}
+		if record[4] != "" {
+			entry.Notes = &record[4]
+		}
+		calendar.DayEntries = append(calendar.DayEntries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+func (s *ImportService) readArchiveFile(files map[string]*zip.File, name string, read func(r io.Reader) error) error {
+	file, ok := files[name]
+	if !ok {
+		return fmt.Errorf("%w: %s is missing", ErrInvalidImport, name)
+	}
+	if file.UncompressedSize64 > maxImportFileSize {
+		return fmt.Errorf("%w: %s is too large", ErrInvalidImport, name)
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidImport, name, err)
+	}
+	defer rc.Close()
+
+	if err := read(rc); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidImport, name, err)
+	}
+	return nil
+}
+
+func (s *ImportService) readCSVFile(files map[string]*zip.File, name string, columns []string, readRecord func(record []string) error) error {
+	return s.readArchiveFile(files, name, func(r io.Reader) error {
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = len(columns)
+
+		header, err := cr.Read()
+		if err != nil {
+			return err
+		}
+		for i, column := range columns {
+			if header[i] != column {
+				return fmt.Errorf("expected column %q, got %q", column, header[i])
+			}
+		}
+
+		for {
+			record, err := cr.Read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := readRecord(record); err != nil {
+				line, _ := cr.FieldPos(0)
+				return fmt.Errorf("line %d: %v", line, err)
+			}
+		}
+	})
+}
+
+// validateDocument checks everything an import could trip over before the
+// transaction starts, so that invalid files are rejected as a whole
+func (s *ImportService) validateDocument(document *ExportDocument) error {
+	if document.SchemaVersion < 1 || document.SchemaVersion > ExportSchemaVersion {
+		return fmt.Errorf("%w %d", ErrUnsupportedSchemaVersion, document.SchemaVersion)
+	}
+
+	for i, calendar := range document.Calendars {
+		invalid := func(format string, args ...any) error {
+			return fmt.Errorf("%w: calendar %d (%q): %s", ErrInvalidImport, i+1, calendar.Name, fmt.Sprintf(format, args...))
+		}
+
+		if err := validateCalendarName(calendar.Name); err != nil {
+			return invalid("%v", err)
+		}
+
+		colorMeaningIDs := make(map[uuid.UUID]bool, len(calendar.ColorMeanings))
+		colorHexes := make(map[string]bool, len(calendar.ColorMeanings))
+		meanings := make(map[string]bool, len(calendar.ColorMeanings))
+		for _, cm := range calendar.ColorMeanings {
+			if err := validateColorHex(cm.ColorHex); err != nil {
+				return invalid("color meaning %s: %v", cm.ID, err)
+			}
+			if err := validateMeaning(cm.Meaning); err != nil {
+				return invalid("color meaning %s: %v", cm.ID, err)
+			}
+
+			colorHex := normalizeColorHex(cm.ColorHex)
+			meaning := strings.ToLower(strings.TrimSpace(cm.Meaning))
+			switch {
+			case colorMeaningIDs[cm.ID]:
+				return invalid("duplicate color meaning %s", cm.ID)
+			case colorHexes[colorHex]:
+				return invalid("color meaning %s: %v", cm.ID, ErrColorExists)
+			case meanings[meaning]:
+				return invalid("color meaning %s: %v", cm.ID, ErrMeaningExists)
+			}
+			colorMeaningIDs[cm.ID] = true
+			colorHexes[colorHex] = true
+			meanings[meaning] = true
+		}
+
+		dates := make(map[string]bool, len(calendar.DayEntries))
+		for _, entry := range calendar.DayEntries {
+			if _, err := time.Parse("2006-01-02", entry.Date); err != nil {
+				return invalid("day entry %q: %v", entry.Date, ErrInvalidDate)
+			}
+			if dates[entry.Date] {
+				return invalid("day entry %s: %v", entry.Date, ErrDayEntryExists)
+			}
+			if !colorMeaningIDs[entry.ColorMeaningID] {
+				return invalid("day entry %s: %v", entry.Date, ErrColorMeaningMismatch)
+			}
+			dates[entry.Date] = true
+		}
+	}
+
+	return nil
+}
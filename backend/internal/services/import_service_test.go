@@ -0,0 +1,449 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"days/internal/db"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockImportRepository implements a mock for the ImportRepository interface
+type MockImportRepository struct {
+	mock.Mock
+}
+
+func (m *MockImportRepository) GetCalendarsByUserID(ctx context.Context, userID uuid.UUID) ([]db.Calendar, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]db.Calendar), args.Error(1)
+}
+
+func (m *MockImportRepository) CreateCalendar(ctx context.Context, arg db.CreateCalendarParams) (db.Calendar, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.Calendar), args.Error(1)
+}
+
+func (m *MockImportRepository) GetColorMeaningsByCalendarID(ctx context.Context, calendarID uuid.UUID) ([]db.ColorMeaning, error) {
+	args := m.Called(ctx, calendarID)
+	return args.Get(0).([]db.ColorMeaning), args.Error(1)
+}
+
+func (m *MockImportRepository) CreateColorMeaning(ctx context.Context, arg db.CreateColorMeaningParams) (db.ColorMeaning, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.ColorMeaning), args.Error(1)
+}
+
+func (m *MockImportRepository) GetDayEntriesByCalendarID(ctx context.Context, calendarID uuid.UUID) ([]db.GetDayEntriesByCalendarIDRow, error) {
+	args := m.Called(ctx, calendarID)
+	return args.Get(0).([]db.GetDayEntriesByCalendarIDRow), args.Error(1)
+}
+
+func (m *MockImportRepository) CreateDayEntry(ctx context.Context, arg db.CreateDayEntryParams) (db.DayEntry, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.DayEntry), args.Error(1)
+}
+
+func (m *MockImportRepository) UpdateDayEntry(ctx context.Context, arg db.UpdateDayEntryParams) (db.DayEntry, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.DayEntry), args.Error(1)
+}
+
+// fakeImportTransactor runs fn directly against a repository and records
+// whether the transaction would have been committed
+type fakeImportTransactor struct {
+	repo      ImportRepository
+	calls     int
+	committed bool
+}
+
+func (f *fakeImportTransactor) InTx(ctx context.Context, fn func(q ImportRepository) error) error {
+	f.calls++
+	err := fn(f.repo)
+	f.committed = err == nil
+	return err
+}
+
+func importJSON(t *testing.T, document ExportDocument) *bytes.Reader {
+	t.Helper()
+	data, err := json.Marshal(document)
+	require.NoError(t, err)
+	return bytes.NewReader(data)
+}
+
+func TestImportService_Import(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	goodID, badID := uuid.New(), uuid.New()
+	mood := ExportCalendar{
+		ID:          uuid.New(),
+		Name:        "Mood",
+		Description: stringPtr("How I felt"),
+		ColorMeanings: []ExportColorMeaning{
+			{ID: goodID, ColorHex: "#0f0", Meaning: "good"},
+			{ID: badID, ColorHex: "#FF0000", Meaning: " bad "},
+		},
+		DayEntries: []ExportDayEntry{
+			{ID: uuid.New(), Date: "2024-01-15", ColorMeaningID: goodID, Notes: stringPtr("walk")},
+			{ID: uuid.New(), Date: "2024-01-16", ColorMeaningID: badID},
+		},
+	}
+	document := ExportDocument{SchemaVersion: ExportSchemaVersion, Calendars: []ExportCalendar{mood}}
+
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+
+	newService := func() (*ImportService, *MockImportRepository, *fakeImportTransactor, *MockQueries) {
+		repo := new(MockImportRepository)
+		tx := &fakeImportTransactor{repo: repo}
+		events := new(MockQueries)
+		events.On("CreateAuditEvent", mock.Anything, mock.Anything).Return(nil).Maybe()
+		return NewImportService(tx, events), repo, tx, events
+	}
+
+	expectCreate := func(repo *MockImportRepository, name string) (db.Calendar, db.ColorMeaning, db.ColorMeaning) {
+		calendar := db.Calendar{ID: uuid.New(), UserID: userID, Name: name}
+		good := db.ColorMeaning{ID: uuid.New(), CalendarID: calendar.ID, ColorHex: "#00FF00", Meaning: "good"}
+		bad := db.ColorMeaning{ID: uuid.New(), CalendarID: calendar.ID, ColorHex: "#FF0000", Meaning: "bad"}
+
+		repo.On("CreateCalendar", mock.Anything, db.CreateCalendarParams{
+			UserID:      userID,
+			Name:        name,
+			Description: sql.NullString{String: "How I felt", Valid: true},
+		}).Return(calendar, nil).Once()
+		repo.On("CreateColorMeaning", mock.Anything, db.CreateColorMeaningParams{CalendarID: calendar.ID, ColorHex: "#00FF00", Meaning: "good"}).Return(good, nil).Once()
+		repo.On("CreateColorMeaning", mock.Anything, db.CreateColorMeaningParams{CalendarID: calendar.ID, ColorHex: "#FF0000", Meaning: "bad"}).Return(bad, nil).Once()
+		repo.On("CreateDayEntry", mock.Anything, db.CreateDayEntryParams{
+			CalendarID:     calendar.ID,
+			Date:           day("2024-01-15"),
+			ColorMeaningID: good.ID,
+			Notes:          sql.NullString{String: "walk", Valid: true},
+		}).Return(db.DayEntry{}, nil).Once()
+		repo.On("CreateDayEntry", mock.Anything, db.CreateDayEntryParams{
+			CalendarID:     calendar.ID,
+			Date:           day("2024-01-16"),
+			ColorMeaningID: bad.ID,
+		}).Return(db.DayEntry{}, nil).Once()
+		return calendar, good, bad
+	}
+
+	t.Run("creates calendars in one transaction", func(t *testing.T) {
+		service, repo, tx, events := newService()
+		repo.On("GetCalendarsByUserID", mock.Anything, userID).Return([]db.Calendar{{ID: uuid.New(), Name: "Sport"}}, nil).Once()
+		calendar, _, _ := expectCreate(repo, "Mood")
+
+		result, err := service.Import(ctx, userID, importJSON(t, document), ImportOptions{})
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, tx.calls)
+		assert.True(t, tx.committed)
+		assert.False(t, result.DryRun)
+		assert.Equal(t, ImportSummary{CalendarsCreated: 1, ColorMeaningsCreated: 2, DayEntriesCreated: 2}, result.Summary)
+		require.Len(t, result.Calendars, 1)
+		assert.Equal(t, ImportActionCreated, result.Calendars[0].Action)
+		assert.Equal(t, "Mood", result.Calendars[0].ImportedAs)
+		assert.Equal(t, &calendar.ID, result.Calendars[0].CalendarID)
+		repo.AssertExpectations(t)
+		events.AssertNumberOfCalls(t, "CreateAuditEvent", 1)
+		events.AssertCalled(t, "CreateAuditEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateAuditEventParams) bool {
+			var metadata map[string]string
+			return arg.UserID == userID && arg.Action == AuditActionDataImported &&
+				json.Unmarshal(arg.Metadata, &metadata) == nil &&
+				metadata["format"] == ExportFormatJSON &&
+				metadata["calendars_created"] == "1" &&
+				metadata["color_meanings_created"] == "2" &&
+				metadata["day_entries_created"] == "2" &&
+				metadata["day_entries_kept"] == "0"
+		}))
+	})
+
+	t.Run("dry run rolls back and hides new IDs", func(t *testing.T) {
+		service, repo, tx, events := newService()
+		repo.On("GetCalendarsByUserID", mock.Anything, userID).Return([]db.Calendar{}, nil).Once()
+		expectCreate(repo, "Mood")
+
+		result, err := service.Import(ctx, userID, importJSON(t, document), ImportOptions{DryRun: true})
+		require.NoError(t, err)
+
+		assert.False(t, tx.committed)
+		assert.True(t, result.DryRun)
+		assert.Equal(t, ImportSummary{CalendarsCreated: 1, ColorMeaningsCreated: 2, DayEntriesCreated: 2}, result.Summary)
+		assert.Nil(t, result.Calendars[0].CalendarID)
+		repo.AssertExpectations(t)
+		events.AssertNotCalled(t, "CreateAuditEvent", mock.Anything, mock.Anything)
+	})
+
+	t.Run("skips calendars whose name is taken", func(t *testing.T) {
+		service, repo, _, _ := newService()
+		existing := db.Calendar{ID: uuid.New(), UserID: userID, Name: "MOOD"}
+		repo.On("GetCalendarsByUserID", mock.Anything, userID).Return([]db.Calendar{existing}, nil).Once()
+
+		result, err := service.Import(ctx, userID, importJSON(t, document), ImportOptions{})
+		require.NoError(t, err)
+
+		assert.Equal(t, ImportSummary{CalendarsSkipped: 1}, result.Summary)
+		assert.Equal(t, ImportActionSkipped, result.Calendars[0].Action)
+		assert.Equal(t, &existing.ID, result.Calendars[0].CalendarID)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("renames calendars whose name is taken", func(t *testing.T) {
+		service, repo, _, _ := newService()
+		repo.On("GetCalendarsByUserID", mock.Anything, userID).Return([]db.Calendar{{Name: "Mood"}, {Name: "mood (2)"}}, nil).Once()
+		expectCreate(repo, "Mood (3)")
+
+		result, err := service.Import(ctx, userID, importJSON(t, document), ImportOptions{CalendarConflict: ImportCalendarConflictRename})
+		require.NoError(t, err)
+
+		assert.Equal(t, ImportActionRenamed, result.Calendars[0].Action)
+		assert.Equal(t, "Mood", result.Calendars[0].Name)
+		assert.Equal(t, "Mood (3)", result.Calendars[0].ImportedAs)
+		assert.Equal(t, 1, result.Summary.CalendarsCreated)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("merges into calendars whose name is taken", func(t *testing.T) {
+		existing := db.Calendar{ID: uuid.New(), UserID: userID, Name: "Mood"}
+		// "good" matches by meaning, "bad" by color
+		good := db.ColorMeaning{ID: uuid.New(), CalendarID: existing.ID, ColorHex: "#008000", Meaning: "Good"}
+		awful := db.ColorMeaning{ID: uuid.New(), CalendarID: existing.ID, ColorHex: "#ff0000", Meaning: "awful"}
+		entry := db.GetDayEntriesByCalendarIDRow{ID: uuid.New(), CalendarID: existing.ID, Date: day("2024-01-15"), ColorMeaningID: awful.ID}
+
+		for _, tt := range []struct {
+			strategy string
+			expected ImportSummary
+		}{
+			{ImportEntryConflictKeep, ImportSummary{CalendarsMerged: 1, DayEntriesCreated: 1, DayEntriesKept: 1}},
+			{ImportEntryConflictOverwrite, ImportSummary{CalendarsMerged: 1, DayEntriesCreated: 1, DayEntriesOverwritten: 1}},
+		} {
+			t.Run(tt.strategy, func(t *testing.T) {
+				service, repo, _, _ := newService()
+				repo.On("GetCalendarsByUserID", mock.Anything, userID).Return([]db.Calendar{existing}, nil).Once()
+				repo.On("GetColorMeaningsByCalendarID", mock.Anything, existing.ID).Return([]db.ColorMeaning{good, awful}, nil).Once()
+				repo.On("GetDayEntriesByCalendarID", mock.Anything, existing.ID).Return([]db.GetDayEntriesByCalendarIDRow{entry}, nil).Once()
+				repo.On("CreateDayEntry", mock.Anything, db.CreateDayEntryParams{
+					CalendarID:     existing.ID,
+					Date:           day("2024-01-16"),
+					ColorMeaningID: awful.ID,
+				}).Return(db.DayEntry{}, nil).Once()
+				if tt.strategy == ImportEntryConflictOverwrite {
+					repo.On("UpdateDayEntry", mock.Anything, db.UpdateDayEntryParams{
+						CalendarID:     existing.ID,
+						ColorMeaningID: good.ID,
+						Notes:          sql.NullString{String: "walk", Valid: true},
+						Date:           day("2024-01-15"),
+					}).Return(db.DayEntry{}, nil).Once()
+				}
+
+				result, err := service.Import(ctx, userID, importJSON(t, document), ImportOptions{
+					CalendarConflict: ImportCalendarConflictMerge,
+					EntryConflict:    tt.strategy,
+				})
+				require.NoError(t, err)
+
+				assert.Equal(t, tt.expected, result.Summary)
+				assert.Equal(t, ImportActionMerged, result.Calendars[0].Action)
+				repo.AssertExpectations(t)
+			})
+		}
+	})
+
+	t.Run("reads archives written by the CSV export", func(t *testing.T) {
+		exportRepo := new(MockExportRepository)
+		calendarID := uuid.New()
+		exportRepo.On("GetCalendarsByUserID", mock.Anything, userID).Return([]db.Calendar{
+			{ID: calendarID, UserID: userID, Name: "Mood", Description: sql.NullString{String: "How I felt", Valid: true}},
+		}, nil)
+		exportRepo.On("GetColorMeaningsByCalendarID", mock.Anything, calendarID).Return([]db.ColorMeaning{
+			{ID: goodID, CalendarID: calendarID, ColorHex: "#00FF00", Meaning: "good"},
+			{ID: badID, CalendarID: calendarID, ColorHex: "#FF0000", Meaning: "bad"},
+		}, nil)
+		exportRepo.On("GetDayEntriesByCalendarID", mock.Anything, calendarID).Return([]db.GetDayEntriesByCalendarIDRow{
+			{ID: uuid.New(), CalendarID: calendarID, Date: day("2024-01-16"), ColorMeaningID: badID},
+			{ID: uuid.New(), CalendarID: calendarID, Date: day("2024-01-15"), ColorMeaningID: goodID, Notes: sql.NullString{String: "walk", Valid: true}},
+		}, nil)
+
+		var archive bytes.Buffer
+		require.NoError(t, NewExportService(exportRepo).Export(ctx, userID, ExportFormatCSV, &archive))
+
+		service, repo, _, _ := newService()
+		repo.On("GetCalendarsByUserID", mock.Anything, userID).Return([]db.Calendar{}, nil).Once()
+		expectCreate(repo, "Mood")
+
+		result, err := service.Import(ctx, userID, &archive, ImportOptions{Format: ExportFormatCSV})
+		require.NoError(t, err)
+		assert.Equal(t, ImportSummary{CalendarsCreated: 1, ColorMeaningsCreated: 2, DayEntriesCreated: 2}, result.Summary)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("repository errors abort the import", func(t *testing.T) {
+		service, repo, tx, events := newService()
+		repo.On("GetCalendarsByUserID", mock.Anything, userID).Return([]db.Calendar{}, nil).Once()
+		repo.On("CreateCalendar", mock.Anything, mock.Anything).Return(db.Calendar{}, assert.AnError).Once()
+
+		_, err := service.Import(ctx, userID, importJSON(t, document), ImportOptions{})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.False(t, tx.committed)
+		events.AssertNotCalled(t, "CreateAuditEvent", mock.Anything, mock.Anything)
+	})
+}
+
+func TestImportService_InvalidInput(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	goodID := uuid.New()
+
+	valid := func() ExportCalendar {
+		return ExportCalendar{
+			Name:          "Mood",
+			ColorMeanings: []ExportColorMeaning{{ID: goodID, ColorHex: "#00FF00", Meaning: "good"}},
+			DayEntries:    []ExportDayEntry{{Date: "2024-01-15", ColorMeaningID: goodID}},
+		}
+	}
+
+	zipped := func(files map[string]string) []byte {
+		var buf bytes.Buffer
+		archive := zip.NewWriter(&buf)
+		for name, content := range files {
+			f, _ := archive.Create(name)
+			f.Write([]byte(content))
+		}
+		archive.Close()
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name          string
+		body          func() []byte
+		opts          ImportOptions
+		expectedError error
+		contains      string
+	}{
+		{
+			name:          "newer schema",
+			body:          func() []byte { b, _ := json.Marshal(ExportDocument{SchemaVersion: ExportSchemaVersion + 1}); return b },
+			expectedError: ErrUnsupportedSchemaVersion,
+		},
+		{
+			name:          "not JSON",
+			body:          func() []byte { return []byte("{calendars") },
+			expectedError: ErrInvalidImport,
+		},
+		{
+			name: "invalid color",
+			body: func() []byte {
+				c := valid()
+				c.ColorMeanings[0].ColorHex = "green"
+				b, _ := json.Marshal(ExportDocument{SchemaVersion: 1, Calendars: []ExportCalendar{c}})
+				return b
+			},
+			expectedError: ErrInvalidImport,
+			contains:      ErrInvalidColorHex.Error(),
+		},
+		{
+			name: "duplicate date",
+			body: func() []byte {
+				c := valid()
+				c.DayEntries = append(c.DayEntries, c.DayEntries[0])
+				b, _ := json.Marshal(ExportDocument{SchemaVersion: 1, Calendars: []ExportCalendar{c}})
+				return b
+			},
+			expectedError: ErrInvalidImport,
+			contains:      ErrDayEntryExists.Error(),
+		},
+		{
+			name: "entry with a meaning of another calendar",
+			body: func() []byte {
+				c := valid()
+				c.DayEntries[0].ColorMeaningID = uuid.New()
+				b, _ := json.Marshal(ExportDocument{SchemaVersion: 1, Calendars: []ExportCalendar{c}})
+				return b
+			},
+			expectedError: ErrInvalidImport,
+			contains:      ErrColorMeaningMismatch.Error(),
+		},
+		{
+			name:          "not a zip archive",
+			body:          func() []byte { return []byte("id,name") },
+			opts:          ImportOptions{Format: ExportFormatCSV},
+			expectedError: ErrInvalidImport,
+		},
+		{
+			name:          "archive without tables",
+			body:          func() []byte { return zipped(map[string]string{ExportManifestFile: `{"schema_version":1}`}) },
+			opts:          ImportOptions{Format: ExportFormatCSV},
+			expectedError: ErrInvalidImport,
+			contains:      ExportCalendarsFile + " is missing",
+		},
+		{
+			name: "archive with unexpected columns",
+			body: func() []byte {
+				return zipped(map[string]string{
+					ExportManifestFile:  `{"schema_version":1}`,
+					ExportCalendarsFile: "id,title,description,created_at,updated_at\n",
+				})
+			},
+			opts:          ImportOptions{Format: ExportFormatCSV},
+			expectedError: ErrInvalidImport,
+			contains:      `expected column "name"`,
+		},
+		{
+			name:          "unknown format",
+			body:          func() []byte { return nil },
+			opts:          ImportOptions{Format: "xml"},
+			expectedError: ErrInvalidImportFormat,
+		},
+		{
+			name:          "unknown calendar strategy",
+			body:          func() []byte { return nil },
+			opts:          ImportOptions{CalendarConflict: "replace"},
+			expectedError: ErrInvalidCalendarConflict,
+		},
+		{
+			name:          "unknown entry strategy",
+			body:          func() []byte { return nil },
+			opts:          ImportOptions{EntryConflict: "merge"},
+			expectedError: ErrInvalidEntryConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &fakeImportTransactor{repo: new(MockImportRepository)}
+			service := NewImportService(tx, nil)
+
+			_, err := service.Import(ctx, userID, bytes.NewReader(tt.body()), tt.opts)
+			assert.ErrorIs(t, err, tt.expectedError)
+			if tt.contains != "" {
+				assert.Contains(t, err.Error(), tt.contains)
+			}
+			assert.Zero(t, tx.calls, "nothing should be written for invalid input")
+		})
+	}
+}
+
+func TestImportService_freeCalendarName(t *testing.T) {
+	service := &ImportService{}
+
+	name, err := service.freeCalendarName("Mood", map[string]db.Calendar{"mood": {}})
+	require.NoError(t, err)
+	assert.Equal(t, "Mood (2)", name)
+
+	long := strings.Repeat("é", 50) // 100 bytes
+	name, err = service.freeCalendarName(long, map[string]db.Calendar{})
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(name), 100)
+	assert.True(t, strings.HasSuffix(name, "é (2)"))
+}
@@ -2,7 +2,11 @@ package services
 
 import (
 	"context"
+	"days/internal/auth"
 	"days/internal/db"
+	"days/internal/ratelimit"
+	"io"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -12,6 +16,42 @@ type UserRepository interface {
 	CreateUser(ctx context.Context, arg db.CreateUserParams) (db.User, error)
 	GetUserByEmail(ctx context.Context, email string) (db.User, error)
 	GetUserByID(ctx context.Context, id uuid.UUID) (db.User, error)
+	UpdateUserPassword(ctx context.Context, arg db.UpdateUserPasswordParams) error
+	UpdateUserEmail(ctx context.Context, arg db.UpdateUserEmailParams) error
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+	RevokeSessionsByUserID(ctx context.Context, userID uuid.UUID) error
+	InvalidateAccountTokens(ctx context.Context, arg db.InvalidateAccountTokensParams) error
+	CreateAuditEvent(ctx context.Context, arg db.CreateAuditEventParams) error
+}
+
+// AccountRepository defines the database operations behind password resets and email verification
+type AccountRepository interface {
+	GetUserByEmail(ctx context.Context, email string) (db.User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (db.User, error)
+	UpdateUserPassword(ctx context.Context, arg db.UpdateUserPasswordParams) error
+	MarkUserEmailVerified(ctx context.Context, id uuid.UUID) error
+	CreateAccountToken(ctx context.Context, arg db.CreateAccountTokenParams) (db.AccountToken, error)
+	ConsumeAccountToken(ctx context.Context, arg db.ConsumeAccountTokenParams) (db.AccountToken, error)
+	InvalidateAccountTokens(ctx context.Context, arg db.InvalidateAccountTokensParams) error
+	RevokeSessionsByUserID(ctx context.Context, userID uuid.UUID) error
+	CreateAuditEvent(ctx context.Context, arg db.CreateAuditEventParams) error
+}
+
+// TwoFactorRepository defines the database operations behind TOTP two-factor authentication
+type TwoFactorRepository interface {
+	GetUserByID(ctx context.Context, id uuid.UUID) (db.User, error)
+	SetUserTOTPSecret(ctx context.Context, arg db.SetUserTOTPSecretParams) error
+	EnableUserTOTP(ctx context.Context, arg db.EnableUserTOTPParams) (int64, error)
+	DisableUserTOTP(ctx context.Context, id uuid.UUID) error
+	UseTOTPStep(ctx context.Context, arg db.UseTOTPStepParams) (int64, error)
+	CreateRecoveryCode(ctx context.Context, arg db.CreateRecoveryCodeParams) error
+	DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error
+	UseRecoveryCode(ctx context.Context, arg db.UseRecoveryCodeParams) (int64, error)
+	CountUnusedRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error)
+	CreateTwoFactorChallenge(ctx context.Context, arg db.CreateTwoFactorChallengeParams) (db.TwoFactorChallenge, error)
+	GetTwoFactorChallenge(ctx context.Context, arg db.GetTwoFactorChallengeParams) (db.TwoFactorChallenge, error)
+	RecordTwoFactorChallengeFailure(ctx context.Context, id uuid.UUID) error
+	DeleteTwoFactorChallenge(ctx context.Context, id uuid.UUID) (int64, error)
 }
 
 // CalendarRepository defines the interface for calendar database operations
@@ -23,24 +63,369 @@ type CalendarRepository interface {
 	DeleteCalendar(ctx context.Context, id uuid.UUID) error
 }
 
+// CalendarAccessRepository defines the lookup every calendar resource is authorized with
+type CalendarAccessRepository interface {
+	GetCalendarWithRole(ctx context.Context, arg db.GetCalendarWithRoleParams) (db.GetCalendarWithRoleRow, error)
+}
+
+// CalendarMemberRepository defines the interface for calendar sharing database operations
+type CalendarMemberRepository interface {
+	CalendarAccessRepository
+	GetUserByID(ctx context.Context, id uuid.UUID) (db.User, error)
+	GetCalendarMembers(ctx context.Context, calendarID uuid.UUID) ([]db.GetCalendarMembersRow, error)
+	UpdateCalendarMemberRole(ctx context.Context, arg db.UpdateCalendarMemberRoleParams) (db.CalendarMember, error)
+	DeleteCalendarMember(ctx context.Context, arg db.DeleteCalendarMemberParams) (int64, error)
+	UpsertCalendarInvitation(ctx context.Context, arg db.UpsertCalendarInvitationParams) (db.CalendarInvitation, error)
+	GetCalendarInvitationsByCalendarID(ctx context.Context, calendarID uuid.UUID) ([]db.CalendarInvitation, error)
+	GetCalendarInvitationsByEmail(ctx context.Context, email string) ([]db.GetCalendarInvitationsByEmailRow, error)
+	AcceptCalendarInvitation(ctx context.Context, arg db.AcceptCalendarInvitationParams) (db.CalendarMember, error)
+	DeleteCalendarInvitation(ctx context.Context, arg db.DeleteCalendarInvitationParams) (int64, error)
+}
+
+// SessionRepository defines the interface for session database operations
+type SessionRepository interface {
+	CreateSession(ctx context.Context, arg db.CreateSessionParams) (db.Session, error)
+	GetSessionByID(ctx context.Context, id uuid.UUID) (db.Session, error)
+	GetSessionByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (db.Session, error)
+	GetActiveSessionsByUserID(ctx context.Context, userID uuid.UUID) ([]db.Session, error)
+	RotateSessionRefreshToken(ctx context.Context, arg db.RotateSessionRefreshTokenParams) (db.Session, error)
+	RevokeSession(ctx context.Context, id uuid.UUID) error
+}
+
+// AuditRepository defines the interface for reading the audit log
+type AuditRepository interface {
+	ListAuditEventsByUser(ctx context.Context, arg db.ListAuditEventsByUserParams) ([]db.AuditEvent, error)
+}
+
+// APIKeyRepository defines the interface for API key database operations
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, arg db.CreateAPIKeyParams) (db.ApiKey, error)
+	GetAPIKeysByUserID(ctx context.Context, userID uuid.UUID) ([]db.ApiKey, error)
+	GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (db.ApiKey, error)
+	TouchAPIKey(ctx context.Context, id uuid.UUID) error
+	DeleteAPIKey(ctx context.Context, arg db.DeleteAPIKeyParams) (int64, error)
+}
+
+// OIDCRepository defines the database operations behind single sign-on
+type OIDCRepository interface {
+	GetUserByID(ctx context.Context, id uuid.UUID) (db.User, error)
+	GetUserByEmail(ctx context.Context, email string) (db.User, error)
+	CreatePasswordlessUser(ctx context.Context, email string) (db.User, error)
+	CreateOIDCLoginState(ctx context.Context, arg db.CreateOIDCLoginStateParams) error
+	ConsumeOIDCLoginState(ctx context.Context, stateHash string) (db.OidcLoginState, error)
+	DeleteExpiredOIDCLoginStates(ctx context.Context) error
+	GetUserIdentity(ctx context.Context, arg db.GetUserIdentityParams) (db.UserIdentity, error)
+	CreateUserIdentity(ctx context.Context, arg db.CreateUserIdentityParams) (db.UserIdentity, error)
+	RecordUserIdentityLogin(ctx context.Context, arg db.RecordUserIdentityLoginParams) error
+}
+
+// StatsRepository defines the database operations statistics are computed from
+type StatsRepository interface {
+	CalendarAccessRepository
+	GetColorMeaningsByCalendarID(ctx context.Context, calendarID uuid.UUID) ([]db.ColorMeaning, error)
+	GetDayEntryDatesByCalendarAndDateRange(ctx context.Context, arg db.GetDayEntryDatesByCalendarAndDateRangeParams) ([]db.GetDayEntryDatesByCalendarAndDateRangeRow, error)
+}
+
+// RenderRepository defines the database operations calendar images are drawn from
+type RenderRepository interface {
+	CalendarAccessRepository
+	GetColorMeaningsByCalendarID(ctx context.Context, calendarID uuid.UUID) ([]db.ColorMeaning, error)
+	GetDayEntriesByCalendarID(ctx context.Context, calendarID uuid.UUID) ([]db.GetDayEntriesByCalendarIDRow, error)
+}
+
+// ExportRepository defines the database operations a user's data is exported from
+type ExportRepository interface {
+	GetCalendarsByUserID(ctx context.Context, userID uuid.UUID) ([]db.Calendar, error)
+	GetColorMeaningsByCalendarID(ctx context.Context, calendarID uuid.UUID) ([]db.ColorMeaning, error)
+	GetDayEntriesByCalendarID(ctx context.Context, calendarID uuid.UUID) ([]db.GetDayEntriesByCalendarIDRow, error)
+}
+
+// ICalRepository defines the database operations behind calendar feeds
+type ICalRepository interface {
+	CalendarAccessRepository
+	GetCalendarByID(ctx context.Context, id uuid.UUID) (db.Calendar, error)
+	GetDayEntriesByCalendarID(ctx context.Context, calendarID uuid.UUID) ([]db.GetDayEntriesByCalendarIDRow, error)
+	UpsertCalendarFeedToken(ctx context.Context, arg db.UpsertCalendarFeedTokenParams) (db.CalendarFeedToken, error)
+	GetCalendarFeedToken(ctx context.Context, calendarID uuid.UUID) (db.CalendarFeedToken, error)
+	DeleteCalendarFeedToken(ctx context.Context, calendarID uuid.UUID) (int64, error)
+}
+
+// AdminRepository defines the database operations behind the operator tasks of daysctl
+type AdminRepository interface {
+	UserRepository
+	ListUsers(ctx context.Context) ([]db.ListUsersRow, error)
+	DisableUser(ctx context.Context, id uuid.UUID) (int64, error)
+	EnableUser(ctx context.Context, id uuid.UUID) (int64, error)
+	GetCalendarByID(ctx context.Context, id uuid.UUID) (db.Calendar, error)
+	GetCalendarsByUserID(ctx context.Context, userID uuid.UUID) ([]db.Calendar, error)
+	TransferCalendar(ctx context.Context, arg db.TransferCalendarParams) (db.TransferCalendarRow, error)
+	ListIntegrityProblems(ctx context.Context) ([]db.ListIntegrityProblemsRow, error)
+}
+
+// ImportRepository defines the database operations an import writes through
+type ImportRepository interface {
+	GetCalendarsByUserID(ctx context.Context, userID uuid.UUID) ([]db.Calendar, error)
+	CreateCalendar(ctx context.Context, arg db.CreateCalendarParams) (db.Calendar, error)
+	GetColorMeaningsByCalendarID(ctx context.Context, calendarID uuid.UUID) ([]db.ColorMeaning, error)
+	CreateColorMeaning(ctx context.Context, arg db.CreateColorMeaningParams) (db.ColorMeaning, error)
+	GetDayEntriesByCalendarID(ctx context.Context, calendarID uuid.UUID) ([]db.GetDayEntriesByCalendarIDRow, error)
+	CreateDayEntry(ctx context.Context, arg db.CreateDayEntryParams) (db.DayEntry, error)
+	UpdateDayEntry(ctx context.Context, arg db.UpdateDayEntryParams) (db.DayEntry, error)
+}
+
+// ImportTransactor runs fn with an ImportRepository bound to a single database transaction
+type ImportTransactor interface {
+	InTx(ctx context.Context, fn func(q ImportRepository) error) error
+}
+
+// SessionIssuer starts a session for a user whose identity has been verified
+type SessionIssuer interface {
+	CreateSession(ctx context.Context, userID uuid.UUID, meta SessionMetadata) (*TokenResponse, error)
+}
+
+// TokenSigner signs the access tokens of sessions
+type TokenSigner interface {
+	GenerateSessionToken(userID, sessionID uuid.UUID, ttl time.Duration) (string, error)
+}
+
+// EmailVerifier mails a new user the link that verifies their email address
+type EmailVerifier interface {
+	SendVerificationEmail(ctx context.Context, userID uuid.UUID) error
+}
+
+// TwoFactorChallenger issues the challenge a password login returns when the user has 2FA on
+type TwoFactorChallenger interface {
+	CreateChallenge(ctx context.Context, userID uuid.UUID) (string, error)
+}
+
+// LoginLockout locks out login attempts for a key after repeated failures
+type LoginLockout interface {
+	Check(ctx context.Context, key string) (time.Duration, error)
+	Fail(ctx context.Context, key string) (time.Duration, error)
+	Reset(ctx context.Context, key string) error
+}
+
 // UserServiceInterface defines the interface for user business logic
 type UserServiceInterface interface {
 	CreateUser(ctx context.Context, req CreateUserRequest) (*UserResponse, error)
 	GetUserByID(ctx context.Context, userID uuid.UUID) (*UserResponse, error)
 	Login(ctx context.Context, req LoginRequest) (*LoginResponse, error)
+	ChangePassword(ctx context.Context, userID uuid.UUID, req ChangePasswordRequest) (*TokenResponse, error)
+	ChangeEmail(ctx context.Context, userID uuid.UUID, req ChangeEmailRequest) (*UserResponse, error)
+	DeleteAccount(ctx context.Context, userID uuid.UUID, req DeleteAccountRequest) error
 }
 
 // CalendarServiceInterface defines the interface for calendar business logic
 type CalendarServiceInterface interface {
 	CreateCalendar(ctx context.Context, userID uuid.UUID, req CreateCalendarRequest) (*CalendarResponse, error)
-	GetCalendarsByUserID(ctx context.Context, userID uuid.UUID) ([]*CalendarResponse, error)
+	GetCalendarsByUserID(ctx context.Context, userID uuid.UUID, req CalendarListRequest) (*CalendarPage, error)
+	GetAllCalendarsByUserID(ctx context.Context, userID uuid.UUID, req CalendarListRequest) ([]*CalendarResponse, error)
 	GetCalendarByID(ctx context.Context, userID, calendarID uuid.UUID) (*CalendarResponse, error)
 	UpdateCalendar(ctx context.Context, userID, calendarID uuid.UUID, req UpdateCalendarRequest) (*CalendarResponse, error)
 	DeleteCalendar(ctx context.Context, userID, calendarID uuid.UUID) error
 }
 
+// SessionServiceInterface defines the interface for session business logic
+type SessionServiceInterface interface {
+	SessionIssuer
+	Refresh(ctx context.Context, refreshToken string, meta SessionMetadata) (*TokenResponse, error)
+	GetActiveSessions(ctx context.Context, userID, currentSessionID uuid.UUID) ([]*SessionResponse, error)
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error
+	IsSessionActive(ctx context.Context, sessionID uuid.UUID) (bool, error)
+}
+
+// AuditServiceInterface defines the interface for reading the audit log
+type AuditServiceInterface interface {
+	GetAuditEvents(ctx context.Context, userID uuid.UUID, cursor string, limit int) (*AuditEventPage, error)
+}
+
+// ColorMeaningServiceInterface defines the interface for color meaning business logic
+type ColorMeaningServiceInterface interface {
+	CreateColorMeaning(ctx context.Context, userID, calendarID uuid.UUID, req CreateColorMeaningRequest) (*ColorMeaningResponse, error)
+	GetColorMeaningsByCalendarID(ctx context.Context, userID, calendarID uuid.UUID) ([]*ColorMeaningResponse, error)
+	GetCalendarColorMeaning(ctx context.Context, userID, calendarID, colorMeaningID uuid.UUID) (*ColorMeaningResponse, error)
+	UpdateColorMeaning(ctx context.Context, userID, calendarID, colorMeaningID uuid.UUID, req UpdateColorMeaningRequest) (*ColorMeaningResponse, error)
+	DeleteColorMeaning(ctx context.Context, userID, calendarID, colorMeaningID uuid.UUID) error
+}
+
+// DayEntryServiceInterface defines the interface for day entry business logic
+type DayEntryServiceInterface interface {
+	CreateDayEntry(ctx context.Context, userID, calendarID uuid.UUID, req CreateDayEntryRequest) (*DayEntryResponse, error)
+	GetDayEntriesByCalendarID(ctx context.Context, userID, calendarID uuid.UUID, req DayEntryListRequest) (*DayEntryPage, error)
+	GetAllDayEntriesByCalendarID(ctx context.Context, userID, calendarID uuid.UUID, req DayEntryListRequest) ([]*DayEntryResponse, error)
+	GetDayEntriesByDateRange(ctx context.Context, userID uuid.UUID, req DateRangeRequest) ([]*DayEntryResponse, error)
+	GetDayEntryByCalendarAndDate(ctx context.Context, userID, calendarID uuid.UUID, dateStr string) (*DayEntryResponse, error)
+	UpdateDayEntry(ctx context.Context, userID, calendarID uuid.UUID, dateStr string, req UpdateDayEntryRequest) (*DayEntryResponse, error)
+	DeleteDayEntry(ctx context.Context, userID, calendarID uuid.UUID, dateStr string) error
+}
+
+// StatsServiceInterface defines the interface for calendar statistics
+type StatsServiceInterface interface {
+	GetCalendarStats(ctx context.Context, userID, calendarID uuid.UUID, req StatsRequest) (*CalendarStatsResponse, error)
+}
+
+// RenderServiceInterface defines the interface for calendar image rendering
+type RenderServiceInterface interface {
+	RenderCalendar(ctx context.Context, userID, calendarID uuid.UUID, req RenderRequest) (*RenderedCalendar, error)
+}
+
+// ExportServiceInterface defines the interface for exporting a user's data
+type ExportServiceInterface interface {
+	Export(ctx context.Context, userID uuid.UUID, format string, w io.Writer) error
+}
+
+// ICalServiceInterface defines the interface for iCalendar feeds
+type ICalServiceInterface interface {
+	CreateFeedToken(ctx context.Context, userID, calendarID uuid.UUID) (*FeedTokenResponse, error)
+	RevokeFeedToken(ctx context.Context, userID, calendarID uuid.UUID) error
+	GetCalendarFeed(ctx context.Context, calendarID uuid.UUID, token string) ([]byte, error)
+}
+
+// CalendarMemberServiceInterface defines the interface for calendar sharing
+type CalendarMemberServiceInterface interface {
+	InviteMember(ctx context.Context, userID, calendarID uuid.UUID, req InviteMemberRequest) (*InvitationResponse, error)
+	GetCalendarInvitations(ctx context.Context, userID, calendarID uuid.UUID) ([]*InvitationResponse, error)
+	GetInvitations(ctx context.Context, userID uuid.UUID) ([]*InvitationResponse, error)
+	AcceptInvitation(ctx context.Context, userID, invitationID uuid.UUID) (*CalendarMemberResponse, error)
+	DeclineInvitation(ctx context.Context, userID, invitationID uuid.UUID) error
+	GetMembers(ctx context.Context, userID, calendarID uuid.UUID) ([]*CalendarMemberResponse, error)
+	UpdateMemberRole(ctx context.Context, userID, calendarID, memberID uuid.UUID, req UpdateMemberRoleRequest) (*CalendarMemberResponse, error)
+	RemoveMember(ctx context.Context, userID, calendarID, memberID uuid.UUID) error
+}
+
+// AccountServiceInterface defines the interface for password resets and email verification
+type AccountServiceInterface interface {
+	EmailVerifier
+	ForgotPassword(ctx context.Context, req ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req ResetPasswordRequest) error
+	VerifyEmail(ctx context.Context, req VerifyEmailRequest) error
+}
+
+// TwoFactorServiceInterface defines the interface for TOTP two-factor authentication
+type TwoFactorServiceInterface interface {
+	TwoFactorChallenger
+	GetStatus(ctx context.Context, userID uuid.UUID) (*TwoFactorStatusResponse, error)
+	Enroll(ctx context.Context, userID uuid.UUID) (*TOTPEnrollmentResponse, error)
+	Confirm(ctx context.Context, userID uuid.UUID, req TwoFactorCodeRequest) (*RecoveryCodesResponse, error)
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, req TwoFactorCodeRequest) (*RecoveryCodesResponse, error)
+	Disable(ctx context.Context, userID uuid.UUID, req DisableTwoFactorRequest) error
+	VerifyLogin(ctx context.Context, req TwoFactorLoginRequest) (*LoginResponse, error)
+}
+
+// APIKeyServiceInterface defines the interface for API key business logic
+type APIKeyServiceInterface interface {
+	CreateAPIKey(ctx context.Context, userID uuid.UUID, req CreateAPIKeyRequest) (*CreatedAPIKeyResponse, error)
+	GetAPIKeys(ctx context.Context, userID uuid.UUID) ([]*APIKeyResponse, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) error
+	AuthenticateAPIKey(ctx context.Context, key string) (*APIKeyPrincipal, error)
+}
+
+// OIDCServiceInterface defines the interface for single sign-on
+type OIDCServiceInterface interface {
+	StartLogin(ctx context.Context) (*OIDCLoginResponse, error)
+	CompleteLogin(ctx context.Context, req OIDCCallbackRequest) (*LoginResponse, error)
+}
+
+// ImportServiceInterface defines the interface for importing exported data
+type ImportServiceInterface interface {
+	Import(ctx context.Context, userID uuid.UUID, r io.Reader, opts ImportOptions) (*ImportResult, error)
+}
+
 // Ensure db.Queries implements UserRepository
 var _ UserRepository = (*db.Queries)(nil)
 
+// Ensure db.Queries implements CalendarMemberRepository
+var _ CalendarMemberRepository = (*db.Queries)(nil)
+
+// Ensure db.Queries implements AccountRepository
+var _ AccountRepository = (*db.Queries)(nil)
+
+// Ensure db.Queries implements TwoFactorRepository
+var _ TwoFactorRepository = (*db.Queries)(nil)
+
+// Ensure db.Queries implements SessionRepository
+var _ SessionRepository = (*db.Queries)(nil)
+
+// Ensure db.Queries implements APIKeyRepository
+var _ APIKeyRepository = (*db.Queries)(nil)
+
+// Ensure db.Queries implements OIDCRepository
+var _ OIDCRepository = (*db.Queries)(nil)
+
+// Ensure db.Queries implements StatsRepository
+var _ StatsRepository = (*db.Queries)(nil)
+
+// Ensure db.Queries implements RenderRepository
+var _ RenderRepository = (*db.Queries)(nil)
+
+// Ensure db.Queries implements ExportRepository
+var _ ExportRepository = (*db.Queries)(nil)
+
+// Ensure db.Queries implements ICalRepository
+var _ ICalRepository = (*db.Queries)(nil)
+
+// Ensure db.Queries implements ImportRepository
+var _ ImportRepository = (*db.Queries)(nil)
+
+// Ensure db.Queries implements AdminRepository
+var _ AdminRepository = (*db.Queries)(nil)
+
+// Ensure SQLImportTransactor implements ImportTransactor
+var _ ImportTransactor = (*SQLImportTransactor)(nil)
+
+// Ensure ratelimit.Lockout implements LoginLockout
+var _ LoginLockout = (*ratelimit.Lockout)(nil)
+
+// Ensure db.Queries implements AuditRepository
+var _ AuditRepository = (*db.Queries)(nil)
+
 // Ensure UserService implements UserServiceInterface
 var _ UserServiceInterface = (*UserService)(nil)
+
+// Ensure AuditService implements AuditServiceInterface
+var _ AuditServiceInterface = (*AuditService)(nil)
+
+// Ensure ColorMeaningService implements ColorMeaningServiceInterface
+var _ ColorMeaningServiceInterface = (*ColorMeaningService)(nil)
+
+// Ensure DayEntryService implements DayEntryServiceInterface
+var _ DayEntryServiceInterface = (*DayEntryService)(nil)
+
+// Ensure SessionService implements SessionServiceInterface
+var _ SessionServiceInterface = (*SessionService)(nil)
+
+// Ensure StatsService implements StatsServiceInterface
+var _ StatsServiceInterface = (*StatsService)(nil)
+
+// Ensure RenderService implements RenderServiceInterface
+var _ RenderServiceInterface = (*RenderService)(nil)
+
+// Ensure ExportService implements ExportServiceInterface
+var _ ExportServiceInterface = (*ExportService)(nil)
+
+// Ensure ImportService implements ImportServiceInterface
+var _ ImportServiceInterface = (*ImportService)(nil)
+
+// Ensure ICalService implements ICalServiceInterface
+var _ ICalServiceInterface = (*ICalService)(nil)
+
+// Ensure CalendarMemberService implements CalendarMemberServiceInterface
+var _ CalendarMemberServiceInterface = (*CalendarMemberService)(nil)
+
+// Ensure AccountService implements AccountServiceInterface
+var _ AccountServiceInterface = (*AccountService)(nil)
+
+// Ensure TwoFactorService implements TwoFactorServiceInterface
+var _ TwoFactorServiceInterface = (*TwoFactorService)(nil)
+
+// Ensure APIKeyService implements APIKeyServiceInterface
+var _ APIKeyServiceInterface = (*APIKeyService)(nil)
+
+// Ensure OIDCService implements OIDCServiceInterface
+var _ OIDCServiceInterface = (*OIDCService)(nil)
+
+// Ensure auth.OIDCProvider implements OIDCAuthenticator
+var _ OIDCAuthenticator = (*auth.OIDCProvider)(nil)
+
+// Ensure auth.Keyring implements TokenSigner
+var _ TokenSigner = (*auth.Keyring)(nil)
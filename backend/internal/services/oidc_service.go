@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"days/internal/auth"
+	"days/internal/db"
+
+	"github.com/google/uuid"
+)
+
+// oidcStateTTL bounds how long a user may spend at the provider's login page
+const oidcStateTTL = 10 * time.Minute
+
+var (
+	ErrInvalidOIDCState       = errors.New("invalid or expired single sign-on state")
+	ErrOIDCLoginFailed        = errors.New("single sign-on failed")
+	ErrOIDCEmailNotVerified   = errors.New("the identity provider has not verified this email address")
+	ErrOIDCAccountNotVerified = errors.New("verify the email address of your account before signing in with single sign-on")
+)
+
+// OIDCAuthenticator runs the provider's side of an authorization code login
+type OIDCAuthenticator interface {
+	Issuer() string
+	AuthCodeURL(state, nonce, codeVerifier string) string
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*auth.OIDCIdentity, error)
+}
+
+// OIDCService signs users in through an OpenID Connect provider. A login
+// starts with a state whose nonce and PKCE verifier stay in the database, so
+// the browser only ever carries the state itself. The provider's identity is
+// linked to a local user, who then gets the same tokens a password login
+// returns.
+type OIDCService struct {
+	queries    OIDCRepository
+	provider   OIDCAuthenticator
+	sessions   SessionIssuer
+	challenges TwoFactorChallenger
+	now        func() time.Time
+}
+
+type OIDCLoginResponse struct {
+	AuthorizationURL string `json:"authorization_url" example:"https://accounts.example.com/authorize?client_id=days&..."`
+}
+
+// OIDCCallbackRequest carries what the provider redirected the browser back with
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" example:"SplxlOBeZQQYbYS6WxSbIA" binding:"required"`
+	State string `json:"state" example:"3q2-7wAAAAA..." binding:"required"`
+
+	// Device details of the session to open, filled in by the handler
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
+
+// NewOIDCService creates a new OIDCService. challenges may be nil when 2FA
+// is not configured.
+func NewOIDCService(queries OIDCRepository, provider OIDCAuthenticator, sessions SessionIssuer, challenges TwoFactorChallenger) *OIDCService {
+	return &OIDCService{
+		queries:    queries,
+		provider:   provider,
+		sessions:   sessions,
+		challenges: challenges,
+		now:        time.Now,
+	}
+}
+
+// StartLogin begins a login and returns the provider URL to send the browser to
+func (s *OIDCService) StartLogin(ctx context.Context) (*OIDCLoginResponse, error) {
+	ctx, span := tracer.Start(ctx, "OIDCService.StartLogin")
+	defer span.End()
+
+	// Abandoned logins are cleared here rather than by a background job
+	if err := s.queries.DeleteExpiredOIDCLoginStates(ctx); err != nil {
+		slog.ErrorContext(ctx, "failed to delete expired OIDC login states", "error", err)
+	}
+
+	state, stateHash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate state: %w", err)
+	}
+	nonce, _, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	verifier, err := auth.GeneratePKCEVerifier()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate code verifier: %w", err)
+	}
+
+	if err := s.queries.CreateOIDCLoginState(ctx, db.CreateOIDCLoginStateParams{
+		StateHash:    stateHash,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    s.now().Add(oidcStateTTL),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create login state: %w", err)
+	}
+
+	return &OIDCLoginResponse{AuthorizationURL: s.provider.AuthCodeURL(state, nonce, verifier)}, nil
+}
+
+// CompleteLogin finishes a login with the code and state the provider
+// redirected back with. Users with 2FA on get a challenge token, as with a
+// password login.
+func (s *OIDCService) CompleteLogin(ctx context.Context, req OIDCCallbackRequest) (*LoginResponse, error) {
+	ctx, span := tracer.Start(ctx, "OIDCService.CompleteLogin")
+	defer span.End()
+
+	if req.Code == "" || req.State == "" {
+		return nil, ErrInvalidOIDCState
+	}
+
+	// A state completes at most one login
+	loginState, err := s.queries.ConsumeOIDCLoginState(ctx, auth.HashOpaqueToken(req.State))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidOIDCState
+		}
+		return nil, fmt.Errorf("failed to get login state: %w", err)
+	}
+
+	identity, err := s.provider.Exchange(ctx, req.Code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		// The details are for the operator, not for whoever holds the code
+		slog.WarnContext(ctx, "OIDC code exchange failed", "error", err)
+		return nil, ErrOIDCLoginFailed
+	}
+
+	user, err := s.linkedUser(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	if user.DisabledAt.Valid {
+		return nil, ErrAccountDisabled
+	}
+
+	if user.TotpEnabledAt.Valid {
+		if s.challenges == nil {
+			return nil, errors.New("two-factor authentication is not configured")
+		}
+		challenge, err := s.challenges.CreateChallenge(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResponse{TwoFactorRequired: true, ChallengeToken: challenge}, nil
+	}
+
+	tokens, err := s.sessions.CreateSession(ctx, user.ID, SessionMetadata{
+		UserAgent: req.UserAgent,
+		IPAddress: req.IPAddress,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResponse{
+		User:         toUserResponse(user),
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+	}, nil
+}
+
+// linkedUser returns the local user of a provider identity. An identity seen
+// before maps to its user. A new one is linked by its email address, which
+// the provider must have verified: to the account with that address, or to
+// a new password-less account.
+func (s *OIDCService) linkedUser(ctx context.Context, identity *auth.OIDCIdentity) (db.User, error) {
+	linked, err := s.queries.GetUserIdentity(ctx, db.GetUserIdentityParams{
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+	})
+	if err == nil {
+		if err := s.queries.RecordUserIdentityLogin(ctx, db.RecordUserIdentityLoginParams{
+			ID:    linked.ID,
+			Email: identity.Email,
+		}); err != nil {
+			return db.User{}, fmt.Errorf("failed to record login: %w", err)
+		}
+		return s.getUser(ctx, linked.UserID)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return db.User{}, fmt.Errorf("failed to get identity: %w", err)
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return db.User{}, ErrOIDCEmailNotVerified
+	}
+
+	user, err := s.queries.GetUserByEmail(ctx, identity.Email)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		user, err = s.queries.CreatePasswordlessUser(ctx, identity.Email)
+		if err != nil {
+			return db.User{}, fmt.Errorf("failed to create user: %w", err)
+		}
+	case err != nil:
+		return db.User{}, fmt.Errorf("failed to get user: %w", err)
+	case !user.EmailVerifiedAt.Valid:
+		// Anyone can sign up with an address they do not own; linking such an
+		// account would let its creator's password into the owner's account
+		return db.User{}, ErrOIDCAccountNotVerified
+	}
+
+	if _, err := s.queries.CreateUserIdentity(ctx, db.CreateUserIdentityParams{
+		UserID:  user.ID,
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+		Email:   identity.Email,
+	}); err != nil {
+		return db.User{}, fmt.Errorf("failed to link identity: %w", err)
+	}
+	return user, nil
+}
+
+func (s *OIDCService) getUser(ctx context.Context, userID uuid.UUID) (db.User, error) {
+	user, err := s.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.User{}, ErrUserNotFound
+		}
+		return db.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
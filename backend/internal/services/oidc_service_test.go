@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"days/internal/auth"
+	"days/internal/auth/oidctest"
+	"days/internal/db"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockOIDCRepository implements a mock for the OIDCRepository interface
+type MockOIDCRepository struct {
+	mock.Mock
+}
+
+func (m *MockOIDCRepository) GetUserByID(ctx context.Context, id uuid.UUID) (db.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(db.User), args.Error(1)
+}
+
+func (m *MockOIDCRepository) GetUserByEmail(ctx context.Context, email string) (db.User, error) {
+	args := m.Called(ctx, email)
+	return args.Get(0).(db.User), args.Error(1)
+}
+
+func (m *MockOIDCRepository) CreatePasswordlessUser(ctx context.Context, email string) (db.User, error) {
+	args := m.Called(ctx, email)
+	return args.Get(0).(db.User), args.Error(1)
+}
+
+func (m *MockOIDCRepository) CreateOIDCLoginState(ctx context.Context, arg db.CreateOIDCLoginStateParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockOIDCRepository) ConsumeOIDCLoginState(ctx context.Context, stateHash string) (db.OidcLoginState, error) {
+	args := m.Called(ctx, stateHash)
+	return args.Get(0).(db.OidcLoginState), args.Error(1)
+}
+
+func (m *MockOIDCRepository) DeleteExpiredOIDCLoginStates(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockOIDCRepository) GetUserIdentity(ctx context.Context, arg db.GetUserIdentityParams) (db.UserIdentity, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.UserIdentity), args.Error(1)
+}
+
+func (m *MockOIDCRepository) CreateUserIdentity(ctx context.Context, arg db.CreateUserIdentityParams) (db.UserIdentity, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.UserIdentity), args.Error(1)
+}
+
+func (m *MockOIDCRepository) RecordUserIdentityLogin(ctx context.Context, arg db.RecordUserIdentityLoginParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+// oidcLogin runs a login against a fake provider up to the callback: it
+// starts the login, keeps the state the service stored, and has the
+// provider authorize it
+type oidcLogin struct {
+	fake     *oidctest.Provider
+	queries  *MockOIDCRepository
+	sessions *MockSessionIssuer
+	service  *OIDCService
+}
+
+func newOIDCLogin(t *testing.T, user oidctest.User) *oidcLogin {
+	t.Helper()
+	fake := oidctest.NewProvider("days", "client-secret")
+	t.Cleanup(fake.Close)
+	fake.SetUser(user)
+
+	provider, err := auth.DiscoverOIDCProvider(context.Background(), fake.Config("https://days.example.com/auth/oidc/callback"), fake.Client())
+	require.NoError(t, err)
+
+	queries := new(MockOIDCRepository)
+	sessions := new(MockSessionIssuer)
+	return &oidcLogin{
+		fake:     fake,
+		queries:  queries,
+		sessions: sessions,
+		service:  NewOIDCService(queries, provider, sessions, nil),
+	}
+}
+
+// authorize starts a login and returns the callback request the browser would make
+func (l *oidcLogin) authorize(t *testing.T) OIDCCallbackRequest {
+	t.Helper()
+	ctx := context.Background()
+
+	var stored db.CreateOIDCLoginStateParams
+	l.queries.On("DeleteExpiredOIDCLoginStates", mock.Anything).Return(nil).Once()
+	l.queries.On("CreateOIDCLoginState", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(db.CreateOIDCLoginStateParams) }).
+		Return(nil).Once()
+
+	started, err := l.service.StartLogin(ctx)
+	require.NoError(t, err)
+
+	code, state, err := l.fake.Authorize(started.AuthorizationURL)
+	require.NoError(t, err)
+	assert.Equal(t, auth.HashOpaqueToken(state), stored.StateHash)
+
+	l.queries.On("ConsumeOIDCLoginState", mock.Anything, stored.StateHash).Return(db.OidcLoginState{
+		StateHash:    stored.StateHash,
+		CodeVerifier: stored.CodeVerifier,
+		Nonce:        stored.Nonce,
+		ExpiresAt:    stored.ExpiresAt,
+	}, nil).Once()
+	return OIDCCallbackRequest{Code: code, State: state, UserAgent: "Firefox", IPAddress: "203.0.113.7"}
+}
+
+func TestOIDCService_StartLogin(t *testing.T) {
+	login := newOIDCLogin(t, oidctest.User{Subject: "user-1"})
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	login.service.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	login.queries.On("DeleteExpiredOIDCLoginStates", mock.Anything).Return(nil).Once()
+	login.queries.On("CreateOIDCLoginState", mock.Anything, mock.MatchedBy(func(arg db.CreateOIDCLoginStateParams) bool {
+		return arg.StateHash != "" && arg.Nonce != "" && len(arg.CodeVerifier) >= 43 && arg.ExpiresAt.Equal(now.Add(oidcStateTTL))
+	})).Return(nil).Once()
+
+	started, err := login.service.StartLogin(ctx)
+
+	require.NoError(t, err)
+	assert.Contains(t, started.AuthorizationURL, login.fake.URL+"/authorize?")
+	assert.Contains(t, started.AuthorizationURL, "code_challenge_method=S256")
+	login.queries.AssertExpectations(t)
+}
+
+func TestOIDCService_CompleteLogin(t *testing.T) {
+	ctx := context.Background()
+	tokens := &TokenResponse{Token: "access", RefreshToken: "refresh", ExpiresIn: 900}
+	meta := SessionMetadata{UserAgent: "Firefox", IPAddress: "203.0.113.7"}
+	verified := sql.NullTime{Time: time.Now(), Valid: true}
+
+	t.Run("returning identity", func(t *testing.T) {
+		login := newOIDCLogin(t, oidctest.User{Subject: "user-1", Email: "ann@example.com", EmailVerified: true})
+		req := login.authorize(t)
+		user := db.User{ID: uuid.New(), Email: "ann@example.com"}
+		identityID := uuid.New()
+
+		login.queries.On("GetUserIdentity", mock.Anything, db.GetUserIdentityParams{Issuer: login.fake.Issuer(), Subject: "user-1"}).
+			Return(db.UserIdentity{ID: identityID, UserID: user.ID}, nil).Once()
+		login.queries.On("RecordUserIdentityLogin", mock.Anything, db.RecordUserIdentityLoginParams{ID: identityID, Email: "ann@example.com"}).Return(nil).Once()
+		login.queries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+		login.sessions.On("CreateSession", mock.Anything, user.ID, meta).Return(tokens, nil).Once()
+
+		result, err := login.service.CompleteLogin(ctx, req)
+
+		require.NoError(t, err)
+		assert.Equal(t, "access", result.Token)
+		assert.Equal(t, user.ID, result.User.ID)
+		login.queries.AssertExpectations(t)
+		login.sessions.AssertExpectations(t)
+	})
+
+	t.Run("links an account with the verified email", func(t *testing.T) {
+		login := newOIDCLogin(t, oidctest.User{Subject: "user-1", Email: "Ann@Example.com", EmailVerified: true})
+		req := login.authorize(t)
+		user := db.User{ID: uuid.New(), Email: "ann@example.com", EmailVerifiedAt: verified}
+
+		login.queries.On("GetUserIdentity", mock.Anything, mock.Anything).Return(db.UserIdentity{}, sql.ErrNoRows).Once()
+		login.queries.On("GetUserByEmail", mock.Anything, "ann@example.com").Return(user, nil).Once()
+		login.queries.On("CreateUserIdentity", mock.Anything, db.CreateUserIdentityParams{
+			UserID:  user.ID,
+			Issuer:  login.fake.Issuer(),
+			Subject: "user-1",
+			Email:   "ann@example.com",
+		}).Return(db.UserIdentity{}, nil).Once()
+		login.sessions.On("CreateSession", mock.Anything, user.ID, meta).Return(tokens, nil).Once()
+
+		result, err := login.service.CompleteLogin(ctx, req)
+
+		require.NoError(t, err)
+		assert.Equal(t, "refresh", result.RefreshToken)
+		login.queries.AssertExpectations(t)
+	})
+
+	t.Run("creates a password-less account", func(t *testing.T) {
+		login := newOIDCLogin(t, oidctest.User{Subject: "user-1", Email: "new@example.com", EmailVerified: true})
+		req := login.authorize(t)
+		user := db.User{ID: uuid.New(), Email: "new@example.com", EmailVerifiedAt: verified}
+
+		login.queries.On("GetUserIdentity", mock.Anything, mock.Anything).Return(db.UserIdentity{}, sql.ErrNoRows).Once()
+		login.queries.On("GetUserByEmail", mock.Anything, "new@example.com").Return(db.User{}, sql.ErrNoRows).Once()
+		login.queries.On("CreatePasswordlessUser", mock.Anything, "new@example.com").Return(user, nil).Once()
+		login.queries.On("CreateUserIdentity", mock.Anything, mock.Anything).Return(db.UserIdentity{}, nil).Once()
+		login.sessions.On("CreateSession", mock.Anything, user.ID, meta).Return(tokens, nil).Once()
+
+		result, err := login.service.CompleteLogin(ctx, req)
+
+		require.NoError(t, err)
+		assert.True(t, result.User.EmailVerified)
+		login.queries.AssertExpectations(t)
+	})
+
+	t.Run("two-factor challenge", func(t *testing.T) {
+		login := newOIDCLogin(t, oidctest.User{Subject: "user-1", Email: "ann@example.com", EmailVerified: true})
+		challenges := new(MockTwoFactorChallenger)
+		login.service.challenges = challenges
+		req := login.authorize(t)
+		user := db.User{ID: uuid.New(), Email: "ann@example.com", TotpEnabledAt: verified}
+
+		login.queries.On("GetUserIdentity", mock.Anything, mock.Anything).Return(db.UserIdentity{ID: uuid.New(), UserID: user.ID}, nil).Once()
+		login.queries.On("RecordUserIdentityLogin", mock.Anything, mock.Anything).Return(nil).Once()
+		login.queries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+		challenges.On("CreateChallenge", mock.Anything, user.ID).Return("challenge", nil).Once()
+
+		result, err := login.service.CompleteLogin(ctx, req)
+
+		require.NoError(t, err)
+		assert.Equal(t, &LoginResponse{TwoFactorRequired: true, ChallengeToken: "challenge"}, result)
+		login.sessions.AssertNotCalled(t, "CreateSession", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("unverified provider email", func(t *testing.T) {
+		login := newOIDCLogin(t, oidctest.User{Subject: "user-1", Email: "ann@example.com"})
+		req := login.authorize(t)
+
+		login.queries.On("GetUserIdentity", mock.Anything, mock.Anything).Return(db.UserIdentity{}, sql.ErrNoRows).Once()
+
+		_, err := login.service.CompleteLogin(ctx, req)
+
+		assert.Equal(t, ErrOIDCEmailNotVerified, err)
+		login.queries.AssertNotCalled(t, "GetUserByEmail", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unverified local account", func(t *testing.T) {
+		login := newOIDCLogin(t, oidctest.User{Subject: "user-1", Email: "ann@example.com", EmailVerified: true})
+		req := login.authorize(t)
+
+		login.queries.On("GetUserIdentity", mock.Anything, mock.Anything).Return(db.UserIdentity{}, sql.ErrNoRows).Once()
+		login.queries.On("GetUserByEmail", mock.Anything, "ann@example.com").Return(db.User{ID: uuid.New(), Email: "ann@example.com"}, nil).Once()
+
+		_, err := login.service.CompleteLogin(ctx, req)
+
+		assert.Equal(t, ErrOIDCAccountNotVerified, err)
+		login.queries.AssertNotCalled(t, "CreateUserIdentity", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown state", func(t *testing.T) {
+		login := newOIDCLogin(t, oidctest.User{Subject: "user-1"})
+		login.queries.On("ConsumeOIDCLoginState", mock.Anything, auth.HashOpaqueToken("forged")).Return(db.OidcLoginState{}, sql.ErrNoRows).Once()
+
+		_, err := login.service.CompleteLogin(ctx, OIDCCallbackRequest{Code: "code", State: "forged"})
+
+		assert.Equal(t, ErrInvalidOIDCState, err)
+	})
+
+	t.Run("code rejected by the provider", func(t *testing.T) {
+		login := newOIDCLogin(t, oidctest.User{Subject: "user-1"})
+		req := login.authorize(t)
+		req.Code = "not-the-code"
+
+		_, err := login.service.CompleteLogin(ctx, req)
+
+		assert.Equal(t, ErrOIDCLoginFailed, err)
+		login.queries.AssertNotCalled(t, "GetUserIdentity", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"time"
+
+	"days/internal/render"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidYear         = errors.New("year must be between 1 and 9999")
+	ErrInvalidRenderFormat = errors.New("format must be svg or png")
+	ErrInvalidRenderLayout = errors.New("layout must be months or weeks")
+)
+
+type RenderService struct {
+	queries RenderRepository
+	now     func() time.Time
+}
+
+type RenderRequest struct {
+	Year   int    // defaults to the current year
+	Format string // svg (default) or png
+	Layout string // months (default, 12x31) or weeks (53x7)
+	Legend bool
+}
+
+// RenderedCalendar is an encoded image of a calendar year
+type RenderedCalendar struct {
+	ContentType string
+	Data        []byte
+}
+
+func NewRenderService(queries RenderRepository) *RenderService {
+	return &RenderService{
+		queries: queries,
+		now:     time.Now,
+	}
+}
+
+// RenderCalendar draws one year of a calendar's entries as an SVG or PNG grid
+func (s *RenderService) RenderCalendar(ctx context.Context, userID, calendarID uuid.UUID, req RenderRequest) (*RenderedCalendar, error) {
+	ctx, span := tracer.Start(ctx, "RenderService.RenderCalendar")
+	defer span.End()
+
+	year := req.Year
+	if year == 0 {
+		year = s.now().Year()
+	}
+	if year < 1 || year > 9999 {
+		return nil, ErrInvalidYear
+	}
+
+	format := req.Format
+	if format == "" {
+		format = "svg"
+	}
+	if format != "svg" && format != "png" {
+		return nil, ErrInvalidRenderFormat
+	}
+
+	layout := render.Layout(req.Layout)
+	if layout == "" {
+		layout = render.LayoutMonths
+	}
+	if layout != render.LayoutMonths && layout != render.LayoutWeeks {
+		return nil, ErrInvalidRenderLayout
+	}
+
+	// Check calendar exists and user is a member of it
+	_, _, err := authorizeCalendar(ctx, s.queries, userID, calendarID, RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.queries.GetDayEntriesByCalendarID(ctx, calendarID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get day entries: %w", err)
+	}
+
+	grid := render.Calendar{
+		Year:   year,
+		Layout: layout,
+		Days:   make(map[string]color.RGBA),
+	}
+	for _, entry := range entries {
+		if entry.Date.Year() != year {
+			continue
+		}
+		fill, err := render.ParseHexColor(entry.ColorHex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render day entry: %w", err)
+		}
+		grid.Days[entry.Date.Format("2006-01-02")] = fill
+	}
+
+	if req.Legend {
+		colorMeanings, err := s.queries.GetColorMeaningsByCalendarID(ctx, calendarID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get color meanings: %w", err)
+		}
+		for _, cm := range colorMeanings {
+			fill, err := render.ParseHexColor(cm.ColorHex)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render color meaning: %w", err)
+			}
+			grid.Legend = append(grid.Legend, render.LegendItem{Color: fill, Label: cm.Meaning})
+		}
+	}
+
+	var buf bytes.Buffer
+	rendered := &RenderedCalendar{}
+	switch format {
+	case "png":
+		rendered.ContentType = "image/png"
+		err = render.PNG(&buf, grid)
+	default:
+		rendered.ContentType = "image/svg+xml"
+		err = render.SVG(&buf, grid)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to render calendar: %w", err)
+	}
+	rendered.Data = buf.Bytes()
+
+	return rendered, nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"image/png"
+	"testing"
+	"time"
+
+	"days/internal/db"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockRenderRepository implements a mock for the RenderRepository interface
+type MockRenderRepository struct {
+	mock.Mock
+}
+
+func (m *MockRenderRepository) GetCalendarWithRole(ctx context.Context, arg db.GetCalendarWithRoleParams) (db.GetCalendarWithRoleRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.GetCalendarWithRoleRow), args.Error(1)
+}
+
+func (m *MockRenderRepository) GetColorMeaningsByCalendarID(ctx context.Context, calendarID uuid.UUID) ([]db.ColorMeaning, error) {
+	args := m.Called(ctx, calendarID)
+	return args.Get(0).([]db.ColorMeaning), args.Error(1)
+}
+
+func (m *MockRenderRepository) GetDayEntriesByCalendarID(ctx context.Context, calendarID uuid.UUID) ([]db.GetDayEntriesByCalendarIDRow, error) {
+	args := m.Called(ctx, calendarID)
+	return args.Get(0).([]db.GetDayEntriesByCalendarIDRow), args.Error(1)
+}
+
+func TestRenderService_RenderCalendar(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	calendarID := uuid.New()
+
+	entries := []db.GetDayEntriesByCalendarIDRow{
+		{Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), ColorHex: "#00FF00"},
+		{Date: time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC), ColorHex: "#FF0000"},
+	}
+
+	newService := func() (*RenderService, *MockRenderRepository) {
+		mockQueries := new(MockRenderRepository)
+		service := NewRenderService(mockQueries)
+		service.now = func() time.Time { return time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC) }
+		return service, mockQueries
+	}
+
+	t.Run("svg of the current year by default", func(t *testing.T) {
+		service, mockQueries := newService()
+
+		mockQueries.On("GetCalendarWithRole", mock.Anything, calendarAccess(userID, calendarID)).Return(calendarWithRole(calendarID, userID, RoleOwner), nil).Once()
+		mockQueries.On("GetDayEntriesByCalendarID", mock.Anything, calendarID).Return(entries, nil).Once()
+
+		rendered, err := service.RenderCalendar(ctx, userID, calendarID, RenderRequest{})
+		require.NoError(t, err)
+
+		assert.Equal(t, "image/svg+xml", rendered.ContentType)
+		svg := string(rendered.Data)
+		assert.Contains(t, svg, `fill="#00FF00"><title>2024-03-01</title>`)
+		// Entries from other years are not drawn
+		assert.NotContains(t, svg, "#FF0000")
+		assert.NotContains(t, svg, "2023-12-31")
+		mockQueries.AssertNotCalled(t, "GetColorMeaningsByCalendarID", mock.Anything, calendarID)
+	})
+
+	t.Run("png with legend", func(t *testing.T) {
+		service, mockQueries := newService()
+
+		mockQueries.On("GetCalendarWithRole", mock.Anything, calendarAccess(userID, calendarID)).Return(calendarWithRole(calendarID, userID, RoleOwner), nil).Once()
+		mockQueries.On("GetDayEntriesByCalendarID", mock.Anything, calendarID).Return(entries, nil).Once()
+		mockQueries.On("GetColorMeaningsByCalendarID", mock.Anything, calendarID).Return([]db.ColorMeaning{
+			{ColorHex: "#00FF00", Meaning: "good"},
+		}, nil).Once()
+
+		rendered, err := service.RenderCalendar(ctx, userID, calendarID, RenderRequest{Year: 2023, Format: "png", Layout: "weeks", Legend: true})
+		require.NoError(t, err)
+
+		assert.Equal(t, "image/png", rendered.ContentType)
+		_, err = png.Decode(bytes.NewReader(rendered.Data))
+		require.NoError(t, err)
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			calendar    db.GetCalendarWithRoleRow
+			calendarErr error
+			req         RenderRequest
+			expectedErr error
+		}{
+			{"year out of range", db.GetCalendarWithRoleRow{}, nil, RenderRequest{Year: 10000}, ErrInvalidYear},
+			{"unknown format", db.GetCalendarWithRoleRow{}, nil, RenderRequest{Format: "gif"}, ErrInvalidRenderFormat},
+			{"unknown layout", db.GetCalendarWithRoleRow{}, nil, RenderRequest{Layout: "spiral"}, ErrInvalidRenderLayout},
+			{"calendar not found", db.GetCalendarWithRoleRow{}, sql.ErrNoRows, RenderRequest{}, ErrCalendarNotFound},
+			{"not a member", calendarWithRole(calendarID, uuid.New(), ""), nil, RenderRequest{}, ErrUnauthorizedCalendar},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				service, mockQueries := newService()
+				mockQueries.On("GetCalendarWithRole", mock.Anything, calendarAccess(userID, calendarID)).Return(tt.calendar, tt.calendarErr).Maybe()
+
+				rendered, err := service.RenderCalendar(ctx, userID, calendarID, tt.req)
+				assert.Nil(t, rendered)
+				assert.Equal(t, tt.expectedErr, err)
+				mockQueries.AssertNotCalled(t, "GetDayEntriesByCalendarID", mock.Anything, calendarID)
+			})
+		}
+	})
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"days/internal/auth"
+	"days/internal/db"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultAccessTokenTTL is the lifetime of the JWTs sent as Bearer tokens
+	DefaultAccessTokenTTL = 15 * time.Minute
+	// DefaultRefreshTokenTTL is how long a session survives without being refreshed
+	DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+// SessionConfig sets how long the tokens of a session last
+type SessionConfig struct {
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" toml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" toml:"refresh_token_ttl"`
+}
+
+// DefaultSessionConfig returns the default token lifetimes
+func DefaultSessionConfig() SessionConfig {
+	return SessionConfig{AccessTokenTTL: DefaultAccessTokenTTL, RefreshTokenTTL: DefaultRefreshTokenTTL}
+}
+
+type SessionService struct {
+	queries         SessionRepository
+	tokens          TokenSigner
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+}
+
+// SessionMetadata describes the device a session is opened or refreshed from
+type SessionMetadata struct {
+	UserAgent string
+	IPAddress string
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" example:"3q2-7wAAAAA..." binding:"required"`
+}
+
+type TokenResponse struct {
+	SessionID    uuid.UUID `json:"session_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Token        string    `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string    `json:"refresh_token" example:"3q2-7wAAAAA..."`
+	ExpiresIn    int64     `json:"expires_in" example:"900"` // access token lifetime in seconds
+}
+
+type SessionResponse struct {
+	ID         uuid.UUID `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	UserAgent  string    `json:"user_agent" example:"Mozilla/5.0 (Android 14)"`
+	IPAddress  string    `json:"ip_address" example:"203.0.113.7"`
+	Current    bool      `json:"current" example:"true"`
+	CreatedAt  string    `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	LastUsedAt string    `json:"last_used_at" example:"2023-01-01T00:00:00Z"`
+	ExpiresAt  string    `json:"expires_at" example:"2023-01-31T00:00:00Z"`
+}
+
+func NewSessionService(queries SessionRepository, tokens TokenSigner, config SessionConfig) *SessionService {
+	return &SessionService{
+		queries:         queries,
+		tokens:          tokens,
+		accessTokenTTL:  config.AccessTokenTTL,
+		refreshTokenTTL: config.RefreshTokenTTL,
+	}
+}
+
+// CreateSession opens a session for a user and issues its first token pair
+func (s *SessionService) CreateSession(ctx context.Context, userID uuid.UUID, meta SessionMetadata) (*TokenResponse, error) {
+	ctx, span := tracer.Start(ctx, "SessionService.CreateSession")
+	defer span.End()
+
+	refreshToken, refreshHash, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	session, err := s.queries.CreateSession(ctx, db.CreateSessionParams{
+		UserID:           userID,
+		RefreshTokenHash: refreshHash,
+		UserAgent:        truncate(meta.UserAgent, 512),
+		IpAddress:        truncate(meta.IPAddress, 45),
+		ExpiresAt:        time.Now().Add(s.refreshTokenTTL),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return s.issueTokens(session, refreshToken)
+}
+
+// Refresh exchanges a refresh token for a new token pair, rotating the refresh token
+func (s *SessionService) Refresh(ctx context.Context, refreshToken string, meta SessionMetadata) (*TokenResponse, error) {
+	ctx, span := tracer.Start(ctx, "SessionService.Refresh")
+	defer span.End()
+
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	currentHash := auth.HashRefreshToken(refreshToken)
+	session, err := s.queries.GetSessionByRefreshTokenHash(ctx, currentHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if !s.isActive(session) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	newRefreshToken, newRefreshHash, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	// The update only matches while the presented token is still current, so two
+	// concurrent refreshes with the same token cannot both succeed
+	rotated, err := s.queries.RotateSessionRefreshToken(ctx, db.RotateSessionRefreshTokenParams{
+		NewRefreshTokenHash: newRefreshHash,
+		UserAgent:           truncate(meta.UserAgent, 512),
+		IpAddress:           truncate(meta.IPAddress, 45),
+		ExpiresAt:           time.Now().Add(s.refreshTokenTTL),
+		ID:                  session.ID,
+		RefreshTokenHash:    currentHash,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	return s.issueTokens(rotated, newRefreshToken)
+}
+
+// GetActiveSessions lists the sessions a user is currently signed in with
+func (s *SessionService) GetActiveSessions(ctx context.Context, userID, currentSessionID uuid.UUID) ([]*SessionResponse, error) {
+	ctx, span := tracer.Start(ctx, "SessionService.GetActiveSessions")
+	defer span.End()
+
+	sessions, err := s.queries.GetActiveSessionsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	var responses []*SessionResponse
+	for _, session := range sessions {
+		response := s.toSessionResponse(session)
+		response.Current = session.ID == currentSessionID
+		responses = append(responses, response)
+	}
+
+	return responses, nil
+}
+
+// RevokeSession signs a user out of one of their sessions
+func (s *SessionService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "SessionService.RevokeSession")
+	defer span.End()
+
+	session, err := s.queries.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSessionNotFound
+		}
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Other users' sessions are reported as missing rather than forbidden
+	if session.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	if err := s.queries.RevokeSession(ctx, sessionID); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return nil
+}
+
+// IsSessionActive reports whether access tokens issued for a session should still be accepted
+func (s *SessionService) IsSessionActive(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	ctx, span := tracer.Start(ctx, "SessionService.IsSessionActive")
+	defer span.End()
+
+	session, err := s.queries.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return s.isActive(session), nil
+}
+
+// Helper methods
+
+func (s *SessionService) issueTokens(session db.Session, refreshToken string) (*TokenResponse, error) {
+	accessToken, err := s.tokens.GenerateSessionToken(session.UserID, session.ID, s.accessTokenTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &TokenResponse{
+		SessionID:    session.ID,
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.accessTokenTTL.Seconds()),
+	}, nil
+}
+
+func (s *SessionService) isActive(session db.Session) bool {
+	return !session.RevokedAt.Valid && time.Now().Before(session.ExpiresAt)
+}
+
+func (s *SessionService) toSessionResponse(session db.Session) *SessionResponse {
+	var createdAt string
+	if session.CreatedAt.Valid {
+		createdAt = session.CreatedAt.Time.Format("2006-01-02T15:04:05Z")
+	}
+
+	return &SessionResponse{
+		ID:         session.ID,
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IpAddress,
+		CreatedAt:  createdAt,
+		LastUsedAt: session.LastUsedAt.Format("2006-01-02T15:04:05Z"),
+		ExpiresAt:  session.ExpiresAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"days/internal/auth"
+	"days/internal/db"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSessionRepository implements a mock for the SessionRepository interface
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) CreateSession(ctx context.Context, arg db.CreateSessionParams) (db.Session, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) GetSessionByID(ctx context.Context, id uuid.UUID) (db.Session, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(db.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) GetSessionByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (db.Session, error) {
+	args := m.Called(ctx, refreshTokenHash)
+	return args.Get(0).(db.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) GetActiveSessionsByUserID(ctx context.Context, userID uuid.UUID) ([]db.Session, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]db.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) RotateSessionRefreshToken(ctx context.Context, arg db.RotateSessionRefreshTokenParams) (db.Session, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) RevokeSession(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// testKeyring returns a keyring with a fresh EdDSA signing key
+func testKeyring(t *testing.T) *auth.Keyring {
+	t.Helper()
+	key, err := auth.GenerateKey(auth.AlgorithmEdDSA)
+	require.NoError(t, err)
+	keys, err := auth.NewKeyring(key)
+	require.NoError(t, err)
+	return keys
+}
+
+func TestSessionService_CreateSession(t *testing.T) {
+	ctx := context.Background()
+
+	mockQueries := new(MockSessionRepository)
+	keys := testKeyring(t)
+	service := NewSessionService(mockQueries, keys, SessionConfig{AccessTokenTTL: 5 * time.Minute, RefreshTokenTTL: 7 * 24 * time.Hour})
+
+	userID := uuid.New()
+	sessionID := uuid.New()
+
+	var stored db.CreateSessionParams
+	mockQueries.On("CreateSession", mock.Anything, mock.AnythingOfType("db.CreateSessionParams")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(db.CreateSessionParams) }).
+		Return(db.Session{ID: sessionID, UserID: userID, ExpiresAt: time.Now().Add(time.Hour)}, nil).Once()
+
+	tokens, err := service.CreateSession(ctx, userID, SessionMetadata{UserAgent: "days-test", IPAddress: "203.0.113.7"})
+	require.NoError(t, err)
+
+	assert.Equal(t, sessionID, tokens.SessionID)
+	// Tokens last as long as configured
+	assert.Equal(t, int64(300), tokens.ExpiresIn)
+	assert.WithinDuration(t, time.Now().Add(7*24*time.Hour), stored.ExpiresAt, time.Minute)
+
+	// Only the hash of the refresh token is persisted
+	assert.NotEqual(t, tokens.RefreshToken, stored.RefreshTokenHash)
+	assert.Equal(t, auth.HashRefreshToken(tokens.RefreshToken), stored.RefreshTokenHash)
+	assert.Equal(t, "days-test", stored.UserAgent)
+	assert.Equal(t, "203.0.113.7", stored.IpAddress)
+
+	// The access token is bound to the new session
+	parsedUserID, parsedSessionID, err := keys.ParseSessionToken(tokens.Token)
+	require.NoError(t, err)
+	assert.Equal(t, userID, parsedUserID)
+	assert.Equal(t, sessionID, parsedSessionID)
+
+	mockQueries.AssertExpectations(t)
+}
+
+func TestSessionService_Refresh(t *testing.T) {
+	ctx := context.Background()
+
+	userID := uuid.New()
+	sessionID := uuid.New()
+	refreshToken := "current-refresh-token"
+	refreshHash := auth.HashRefreshToken(refreshToken)
+
+	t.Run("rotates the refresh token", func(t *testing.T) {
+		mockQueries := new(MockSessionRepository)
+		service := NewSessionService(mockQueries, testKeyring(t), DefaultSessionConfig())
+
+		session := db.Session{ID: sessionID, UserID: userID, RefreshTokenHash: refreshHash, ExpiresAt: time.Now().Add(time.Hour)}
+
+		var rotation db.RotateSessionRefreshTokenParams
+		mockQueries.On("GetSessionByRefreshTokenHash", mock.Anything, refreshHash).Return(session, nil).Once()
+		mockQueries.On("RotateSessionRefreshToken", mock.Anything, mock.AnythingOfType("db.RotateSessionRefreshTokenParams")).
+			Run(func(args mock.Arguments) { rotation = args.Get(1).(db.RotateSessionRefreshTokenParams) }).
+			Return(session, nil).Once()
+
+		tokens, err := service.Refresh(ctx, refreshToken, SessionMetadata{})
+		require.NoError(t, err)
+
+		assert.NotEqual(t, refreshToken, tokens.RefreshToken)
+		assert.Equal(t, sessionID, rotation.ID)
+		assert.Equal(t, refreshHash, rotation.RefreshTokenHash)
+		assert.Equal(t, auth.HashRefreshToken(tokens.RefreshToken), rotation.NewRefreshTokenHash)
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		mockQueries := new(MockSessionRepository)
+		service := NewSessionService(mockQueries, testKeyring(t), DefaultSessionConfig())
+
+		mockQueries.On("GetSessionByRefreshTokenHash", mock.Anything, refreshHash).Return(db.Session{}, sql.ErrNoRows).Once()
+
+		tokens, err := service.Refresh(ctx, refreshToken, SessionMetadata{})
+		assert.Nil(t, tokens)
+		assert.Equal(t, ErrInvalidRefreshToken, err)
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("revoked session", func(t *testing.T) {
+		mockQueries := new(MockSessionRepository)
+		service := NewSessionService(mockQueries, testKeyring(t), DefaultSessionConfig())
+
+		session := db.Session{
+			ID:               sessionID,
+			UserID:           userID,
+			RefreshTokenHash: refreshHash,
+			ExpiresAt:        time.Now().Add(time.Hour),
+			RevokedAt:        sql.NullTime{Time: time.Now(), Valid: true},
+		}
+		mockQueries.On("GetSessionByRefreshTokenHash", mock.Anything, refreshHash).Return(session, nil).Once()
+
+		_, err := service.Refresh(ctx, refreshToken, SessionMetadata{})
+		assert.Equal(t, ErrInvalidRefreshToken, err)
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("expired session", func(t *testing.T) {
+		mockQueries := new(MockSessionRepository)
+		service := NewSessionService(mockQueries, testKeyring(t), DefaultSessionConfig())
+
+		session := db.Session{ID: sessionID, UserID: userID, RefreshTokenHash: refreshHash, ExpiresAt: time.Now().Add(-time.Minute)}
+		mockQueries.On("GetSessionByRefreshTokenHash", mock.Anything, refreshHash).Return(session, nil).Once()
+
+		_, err := service.Refresh(ctx, refreshToken, SessionMetadata{})
+		assert.Equal(t, ErrInvalidRefreshToken, err)
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("token rotated concurrently", func(t *testing.T) {
+		mockQueries := new(MockSessionRepository)
+		service := NewSessionService(mockQueries, testKeyring(t), DefaultSessionConfig())
+
+		session := db.Session{ID: sessionID, UserID: userID, RefreshTokenHash: refreshHash, ExpiresAt: time.Now().Add(time.Hour)}
+		mockQueries.On("GetSessionByRefreshTokenHash", mock.Anything, refreshHash).Return(session, nil).Once()
+		mockQueries.On("RotateSessionRefreshToken", mock.Anything, mock.Anything).Return(db.Session{}, sql.ErrNoRows).Once()
+
+		_, err := service.Refresh(ctx, refreshToken, SessionMetadata{})
+		assert.Equal(t, ErrInvalidRefreshToken, err)
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("empty token", func(t *testing.T) {
+		service := NewSessionService(new(MockSessionRepository), testKeyring(t), DefaultSessionConfig())
+
+		_, err := service.Refresh(ctx, "", SessionMetadata{})
+		assert.Equal(t, ErrInvalidRefreshToken, err)
+	})
+}
+
+func TestSessionService_RevokeSession(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	sessionID := uuid.New()
+
+	t.Run("own session", func(t *testing.T) {
+		mockQueries := new(MockSessionRepository)
+		service := NewSessionService(mockQueries, testKeyring(t), DefaultSessionConfig())
+
+		mockQueries.On("GetSessionByID", mock.Anything, sessionID).Return(db.Session{ID: sessionID, UserID: userID}, nil).Once()
+		mockQueries.On("RevokeSession", mock.Anything, sessionID).Return(nil).Once()
+
+		err := service.RevokeSession(ctx, userID, sessionID)
+		require.NoError(t, err)
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("another user's session", func(t *testing.T) {
+		mockQueries := new(MockSessionRepository)
+		service := NewSessionService(mockQueries, testKeyring(t), DefaultSessionConfig())
+
+		mockQueries.On("GetSessionByID", mock.Anything, sessionID).Return(db.Session{ID: sessionID, UserID: uuid.New()}, nil).Once()
+
+		err := service.RevokeSession(ctx, userID, sessionID)
+		assert.Equal(t, ErrSessionNotFound, err)
+		mockQueries.AssertNotCalled(t, "RevokeSession", mock.Anything, sessionID)
+	})
+}
+
+func TestSessionService_IsSessionActive(t *testing.T) {
+	ctx := context.Background()
+	sessionID := uuid.New()
+
+	tests := []struct {
+		name     string
+		session  db.Session
+		err      error
+		expected bool
+	}{
+		{"active", db.Session{ExpiresAt: time.Now().Add(time.Hour)}, nil, true},
+		{"revoked", db.Session{ExpiresAt: time.Now().Add(time.Hour), RevokedAt: sql.NullTime{Time: time.Now(), Valid: true}}, nil, false},
+		{"expired", db.Session{ExpiresAt: time.Now().Add(-time.Hour)}, nil, false},
+		{"missing", db.Session{}, sql.ErrNoRows, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := new(MockSessionRepository)
+			service := NewSessionService(mockQueries, testKeyring(t), DefaultSessionConfig())
+
+			mockQueries.On("GetSessionByID", mock.Anything, sessionID).Return(tt.session, tt.err).Once()
+
+			active, err := service.IsSessionActive(ctx, sessionID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, active)
+		})
+	}
+}
+
+func TestSessionService_GetActiveSessions(t *testing.T) {
+	ctx := context.Background()
+	mockQueries := new(MockSessionRepository)
+	service := NewSessionService(mockQueries, testKeyring(t), DefaultSessionConfig())
+
+	userID := uuid.New()
+	current := uuid.New()
+	other := uuid.New()
+
+	mockQueries.On("GetActiveSessionsByUserID", mock.Anything, userID).Return([]db.Session{
+		{ID: current, UserID: userID, UserAgent: "phone", ExpiresAt: time.Now().Add(time.Hour)},
+		{ID: other, UserID: userID, UserAgent: "laptop", ExpiresAt: time.Now().Add(time.Hour)},
+	}, nil).Once()
+
+	sessions, err := service.GetActiveSessions(ctx, userID, current)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.True(t, sessions[0].Current)
+	assert.False(t, sessions[1].Current)
+	assert.Equal(t, "laptop", sessions[1].UserAgent)
+}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"days/internal/db"
+
+	"github.com/google/uuid"
+)
+
+const secondsPerDay = 24 * 60 * 60
+
+// maxStatsRangeYears bounds the range statistics are computed over, as the
+// response holds a row for each of its months
+const maxStatsRangeYears = 100
+
+var ErrDateRangeTooLong = errors.New("date range cannot span more than 100 years")
+
+// earliestDate is used as the lower bound when no start date is requested
+var earliestDate = time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+type StatsService struct {
+	queries StatsRepository
+	now     func() time.Time
+}
+
+type StatsRequest struct {
+	From           string     // YYYY-MM-DD, defaults to the first entry of the calendar
+	To             string     // YYYY-MM-DD, defaults to today
+	ColorMeaningID *uuid.UUID // streaks only count days with this meaning when set
+}
+
+type StreakStats struct {
+	Days      int    `json:"days" example:"12"`
+	StartDate string `json:"start_date,omitempty" example:"2024-01-04"`
+	EndDate   string `json:"end_date,omitempty" example:"2024-01-15"`
+}
+
+type MeaningStats struct {
+	ColorMeaningID uuid.UUID `json:"color_meaning_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	ColorHex       string    `json:"color_hex" example:"#00FF00"`
+	Meaning        string    `json:"meaning" example:"relaxed"`
+	Count          int       `json:"count" example:"20"`
+	Percentage     float64   `json:"percentage" example:"64.5"` // share of the entries in the range
+}
+
+type WeekdayStats struct {
+	Weekday string `json:"weekday" example:"Monday"`
+	Count   int    `json:"count" example:"4"`
+}
+
+type MonthStats struct {
+	Month string `json:"month" example:"2024-01"`
+	Count int    `json:"count" example:"31"`
+}
+
+type CalendarStatsResponse struct {
+	CalendarID     uuid.UUID      `json:"calendar_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	From           string         `json:"from" example:"2024-01-01"`
+	To             string         `json:"to" example:"2024-01-31"`
+	ColorMeaningID *uuid.UUID     `json:"color_meaning_id,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+	TotalDays      int            `json:"total_days" example:"31"`
+	TotalEntries   int            `json:"total_entries" example:"31"`
+	CurrentStreak  StreakStats    `json:"current_streak"`
+	LongestStreak  StreakStats    `json:"longest_streak"`
+	LongestGap     StreakStats    `json:"longest_gap"`
+	Meanings       []MeaningStats `json:"meanings"`
+	Weekdays       []WeekdayStats `json:"weekdays"`
+	Months         []MonthStats   `json:"months"`
+}
+
+func NewStatsService(queries StatsRepository) *StatsService {
+	return &StatsService{
+		queries: queries,
+		now:     time.Now,
+	}
+}
+
+// GetCalendarStats computes streaks and distributions of a calendar's entries over a date range
+func (s *StatsService) GetCalendarStats(ctx context.Context, userID, calendarID uuid.UUID, req StatsRequest) (*CalendarStatsResponse, error) {
+	ctx, span := tracer.Start(ctx, "StatsService.GetCalendarStats")
+	defer span.End()
+
+	// Check calendar exists and user is a member of it
+	_, _, err := authorizeCalendar(ctx, s.queries, userID, calendarID, RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	to := startOfDay(s.now())
+	if req.To != "" {
+		if to, err = s.parseDate(req.To); err != nil {
+			return nil, err
+		}
+	}
+
+	from := earliestDate
+	if req.From != "" {
+		if from, err = s.parseDate(req.From); err != nil {
+			return nil, err
+		}
+	}
+
+	if to.Before(from) {
+		return nil, ErrInvalidDateRange
+	}
+	if req.From != "" && rangeTooLong(from, to) {
+		return nil, ErrDateRangeTooLong
+	}
+
+	colorMeanings, err := s.queries.GetColorMeaningsByCalendarID(ctx, calendarID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get color meanings: %w", err)
+	}
+
+	if req.ColorMeaningID != nil && !containsColorMeaning(colorMeanings, *req.ColorMeaningID) {
+		return nil, ErrColorMeaningMismatch
+	}
+
+	entries, err := s.queries.GetDayEntryDatesByCalendarAndDateRange(ctx, db.GetDayEntryDatesByCalendarAndDateRangeParams{
+		CalendarID: calendarID,
+		StartDate:  from,
+		EndDate:    to,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get day entries: %w", err)
+	}
+
+	// Without an explicit start, the range begins with the first entry
+	if req.From == "" {
+		from = to
+		if len(entries) > 0 {
+			from = startOfDay(entries[0].Date)
+		}
+	}
+	if rangeTooLong(from, to) {
+		return nil, ErrDateRangeTooLong
+	}
+
+	stats := &CalendarStatsResponse{
+		CalendarID:     calendarID,
+		From:           from.Format("2006-01-02"),
+		To:             to.Format("2006-01-02"),
+		ColorMeaningID: req.ColorMeaningID,
+		TotalDays:      int(dayNumber(to)-dayNumber(from)) + 1,
+		TotalEntries:   len(entries),
+	}
+
+	// Days that count towards a streak, oldest first
+	var streakDays []int64
+	for _, entry := range entries {
+		if req.ColorMeaningID == nil || entry.ColorMeaningID == *req.ColorMeaningID {
+			streakDays = append(streakDays, dayNumber(entry.Date))
+		}
+	}
+
+	stats.CurrentStreak = currentStreak(streakDays, dayNumber(to))
+	stats.LongestStreak = longestStreak(streakDays)
+	stats.LongestGap = longestGap(entries, dayNumber(from), dayNumber(to))
+	stats.Meanings = meaningStats(colorMeanings, entries)
+	stats.Weekdays = weekdayStats(entries)
+	stats.Months = monthStats(entries, from, to)
+
+	return stats, nil
+}
+
+// Helper methods
+
+func (s *StatsService) parseDate(dateStr string) (time.Time, error) {
+	date, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return time.Time{}, ErrInvalidDate
+	}
+
+	return date, nil
+}
+
+func containsColorMeaning(colorMeanings []db.ColorMeaning, id uuid.UUID) bool {
+	for _, cm := range colorMeanings {
+		if cm.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// currentStreak counts the consecutive days ending at the last day of the
+// range. A day without an entry yet does not break the streak, so it may also
+// end the day before.
+func currentStreak(days []int64, last int64) StreakStats {
+	if len(days) == 0 {
+		return StreakStats{}
+	}
+
+	end := len(days) - 1
+	if days[end] != last && days[end] != last-1 {
+		return StreakStats{}
+	}
+
+	start := end
+	for start > 0 && days[start-1] == days[start]-1 {
+		start--
+	}
+
+	return newStreakStats(days[start], days[end])
+}
+
+// longestStreak finds the longest run of consecutive days, keeping the earliest on ties
+func longestStreak(days []int64) StreakStats {
+	var longest StreakStats
+
+	start := 0
+	for i := range days {
+		if i > 0 && days[i] != days[i-1]+1 {
+			start = i
+		}
+		if length := i - start + 1; length > longest.Days {
+			longest = newStreakStats(days[start], days[i])
+		}
+	}
+
+	return longest
+}
+
+// longestGap finds the longest run of days in [first, last] without any entry
+func longestGap(entries []db.GetDayEntryDatesByCalendarAndDateRangeRow, first, last int64) StreakStats {
+	var gap StreakStats
+
+	previous := first - 1
+	consider := func(day int64) {
+		if day-previous-1 > int64(gap.Days) {
+			gap = newStreakStats(previous+1, day-1)
+		}
+	}
+
+	for _, entry := range entries {
+		day := dayNumber(entry.Date)
+		consider(day)
+		previous = day
+	}
+	consider(last + 1)
+
+	return gap
+}
+
+func meaningStats(colorMeanings []db.ColorMeaning, entries []db.GetDayEntryDatesByCalendarAndDateRangeRow) []MeaningStats {
+	counts := make(map[uuid.UUID]int)
+	for _, entry := range entries {
+		counts[entry.ColorMeaningID]++
+	}
+
+	meanings := make([]MeaningStats, 0, len(colorMeanings))
+	for _, cm := range colorMeanings {
+		stat := MeaningStats{
+			ColorMeaningID: cm.ID,
+			ColorHex:       cm.ColorHex,
+			Meaning:        cm.Meaning,
+			Count:          counts[cm.ID],
+		}
+		if len(entries) > 0 {
+			stat.Percentage = math.Round(float64(stat.Count)*1000/float64(len(entries))) / 10
+		}
+		meanings = append(meanings, stat)
+	}
+
+	return meanings
+}
+
+// weekdayStats counts entries per day of the week, starting on Monday
+func weekdayStats(entries []db.GetDayEntryDatesByCalendarAndDateRangeRow) []WeekdayStats {
+	var counts [7]int
+	for _, entry := range entries {
+		counts[(entry.Date.Weekday()+6)%7]++
+	}
+
+	weekdays := make([]WeekdayStats, 7)
+	for i := range weekdays {
+		weekdays[i] = WeekdayStats{
+			Weekday: time.Weekday((i + 1) % 7).String(),
+			Count:   counts[i],
+		}
+	}
+
+	return weekdays
+}
+
+// monthStats counts entries for every month of the range, including empty ones
+// rangeTooLong reports whether a range spans more than maxStatsRangeYears
+func rangeTooLong(from, to time.Time) bool {
+	return from.AddDate(maxStatsRangeYears, 0, 0).Before(to)
+}
+
+func monthStats(entries []db.GetDayEntryDatesByCalendarAndDateRangeRow, from, to time.Time) []MonthStats {
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		counts[entry.Date.Format("2006-01")]++
+	}
+
+	var months []MonthStats
+	last := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC); !month.After(last); month = month.AddDate(0, 1, 0) {
+		key := month.Format("2006-01")
+		months = append(months, MonthStats{Month: key, Count: counts[key]})
+	}
+
+	return months
+}
+
+func newStreakStats(first, last int64) StreakStats {
+	return StreakStats{
+		Days:      int(last-first) + 1,
+		StartDate: dateFromDayNumber(first).Format("2006-01-02"),
+		EndDate:   dateFromDayNumber(last).Format("2006-01-02"),
+	}
+}
+
+// dayNumber converts a date to a count of days since the Unix epoch so that
+// consecutive days differ by exactly one
+func dayNumber(t time.Time) int64 {
+	return startOfDay(t).Unix() / secondsPerDay
+}
+
+func dateFromDayNumber(day int64) time.Time {
+	return time.Unix(day*secondsPerDay, 0).UTC()
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"days/internal/db"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockStatsRepository implements a mock for the StatsRepository interface
+type MockStatsRepository struct {
+	mock.Mock
+}
+
+func (m *MockStatsRepository) GetCalendarWithRole(ctx context.Context, arg db.GetCalendarWithRoleParams) (db.GetCalendarWithRoleRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.GetCalendarWithRoleRow), args.Error(1)
+}
+
+func (m *MockStatsRepository) GetColorMeaningsByCalendarID(ctx context.Context, calendarID uuid.UUID) ([]db.ColorMeaning, error) {
+	args := m.Called(ctx, calendarID)
+	return args.Get(0).([]db.ColorMeaning), args.Error(1)
+}
+
+func (m *MockStatsRepository) GetDayEntryDatesByCalendarAndDateRange(ctx context.Context, arg db.GetDayEntryDatesByCalendarAndDateRangeParams) ([]db.GetDayEntryDatesByCalendarAndDateRangeRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]db.GetDayEntryDatesByCalendarAndDateRangeRow), args.Error(1)
+}
+
+func mustDate(t *testing.T, s string) time.Time {
+	t.Helper()
+	date, err := time.Parse("2006-01-02", s)
+	require.NoError(t, err)
+	return date
+}
+
+func TestStatsService_GetCalendarStats(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	calendarID := uuid.New()
+	good := db.ColorMeaning{ID: uuid.New(), CalendarID: calendarID, ColorHex: "#00FF00", Meaning: "good"}
+	bad := db.ColorMeaning{ID: uuid.New(), CalendarID: calendarID, ColorHex: "#FF0000", Meaning: "bad"}
+	unused := db.ColorMeaning{ID: uuid.New(), CalendarID: calendarID, ColorHex: "#0000FF", Meaning: "unused"}
+
+	// Jan 1-3 good, Jan 4 bad, Jan 8-10 good, nothing logged on Jan 11 ("today")
+	entries := []db.GetDayEntryDatesByCalendarAndDateRangeRow{
+		{Date: mustDate(t, "2024-01-01"), ColorMeaningID: good.ID}, // Monday
+		{Date: mustDate(t, "2024-01-02"), ColorMeaningID: good.ID},
+		{Date: mustDate(t, "2024-01-03"), ColorMeaningID: good.ID},
+		{Date: mustDate(t, "2024-01-04"), ColorMeaningID: bad.ID},
+		{Date: mustDate(t, "2024-01-08"), ColorMeaningID: good.ID}, // Monday
+		{Date: mustDate(t, "2024-01-09"), ColorMeaningID: good.ID},
+		{Date: mustDate(t, "2024-01-10"), ColorMeaningID: good.ID},
+	}
+
+	newService := func() (*StatsService, *MockStatsRepository) {
+		mockQueries := new(MockStatsRepository)
+		service := NewStatsService(mockQueries)
+		service.now = func() time.Time { return time.Date(2024, 1, 11, 18, 30, 0, 0, time.UTC) }
+		return service, mockQueries
+	}
+
+	t.Run("streaks for a meaning with default range", func(t *testing.T) {
+		service, mockQueries := newService()
+
+		mockQueries.On("GetCalendarWithRole", mock.Anything, calendarAccess(userID, calendarID)).Return(calendarWithRole(calendarID, userID, RoleOwner), nil).Once()
+		mockQueries.On("GetColorMeaningsByCalendarID", mock.Anything, calendarID).Return([]db.ColorMeaning{good, bad, unused}, nil).Once()
+		mockQueries.On("GetDayEntryDatesByCalendarAndDateRange", mock.Anything, db.GetDayEntryDatesByCalendarAndDateRangeParams{
+			CalendarID: calendarID,
+			StartDate:  earliestDate,
+			EndDate:    mustDate(t, "2024-01-11"),
+		}).Return(entries, nil).Once()
+
+		stats, err := service.GetCalendarStats(ctx, userID, calendarID, StatsRequest{ColorMeaningID: &good.ID})
+		require.NoError(t, err)
+
+		assert.Equal(t, "2024-01-01", stats.From)
+		assert.Equal(t, "2024-01-11", stats.To)
+		assert.Equal(t, 11, stats.TotalDays)
+		assert.Equal(t, 7, stats.TotalEntries)
+
+		// Today is not logged yet, so the streak ending yesterday is still current
+		assert.Equal(t, StreakStats{Days: 3, StartDate: "2024-01-08", EndDate: "2024-01-10"}, stats.CurrentStreak)
+		// Ties keep the earliest streak
+		assert.Equal(t, StreakStats{Days: 3, StartDate: "2024-01-01", EndDate: "2024-01-03"}, stats.LongestStreak)
+		assert.Equal(t, StreakStats{Days: 3, StartDate: "2024-01-05", EndDate: "2024-01-07"}, stats.LongestGap)
+
+		require.Len(t, stats.Meanings, 3)
+		assert.Equal(t, 6, stats.Meanings[0].Count)
+		assert.Equal(t, 85.7, stats.Meanings[0].Percentage)
+		assert.Equal(t, 1, stats.Meanings[1].Count)
+		assert.Equal(t, 14.3, stats.Meanings[1].Percentage)
+		assert.Equal(t, 0, stats.Meanings[2].Count)
+
+		require.Len(t, stats.Weekdays, 7)
+		assert.Equal(t, WeekdayStats{Weekday: "Monday", Count: 2}, stats.Weekdays[0])
+		assert.Equal(t, WeekdayStats{Weekday: "Sunday", Count: 0}, stats.Weekdays[6])
+
+		assert.Equal(t, []MonthStats{{Month: "2024-01", Count: 7}}, stats.Months)
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("any entry counts without a meaning", func(t *testing.T) {
+		service, mockQueries := newService()
+
+		mockQueries.On("GetCalendarWithRole", mock.Anything, calendarAccess(userID, calendarID)).Return(calendarWithRole(calendarID, userID, RoleOwner), nil).Once()
+		mockQueries.On("GetColorMeaningsByCalendarID", mock.Anything, calendarID).Return([]db.ColorMeaning{good, bad}, nil).Once()
+		mockQueries.On("GetDayEntryDatesByCalendarAndDateRange", mock.Anything, mock.Anything).Return(entries, nil).Once()
+
+		stats, err := service.GetCalendarStats(ctx, userID, calendarID, StatsRequest{From: "2023-12-15", To: "2024-02-10"})
+		require.NoError(t, err)
+
+		assert.Equal(t, 58, stats.TotalDays)
+		assert.Equal(t, StreakStats{Days: 4, StartDate: "2024-01-01", EndDate: "2024-01-04"}, stats.LongestStreak)
+		// The range ends long after the last entry, so there is no current streak
+		assert.Equal(t, StreakStats{}, stats.CurrentStreak)
+		assert.Equal(t, StreakStats{Days: 31, StartDate: "2024-01-11", EndDate: "2024-02-10"}, stats.LongestGap)
+		assert.Equal(t, []MonthStats{
+			{Month: "2023-12", Count: 0},
+			{Month: "2024-01", Count: 7},
+			{Month: "2024-02", Count: 0},
+		}, stats.Months)
+	})
+
+	t.Run("no entries", func(t *testing.T) {
+		service, mockQueries := newService()
+
+		mockQueries.On("GetCalendarWithRole", mock.Anything, calendarAccess(userID, calendarID)).Return(calendarWithRole(calendarID, userID, RoleOwner), nil).Once()
+		mockQueries.On("GetColorMeaningsByCalendarID", mock.Anything, calendarID).Return([]db.ColorMeaning{good}, nil).Once()
+		mockQueries.On("GetDayEntryDatesByCalendarAndDateRange", mock.Anything, mock.Anything).Return([]db.GetDayEntryDatesByCalendarAndDateRangeRow{}, nil).Once()
+
+		stats, err := service.GetCalendarStats(ctx, userID, calendarID, StatsRequest{})
+		require.NoError(t, err)
+
+		assert.Equal(t, "2024-01-11", stats.From)
+		assert.Equal(t, 1, stats.TotalDays)
+		assert.Equal(t, StreakStats{}, stats.CurrentStreak)
+		assert.Equal(t, StreakStats{}, stats.LongestStreak)
+		assert.Equal(t, 1, stats.LongestGap.Days)
+		assert.Equal(t, 0.0, stats.Meanings[0].Percentage)
+	})
+
+	t.Run("first entry too long ago", func(t *testing.T) {
+		service, mockQueries := newService()
+
+		mockQueries.On("GetCalendarWithRole", mock.Anything, calendarAccess(userID, calendarID)).Return(calendarWithRole(calendarID, userID, RoleOwner), nil).Once()
+		mockQueries.On("GetColorMeaningsByCalendarID", mock.Anything, calendarID).Return([]db.ColorMeaning{good}, nil).Once()
+		mockQueries.On("GetDayEntryDatesByCalendarAndDateRange", mock.Anything, mock.Anything).Return([]db.GetDayEntryDatesByCalendarAndDateRangeRow{
+			{Date: mustDate(t, "1900-01-01"), ColorMeaningID: good.ID},
+		}, nil).Once()
+
+		stats, err := service.GetCalendarStats(ctx, userID, calendarID, StatsRequest{})
+		assert.Nil(t, stats)
+		assert.ErrorIs(t, err, ErrDateRangeTooLong)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		otherMeaning := uuid.New()
+
+		tests := []struct {
+			name        string
+			calendar    db.GetCalendarWithRoleRow
+			calendarErr error
+			req         StatsRequest
+			expectedErr error
+		}{
+			{"calendar not found", db.GetCalendarWithRoleRow{}, sql.ErrNoRows, StatsRequest{}, ErrCalendarNotFound},
+			{"not a member", calendarWithRole(calendarID, uuid.New(), ""), nil, StatsRequest{}, ErrUnauthorizedCalendar},
+			{"invalid from", calendarWithRole(calendarID, userID, RoleOwner), nil, StatsRequest{From: "01/02/2024"}, ErrInvalidDate},
+			{"invalid to", calendarWithRole(calendarID, userID, RoleOwner), nil, StatsRequest{To: "tomorrow"}, ErrInvalidDate},
+			{"reversed range", calendarWithRole(calendarID, userID, RoleOwner), nil, StatsRequest{From: "2024-02-01", To: "2024-01-01"}, ErrInvalidDateRange},
+			{"range too long", calendarWithRole(calendarID, userID, RoleOwner), nil, StatsRequest{From: "0001-01-01", To: "9999-12-31"}, ErrDateRangeTooLong},
+			{"meaning of another calendar", calendarWithRole(calendarID, userID, RoleOwner), nil, StatsRequest{ColorMeaningID: &otherMeaning}, ErrColorMeaningMismatch},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				service, mockQueries := newService()
+
+				mockQueries.On("GetCalendarWithRole", mock.Anything, calendarAccess(userID, calendarID)).Return(tt.calendar, tt.calendarErr).Once()
+				mockQueries.On("GetColorMeaningsByCalendarID", mock.Anything, calendarID).Return([]db.ColorMeaning{good}, nil).Maybe()
+
+				stats, err := service.GetCalendarStats(ctx, userID, calendarID, tt.req)
+				assert.Nil(t, stats)
+				assert.ErrorIs(t, err, tt.expectedErr)
+				mockQueries.AssertNotCalled(t, "GetDayEntryDatesByCalendarAndDateRange", mock.Anything, mock.Anything)
+			})
+		}
+	})
+}
@@ -0,0 +1,7 @@
+package services
+
+import "go.opentelemetry.io/otel"
+
+// tracer starts the span of each service method, between the HTTP request
+// and the queries it runs
+var tracer = otel.Tracer("days/internal/services")
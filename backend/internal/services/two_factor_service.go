@@ -0,0 +1,479 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"days/internal/auth"
+	"days/internal/db"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// TOTPIssuer names the account in authenticator apps
+	TOTPIssuer = "Days"
+
+	recoveryCodeCount = 10
+	// A challenge covers the time it takes to open an authenticator app
+	twoFactorChallengeTTL = 5 * time.Minute
+	// After this many wrong codes the password has to be entered again
+	maxTwoFactorAttempts = 5
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor enrollment has not been started")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrInvalidChallenge        = errors.New("invalid or expired two-factor challenge")
+	ErrIncorrectPassword       = errors.New("incorrect password")
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TwoFactorService manages TOTP two-factor authentication: enrolling an
+// authenticator app, single-use recovery codes, and the second step of a
+// password login.
+type TwoFactorService struct {
+	queries  TwoFactorRepository
+	sessions SessionIssuer
+	lockout  LoginLockout
+	now      func() time.Time
+}
+
+type TwoFactorStatusResponse struct {
+	Enabled                bool  `json:"enabled" example:"true"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining" example:"10"`
+}
+
+type TOTPEnrollmentResponse struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	URI    string `json:"otpauth_uri" example:"otpauth://totp/Days:user@example.com?secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP&issuer=Days"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"k7d2q-x9m4a,p3v8r-b6n2t"`
+}
+
+type TwoFactorCodeRequest struct {
+	// A code from the authenticator app, or a recovery code where accepted
+	Code string `json:"code" example:"123456" binding:"required"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" example:"password123" binding:"required"`
+	Code     string `json:"code" example:"123456" binding:"required"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" example:"3q2-7wAAAAA..." binding:"required"`
+	// A code from the authenticator app or an unused recovery code
+	Code string `json:"code" example:"123456" binding:"required"`
+
+	// Device details recorded on the session, filled in by the handler
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
+
+// NewTwoFactorService returns a TwoFactorService. Wrong codes count towards
+// a lockout of the user across challenges, so that logging in again for a
+// fresh challenge does not allow more guesses; when lockout is nil they are
+// limited only per challenge.
+func NewTwoFactorService(queries TwoFactorRepository, sessions SessionIssuer, lockout LoginLockout) *TwoFactorService {
+	return &TwoFactorService{
+		queries:  queries,
+		sessions: sessions,
+		lockout:  lockout,
+		now:      time.Now,
+	}
+}
+
+// GetStatus reports whether a user has 2FA on and how many recovery codes are left
+func (s *TwoFactorService) GetStatus(ctx context.Context, userID uuid.UUID) (*TwoFactorStatusResponse, error) {
+	ctx, span := tracer.Start(ctx, "TwoFactorService.GetStatus")
+	defer span.End()
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TotpEnabledAt.Valid {
+		return &TwoFactorStatusResponse{}, nil
+	}
+
+	remaining, err := s.queries.CountUnusedRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return &TwoFactorStatusResponse{Enabled: true, RecoveryCodesRemaining: remaining}, nil
+}
+
+// Enroll generates a new TOTP secret for a user. 2FA stays off until the
+// secret is confirmed with a code; enrolling again replaces the secret.
+func (s *TwoFactorService) Enroll(ctx context.Context, userID uuid.UUID) (*TOTPEnrollmentResponse, error) {
+	ctx, span := tracer.Start(ctx, "TwoFactorService.Enroll")
+	defer span.End()
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TotpEnabledAt.Valid {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+	if err := s.queries.SetUserTOTPSecret(ctx, db.SetUserTOTPSecretParams{
+		ID:         userID,
+		TotpSecret: sql.NullString{String: secret, Valid: true},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to store secret: %w", err)
+	}
+
+	return &TOTPEnrollmentResponse{
+		Secret: secret,
+		URI:    auth.TOTPURI(TOTPIssuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm turns 2FA on once the user proves their app produces the right
+// codes, and returns the recovery codes. They are shown only this once.
+func (s *TwoFactorService) Confirm(ctx context.Context, userID uuid.UUID, req TwoFactorCodeRequest) (*RecoveryCodesResponse, error) {
+	ctx, span := tracer.Start(ctx, "TwoFactorService.Confirm")
+	defer span.End()
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TotpEnabledAt.Valid {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if !user.TotpSecret.Valid {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	step, ok := auth.ValidateTOTP(user.TotpSecret.String, normalizeTOTPCode(req.Code), s.now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The confirming code counts as used
+	enabled, err := s.queries.EnableUserTOTP(ctx, db.EnableUserTOTPParams{
+		ID:               userID,
+		TotpLastUsedStep: sql.NullInt64{Int64: step, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+	if enabled == 0 {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// RegenerateRecoveryCodes replaces all of a user's recovery codes, used or not
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, req TwoFactorCodeRequest) (*RecoveryCodesResponse, error) {
+	ctx, span := tracer.Start(ctx, "TwoFactorService.RegenerateRecoveryCodes")
+	defer span.End()
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TotpEnabledAt.Valid {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err := s.checkCode(ctx, user, req.Code); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Disable turns 2FA off. It takes both the password and a code, so that
+// neither a stolen session nor a stolen phone is enough on its own.
+func (s *TwoFactorService) Disable(ctx context.Context, userID uuid.UUID, req DisableTwoFactorRequest) error {
+	ctx, span := tracer.Start(ctx, "TwoFactorService.Disable")
+	defer span.End()
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TotpEnabledAt.Valid {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := checkPassword(user, req.Password); err != nil {
+		return err
+	}
+	if err := s.checkCode(ctx, user, req.Code); err != nil {
+		return err
+	}
+
+	if err := s.queries.DisableUserTOTP(ctx, userID); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+	if err := s.queries.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	return nil
+}
+
+// CreateChallenge issues the token a password login returns in place of a
+// session when the user has 2FA on
+func (s *TwoFactorService) CreateChallenge(ctx context.Context, userID uuid.UUID) (string, error) {
+	ctx, span := tracer.Start(ctx, "TwoFactorService.CreateChallenge")
+	defer span.End()
+
+	token, hash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate challenge token: %w", err)
+	}
+
+	if _, err := s.queries.CreateTwoFactorChallenge(ctx, db.CreateTwoFactorChallengeParams{
+		UserID:    userID,
+		TokenHash: hash,
+		ExpiresAt: s.now().Add(twoFactorChallengeTTL),
+	}); err != nil {
+		return "", fmt.Errorf("failed to create challenge: %w", err)
+	}
+	return token, nil
+}
+
+// VerifyLogin completes a login by exchanging a challenge token and a valid
+// code for a session. A challenge survives a few wrong codes, then expires.
+func (s *TwoFactorService) VerifyLogin(ctx context.Context, req TwoFactorLoginRequest) (*LoginResponse, error) {
+	ctx, span := tracer.Start(ctx, "TwoFactorService.VerifyLogin")
+	defer span.End()
+
+	if req.ChallengeToken == "" {
+		return nil, ErrInvalidChallenge
+	}
+
+	challenge, err := s.queries.GetTwoFactorChallenge(ctx, db.GetTwoFactorChallengeParams{
+		TokenHash:   auth.HashOpaqueToken(req.ChallengeToken),
+		MaxAttempts: maxTwoFactorAttempts,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidChallenge
+		}
+		return nil, fmt.Errorf("failed to get challenge: %w", err)
+	}
+
+	user, err := s.getUser(ctx, challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TotpEnabledAt.Valid {
+		// 2FA was turned off since the password was checked
+		return nil, ErrInvalidChallenge
+	}
+	if user.DisabledAt.Valid {
+		return nil, ErrAccountDisabled
+	}
+
+	if err := s.checkLockout(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	if err := s.checkCode(ctx, user, req.Code); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			s.codeFailed(ctx, user.ID)
+			if err := s.queries.RecordTwoFactorChallengeFailure(ctx, challenge.ID); err != nil {
+				return nil, fmt.Errorf("failed to record failed attempt: %w", err)
+			}
+		}
+		return nil, err
+	}
+	s.resetLockout(ctx, user.ID)
+
+	redeemed, err := s.queries.DeleteTwoFactorChallenge(ctx, challenge.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeem challenge: %w", err)
+	}
+	if redeemed == 0 {
+		return nil, ErrInvalidChallenge
+	}
+
+	tokens, err := s.sessions.CreateSession(ctx, user.ID, SessionMetadata{
+		UserAgent: req.UserAgent,
+		IPAddress: req.IPAddress,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResponse{
+		User:         toUserResponse(user),
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+	}, nil
+}
+
+// Helper methods
+
+func (s *TwoFactorService) getUser(ctx context.Context, userID uuid.UUID) (db.User, error) {
+	user, err := s.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.User{}, ErrUserNotFound
+		}
+		return db.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// checkLockout returns an AccountLockedError while repeated wrong codes keep
+// a user's second step locked. Errors from the lockout store let the attempt
+// through rather than lock everyone out.
+func (s *TwoFactorService) checkLockout(ctx context.Context, userID uuid.UUID) error {
+	if s.lockout == nil {
+		return nil
+	}
+	wait, err := s.lockout.Check(ctx, twoFactorLockoutKey(userID))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to check two-factor lockout", "error", err)
+		return nil
+	}
+	if wait > 0 {
+		return &AccountLockedError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// codeFailed counts a wrong code towards the user's lockout
+func (s *TwoFactorService) codeFailed(ctx context.Context, userID uuid.UUID) {
+	if s.lockout == nil {
+		return
+	}
+	if _, err := s.lockout.Fail(ctx, twoFactorLockoutKey(userID)); err != nil {
+		slog.ErrorContext(ctx, "failed to record failed two-factor code", "error", err)
+	}
+}
+
+func (s *TwoFactorService) resetLockout(ctx context.Context, userID uuid.UUID) {
+	if s.lockout == nil {
+		return
+	}
+	if err := s.lockout.Reset(ctx, twoFactorLockoutKey(userID)); err != nil {
+		slog.ErrorContext(ctx, "failed to reset two-factor lockout", "error", err)
+	}
+}
+
+func twoFactorLockoutKey(userID uuid.UUID) string {
+	return "2fa:" + userID.String()
+}
+
+// checkCode accepts a current TOTP code or an unused recovery code, and uses it up
+func (s *TwoFactorService) checkCode(ctx context.Context, user db.User, code string) error {
+	if totp := normalizeTOTPCode(code); len(totp) == auth.TOTPDigits && isDigits(totp) {
+		step, ok := auth.ValidateTOTP(user.TotpSecret.String, totp, s.now())
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		used, err := s.queries.UseTOTPStep(ctx, db.UseTOTPStepParams{
+			ID:               user.ID,
+			TotpLastUsedStep: sql.NullInt64{Int64: step, Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record code use: %w", err)
+		}
+		if used == 0 {
+			// This code, or a later one, was already accepted
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if normalized == "" {
+		return ErrInvalidTwoFactorCode
+	}
+	used, err := s.queries.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+		UserID:   user.ID,
+		CodeHash: auth.HashOpaqueToken(normalized),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	if used == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// replaceRecoveryCodes stores a fresh set of recovery codes in place of the
+// old ones and returns them in the form shown to the user
+func (s *TwoFactorService) replaceRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	if err := s.queries.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for len(codes) < recoveryCodeCount {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		if err := s.queries.CreateRecoveryCode(ctx, db.CreateRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: auth.HashOpaqueToken(normalizeRecoveryCode(code)),
+		}); err != nil {
+			return nil, fmt.Errorf("failed to store recovery code: %w", err)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode returns a random 50-bit code such as "k7d2q-x9m4a"
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// normalizeRecoveryCode ignores case, dashes and spaces, which people add or drop when typing codes
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// normalizeTOTPCode drops the space some apps show in the middle of a code
+func normalizeTOTPCode(code string) string {
+	return strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,388 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"days/internal/auth"
+	"days/internal/db"
+	"days/internal/ratelimit"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockTwoFactorRepository implements a mock for the TwoFactorRepository interface
+type MockTwoFactorRepository struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorRepository) GetUserByID(ctx context.Context, id uuid.UUID) (db.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(db.User), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) SetUserTOTPSecret(ctx context.Context, arg db.SetUserTOTPSecretParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) EnableUserTOTP(ctx context.Context, arg db.EnableUserTOTPParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) DisableUserTOTP(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) UseTOTPStep(ctx context.Context, arg db.UseTOTPStepParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) CreateRecoveryCode(ctx context.Context, arg db.CreateRecoveryCodeParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) UseRecoveryCode(ctx context.Context, arg db.UseRecoveryCodeParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) CountUnusedRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) CreateTwoFactorChallenge(ctx context.Context, arg db.CreateTwoFactorChallengeParams) (db.TwoFactorChallenge, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.TwoFactorChallenge), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) GetTwoFactorChallenge(ctx context.Context, arg db.GetTwoFactorChallengeParams) (db.TwoFactorChallenge, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.TwoFactorChallenge), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) RecordTwoFactorChallengeFailure(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) DeleteTwoFactorChallenge(ctx context.Context, id uuid.UUID) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+
+var twoFactorNow = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func newTwoFactorService() (*TwoFactorService, *MockTwoFactorRepository, *MockSessionIssuer) {
+	mockQueries := new(MockTwoFactorRepository)
+	mockSessions := new(MockSessionIssuer)
+	service := NewTwoFactorService(mockQueries, mockSessions, nil)
+	service.now = func() time.Time { return twoFactorNow }
+	return service, mockQueries, mockSessions
+}
+
+// twoFactorUser returns a user with the test secret, enabled or only enrolled
+func twoFactorUser(t *testing.T, enabled bool) db.User {
+	t.Helper()
+	user := createTestUser(uuid.New(), "ann@example.com")
+	hash, err := hashPassword("password123")
+	require.NoError(t, err)
+	user.PasswordHash = sql.NullString{String: hash, Valid: true}
+	user.TotpSecret = sql.NullString{String: testTOTPSecret, Valid: true}
+	if enabled {
+		user.TotpEnabledAt = sql.NullTime{Time: twoFactorNow.Add(-time.Hour), Valid: true}
+	}
+	return user
+}
+
+func currentCode(t *testing.T) string {
+	t.Helper()
+	code, err := auth.TOTPCode(testTOTPSecret, auth.TOTPStep(twoFactorNow))
+	require.NoError(t, err)
+	return code
+}
+
+func TestTwoFactorService_Enroll(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stores a new secret", func(t *testing.T) {
+		service, mockQueries, _ := newTwoFactorService()
+		user := createTestUser(uuid.New(), "ann@example.com")
+
+		var stored db.SetUserTOTPSecretParams
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+		mockQueries.On("SetUserTOTPSecret", mock.Anything, mock.AnythingOfType("db.SetUserTOTPSecretParams")).
+			Run(func(args mock.Arguments) { stored = args.Get(1).(db.SetUserTOTPSecretParams) }).
+			Return(nil).Once()
+
+		result, err := service.Enroll(ctx, user.ID)
+
+		require.NoError(t, err)
+		assert.Equal(t, stored.TotpSecret.String, result.Secret)
+		assert.True(t, strings.HasPrefix(result.URI, "otpauth://totp/Days:ann@example.com?"))
+		assert.Contains(t, result.URI, "secret="+result.Secret)
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("already enabled", func(t *testing.T) {
+		service, mockQueries, _ := newTwoFactorService()
+		user := twoFactorUser(t, true)
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+
+		_, err := service.Enroll(ctx, user.ID)
+
+		assert.Equal(t, ErrTwoFactorAlreadyEnabled, err)
+		mockQueries.AssertNotCalled(t, "SetUserTOTPSecret", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTwoFactorService_Confirm(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("enables 2FA and returns recovery codes", func(t *testing.T) {
+		service, mockQueries, _ := newTwoFactorService()
+		user := twoFactorUser(t, false)
+
+		var hashes []string
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+		mockQueries.On("DeleteRecoveryCodes", mock.Anything, user.ID).Return(nil).Once()
+		mockQueries.On("CreateRecoveryCode", mock.Anything, mock.AnythingOfType("db.CreateRecoveryCodeParams")).
+			Run(func(args mock.Arguments) { hashes = append(hashes, args.Get(1).(db.CreateRecoveryCodeParams).CodeHash) }).
+			Return(nil).Times(recoveryCodeCount)
+		mockQueries.On("EnableUserTOTP", mock.Anything, db.EnableUserTOTPParams{
+			ID:               user.ID,
+			TotpLastUsedStep: sql.NullInt64{Int64: auth.TOTPStep(twoFactorNow), Valid: true},
+		}).Return(int64(1), nil).Once()
+
+		result, err := service.Confirm(ctx, user.ID, TwoFactorCodeRequest{Code: currentCode(t)})
+
+		require.NoError(t, err)
+		require.Len(t, result.RecoveryCodes, recoveryCodeCount)
+		for i, code := range result.RecoveryCodes {
+			assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, code)
+			assert.Equal(t, auth.HashOpaqueToken(normalizeRecoveryCode(code)), hashes[i])
+		}
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("wrong code", func(t *testing.T) {
+		service, mockQueries, _ := newTwoFactorService()
+		user := twoFactorUser(t, false)
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+
+		_, err := service.Confirm(ctx, user.ID, TwoFactorCodeRequest{Code: "000000"})
+
+		assert.Equal(t, ErrInvalidTwoFactorCode, err)
+		mockQueries.AssertNotCalled(t, "EnableUserTOTP", mock.Anything, mock.Anything)
+	})
+
+	t.Run("not enrolled", func(t *testing.T) {
+		service, mockQueries, _ := newTwoFactorService()
+		user := createTestUser(uuid.New(), "ann@example.com")
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+
+		_, err := service.Confirm(ctx, user.ID, TwoFactorCodeRequest{Code: currentCode(t)})
+
+		assert.Equal(t, ErrTwoFactorNotEnrolled, err)
+	})
+}
+
+func TestTwoFactorService_VerifyLogin(t *testing.T) {
+	ctx := context.Background()
+	challenge := db.TwoFactorChallenge{ID: uuid.New()}
+	getChallenge := db.GetTwoFactorChallengeParams{TokenHash: auth.HashOpaqueToken("challenge"), MaxAttempts: maxTwoFactorAttempts}
+	useStep := func(user db.User) db.UseTOTPStepParams {
+		return db.UseTOTPStepParams{ID: user.ID, TotpLastUsedStep: sql.NullInt64{Int64: auth.TOTPStep(twoFactorNow), Valid: true}}
+	}
+
+	t.Run("authenticator code opens a session", func(t *testing.T) {
+		service, mockQueries, mockSessions := newTwoFactorService()
+		user := twoFactorUser(t, true)
+		challenge.UserID = user.ID
+
+		mockQueries.On("GetTwoFactorChallenge", mock.Anything, getChallenge).Return(challenge, nil).Once()
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+		mockQueries.On("UseTOTPStep", mock.Anything, useStep(user)).Return(int64(1), nil).Once()
+		mockQueries.On("DeleteTwoFactorChallenge", mock.Anything, challenge.ID).Return(int64(1), nil).Once()
+		mockSessions.On("CreateSession", mock.Anything, user.ID, SessionMetadata{UserAgent: "days-test", IPAddress: "203.0.113.7"}).
+			Return(&TokenResponse{Token: "access", RefreshToken: "refresh", ExpiresIn: 900}, nil).Once()
+
+		result, err := service.VerifyLogin(ctx, TwoFactorLoginRequest{
+			ChallengeToken: "challenge",
+			Code:           currentCode(t)[:3] + " " + currentCode(t)[3:],
+			UserAgent:      "days-test",
+			IPAddress:      "203.0.113.7",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, result.User.ID)
+		assert.Equal(t, "access", result.Token)
+		assert.False(t, result.TwoFactorRequired)
+		mockQueries.AssertExpectations(t)
+		mockSessions.AssertExpectations(t)
+	})
+
+	t.Run("recovery code opens a session", func(t *testing.T) {
+		service, mockQueries, mockSessions := newTwoFactorService()
+		user := twoFactorUser(t, true)
+		challenge.UserID = user.ID
+
+		mockQueries.On("GetTwoFactorChallenge", mock.Anything, getChallenge).Return(challenge, nil).Once()
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+		mockQueries.On("UseRecoveryCode", mock.Anything, db.UseRecoveryCodeParams{UserID: user.ID, CodeHash: auth.HashOpaqueToken("k7d2qx9m4a")}).
+			Return(int64(1), nil).Once()
+		mockQueries.On("DeleteTwoFactorChallenge", mock.Anything, challenge.ID).Return(int64(1), nil).Once()
+		mockSessions.On("CreateSession", mock.Anything, user.ID, SessionMetadata{}).
+			Return(&TokenResponse{Token: "access"}, nil).Once()
+
+		_, err := service.VerifyLogin(ctx, TwoFactorLoginRequest{ChallengeToken: "challenge", Code: "K7D2Q X9M4A"})
+
+		require.NoError(t, err)
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("replayed code counts as a failed attempt", func(t *testing.T) {
+		service, mockQueries, mockSessions := newTwoFactorService()
+		user := twoFactorUser(t, true)
+		challenge.UserID = user.ID
+
+		mockQueries.On("GetTwoFactorChallenge", mock.Anything, getChallenge).Return(challenge, nil).Once()
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+		mockQueries.On("UseTOTPStep", mock.Anything, useStep(user)).Return(int64(0), nil).Once()
+		mockQueries.On("RecordTwoFactorChallengeFailure", mock.Anything, challenge.ID).Return(nil).Once()
+
+		_, err := service.VerifyLogin(ctx, TwoFactorLoginRequest{ChallengeToken: "challenge", Code: currentCode(t)})
+
+		assert.Equal(t, ErrInvalidTwoFactorCode, err)
+		mockQueries.AssertExpectations(t)
+		mockQueries.AssertNotCalled(t, "DeleteTwoFactorChallenge", mock.Anything, mock.Anything)
+		mockSessions.AssertNotCalled(t, "CreateSession", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("wrong codes across challenges lock the user", func(t *testing.T) {
+		service, mockQueries, mockSessions := newTwoFactorService()
+		service.lockout = ratelimit.NewLockout(ratelimit.NewMemoryStore(), ratelimit.DefaultLockoutPolicy)
+		user := twoFactorUser(t, true)
+		challenge.UserID = user.ID
+
+		// Each attempt comes with a fresh challenge, as after logging in again
+		mockQueries.On("GetTwoFactorChallenge", mock.Anything, getChallenge).Return(challenge, nil)
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+		mockQueries.On("RecordTwoFactorChallengeFailure", mock.Anything, challenge.ID).Return(nil).
+			Times(ratelimit.DefaultLockoutPolicy.Threshold)
+
+		for i := 0; i < ratelimit.DefaultLockoutPolicy.Threshold; i++ {
+			_, err := service.VerifyLogin(ctx, TwoFactorLoginRequest{ChallengeToken: "challenge", Code: "000000"})
+			assert.Equal(t, ErrInvalidTwoFactorCode, err, "attempt %d", i)
+		}
+
+		// Now even the right code is refused until the lock runs out
+		_, err := service.VerifyLogin(ctx, TwoFactorLoginRequest{ChallengeToken: "challenge", Code: currentCode(t)})
+		var locked *AccountLockedError
+		require.ErrorAs(t, err, &locked)
+		assert.InDelta(t, ratelimit.DefaultLockoutPolicy.Base.Seconds(), locked.RetryAfter.Seconds(), 1)
+		mockQueries.AssertExpectations(t)
+		mockQueries.AssertNotCalled(t, "UseTOTPStep", mock.Anything, mock.Anything)
+		mockSessions.AssertNotCalled(t, "CreateSession", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown, expired or exhausted challenge", func(t *testing.T) {
+		service, mockQueries, _ := newTwoFactorService()
+		mockQueries.On("GetTwoFactorChallenge", mock.Anything, getChallenge).Return(db.TwoFactorChallenge{}, sql.ErrNoRows).Once()
+
+		_, err := service.VerifyLogin(ctx, TwoFactorLoginRequest{ChallengeToken: "challenge", Code: "123456"})
+
+		assert.Equal(t, ErrInvalidChallenge, err)
+	})
+
+	t.Run("challenge redeemed concurrently", func(t *testing.T) {
+		service, mockQueries, mockSessions := newTwoFactorService()
+		user := twoFactorUser(t, true)
+		challenge.UserID = user.ID
+
+		mockQueries.On("GetTwoFactorChallenge", mock.Anything, getChallenge).Return(challenge, nil).Once()
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+		mockQueries.On("UseTOTPStep", mock.Anything, useStep(user)).Return(int64(1), nil).Once()
+		mockQueries.On("DeleteTwoFactorChallenge", mock.Anything, challenge.ID).Return(int64(0), nil).Once()
+
+		_, err := service.VerifyLogin(ctx, TwoFactorLoginRequest{ChallengeToken: "challenge", Code: currentCode(t)})
+
+		assert.Equal(t, ErrInvalidChallenge, err)
+		mockSessions.AssertNotCalled(t, "CreateSession", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestTwoFactorService_CreateChallenge(t *testing.T) {
+	ctx := context.Background()
+	service, mockQueries, _ := newTwoFactorService()
+	userID := uuid.New()
+
+	var stored db.CreateTwoFactorChallengeParams
+	mockQueries.On("CreateTwoFactorChallenge", mock.Anything, mock.AnythingOfType("db.CreateTwoFactorChallengeParams")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(db.CreateTwoFactorChallengeParams) }).
+		Return(db.TwoFactorChallenge{}, nil).Once()
+
+	token, err := service.CreateChallenge(ctx, userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, userID, stored.UserID)
+	assert.Equal(t, auth.HashOpaqueToken(token), stored.TokenHash)
+	assert.Equal(t, twoFactorNow.Add(twoFactorChallengeTTL), stored.ExpiresAt)
+}
+
+func TestTwoFactorService_Disable(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("disables with password and code", func(t *testing.T) {
+		service, mockQueries, _ := newTwoFactorService()
+		user := twoFactorUser(t, true)
+
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+		mockQueries.On("UseTOTPStep", mock.Anything, mock.AnythingOfType("db.UseTOTPStepParams")).Return(int64(1), nil).Once()
+		mockQueries.On("DisableUserTOTP", mock.Anything, user.ID).Return(nil).Once()
+		mockQueries.On("DeleteRecoveryCodes", mock.Anything, user.ID).Return(nil).Once()
+
+		require.NoError(t, service.Disable(ctx, user.ID, DisableTwoFactorRequest{Password: "password123", Code: currentCode(t)}))
+		mockQueries.AssertExpectations(t)
+	})
+
+	tests := []struct {
+		name        string
+		enabled     bool
+		req         DisableTwoFactorRequest
+		expectedErr error
+	}{
+		{"wrong password", true, DisableTwoFactorRequest{Password: "wrongpassword", Code: "123456"}, ErrIncorrectPassword},
+		{"not enabled", false, DisableTwoFactorRequest{Password: "password123", Code: "123456"}, ErrTwoFactorNotEnabled},
+		{"empty code", true, DisableTwoFactorRequest{Password: "password123"}, ErrInvalidTwoFactorCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockQueries, _ := newTwoFactorService()
+			user := twoFactorUser(t, tt.enabled)
+			mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+
+			assert.Equal(t, tt.expectedErr, service.Disable(ctx, user.ID, tt.req))
+			mockQueries.AssertNotCalled(t, "DisableUserTOTP", mock.Anything, mock.Anything)
+		})
+	}
+}
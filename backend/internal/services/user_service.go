@@ -8,12 +8,12 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/mail"
-	"os"
 	"strings"
 	"time"
 
-	"days/internal/auth"
+	"days/internal/audit"
 	"days/internal/db"
 
 	"github.com/google/uuid"
@@ -26,48 +26,131 @@ var (
 	ErrWeakPassword       = errors.New("password must be at least 8 characters")
 	ErrEmailExists        = errors.New("email already exists")
 	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrAccountLocked      = errors.New("too many failed login attempts, try again later")
+	ErrPasswordNotSet     = errors.New("account has no password, set one with a password reset")
+	ErrAccountDisabled    = errors.New("account is disabled")
 )
 
+// Actions recorded in the audit log for account changes
+const (
+	AuditActionSignup          = "user.created"
+	AuditActionLogin           = "user.login"
+	AuditActionLoginFailed     = "user.login_failed"
+	AuditActionPasswordChanged = "user.password_changed"
+	AuditActionEmailChanged    = "user.email_changed"
+	AuditActionAccountDeleted  = "user.deleted"
+)
+
+// AccountLockedError is returned by Login, and by TwoFactorService.VerifyLogin,
+// while repeated failures keep an account locked. It matches ErrAccountLocked with errors.Is.
+type AccountLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *AccountLockedError) Error() string { return ErrAccountLocked.Error() }
+
+func (e *AccountLockedError) Unwrap() error { return ErrAccountLocked }
+
 type UserService struct {
-	queries UserRepository
+	queries    UserRepository
+	sessions   SessionIssuer
+	verifier   EmailVerifier
+	challenges TwoFactorChallenger
+	lockout    LoginLockout
+	audit      *audit.Recorder
 }
 
 type CreateUserRequest struct {
 	Email    string `json:"email" example:"user@example.com" binding:"required"`
 	Password string `json:"password" example:"password123" binding:"required,min=8"`
+
+	// Device details recorded on the audit record, filled in by the handler
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
 }
 
 type UserResponse struct {
-	ID        uuid.UUID `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
-	Email     string    `json:"email" example:"user@example.com"`
-	CreatedAt string    `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	ID            uuid.UUID `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Email         string    `json:"email" example:"user@example.com"`
+	EmailVerified bool      `json:"email_verified" example:"false"`
+	CreatedAt     string    `json:"created_at" example:"2023-01-01T00:00:00Z"`
 }
 
 type LoginRequest struct {
 	Email    string `json:"email" example:"user@example.com" binding:"required"`
 	Password string `json:"password" example:"password123" binding:"required"`
+
+	// Device details recorded on the session and audit record, filled in by the handler
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
 }
 
+// LoginResponse either carries the tokens of a new session or, for accounts
+// with 2FA on, a challenge to complete at /api/auth/2fa/verify
 type LoginResponse struct {
-	User  UserResponse `json:"user"`
-	Token string       `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	User         *UserResponse `json:"user,omitempty"`
+	Token        string        `json:"token,omitempty" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string        `json:"refresh_token,omitempty" example:"3q2-7wAAAAA..."`
+	ExpiresIn    int64         `json:"expires_in,omitempty" example:"900"` // access token lifetime in seconds
+
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty" example:"false"`
+	ChallengeToken    string `json:"challenge_token,omitempty" example:"3q2-7wAAAAA..."`
 }
 
-func NewUserService(queries UserRepository) *UserService {
+// ChangePasswordRequest sets a new password; the current one is required
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" example:"password123" binding:"required"`
+	NewPassword     string `json:"new_password" example:"newpassword123" binding:"required,min=8"`
+
+	// Device details recorded on the new session and audit record, filled in by the handler
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" example:"new@example.com" binding:"required"`
+	Password string `json:"password" example:"password123" binding:"required"`
+
+	// Device details recorded on the audit record, filled in by the handler
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" example:"password123" binding:"required"`
+
+	// Device details recorded on the audit record, filled in by the handler
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
+
+// NewUserService returns a UserService. When verifier is nil new users are
+// not sent a verification email. When challenges is nil users with 2FA on
+// cannot log in. When lockout is nil failed logins are not limited.
+// Account events are recorded in the audit log through queries.
+func NewUserService(queries UserRepository, sessions SessionIssuer, verifier EmailVerifier, challenges TwoFactorChallenger, lockout LoginLockout) *UserService {
 	return &UserService{
-		queries: queries,
+		queries:    queries,
+		sessions:   sessions,
+		verifier:   verifier,
+		challenges: challenges,
+		lockout:    lockout,
+		audit:      audit.NewRecorder(queries),
 	}
 }
 
 // CreateUser creates a new user with email and password validation
 func (s *UserService) CreateUser(ctx context.Context, req CreateUserRequest) (*UserResponse, error) {
+	ctx, span := tracer.Start(ctx, "UserService.CreateUser")
+	defer span.End()
+
 	// Validate email
-	if err := s.validateEmail(req.Email); err != nil {
+	if err := validateEmail(req.Email); err != nil {
 		return nil, err
 	}
 
 	// Validate password
-	if err := s.validatePassword(req.Password); err != nil {
+	if err := validatePassword(req.Password); err != nil {
 		return nil, err
 	}
 
@@ -81,7 +164,7 @@ func (s *UserService) CreateUser(ctx context.Context, req CreateUserRequest) (*U
 	}
 
 	// Hash password
-	hashedPassword, err := s.hashPassword(req.Password)
+	hashedPassword, err := hashPassword(req.Password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
@@ -94,12 +177,28 @@ func (s *UserService) CreateUser(ctx context.Context, req CreateUserRequest) (*U
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
+	s.audit.Record(ctx, audit.Event{
+		UserID:    user.ID,
+		Action:    AuditActionSignup,
+		UserAgent: req.UserAgent,
+		IPAddress: req.IPAddress,
+	})
+
+	// The account is usable either way, and a new link can be requested later
+	if s.verifier != nil {
+		if err := s.verifier.SendVerificationEmail(ctx, user.ID); err != nil {
+			slog.ErrorContext(ctx, "failed to send verification email", "user_id", user.ID, "error", err)
+		}
+	}
 
-	return s.toUserResponse(user), nil
+	return toUserResponse(user), nil
 }
 
 // GetUserByID retrieves a user by ID
 func (s *UserService) GetUserByID(ctx context.Context, userID uuid.UUID) (*UserResponse, error) {
+	ctx, span := tracer.Start(ctx, "UserService.GetUserByID")
+	defer span.End()
+
 	user, err := s.queries.GetUserByID(ctx, userID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -108,11 +207,14 @@ func (s *UserService) GetUserByID(ctx context.Context, userID uuid.UUID) (*UserR
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	return s.toUserResponse(user), nil
+	return toUserResponse(user), nil
 }
 
 // GetUserByEmail retrieves a user by email
 func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*UserResponse, error) {
+	ctx, span := tracer.Start(ctx, "UserService.GetUserByEmail")
+	defer span.End()
+
 	user, err := s.queries.GetUserByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -121,44 +223,300 @@ func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*UserRe
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	return s.toUserResponse(user), nil
+	return toUserResponse(user), nil
 }
 
-// Login authenticates a user and returns user info with token
+// Login authenticates a user and returns user info with token. Users with
+// 2FA on get a challenge token instead, to exchange along with a code.
 func (s *UserService) Login(ctx context.Context, req LoginRequest) (*LoginResponse, error) {
+	ctx, span := tracer.Start(ctx, "UserService.Login")
+	defer span.End()
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	// A locked account rejects even the right password, so guesses cannot be tested
+	if err := s.checkLockout(ctx, email); err != nil {
+		return nil, err
+	}
+
 	// Get user by email
-	user, err := s.queries.GetUserByEmail(ctx, strings.ToLower(strings.TrimSpace(req.Email)))
+	user, err := s.queries.GetUserByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, ErrInvalidCredentials
+			return nil, s.loginFailed(ctx, email)
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	// Verify password
-	if !s.verifyPassword(req.Password, user.PasswordHash) {
-		return nil, ErrInvalidCredentials
+	// Verify password; accounts created through single sign-on may have none
+	if !user.PasswordHash.Valid || !verifyPassword(req.Password, user.PasswordHash.String) {
+		s.audit.Record(ctx, audit.Event{
+			UserID:    user.ID,
+			Action:    AuditActionLoginFailed,
+			UserAgent: req.UserAgent,
+			IPAddress: req.IPAddress,
+		})
+		return nil, s.loginFailed(ctx, email)
+	}
+	s.resetLockout(ctx, email)
+
+	// Only someone who knows the password learns that the account is disabled
+	if user.DisabledAt.Valid {
+		s.audit.Record(ctx, audit.Event{
+			UserID:    user.ID,
+			Action:    AuditActionLoginFailed,
+			Metadata:  map[string]string{"reason": "disabled"},
+			UserAgent: req.UserAgent,
+			IPAddress: req.IPAddress,
+		})
+		return nil, ErrAccountDisabled
+	}
+
+	// With 2FA on the password is only the first step, which the event notes
+	event := audit.Event{
+		UserID:    user.ID,
+		Action:    AuditActionLogin,
+		Metadata:  map[string]string{"method": "password"},
+		UserAgent: req.UserAgent,
+		IPAddress: req.IPAddress,
+	}
+	if user.TotpEnabledAt.Valid {
+		event.Metadata["two_factor"] = "required"
 	}
+	s.audit.Record(ctx, event)
 
-	// Generate JWT token
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return nil, fmt.Errorf("server misconfigured: missing JWT secret")
+	// The password alone does not open a session when 2FA is on
+	if user.TotpEnabledAt.Valid {
+		if s.challenges == nil {
+			return nil, errors.New("two-factor authentication is not configured")
+		}
+		challenge, err := s.challenges.CreateChallenge(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResponse{TwoFactorRequired: true, ChallengeToken: challenge}, nil
 	}
-	token, err := auth.GenerateToken(user.ID, secret, 24*time.Hour)
+
+	// Open a session and issue its access and refresh tokens
+	tokens, err := s.sessions.CreateSession(ctx, user.ID, SessionMetadata{
+		UserAgent: req.UserAgent,
+		IPAddress: req.IPAddress,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, err
 	}
 
 	return &LoginResponse{
-		User:  *s.toUserResponse(user),
-		Token: token,
+		User:         toUserResponse(user),
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
 	}, nil
 }
 
+// ChangePassword sets a new password after checking the current one. Every
+// session is signed out, including the current one, and the caller gets the
+// tokens of a fresh session in return.
+func (s *UserService) ChangePassword(ctx context.Context, userID uuid.UUID, req ChangePasswordRequest) (*TokenResponse, error) {
+	ctx, span := tracer.Start(ctx, "UserService.ChangePassword")
+	defer span.End()
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPassword(user, req.CurrentPassword); err != nil {
+		return nil, err
+	}
+	if err := validatePassword(req.NewPassword); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := hashPassword(req.NewPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := s.queries.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		ID:           userID,
+		PasswordHash: hashedPassword,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to update password: %w", err)
+	}
+
+	// Tokens issued under the old password stop working, as do reset links
+	if err := s.queries.RevokeSessionsByUserID(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	if err := s.queries.InvalidateAccountTokens(ctx, db.InvalidateAccountTokensParams{
+		UserID:  userID,
+		Purpose: TokenPurposePasswordReset,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to invalidate password reset tokens: %w", err)
+	}
+	s.audit.Record(ctx, audit.Event{
+		UserID:    userID,
+		Action:    AuditActionPasswordChanged,
+		UserAgent: req.UserAgent,
+		IPAddress: req.IPAddress,
+	})
+
+	return s.sessions.CreateSession(ctx, userID, SessionMetadata{
+		UserAgent: req.UserAgent,
+		IPAddress: req.IPAddress,
+	})
+}
+
+// ChangeEmail moves an account to a new email address after checking the
+// password. The new address starts unverified and is sent a verification email.
+func (s *UserService) ChangeEmail(ctx context.Context, userID uuid.UUID, req ChangeEmailRequest) (*UserResponse, error) {
+	ctx, span := tracer.Start(ctx, "UserService.ChangeEmail")
+	defer span.End()
+
+	if err := validateEmail(req.Email); err != nil {
+		return nil, err
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPassword(user, req.Password); err != nil {
+		return nil, err
+	}
+	if email == user.Email {
+		return toUserResponse(user), nil
+	}
+
+	_, err = s.queries.GetUserByEmail(ctx, email)
+	if err == nil {
+		return nil, ErrEmailExists
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to check email existence: %w", err)
+	}
+
+	if err := s.queries.UpdateUserEmail(ctx, db.UpdateUserEmailParams{
+		ID:    userID,
+		Email: email,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to update email: %w", err)
+	}
+
+	// Links mailed to the old address must not outlive it, whether or not a
+	// verification email goes out below
+	for _, purpose := range []string{TokenPurposePasswordReset, TokenPurposeEmailVerification} {
+		if err := s.queries.InvalidateAccountTokens(ctx, db.InvalidateAccountTokensParams{
+			UserID:  userID,
+			Purpose: purpose,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to invalidate account tokens: %w", err)
+		}
+	}
+	s.audit.Record(ctx, audit.Event{
+		UserID: userID,
+		Action: AuditActionEmailChanged,
+		Metadata: map[string]string{
+			"old_email": user.Email,
+			"new_email": email,
+		},
+		UserAgent: req.UserAgent,
+		IPAddress: req.IPAddress,
+	})
+
+	if s.verifier != nil {
+		if err := s.verifier.SendVerificationEmail(ctx, userID); err != nil {
+			slog.ErrorContext(ctx, "failed to send verification email", "user_id", userID, "error", err)
+		}
+	}
+
+	user.Email = email
+	user.EmailVerifiedAt = sql.NullTime{}
+	return toUserResponse(user), nil
+}
+
+// DeleteAccount deletes a user and, through the database's cascading
+// foreign keys, everything they own, after checking the password
+func (s *UserService) DeleteAccount(ctx context.Context, userID uuid.UUID, req DeleteAccountRequest) error {
+	ctx, span := tracer.Start(ctx, "UserService.DeleteAccount")
+	defer span.End()
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := checkPassword(user, req.Password); err != nil {
+		return err
+	}
+
+	if err := s.queries.DeleteUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	s.audit.Record(ctx, audit.Event{
+		UserID:    userID,
+		Action:    AuditActionAccountDeleted,
+		UserAgent: req.UserAgent,
+		IPAddress: req.IPAddress,
+	})
+	return nil
+}
+
 // Helper methods
 
-func (s *UserService) validateEmail(email string) error {
+func (s *UserService) getUser(ctx context.Context, userID uuid.UUID) (db.User, error) {
+	user, err := s.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.User{}, ErrUserNotFound
+		}
+		return db.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// checkLockout returns an AccountLockedError while an email address is locked.
+// Lockouts are keyed by address whether or not it has an account, so they do
+// not reveal which addresses exist. Errors from the lockout store let the
+// login through rather than lock everyone out.
+func (s *UserService) checkLockout(ctx context.Context, email string) error {
+	if s.lockout == nil {
+		return nil
+	}
+	wait, err := s.lockout.Check(ctx, loginLockoutKey(email))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to check login lockout", "error", err)
+		return nil
+	}
+	if wait > 0 {
+		return &AccountLockedError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// loginFailed counts a failed login towards a lockout and returns ErrInvalidCredentials
+func (s *UserService) loginFailed(ctx context.Context, email string) error {
+	if s.lockout != nil {
+		if _, err := s.lockout.Fail(ctx, loginLockoutKey(email)); err != nil {
+			slog.ErrorContext(ctx, "failed to record failed login", "error", err)
+		}
+	}
+	return ErrInvalidCredentials
+}
+
+func (s *UserService) resetLockout(ctx context.Context, email string) {
+	if s.lockout == nil {
+		return
+	}
+	if err := s.lockout.Reset(ctx, loginLockoutKey(email)); err != nil {
+		slog.ErrorContext(ctx, "failed to reset login lockout", "error", err)
+	}
+}
+
+func loginLockoutKey(email string) string {
+	return "login:" + email
+}
+
+func validateEmail(email string) error {
 	email = strings.TrimSpace(email)
 	if email == "" {
 		return ErrInvalidEmail
@@ -172,14 +530,14 @@ func (s *UserService) validateEmail(email string) error {
 	return nil
 }
 
-func (s *UserService) validatePassword(password string) error {
+func validatePassword(password string) error {
 	if len(password) < 8 {
 		return ErrWeakPassword
 	}
 	return nil
 }
 
-func (s *UserService) hashPassword(password string) (string, error) {
+func hashPassword(password string) (string, error) {
 	// Generate random salt
 	salt := make([]byte, 16)
 	if _, err := rand.Read(salt); err != nil {
@@ -196,7 +554,18 @@ func (s *UserService) hashPassword(password string) (string, error) {
 	return fmt.Sprintf("%s:%s", saltHex, hashHex), nil
 }
 
-func (s *UserService) verifyPassword(password, hashedPassword string) bool {
+// checkPassword confirms a password for an action on the user's account
+func checkPassword(user db.User, password string) error {
+	if !user.PasswordHash.Valid {
+		return ErrPasswordNotSet
+	}
+	if !verifyPassword(password, user.PasswordHash.String) {
+		return ErrIncorrectPassword
+	}
+	return nil
+}
+
+func verifyPassword(password, hashedPassword string) bool {
 	parts := strings.Split(hashedPassword, ":")
 	if len(parts) != 2 {
 		return false
@@ -226,15 +595,16 @@ func (s *UserService) generateSessionToken() string {
 	return hex.EncodeToString(tokenBytes)
 }
 
-func (s *UserService) toUserResponse(user db.User) *UserResponse {
+func toUserResponse(user db.User) *UserResponse {
 	var createdAt string
 	if user.CreatedAt.Valid {
 		createdAt = user.CreatedAt.Time.Format("2006-01-02T15:04:05Z")
 	}
 
 	return &UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		CreatedAt: createdAt,
+		ID:            user.ID,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+		CreatedAt:     createdAt,
 	}
 }
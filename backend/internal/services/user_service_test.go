@@ -3,10 +3,13 @@ package services
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
 	"days/internal/db"
+	"days/internal/ratelimit"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -34,20 +37,88 @@ func (m *MockQueries) GetUserByID(ctx context.Context, id uuid.UUID) (db.User, e
 	return args.Get(0).(db.User), args.Error(1)
 }
 
+func (m *MockQueries) UpdateUserPassword(ctx context.Context, arg db.UpdateUserPasswordParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) UpdateUserEmail(ctx context.Context, arg db.UpdateUserEmailParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockQueries) RevokeSessionsByUserID(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockQueries) InvalidateAccountTokens(ctx context.Context, arg db.InvalidateAccountTokensParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) CreateAuditEvent(ctx context.Context, arg db.CreateAuditEventParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+// expectAuditEvent expects one audit event of action to be recorded for userID
+func expectAuditEvent(m *MockQueries, userID uuid.UUID, action string) {
+	m.On("CreateAuditEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateAuditEventParams) bool {
+		return arg.UserID == userID && arg.Action == action
+	})).Return(nil).Once()
+}
+
+// MockSessionIssuer implements a mock for the SessionIssuer interface
+type MockSessionIssuer struct {
+	mock.Mock
+}
+
+func (m *MockSessionIssuer) CreateSession(ctx context.Context, userID uuid.UUID, meta SessionMetadata) (*TokenResponse, error) {
+	args := m.Called(ctx, userID, meta)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*TokenResponse), args.Error(1)
+}
+
+// MockTwoFactorChallenger implements a mock for the TwoFactorChallenger interface
+type MockTwoFactorChallenger struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorChallenger) CreateChallenge(ctx context.Context, userID uuid.UUID) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
+
+// MockEmailVerifier implements a mock for the EmailVerifier interface
+type MockEmailVerifier struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerifier) SendVerificationEmail(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
 // Helper function to create a test user
 func createTestUser(id uuid.UUID, email string) db.User {
 	return db.User{
 		ID:           id,
 		Email:        email,
-		PasswordHash: "hashed_password",
+		PasswordHash: sql.NullString{String: "hashed_password", Valid: true},
 		CreatedAt:    sql.NullTime{Time: time.Now(), Valid: true},
 		UpdatedAt:    sql.NullTime{Time: time.Now(), Valid: true},
 	}
 }
 
-func TestUserService_validateEmail(t *testing.T) {
-	service := &UserService{}
-
+func TestValidateEmail(t *testing.T) {
 	tests := []struct {
 		name    string
 		email   string
@@ -65,7 +136,7 @@ func TestUserService_validateEmail(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := service.validateEmail(tt.email)
+			err := validateEmail(tt.email)
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Equal(t, ErrInvalidEmail, err)
@@ -76,9 +147,7 @@ func TestUserService_validateEmail(t *testing.T) {
 	}
 }
 
-func TestUserService_validatePassword(t *testing.T) {
-	service := &UserService{}
-
+func TestValidatePassword(t *testing.T) {
 	tests := []struct {
 		name     string
 		password string
@@ -93,7 +162,7 @@ func TestUserService_validatePassword(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := service.validatePassword(tt.password)
+			err := validatePassword(tt.password)
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Equal(t, ErrWeakPassword, err)
@@ -104,30 +173,28 @@ func TestUserService_validatePassword(t *testing.T) {
 	}
 }
 
-func TestUserService_hashPassword(t *testing.T) {
-	service := &UserService{}
+func TestHashPassword(t *testing.T) {
 	password := "testpassword123"
 
-	hash1, err := service.hashPassword(password)
+	hash1, err := hashPassword(password)
 	require.NoError(t, err)
 	assert.NotEmpty(t, hash1)
 	assert.Contains(t, hash1, ":") // Should contain salt:hash format
 
 	// Hash same password again - should be different due to random salt
-	hash2, err := service.hashPassword(password)
+	hash2, err := hashPassword(password)
 	require.NoError(t, err)
 	assert.NotEqual(t, hash1, hash2)
 
 	// Both hashes should verify successfully
-	assert.True(t, service.verifyPassword(password, hash1))
-	assert.True(t, service.verifyPassword(password, hash2))
+	assert.True(t, verifyPassword(password, hash1))
+	assert.True(t, verifyPassword(password, hash2))
 }
 
-func TestUserService_verifyPassword(t *testing.T) {
-	service := &UserService{}
+func TestVerifyPassword(t *testing.T) {
 	password := "correctpassword"
 
-	hash, err := service.hashPassword(password)
+	hash, err := hashPassword(password)
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -147,7 +214,7 @@ func TestUserService_verifyPassword(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := service.verifyPassword(tt.password, tt.hash)
+			result := verifyPassword(tt.password, tt.hash)
 			assert.Equal(t, tt.want, result)
 		})
 	}
@@ -156,25 +223,35 @@ func TestUserService_verifyPassword(t *testing.T) {
 func TestUserService_CreateUser(t *testing.T) {
 	ctx := context.Background()
 	mockQueries := new(MockQueries)
-	service := NewUserService(mockQueries)
+	service := NewUserService(mockQueries, nil, nil, nil, nil)
 
 	t.Run("successful user creation", func(t *testing.T) {
 		req := CreateUserRequest{
-			Email:    "test@example.com",
-			Password: "password123",
+			Email:     "test@example.com",
+			Password:  "password123",
+			UserAgent: "days-test",
+			IPAddress: "203.0.113.7",
 		}
 
 		userID := uuid.New()
 		expectedUser := createTestUser(userID, "test@example.com")
 
 		// Mock email check (user doesn't exist)
-		mockQueries.On("GetUserByEmail", ctx, "test@example.com").
+		mockQueries.On("GetUserByEmail", mock.Anything, "test@example.com").
 			Return(db.User{}, sql.ErrNoRows).Once()
 
 		// Mock user creation
-		mockQueries.On("CreateUser", ctx, mock.MatchedBy(func(params db.CreateUserParams) bool {
+		mockQueries.On("CreateUser", mock.Anything, mock.MatchedBy(func(params db.CreateUserParams) bool {
 			return params.Email == "test@example.com" && params.PasswordHash != ""
 		})).Return(expectedUser, nil).Once()
+		mockQueries.On("CreateAuditEvent", mock.Anything, db.CreateAuditEventParams{
+			UserID:    userID,
+			Action:    AuditActionSignup,
+			Metadata:  json.RawMessage("{}"),
+			Changes:   json.RawMessage("{}"),
+			UserAgent: "days-test",
+			IpAddress: "203.0.113.7",
+		}).Return(nil).Once()
 
 		result, err := service.CreateUser(ctx, req)
 
@@ -185,6 +262,27 @@ func TestUserService_CreateUser(t *testing.T) {
 		mockQueries.AssertExpectations(t)
 	})
 
+	t.Run("sends a verification email", func(t *testing.T) {
+		for _, sendErr := range []error{nil, errors.New("mail server down")} {
+			mockVerifier := new(MockEmailVerifier)
+			service := NewUserService(mockQueries, nil, mockVerifier, nil, nil)
+			userID := uuid.New()
+
+			mockQueries.On("GetUserByEmail", mock.Anything, "new@example.com").Return(db.User{}, sql.ErrNoRows).Once()
+			mockQueries.On("CreateUser", mock.Anything, mock.AnythingOfType("db.CreateUserParams")).
+				Return(createTestUser(userID, "new@example.com"), nil).Once()
+			expectAuditEvent(mockQueries, userID, AuditActionSignup)
+			mockVerifier.On("SendVerificationEmail", mock.Anything, userID).Return(sendErr).Once()
+
+			// Mail failures do not undo the registration
+			result, err := service.CreateUser(ctx, CreateUserRequest{Email: "new@example.com", Password: "password123"})
+			require.NoError(t, err)
+			assert.Equal(t, userID, result.ID)
+			assert.False(t, result.EmailVerified)
+			mockVerifier.AssertExpectations(t)
+		}
+	})
+
 	t.Run("invalid email", func(t *testing.T) {
 		req := CreateUserRequest{
 			Email:    "invalid-email",
@@ -220,7 +318,7 @@ func TestUserService_CreateUser(t *testing.T) {
 		existingUser := createTestUser(uuid.New(), "existing@example.com")
 
 		// Mock email check (user exists)
-		mockQueries.On("GetUserByEmail", ctx, "existing@example.com").
+		mockQueries.On("GetUserByEmail", mock.Anything, "existing@example.com").
 			Return(existingUser, nil).Once()
 
 		result, err := service.CreateUser(ctx, req)
@@ -234,13 +332,13 @@ func TestUserService_CreateUser(t *testing.T) {
 func TestUserService_GetUserByID(t *testing.T) {
 	ctx := context.Background()
 	mockQueries := new(MockQueries)
-	service := NewUserService(mockQueries)
+	service := NewUserService(mockQueries, nil, nil, nil, nil)
 
 	t.Run("user found", func(t *testing.T) {
 		userID := uuid.New()
 		expectedUser := createTestUser(userID, "test@example.com")
 
-		mockQueries.On("GetUserByID", ctx, userID).
+		mockQueries.On("GetUserByID", mock.Anything, userID).
 			Return(expectedUser, nil).Once()
 
 		result, err := service.GetUserByID(ctx, userID)
@@ -254,7 +352,7 @@ func TestUserService_GetUserByID(t *testing.T) {
 	t.Run("user not found", func(t *testing.T) {
 		userID := uuid.New()
 
-		mockQueries.On("GetUserByID", ctx, userID).
+		mockQueries.On("GetUserByID", mock.Anything, userID).
 			Return(db.User{}, sql.ErrNoRows).Once()
 
 		result, err := service.GetUserByID(ctx, userID)
@@ -268,10 +366,8 @@ func TestUserService_GetUserByID(t *testing.T) {
 func TestUserService_Login(t *testing.T) {
 	ctx := context.Background()
 	mockQueries := new(MockQueries)
-	service := NewUserService(mockQueries)
-
-	// Set up environment for JWT generation
-	t.Setenv("JWT_SECRET", "test-secret-for-login")
+	mockSessions := new(MockSessionIssuer)
+	service := NewUserService(mockQueries, mockSessions, nil, nil, nil)
 
 	t.Run("successful login", func(t *testing.T) {
 		userID := uuid.New()
@@ -279,31 +375,46 @@ func TestUserService_Login(t *testing.T) {
 		email := "test@example.com"
 
 		// Hash the password like the service would
-		hash, err := service.hashPassword(password)
+		hash, err := hashPassword(password)
 		require.NoError(t, err)
 
 		user := db.User{
 			ID:           userID,
 			Email:        email,
-			PasswordHash: hash,
+			PasswordHash: sql.NullString{String: hash, Valid: true},
 			CreatedAt:    sql.NullTime{Time: time.Now(), Valid: true},
 		}
 
 		req := LoginRequest{
-			Email:    email,
-			Password: password,
+			Email:     email,
+			Password:  password,
+			UserAgent: "days-test",
+			IPAddress: "203.0.113.7",
 		}
 
-		mockQueries.On("GetUserByEmail", ctx, email).
+		mockQueries.On("GetUserByEmail", mock.Anything, email).
 			Return(user, nil).Once()
+		mockQueries.On("CreateAuditEvent", mock.Anything, db.CreateAuditEventParams{
+			UserID:    userID,
+			Action:    AuditActionLogin,
+			Metadata:  json.RawMessage(`{"method":"password"}`),
+			Changes:   json.RawMessage("{}"),
+			UserAgent: "days-test",
+			IpAddress: "203.0.113.7",
+		}).Return(nil).Once()
+		mockSessions.On("CreateSession", mock.Anything, userID, SessionMetadata{UserAgent: "days-test", IPAddress: "203.0.113.7"}).
+			Return(&TokenResponse{Token: "access", RefreshToken: "refresh", ExpiresIn: 900}, nil).Once()
 
 		result, err := service.Login(ctx, req)
 
 		require.NoError(t, err)
 		assert.Equal(t, userID, result.User.ID)
 		assert.Equal(t, email, result.User.Email)
-		assert.NotEmpty(t, result.Token)
+		assert.Equal(t, "access", result.Token)
+		assert.Equal(t, "refresh", result.RefreshToken)
+		assert.Equal(t, int64(900), result.ExpiresIn)
 		mockQueries.AssertExpectations(t)
+		mockSessions.AssertExpectations(t)
 	})
 
 	t.Run("user not found", func(t *testing.T) {
@@ -312,7 +423,7 @@ func TestUserService_Login(t *testing.T) {
 			Password: "password123",
 		}
 
-		mockQueries.On("GetUserByEmail", ctx, "nonexistent@example.com").
+		mockQueries.On("GetUserByEmail", mock.Anything, "nonexistent@example.com").
 			Return(db.User{}, sql.ErrNoRows).Once()
 
 		result, err := service.Login(ctx, req)
@@ -327,13 +438,13 @@ func TestUserService_Login(t *testing.T) {
 		email := "test@example.com"
 
 		// Hash a different password
-		hash, err := service.hashPassword("correctpassword")
+		hash, err := hashPassword("correctpassword")
 		require.NoError(t, err)
 
 		user := db.User{
 			ID:           userID,
 			Email:        email,
-			PasswordHash: hash,
+			PasswordHash: sql.NullString{String: hash, Valid: true},
 		}
 
 		req := LoginRequest{
@@ -341,8 +452,9 @@ func TestUserService_Login(t *testing.T) {
 			Password: "wrongpassword",
 		}
 
-		mockQueries.On("GetUserByEmail", ctx, email).
+		mockQueries.On("GetUserByEmail", mock.Anything, email).
 			Return(user, nil).Once()
+		expectAuditEvent(mockQueries, userID, AuditActionLoginFailed)
 
 		result, err := service.Login(ctx, req)
 
@@ -350,10 +462,335 @@ func TestUserService_Login(t *testing.T) {
 		assert.Equal(t, ErrInvalidCredentials, err)
 		mockQueries.AssertExpectations(t)
 	})
+
+	t.Run("account without a password", func(t *testing.T) {
+		email := "sso@example.com"
+		user := db.User{ID: uuid.New(), Email: email}
+
+		mockQueries.On("GetUserByEmail", mock.Anything, email).
+			Return(user, nil).Once()
+		expectAuditEvent(mockQueries, user.ID, AuditActionLoginFailed)
+
+		result, err := service.Login(ctx, LoginRequest{Email: email, Password: ""})
+
+		assert.Nil(t, result)
+		assert.Equal(t, ErrInvalidCredentials, err)
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("disabled account", func(t *testing.T) {
+		hash, err := hashPassword("correctpassword")
+		require.NoError(t, err)
+		user := createTestUser(uuid.New(), "disabled@example.com")
+		user.PasswordHash = sql.NullString{String: hash, Valid: true}
+		user.DisabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+		mockQueries.On("GetUserByEmail", mock.Anything, "disabled@example.com").Return(user, nil).Once()
+		mockQueries.On("CreateAuditEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateAuditEventParams) bool {
+			return arg.UserID == user.ID && arg.Action == AuditActionLoginFailed &&
+				string(arg.Metadata) == `{"reason":"disabled"}`
+		})).Return(nil).Once()
+
+		result, err := service.Login(ctx, LoginRequest{Email: "disabled@example.com", Password: "correctpassword"})
+
+		assert.Nil(t, result)
+		assert.Equal(t, ErrAccountDisabled, err)
+		mockQueries.AssertExpectations(t)
+		mockSessions.AssertNotCalled(t, "CreateSession", mock.Anything, user.ID, mock.Anything)
+	})
+
+	t.Run("two-factor challenge", func(t *testing.T) {
+		mockChallenges := new(MockTwoFactorChallenger)
+		service := NewUserService(mockQueries, mockSessions, nil, mockChallenges, nil)
+
+		hash, err := hashPassword("correctpassword")
+		require.NoError(t, err)
+		user := createTestUser(uuid.New(), "2fa@example.com")
+		user.PasswordHash = sql.NullString{String: hash, Valid: true}
+		user.TotpEnabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+		mockQueries.On("GetUserByEmail", mock.Anything, "2fa@example.com").Return(user, nil).Once()
+		mockQueries.On("CreateAuditEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateAuditEventParams) bool {
+			return arg.UserID == user.ID && arg.Action == AuditActionLogin &&
+				string(arg.Metadata) == `{"method":"password","two_factor":"required"}`
+		})).Return(nil).Once()
+		mockChallenges.On("CreateChallenge", mock.Anything, user.ID).Return("challenge", nil).Once()
+
+		result, err := service.Login(ctx, LoginRequest{Email: "2fa@example.com", Password: "correctpassword"})
+
+		require.NoError(t, err)
+		assert.True(t, result.TwoFactorRequired)
+		assert.Equal(t, "challenge", result.ChallengeToken)
+		assert.Nil(t, result.User)
+		assert.Empty(t, result.Token)
+		mockChallenges.AssertExpectations(t)
+		mockSessions.AssertNotCalled(t, "CreateSession", mock.Anything, user.ID, mock.Anything)
+	})
+
+	t.Run("two-factor user with wrong password", func(t *testing.T) {
+		mockChallenges := new(MockTwoFactorChallenger)
+		service := NewUserService(mockQueries, mockSessions, nil, mockChallenges, nil)
+
+		user := createTestUser(uuid.New(), "2fa@example.com")
+		user.TotpEnabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+		mockQueries.On("GetUserByEmail", mock.Anything, "2fa@example.com").Return(user, nil).Once()
+		expectAuditEvent(mockQueries, user.ID, AuditActionLoginFailed)
+
+		_, err := service.Login(ctx, LoginRequest{Email: "2fa@example.com", Password: "wrongpassword"})
+
+		assert.Equal(t, ErrInvalidCredentials, err)
+		mockChallenges.AssertNotCalled(t, "CreateChallenge", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUserService_LoginLockout(t *testing.T) {
+	ctx := context.Background()
+	mockQueries := new(MockQueries)
+	mockSessions := new(MockSessionIssuer)
+	lockout := ratelimit.NewLockout(ratelimit.NewMemoryStore(), ratelimit.DefaultLockoutPolicy)
+	service := NewUserService(mockQueries, mockSessions, nil, nil, lockout)
+
+	hash, err := hashPassword("correctpassword")
+	require.NoError(t, err)
+	user := createTestUser(uuid.New(), "ann@example.com")
+	user.PasswordHash = sql.NullString{String: hash, Valid: true}
+	mockQueries.On("GetUserByEmail", mock.Anything, "ann@example.com").Return(user, nil)
+	mockQueries.On("GetUserByEmail", mock.Anything, "nobody@example.com").Return(db.User{}, sql.ErrNoRows)
+	mockQueries.On("CreateAuditEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateAuditEventParams) bool {
+		return arg.UserID == user.ID && arg.Action == AuditActionLoginFailed
+	})).Return(nil).Times(ratelimit.DefaultLockoutPolicy.Threshold)
+
+	for i := 0; i < ratelimit.DefaultLockoutPolicy.Threshold; i++ {
+		_, err := service.Login(ctx, LoginRequest{Email: "Ann@example.com", Password: "wrongpassword"})
+		assert.Equal(t, ErrInvalidCredentials, err, "attempt %d", i)
+	}
+
+	// Now even the right password is refused until the lock runs out
+	_, err = service.Login(ctx, LoginRequest{Email: "ann@example.com", Password: "correctpassword"})
+	var locked *AccountLockedError
+	require.ErrorAs(t, err, &locked)
+	assert.ErrorIs(t, err, ErrAccountLocked)
+	assert.InDelta(t, ratelimit.DefaultLockoutPolicy.Base.Seconds(), locked.RetryAfter.Seconds(), 1)
+	mockSessions.AssertNotCalled(t, "CreateSession", mock.Anything, mock.Anything, mock.Anything)
+
+	// Addresses without an account lock the same way
+	for i := 0; i < ratelimit.DefaultLockoutPolicy.Threshold; i++ {
+		_, err := service.Login(ctx, LoginRequest{Email: "nobody@example.com", Password: "password123"})
+		assert.Equal(t, ErrInvalidCredentials, err)
+	}
+	_, err = service.Login(ctx, LoginRequest{Email: "nobody@example.com", Password: "password123"})
+	assert.ErrorIs(t, err, ErrAccountLocked)
+	mockQueries.AssertExpectations(t)
+}
+
+// userWithPassword returns a test user whose password is "correctpassword"
+func userWithPassword(t *testing.T, email string) db.User {
+	t.Helper()
+	hash, err := hashPassword("correctpassword")
+	require.NoError(t, err)
+	user := createTestUser(uuid.New(), email)
+	user.PasswordHash = sql.NullString{String: hash, Valid: true}
+	return user
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("signs out everywhere and opens a new session", func(t *testing.T) {
+		mockQueries := new(MockQueries)
+		mockSessions := new(MockSessionIssuer)
+		service := NewUserService(mockQueries, mockSessions, nil, nil, nil)
+		user := userWithPassword(t, "ann@example.com")
+
+		var updated db.UpdateUserPasswordParams
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+		mockQueries.On("UpdateUserPassword", mock.Anything, mock.AnythingOfType("db.UpdateUserPasswordParams")).
+			Run(func(args mock.Arguments) { updated = args.Get(1).(db.UpdateUserPasswordParams) }).
+			Return(nil).Once()
+		mockQueries.On("RevokeSessionsByUserID", mock.Anything, user.ID).Return(nil).Once()
+		mockQueries.On("InvalidateAccountTokens", mock.Anything, db.InvalidateAccountTokensParams{UserID: user.ID, Purpose: TokenPurposePasswordReset}).Return(nil).Once()
+		mockQueries.On("CreateAuditEvent", mock.Anything, db.CreateAuditEventParams{
+			UserID:    user.ID,
+			Action:    AuditActionPasswordChanged,
+			Metadata:  json.RawMessage("{}"),
+			Changes:   json.RawMessage("{}"),
+			UserAgent: "days-test",
+			IpAddress: "203.0.113.7",
+		}).Return(nil).Once()
+		mockSessions.On("CreateSession", mock.Anything, user.ID, SessionMetadata{UserAgent: "days-test", IPAddress: "203.0.113.7"}).
+			Return(&TokenResponse{Token: "access", RefreshToken: "refresh"}, nil).Once()
+
+		tokens, err := service.ChangePassword(ctx, user.ID, ChangePasswordRequest{
+			CurrentPassword: "correctpassword",
+			NewPassword:     "newpassword123",
+			UserAgent:       "days-test",
+			IPAddress:       "203.0.113.7",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "access", tokens.Token)
+		assert.True(t, verifyPassword("newpassword123", updated.PasswordHash))
+		mockQueries.AssertExpectations(t)
+		mockSessions.AssertExpectations(t)
+	})
+
+	tests := []struct {
+		name        string
+		req         ChangePasswordRequest
+		expectedErr error
+	}{
+		{"wrong current password", ChangePasswordRequest{CurrentPassword: "wrongpassword", NewPassword: "newpassword123"}, ErrIncorrectPassword},
+		{"weak new password", ChangePasswordRequest{CurrentPassword: "correctpassword", NewPassword: "short"}, ErrWeakPassword},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := new(MockQueries)
+			service := NewUserService(mockQueries, nil, nil, nil, nil)
+			user := userWithPassword(t, "ann@example.com")
+			mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+
+			_, err := service.ChangePassword(ctx, user.ID, tt.req)
+
+			assert.Equal(t, tt.expectedErr, err)
+			mockQueries.AssertNotCalled(t, "UpdateUserPassword", mock.Anything, mock.Anything)
+		})
+	}
 }
 
-func TestUserService_toUserResponse(t *testing.T) {
-	service := &UserService{}
+func TestUserService_ChangeEmail(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("changes the address and sends a verification email", func(t *testing.T) {
+		mockQueries := new(MockQueries)
+		mockVerifier := new(MockEmailVerifier)
+		service := NewUserService(mockQueries, nil, mockVerifier, nil, nil)
+		user := userWithPassword(t, "ann@example.com")
+		user.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+		mockQueries.On("GetUserByEmail", mock.Anything, "ann@new.example").Return(db.User{}, sql.ErrNoRows).Once()
+		mockQueries.On("UpdateUserEmail", mock.Anything, db.UpdateUserEmailParams{ID: user.ID, Email: "ann@new.example"}).Return(nil).Once()
+		mockQueries.On("InvalidateAccountTokens", mock.Anything, db.InvalidateAccountTokensParams{UserID: user.ID, Purpose: TokenPurposePasswordReset}).Return(nil).Once()
+		mockQueries.On("InvalidateAccountTokens", mock.Anything, db.InvalidateAccountTokensParams{UserID: user.ID, Purpose: TokenPurposeEmailVerification}).Return(nil).Once()
+		mockQueries.On("CreateAuditEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateAuditEventParams) bool {
+			return arg.Action == AuditActionEmailChanged &&
+				string(arg.Metadata) == `{"new_email":"ann@new.example","old_email":"ann@example.com"}`
+		})).Return(nil).Once()
+		mockVerifier.On("SendVerificationEmail", mock.Anything, user.ID).Return(nil).Once()
+
+		result, err := service.ChangeEmail(ctx, user.ID, ChangeEmailRequest{Email: " Ann@New.example ", Password: "correctpassword"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "ann@new.example", result.Email)
+		assert.False(t, result.EmailVerified)
+		mockQueries.AssertExpectations(t)
+		mockVerifier.AssertExpectations(t)
+	})
+
+	t.Run("invalidates old links without a verifier", func(t *testing.T) {
+		mockQueries := new(MockQueries)
+		service := NewUserService(mockQueries, nil, nil, nil, nil)
+		user := userWithPassword(t, "ann@example.com")
+
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+		mockQueries.On("GetUserByEmail", mock.Anything, "ann@new.example").Return(db.User{}, sql.ErrNoRows).Once()
+		mockQueries.On("UpdateUserEmail", mock.Anything, db.UpdateUserEmailParams{ID: user.ID, Email: "ann@new.example"}).Return(nil).Once()
+		mockQueries.On("InvalidateAccountTokens", mock.Anything, db.InvalidateAccountTokensParams{UserID: user.ID, Purpose: TokenPurposePasswordReset}).Return(nil).Once()
+		mockQueries.On("InvalidateAccountTokens", mock.Anything, db.InvalidateAccountTokensParams{UserID: user.ID, Purpose: TokenPurposeEmailVerification}).Return(nil).Once()
+		mockQueries.On("CreateAuditEvent", mock.Anything, mock.Anything).Return(nil).Once()
+
+		_, err := service.ChangeEmail(ctx, user.ID, ChangeEmailRequest{Email: "ann@new.example", Password: "correctpassword"})
+
+		require.NoError(t, err)
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("address taken", func(t *testing.T) {
+		mockQueries := new(MockQueries)
+		service := NewUserService(mockQueries, nil, nil, nil, nil)
+		user := userWithPassword(t, "ann@example.com")
+
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+		mockQueries.On("GetUserByEmail", mock.Anything, "bob@example.com").Return(createTestUser(uuid.New(), "bob@example.com"), nil).Once()
+
+		_, err := service.ChangeEmail(ctx, user.ID, ChangeEmailRequest{Email: "bob@example.com", Password: "correctpassword"})
+
+		assert.Equal(t, ErrEmailExists, err)
+		mockQueries.AssertNotCalled(t, "UpdateUserEmail", mock.Anything, mock.Anything)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		mockQueries := new(MockQueries)
+		service := NewUserService(mockQueries, nil, nil, nil, nil)
+		user := userWithPassword(t, "ann@example.com")
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+
+		_, err := service.ChangeEmail(ctx, user.ID, ChangeEmailRequest{Email: "ann@new.example", Password: "wrongpassword"})
+
+		assert.Equal(t, ErrIncorrectPassword, err)
+	})
+
+	t.Run("account without a password", func(t *testing.T) {
+		mockQueries := new(MockQueries)
+		service := NewUserService(mockQueries, nil, nil, nil, nil)
+		user := db.User{ID: uuid.New(), Email: "sso@example.com"}
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+
+		_, err := service.ChangeEmail(ctx, user.ID, ChangeEmailRequest{Email: "sso@new.example", Password: ""})
+
+		assert.Equal(t, ErrPasswordNotSet, err)
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		service := NewUserService(new(MockQueries), nil, nil, nil, nil)
+
+		_, err := service.ChangeEmail(ctx, uuid.New(), ChangeEmailRequest{Email: "not-an-email", Password: "correctpassword"})
+
+		assert.Equal(t, ErrInvalidEmail, err)
+	})
+}
+
+func TestUserService_DeleteAccount(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("deletes and records the deletion", func(t *testing.T) {
+		mockQueries := new(MockQueries)
+		service := NewUserService(mockQueries, nil, nil, nil, nil)
+		user := userWithPassword(t, "ann@example.com")
+
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+		mockQueries.On("DeleteUser", mock.Anything, user.ID).Return(nil).Once()
+		mockQueries.On("CreateAuditEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateAuditEventParams) bool {
+			return arg.UserID == user.ID && arg.Action == AuditActionAccountDeleted
+		})).Return(errors.New("audit store down")).Once()
+
+		// The account is gone even if the audit record could not be written
+		require.NoError(t, service.DeleteAccount(ctx, user.ID, DeleteAccountRequest{Password: "correctpassword"}))
+		mockQueries.AssertExpectations(t)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		mockQueries := new(MockQueries)
+		service := NewUserService(mockQueries, nil, nil, nil, nil)
+		user := userWithPassword(t, "ann@example.com")
+		mockQueries.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+
+		assert.Equal(t, ErrIncorrectPassword, service.DeleteAccount(ctx, user.ID, DeleteAccountRequest{Password: "wrongpassword"}))
+		mockQueries.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		mockQueries := new(MockQueries)
+		service := NewUserService(mockQueries, nil, nil, nil, nil)
+		userID := uuid.New()
+		mockQueries.On("GetUserByID", mock.Anything, userID).Return(db.User{}, sql.ErrNoRows).Once()
+
+		assert.Equal(t, ErrUserNotFound, service.DeleteAccount(ctx, userID, DeleteAccountRequest{Password: "correctpassword"}))
+	})
+}
+
+func TestToUserResponse(t *testing.T) {
 	userID := uuid.New()
 	createdAt := time.Now()
 
@@ -375,6 +812,21 @@ func TestUserService_toUserResponse(t *testing.T) {
 				CreatedAt: createdAt.Format("2006-01-02T15:04:05Z"),
 			},
 		},
+		{
+			name: "user with verified email",
+			user: db.User{
+				ID:              userID,
+				Email:           "test@example.com",
+				CreatedAt:       sql.NullTime{Time: createdAt, Valid: true},
+				EmailVerifiedAt: sql.NullTime{Time: createdAt, Valid: true},
+			},
+			want: &UserResponse{
+				ID:            userID,
+				Email:         "test@example.com",
+				EmailVerified: true,
+				CreatedAt:     createdAt.Format("2006-01-02T15:04:05Z"),
+			},
+		},
 		{
 			name: "user with invalid created_at",
 			user: db.User{
@@ -392,7 +844,7 @@ func TestUserService_toUserResponse(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := service.toUserResponse(tt.user)
+			result := toUserResponse(tt.user)
 			assert.Equal(t, tt.want, result)
 		})
 	}
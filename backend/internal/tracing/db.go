@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"days/internal/db"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedDB starts a span for each statement run through it
+type tracedDB struct {
+	next   db.DBTX
+	tracer trace.Tracer
+}
+
+// WrapDBTX returns a db.DBTX that traces the statements it passes on to
+// next, such as a *sql.DB or a *sql.Tx. Spans are named after the sqlc query
+// that ran.
+func WrapDBTX(next db.DBTX) db.DBTX {
+	return tracedDB{next: next, tracer: otel.Tracer("days/internal/db")}
+}
+
+func (t tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := t.startQuery(ctx, query)
+	defer span.End()
+	result, err := t.next.ExecContext(ctx, query, args...)
+	recordError(span, err)
+	return result, err
+}
+
+func (t tracedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := t.startQuery(ctx, query)
+	defer span.End()
+	stmt, err := t.next.PrepareContext(ctx, query)
+	recordError(span, err)
+	return stmt, err
+}
+
+// QueryContext traces the query until its first rows are ready, not while
+// they are read
+func (t tracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := t.startQuery(ctx, query)
+	defer span.End()
+	rows, err := t.next.QueryContext(ctx, query, args...)
+	recordError(span, err)
+	return rows, err
+}
+
+// QueryRowContext errors only show up on Scan, after the span has ended
+func (t tracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := t.startQuery(ctx, query)
+	defer span.End()
+	return t.next.QueryRowContext(ctx, query, args...)
+}
+
+func (t tracedDB) startQuery(ctx context.Context, query string) (context.Context, trace.Span) {
+	name := queryName(query)
+	return t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(name),
+			semconv.DBQueryText(query),
+		),
+	)
+}
+
+func recordError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// queryName returns the name sqlc gives a query in its leading
+// "-- name: GetUser :one" comment, or the SQL command of other statements
+func queryName(query string) string {
+	query = strings.TrimSpace(query)
+	if rest, ok := strings.CutPrefix(query, "-- name: "); ok {
+		if fields := strings.Fields(rest); len(fields) > 0 {
+			return fields[0]
+		}
+	}
+	if fields := strings.Fields(query); len(fields) > 0 {
+		return strings.ToUpper(fields[0])
+	}
+	return "query"
+}
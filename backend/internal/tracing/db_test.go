@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// stubDBTX answers every statement with err
+type stubDBTX struct {
+	err error
+}
+
+func (s stubDBTX) ExecContext(context.Context, string, ...interface{}) (sql.Result, error) {
+	return nil, s.err
+}
+
+func (s stubDBTX) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, s.err
+}
+
+func (s stubDBTX) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, s.err
+}
+
+func (s stubDBTX) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	return nil
+}
+
+// newTestDB returns next wrapped like WrapDBTX does, with its spans recorded
+func newTestDB(next stubDBTX) (tracedDB, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	return tracedDB{next: next, tracer: provider.Tracer("test")}, recorder
+}
+
+func TestTracedDB(t *testing.T) {
+	traced, recorder := newTestDB(stubDBTX{})
+	ctx := context.Background()
+
+	_, err := traced.ExecContext(ctx, "-- name: DeleteDayEntry :exec\nDELETE FROM day_entries WHERE calendar_id = $1 AND date = $2", 1, 2)
+	require.NoError(t, err)
+	traced.QueryRowContext(ctx, "-- name: GetUserByID :one\nSELECT * FROM users WHERE id = $1", 1)
+	_, err = traced.QueryContext(ctx, "SELECT 1")
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "DeleteDayEntry", spans[0].Name())
+	assert.Equal(t, "GetUserByID", spans[1].Name())
+	assert.Equal(t, "SELECT", spans[2].Name())
+
+	attrs := attribute.NewSet(spans[0].Attributes()...)
+	system, _ := attrs.Value("db.system.name")
+	assert.Equal(t, "postgresql", system.AsString())
+	text, _ := attrs.Value("db.query.text")
+	assert.Contains(t, text.AsString(), "DELETE FROM day_entries")
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+}
+
+func TestTracedDB_Error(t *testing.T) {
+	traced, recorder := newTestDB(stubDBTX{err: errors.New("connection refused")})
+
+	_, err := traced.QueryContext(context.Background(), "-- name: GetCalendarsByUserID :many\nSELECT 1")
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "connection refused", spans[0].Status().Description)
+	require.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, "exception", spans[0].Events()[0].Name)
+}
+
+func TestQueryName(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"-- name: CreateDayEntry :one\nINSERT INTO day_entries VALUES ($1)", "CreateDayEntry"},
+		{"  -- name: ListThings :many\nSELECT 1", "ListThings"},
+		{"select version()", "SELECT"},
+		{"", "query"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, queryName(tt.query), tt.query)
+	}
+}
@@ -0,0 +1,92 @@
+// Package tracing sets up OpenTelemetry tracing for the server.
+//
+// Spans are exported over OTLP, or written to stdout for local runs, and
+// trace context travels in W3C traceparent headers. The OTLP exporter reads
+// its endpoint, headers and protocol options from the standard
+// OTEL_EXPORTER_OTLP_* environment variables, and the sampler from
+// OTEL_TRACES_SAMPLER.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+)
+
+// Exporters selected with OTEL_TRACES_EXPORTER
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+var ErrInvalidConfig = errors.New("invalid tracing config")
+
+// Config selects where spans go
+type Config struct {
+	Exporter    string `yaml:"exporter" toml:"exporter"`
+	ServiceName string `yaml:"service_name" toml:"service_name"`
+}
+
+// DefaultConfig turns tracing off until an exporter is named
+func DefaultConfig() Config {
+	return Config{Exporter: ExporterNone, ServiceName: "days"}
+}
+
+// Enabled reports whether spans are exported
+func (c *Config) Enabled() bool {
+	return strings.ToLower(c.Exporter) != ExporterNone
+}
+
+// Setup installs the W3C trace context propagator and, when tracing is
+// enabled, a tracer provider exporting as config selects. The returned func
+// flushes the spans not exported yet; call it before exiting.
+func Setup(ctx context.Context, config *Config) (func(context.Context) error, error) {
+	// Incoming trace context is carried on even when spans are not exported
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if !config.Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch strings.ToLower(config.Exporter) {
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("%w: OTEL_TRACES_EXPORTER %q", ErrInvalidConfig, config.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create span exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(semconv.ServiceName(config.ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
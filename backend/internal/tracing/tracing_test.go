@@ -0,0 +1,35 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestDefaultConfig(t *testing.T) {
+	config := DefaultConfig()
+	assert.Equal(t, Config{Exporter: ExporterNone, ServiceName: "days"}, config)
+	assert.False(t, config.Enabled())
+
+	config.Exporter = ExporterOTLP
+	assert.True(t, config.Enabled())
+}
+
+func TestSetup(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		shutdown, err := Setup(context.Background(), &Config{Exporter: ExporterNone, ServiceName: "days"})
+		require.NoError(t, err)
+		assert.NoError(t, shutdown(context.Background()))
+
+		// Trace context is still propagated
+		assert.ElementsMatch(t, []string{"traceparent", "tracestate", "baggage"}, otel.GetTextMapPropagator().Fields())
+	})
+
+	t.Run("unknown exporter", func(t *testing.T) {
+		_, err := Setup(context.Background(), &Config{Exporter: "zipkin", ServiceName: "days"})
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+	})
+}
@@ -167,6 +167,15 @@ if [ $login_status -eq 200 ]; then
         else
             print_status "ERROR" "Failed to get calendars"
         fi
+
+        # Asking for a limit or cursor returns a page object instead of an array
+        print_status "INFO" "Getting the first page of calendars..."
+        make_request "GET" "$API_URL/calendars?limit=1" "" "$AUTH_HEADER"
+        if [ $? -eq 200 ] && echo "$body" | jq -e '.calendars | type == "array"' > /dev/null 2>&1; then
+            print_status "SUCCESS" "Calendar page retrieved successfully"
+        else
+            print_status "ERROR" "Failed to get a page of calendars"
+        fi
         
         # Test 7: Get Specific Calendar (if we have an ID)
         if [ "$CALENDAR_ID" != "null" ] && [ -n "$CALENDAR_ID" ]; then